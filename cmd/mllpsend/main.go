@@ -0,0 +1,57 @@
+// Command mllpsend sends HL7 v2 messages to an MLLP listener and prints
+// the acknowledgments, for testing the HL7 interface locally:
+//
+//	go run ./cmd/mllpsend -addr localhost:2575 message.hl7
+//
+// Each file holds one message; with no files the message is read from
+// stdin. Line breaks are converted to the CR segment separators HL7 uses.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"hospital-management-system/pkg/hl7"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:2575", "MLLP listener address")
+	timeout := flag.Duration("timeout", 10*time.Second, "how long to wait for each acknowledgment")
+	flag.Parse()
+
+	client, err := hl7.Dial(*addr, *timeout)
+	if err != nil {
+		log.Fatalf("could not connect to %s: %v", *addr, err)
+	}
+	defer client.Close()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		var data []byte
+		if name == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(name)
+		}
+		if err != nil {
+			log.Fatalf("could not read %s: %v", name, err)
+		}
+
+		msg := bytes.ReplaceAll(bytes.TrimSpace(data), []byte("\r\n"), []byte("\r"))
+		msg = bytes.ReplaceAll(msg, []byte("\n"), []byte("\r"))
+		ack, err := client.Send(msg, *timeout)
+		if err != nil {
+			log.Fatalf("could not send %s: %v", name, err)
+		}
+		fmt.Println(strings.ReplaceAll(string(ack), "\r", "\n"))
+	}
+}
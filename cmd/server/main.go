@@ -26,6 +26,11 @@ func main() {
 
 	// Set up Gin router
 	router := gin.Default()
+	// Client IPs feed the login throttle, so X-Forwarded-For is only
+	// believed when it comes from a known proxy
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Add middleware
 	router.Use(middleware.CORSMiddleware())
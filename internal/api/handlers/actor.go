@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"hospital-management-system/internal/domain/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFromContext builds the acting user from the claims AuthMiddleware
+// put in the context
+func actorFromContext(c *gin.Context) models.Actor {
+	return models.Actor{
+		UserID:   c.GetInt64("user_id"),
+		Username: c.GetString("userID"),
+		Role:     c.GetString("role"),
+	}
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ADTHandler struct {
+	adtService *services.ADTService
+}
+
+func NewADTHandler(adtService *services.ADTService) *ADTHandler {
+	return &ADTHandler{adtService: adtService}
+}
+
+type UpdateBedRequest struct {
+	Label        string `json:"label"`
+	OutOfService bool   `json:"out_of_service"`
+}
+
+type AdmitRequest struct {
+	BedID             int    `json:"bed_id" binding:"required"`
+	AttendingDoctorID *int64 `json:"attending_doctor_id"`
+	Reason            string `json:"reason"`
+}
+
+type TransferRequest struct {
+	BedID  int    `json:"bed_id" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type DischargeRequest struct {
+	Disposition string `json:"disposition"`
+}
+
+// GetWards handles listing the wards
+func (h *ADTHandler) GetWards(c *gin.Context) {
+	wards, err := h.adtService.GetWards()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, wards)
+}
+
+// CreateWard handles adding a ward
+func (h *ADTHandler) CreateWard(c *gin.Context) {
+	var ward models.Ward
+	if err := c.ShouldBindJSON(&ward); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adtService.CreateWard(&ward); err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ward)
+}
+
+// CreateRoom handles adding a room to a ward
+func (h *ADTHandler) CreateRoom(c *gin.Context) {
+	wardID, ok := uintParam(c, "id", "Invalid ward ID")
+	if !ok {
+		return
+	}
+
+	var room models.Room
+	if err := c.ShouldBindJSON(&room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adtService.CreateRoom(wardID, &room); err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, room)
+}
+
+// CreateBed handles adding a bed to one of a ward's rooms
+func (h *ADTHandler) CreateBed(c *gin.Context) {
+	wardID, ok := uintParam(c, "id", "Invalid ward ID")
+	if !ok {
+		return
+	}
+
+	var bed models.Bed
+	if err := c.ShouldBindJSON(&bed); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adtService.CreateBed(wardID, &bed); err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bed)
+}
+
+// GetBedBoard handles showing a ward's live bed board
+func (h *ADTHandler) GetBedBoard(c *gin.Context) {
+	wardID, ok := uintParam(c, "id", "Invalid ward ID")
+	if !ok {
+		return
+	}
+
+	board, err := h.adtService.GetBedBoard(actorFromContext(c), wardID)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// UpdateBed handles relabelling a bed or taking it in or out of service
+func (h *ADTHandler) UpdateBed(c *gin.Context) {
+	wardID, bedID, ok := bedParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateBedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bed, err := h.adtService.UpdateBed(wardID, bedID, req.Label, req.OutOfService)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bed)
+}
+
+// GetBedHistory handles listing who has been in a bed
+func (h *ADTHandler) GetBedHistory(c *gin.Context) {
+	wardID, bedID, ok := bedParams(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.adtService.GetBedHistory(actorFromContext(c), wardID, bedID)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetCensus handles the hospital's bed census
+func (h *ADTHandler) GetCensus(c *gin.Context) {
+	census, err := h.adtService.GetCensus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, census)
+}
+
+// GetAdmissions handles listing a patient's admissions
+func (h *ADTHandler) GetAdmissions(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	admissions, err := h.adtService.GetAdmissions(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, admissions)
+}
+
+// Admit handles admitting a patient into a bed
+func (h *ADTHandler) Admit(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var req AdmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admission := models.Admission{
+		PatientID:         int(patientID),
+		BedID:             req.BedID,
+		AttendingDoctorID: req.AttendingDoctorID,
+		Reason:            req.Reason,
+	}
+	if err := h.adtService.Admit(actorFromContext(c), &admission); err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, admission)
+}
+
+// GetAdmission handles showing an admission and its bed history
+func (h *ADTHandler) GetAdmission(c *gin.Context) {
+	patientID, admissionID, ok := admissionParams(c)
+	if !ok {
+		return
+	}
+
+	admission, err := h.adtService.GetAdmission(actorFromContext(c), patientID, admissionID)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, admission)
+}
+
+// Transfer handles moving an admitted patient to another bed
+func (h *ADTHandler) Transfer(c *gin.Context) {
+	patientID, admissionID, ok := admissionParams(c)
+	if !ok {
+		return
+	}
+
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admission, err := h.adtService.Transfer(actorFromContext(c), patientID, admissionID, req.BedID, req.Reason)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, admission)
+}
+
+// Discharge handles discharging an admitted patient
+func (h *ADTHandler) Discharge(c *gin.Context) {
+	patientID, admissionID, ok := admissionParams(c)
+	if !ok {
+		return
+	}
+
+	var req DischargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admission, err := h.adtService.Discharge(actorFromContext(c), patientID, admissionID, req.Disposition)
+	if err != nil {
+		c.JSON(adtErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, admission)
+}
+
+func bedParams(c *gin.Context) (wardID, bedID uint, ok bool) {
+	if wardID, ok = uintParam(c, "id", "Invalid ward ID"); !ok {
+		return
+	}
+	bedID, ok = uintParam(c, "bed_id", "Invalid bed ID")
+	return
+}
+
+func admissionParams(c *gin.Context) (patientID, admissionID uint, ok bool) {
+	if patientID, ok = uintParam(c, "id", "Invalid patient ID"); !ok {
+		return
+	}
+	admissionID, ok = uintParam(c, "admission_id", "Invalid admission ID")
+	return
+}
+
+func adtErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidWard),
+		errors.Is(err, services.ErrInvalidAdmission),
+		errors.Is(err, services.ErrInvalidDoctor):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrAdmissionStatus),
+		errors.Is(err, services.ErrBedUnavailable),
+		errors.Is(err, services.ErrAlreadyAdmitted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppointmentHandler struct {
+	appointmentService *services.AppointmentService
+}
+
+func NewAppointmentHandler(appointmentService *services.AppointmentService) *AppointmentHandler {
+	return &AppointmentHandler{appointmentService: appointmentService}
+}
+
+// CreateAppointment handles booking a new appointment
+func (h *AppointmentHandler) CreateAppointment(c *gin.Context) {
+	var appointment models.Appointment
+	if err := c.ShouldBindJSON(&appointment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.appointmentService.CreateAppointment(&appointment); err != nil {
+		c.JSON(appointmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, appointment)
+}
+
+// GetAppointment handles fetching an appointment by ID
+func (h *AppointmentHandler) GetAppointment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	appointment, err := h.appointmentService.GetAppointmentByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, appointment)
+}
+
+// UpdateAppointment handles rescheduling or changing the status of an appointment
+func (h *AppointmentHandler) UpdateAppointment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var appointment models.Appointment
+	if err := c.ShouldBindJSON(&appointment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appointment.ID = int(id)
+	if err := h.appointmentService.UpdateAppointment(&appointment); err != nil {
+		c.JSON(appointmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, appointment)
+}
+
+// DeleteAppointment handles deleting an appointment by ID
+func (h *AppointmentHandler) DeleteAppointment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	if err := h.appointmentService.DeleteAppointment(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAllAppointments handles listing appointments, optionally narrowed to a
+// patient (?patient_id=) or to a doctor's schedule (?doctor_id=&from=&to=)
+func (h *AppointmentHandler) GetAllAppointments(c *gin.Context) {
+	var (
+		appointments []models.Appointment
+		err          error
+	)
+
+	switch {
+	case c.Query("patient_id") != "":
+		patientID, parseErr := strconv.ParseUint(c.Query("patient_id"), 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+			return
+		}
+		appointments, err = h.appointmentService.GetAppointmentsByPatient(uint(patientID))
+	case c.Query("doctor_id") != "":
+		doctorID, parseErr := strconv.ParseInt(c.Query("doctor_id"), 10, 64)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+			return
+		}
+		from, to, parseErr := parseTimeRange(c, time.Now().Truncate(24*time.Hour), 7*24*time.Hour)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+			return
+		}
+		appointments, err = h.appointmentService.GetAppointmentsByDoctor(doctorID, from, to)
+	default:
+		appointments, err = h.appointmentService.GetAllAppointments()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, appointments)
+}
+
+// parseTimeRange reads RFC 3339 ?from= and ?to= query parameters, falling
+// back to defaultFrom and a window of defaultSpan when they are omitted
+func parseTimeRange(c *gin.Context, defaultFrom time.Time, defaultSpan time.Duration) (time.Time, time.Time, error) {
+	from := defaultFrom
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be an RFC 3339 timestamp")
+		}
+		from = t
+	}
+
+	to := from.Add(defaultSpan)
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be an RFC 3339 timestamp")
+		}
+		to = t
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	return from, to, nil
+}
+
+func appointmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAppointmentConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidAppointment),
+		errors.Is(err, services.ErrInvalidDoctor),
+		errors.Is(err, services.ErrInvalidPatient):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"hospital-management-system/internal/domain/models"
 	"hospital-management-system/internal/services"
@@ -27,6 +30,10 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -40,17 +47,52 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Get user data along with token
-	user, token, err := h.authService.LoginWithUser(req.Username, req.Password)
+	// Get user data along with tokens
+	user, tokens, err := h.authService.LoginWithUser(req.Username, req.Password, c.ClientIP())
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		tooManyAttempts(c, throttled)
+		return
+	}
+	var mfaRequired *services.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		// The password was right, but no token is issued before the second
+		// factor has been verified
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"mfa_required": true,
+			"mfa_token":    mfaRequired.Token,
+			"expires_in":   mfaRequired.ExpiresIn,
+			"message":      "Enter the code from your authenticator app",
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log in"})
+		}
 		return
 	}
 
-	// Return user data along with token
+	loginSucceeded(c, user, tokens)
+}
+
+// tooManyAttempts tells the client when it may try to log in again
+func tooManyAttempts(c *gin.Context, throttled *services.LoginThrottledError) {
+	retryAfter := int64((throttled.RetryAfter + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later", "retry_after": retryAfter})
+}
+
+// loginSucceeded returns user data along with tokens
+func loginSucceeded(c *gin.Context, user *models.User, tokens *models.TokenPair) {
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"token":   token,
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
@@ -60,6 +102,35 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// Refresh exchanges a refresh token for a new access and refresh token
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	_, tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Register handles public self-registration, which only creates
+// receptionists. Doctors and other staff get clinical or administrative
+// access, so their accounts are created by an admin through CreateUser.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -67,12 +138,33 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Validate role
-	if req.Role != "receptionist" && req.Role != "doctor" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be 'receptionist' or 'doctor'"})
+	if req.Role != models.RoleReceptionist {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Only 'receptionist' accounts can self-register; ask an admin to create other accounts"})
+		return
+	}
+
+	h.createUser(c, req)
+}
+
+// CreateUser lets an admin create an account with any role
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	switch req.Role {
+	case models.RoleReceptionist, models.RoleDoctor, models.RoleAdmin, models.RoleCompliance, models.RoleBilling:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be 'receptionist', 'doctor', 'admin', 'compliance' or 'billing'"})
 		return
 	}
 
+	h.createUser(c, req)
+}
+
+func (h *AuthHandler) createUser(c *gin.Context, req RegisterRequest) {
 	// Validate password strength
 	validator := utils.NewValidator()
 	if !validator.IsPasswordStrong(req.Password) {
@@ -124,10 +216,68 @@ func (h *AuthHandler) ShowDashboard(c *gin.Context) {
 	})
 }
 
+// Logout revokes the caller's access token and the session behind it
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side
+	tokenID := c.GetString("token_id")
+	expiresAt := c.GetTime("token_expires_at")
+
+	if err := h.authService.Logout(tokenID, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Logged out successfully",
 	})
 }
+
+// RevokeSessions kills every active session of a user, e.g. after a token
+// has been stolen
+func (h *AuthHandler) RevokeSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if _, err := h.userService.GetUserByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.authService.RevokeUserSessions(int64(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "All sessions revoked",
+	})
+}
+
+// UnlockUser lifts a lockout of a user after too many failed logins
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.authService.UnlockUser(actorFromContext(c), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User unlocked",
+	})
+}
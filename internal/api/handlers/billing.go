@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BillingHandler struct {
+	billingService *services.BillingService
+}
+
+func NewBillingHandler(billingService *services.BillingService) *BillingHandler {
+	return &BillingHandler{billingService: billingService}
+}
+
+type InvoiceLineRequest struct {
+	ChargeCode string `json:"charge_code" binding:"required"`
+	Quantity   int    `json:"quantity"`
+}
+
+type InvoiceRequest struct {
+	EncounterID *int                 `json:"encounter_id"`
+	Notes       string               `json:"notes"`
+	Lines       []InvoiceLineRequest `json:"lines" binding:"required,dive"`
+}
+
+type PaymentRequest struct {
+	AmountCents int64  `json:"amount_cents" binding:"required"`
+	Method      string `json:"method" binding:"required"`
+	Reference   string `json:"reference"`
+}
+
+type RefundRequest struct {
+	RefundOf    int    `json:"refund_of" binding:"required"`
+	AmountCents int64  `json:"amount_cents" binding:"required"`
+	Method      string `json:"method"`
+	Reference   string `json:"reference"`
+}
+
+// GetTaxRules handles listing the tax rules
+func (h *BillingHandler) GetTaxRules(c *gin.Context) {
+	rules, err := h.billingService.GetTaxRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// SaveTaxRule handles adding or changing the tax rule named in the path
+func (h *BillingHandler) SaveTaxRule(c *gin.Context) {
+	var rule models.TaxRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.Code = c.Param("code")
+	if err := h.billingService.SaveTaxRule(&rule); err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetChargemaster handles listing the chargemaster
+func (h *BillingHandler) GetChargemaster(c *gin.Context) {
+	items, err := h.billingService.GetChargemaster()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// CreateChargeItem handles adding a service to the chargemaster
+func (h *BillingHandler) CreateChargeItem(c *gin.Context) {
+	item := models.ChargeItem{Active: true}
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.billingService.CreateChargeItem(&item); err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateChargeItem handles changing a chargemaster item
+func (h *BillingHandler) UpdateChargeItem(c *gin.Context) {
+	var item models.ChargeItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item.Code = c.Param("code")
+	if err := h.billingService.UpdateChargeItem(&item); err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// GetInvoices handles listing a patient's invoices
+func (h *BillingHandler) GetInvoices(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	invoices, err := h.billingService.GetInvoices(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// CreateInvoice handles issuing an invoice to a patient
+func (h *BillingHandler) CreateInvoice(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var req InvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoice := models.Invoice{PatientID: int(patientID), EncounterID: req.EncounterID, Notes: req.Notes}
+	for _, l := range req.Lines {
+		quantity := l.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{ChargeCode: l.ChargeCode, Quantity: quantity})
+	}
+	if err := h.billingService.CreateInvoice(actorFromContext(c), &invoice); err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// GetBalance handles showing what a patient owes
+func (h *BillingHandler) GetBalance(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	balance, err := h.billingService.GetBalance(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// GetInvoice handles showing an invoice with its lines and payments
+func (h *BillingHandler) GetInvoice(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	invoice, err := h.billingService.GetInvoice(actorFromContext(c), invoiceID)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// RecordPayment handles a payment against an invoice
+func (h *BillingHandler) RecordPayment(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	var req PaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment := models.InvoicePayment{AmountCents: req.AmountCents, Method: req.Method, Reference: req.Reference}
+	invoice, err := h.billingService.RecordPayment(actorFromContext(c), invoiceID, &payment)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// RefundPayment handles refunding one of an invoice's payments
+func (h *BillingHandler) RefundPayment(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund := models.InvoicePayment{RefundOf: &req.RefundOf, AmountCents: req.AmountCents, Method: req.Method, Reference: req.Reference}
+	invoice, err := h.billingService.RefundPayment(actorFromContext(c), invoiceID, &refund)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// VoidInvoice handles cancelling an invoice with nothing paid on it
+func (h *BillingHandler) VoidInvoice(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	invoice, err := h.billingService.VoidInvoice(actorFromContext(c), invoiceID)
+	if err != nil {
+		c.JSON(billingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func billingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidCharge),
+		errors.Is(err, services.ErrInvalidInvoice),
+		errors.Is(err, services.ErrInvalidPayment):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvoiceStatus),
+		errors.Is(err, services.ErrPaymentExceedsBalance):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/fhir"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BulkExportHandler struct {
+	exportService *services.BulkExportService
+}
+
+func NewBulkExportHandler(exportService *services.BulkExportService) *BulkExportHandler {
+	return &BulkExportHandler{exportService: exportService}
+}
+
+// StartExport handles the $export kick-off request. It accepts _type (a
+// comma-separated list of resource types), _since and _outputFormat, and
+// answers 202 with the status URL in Content-Location.
+func (h *BulkExportHandler) StartExport(c *gin.Context) {
+	params := c.Request.URL.Query()
+	if err := checkFHIRSearchParams(params, "_type", "_since", "_outputFormat"); err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+	switch params.Get("_outputFormat") {
+	case "", fhir.NDJSONContentType, "application/ndjson", "ndjson":
+	default:
+		writeFHIRError(c, fmt.Errorf("%w: only %s output is supported", services.ErrInvalidExport, fhir.NDJSONContentType))
+		return
+	}
+
+	var types []string
+	if v := params.Get("_type"); v != "" {
+		types = strings.Split(v, ",")
+	}
+	var since *time.Time
+	if v := params.Get("_since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFHIRError(c, fmt.Errorf("%w: _since must be an instant such as 2024-01-01T00:00:00Z", services.ErrInvalidExport))
+			return
+		}
+		since = &t
+	}
+
+	base := fhirBaseURL(c)
+	requestURL := base + strings.TrimPrefix(c.Request.URL.RequestURI(), FHIRBasePath)
+	job, err := h.exportService.StartExport(actorFromContext(c), requestURL, types, since)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	c.Header("Content-Location", base+"/$export-status/"+job.ID)
+	c.Status(http.StatusAccepted)
+}
+
+// GetStatus handles polling an export: 202 with X-Progress while it runs,
+// the manifest of signed file links once complete, and an OperationOutcome
+// if it failed
+func (h *BulkExportHandler) GetStatus(c *gin.Context) {
+	job, err := h.exportService.GetExport(actorFromContext(c), c.Param("job_id"))
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	switch job.Status {
+	case models.ExportInProgress:
+		c.Header("X-Progress", fmt.Sprintf("%d resources exported", job.Processed))
+		c.Header("Retry-After", "5")
+		c.Status(http.StatusAccepted)
+	case models.ExportFailed:
+		writeFHIR(c, http.StatusInternalServerError, fhir.NewOperationOutcome(fhir.IssueException, job.Error))
+	default:
+		base := fhirBaseURL(c)
+		manifest := fhir.BulkExportManifest{
+			TransactionTime: job.CreatedAt.UTC().Format(time.RFC3339),
+			Request:         job.RequestURL,
+			Output:          []fhir.BulkExportFile{},
+			Error:           []fhir.BulkExportFile{},
+		}
+		for _, output := range job.Output {
+			expires, signature := h.exportService.SignFile(job.ID, output.File)
+			query := url.Values{"expires": {strconv.FormatInt(expires, 10)}, "signature": {signature}}
+			manifest.Output = append(manifest.Output, fhir.BulkExportFile{
+				Type:  output.Type,
+				URL:   base + "/$export-file/" + job.ID + "/" + output.File + "?" + query.Encode(),
+				Count: output.Count,
+			})
+		}
+		c.JSON(http.StatusOK, manifest)
+	}
+}
+
+// DeleteExport handles cancelling an export or deleting a finished one
+func (h *BulkExportHandler) DeleteExport(c *gin.Context) {
+	if err := h.exportService.DeleteExport(actorFromContext(c), c.Param("job_id")); err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// DownloadFile serves an export file to anyone holding its signed link,
+// so the link works without an access token until it expires
+func (h *BulkExportHandler) DownloadFile(c *gin.Context) {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		writeFHIRError(c, services.ErrExportLink)
+		return
+	}
+
+	f, err := h.exportService.OpenFile(c.Param("job_id"), c.Param("file"), expires, c.Query("signature"), c.ClientIP())
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+	c.DataFromReader(http.StatusOK, info.Size(), fhir.NDJSONContentType, f, nil)
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EncounterHandler struct {
+	encounterService *services.EncounterService
+}
+
+func NewEncounterHandler(encounterService *services.EncounterService) *EncounterHandler {
+	return &EncounterHandler{encounterService: encounterService}
+}
+
+type ClinicalNoteRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// CreateEncounter handles opening an encounter for a patient
+func (h *EncounterHandler) CreateEncounter(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var encounter models.Encounter
+	if err := c.ShouldBindJSON(&encounter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	encounter.PatientID = int(patientID)
+	if err := h.encounterService.CreateEncounter(actorFromContext(c), &encounter); err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, encounter)
+}
+
+// GetEncounters handles listing a patient's encounters
+func (h *EncounterHandler) GetEncounters(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	encounters, err := h.encounterService.GetEncounters(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, encounters)
+}
+
+// GetEncounter handles fetching one of a patient's encounters
+func (h *EncounterHandler) GetEncounter(c *gin.Context) {
+	patientID, encounterID, ok := encounterParams(c)
+	if !ok {
+		return
+	}
+
+	encounter, err := h.encounterService.GetEncounter(actorFromContext(c), patientID, encounterID)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, encounter)
+}
+
+// UpdateEncounter handles changing, finishing or cancelling an encounter
+func (h *EncounterHandler) UpdateEncounter(c *gin.Context) {
+	patientID, encounterID, ok := encounterParams(c)
+	if !ok {
+		return
+	}
+
+	var encounter models.Encounter
+	if err := c.ShouldBindJSON(&encounter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	encounter.ID = int(encounterID)
+	encounter.PatientID = int(patientID)
+	if err := h.encounterService.UpdateEncounter(actorFromContext(c), &encounter); err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, encounter)
+}
+
+// GetNotes handles listing the latest version of each note on an encounter
+func (h *EncounterHandler) GetNotes(c *gin.Context) {
+	patientID, encounterID, ok := encounterParams(c)
+	if !ok {
+		return
+	}
+
+	notes, err := h.encounterService.GetNotes(actorFromContext(c), patientID, encounterID)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+// CreateNote handles starting a draft note on an encounter
+func (h *EncounterHandler) CreateNote(c *gin.Context) {
+	patientID, encounterID, ok := encounterParams(c)
+	if !ok {
+		return
+	}
+
+	var req ClinicalNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.encounterService.AddNote(actorFromContext(c), patientID, encounterID, req.Content)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// GetNoteVersions handles fetching the full version history of a note
+func (h *EncounterHandler) GetNoteVersions(c *gin.Context) {
+	patientID, encounterID, noteID, ok := noteParams(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.encounterService.GetNoteVersions(actorFromContext(c), patientID, encounterID, noteID)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// UpdateNote handles the author editing their unsigned draft
+func (h *EncounterHandler) UpdateNote(c *gin.Context) {
+	patientID, encounterID, noteID, ok := noteParams(c)
+	if !ok {
+		return
+	}
+
+	var req ClinicalNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.encounterService.EditNote(actorFromContext(c), patientID, encounterID, noteID, req.Content)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// SignNote handles the author signing their draft
+func (h *EncounterHandler) SignNote(c *gin.Context) {
+	patientID, encounterID, noteID, ok := noteParams(c)
+	if !ok {
+		return
+	}
+
+	note, err := h.encounterService.SignNote(actorFromContext(c), patientID, encounterID, noteID)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// AmendNote handles adding a new version to a signed note
+func (h *EncounterHandler) AmendNote(c *gin.Context) {
+	patientID, encounterID, noteID, ok := noteParams(c)
+	if !ok {
+		return
+	}
+
+	var req ClinicalNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.encounterService.AmendNote(actorFromContext(c), patientID, encounterID, noteID, req.Content)
+	if err != nil {
+		c.JSON(encounterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// uintParam parses a numeric path parameter, writing a 400 with message
+// when it is invalid
+func uintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func encounterParams(c *gin.Context) (patientID, encounterID uint, ok bool) {
+	if patientID, ok = uintParam(c, "id", "Invalid patient ID"); !ok {
+		return
+	}
+	encounterID, ok = uintParam(c, "encounter_id", "Invalid encounter ID")
+	return
+}
+
+func noteParams(c *gin.Context) (patientID, encounterID uint, noteID int64, ok bool) {
+	if patientID, encounterID, ok = encounterParams(c); !ok {
+		return
+	}
+	noteID, err := strconv.ParseInt(c.Param("note_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return 0, 0, 0, false
+	}
+	return patientID, encounterID, noteID, true
+}
+
+func encounterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotNoteAuthor):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNoteLocked):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidEncounter),
+		errors.Is(err, services.ErrInvalidNote),
+		errors.Is(err, services.ErrInvalidDoctor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
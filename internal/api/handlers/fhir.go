@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/fhir"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FHIRBasePath is where the FHIR R4 API is mounted.
+const FHIRBasePath = "/fhir/R4"
+
+type FHIRHandler struct {
+	fhirService *services.FHIRService
+	startedAt   time.Time
+}
+
+func NewFHIRHandler(fhirService *services.FHIRService) *FHIRHandler {
+	return &FHIRHandler{fhirService: fhirService, startedAt: time.Now()}
+}
+
+// Metadata handles the capabilities interaction with the server's
+// CapabilityStatement
+func (h *FHIRHandler) Metadata(c *gin.Context) {
+	read, search := fhir.CapabilityInteraction{Code: "read"}, fhir.CapabilityInteraction{Code: "search-type"}
+	statement := fhir.CapabilityStatement{
+		ResourceType: "CapabilityStatement",
+		Status:       "active",
+		Date:         h.startedAt.UTC().Format(time.RFC3339),
+		Kind:         "instance",
+		Software:     fhir.CapabilitySoftware{Name: "Hospital Management System"},
+		FHIRVersion:  fhir.Version,
+		Format:       []string{"json"},
+		Rest: []fhir.CapabilityRest{{
+			Mode:     "server",
+			Security: &fhir.CapabilitySecurity{Description: "Bearer access token from POST /api/auth/login"},
+			Resource: []fhir.CapabilityResource{
+				{
+					Type:        "Patient",
+					Interaction: []fhir.CapabilityInteraction{read, search, {Code: "create"}, {Code: "update"}},
+					SearchParam: []fhir.CapabilitySearchParam{
+						{Name: "name", Type: "string", Documentation: "Start of the given or family name"},
+						{Name: "birthdate", Type: "date", Documentation: "eq, ge, gt, le and lt prefixes; may be repeated"},
+						{Name: "identifier", Type: "token", Documentation: "system|value; a value without a system is an MRN"},
+						{Name: "_count", Type: "number"},
+					},
+				},
+				{
+					Type:        "Encounter",
+					Interaction: []fhir.CapabilityInteraction{read, search},
+					SearchParam: []fhir.CapabilitySearchParam{
+						{Name: "patient", Type: "reference", Documentation: "Required"},
+						{Name: "subject", Type: "reference", Documentation: "Patient/<id>; same as patient"},
+					},
+				},
+			},
+		}},
+	}
+
+	writeFHIR(c, http.StatusOK, statement)
+}
+
+// ReadPatient handles GET Patient/:id
+func (h *FHIRHandler) ReadPatient(c *gin.Context) {
+	id, ok := fhirID(c)
+	if !ok {
+		return
+	}
+
+	patient, err := h.fhirService.ReadPatient(actorFromContext(c), id)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, patient)
+}
+
+// SearchPatients handles GET Patient with the name, birthdate and
+// identifier parameters, returning a searchset Bundle. Further pages are
+// linked from the Bundle's next link.
+func (h *FHIRHandler) SearchPatients(c *gin.Context) {
+	params := c.Request.URL.Query()
+	if err := checkFHIRSearchParams(params, "name", "birthdate", "identifier", "_count", "_cursor"); err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	search := services.FHIRPatientSearch{
+		Name:       params.Get("name"),
+		BirthDate:  params["birthdate"],
+		Identifier: params.Get("identifier"),
+		Cursor:     params.Get("_cursor"),
+	}
+	if v := params.Get("_count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil || count <= 0 {
+			writeFHIRError(c, fmt.Errorf("%w: _count must be a positive number", services.ErrInvalidFHIRResource))
+			return
+		}
+		search.Count = count
+	}
+
+	patients, next, err := h.fhirService.SearchPatients(actorFromContext(c), search)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	base := fhirBaseURL(c)
+	resources := make([]interface{}, len(patients))
+	fullURLs := make([]string, len(patients))
+	for i, patient := range patients {
+		resources[i] = patient
+		fullURLs[i] = base + "/Patient/" + patient.ID
+	}
+	bundle := fhir.NewSearchBundle(base+"/Patient?"+params.Encode(), resources, fullURLs)
+	if next != "" {
+		params.Set("_cursor", next)
+		bundle.Link = append(bundle.Link, fhir.BundleLink{Relation: "next", URL: base + "/Patient?" + params.Encode()})
+	}
+
+	writeFHIR(c, http.StatusOK, bundle)
+}
+
+// CreatePatient handles POST Patient. A likely duplicate of an existing
+// patient is refused with 409.
+func (h *FHIRHandler) CreatePatient(c *gin.Context) {
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeFHIRError(c, fmt.Errorf("%w: %v", services.ErrInvalidFHIRResource, err))
+		return
+	}
+
+	patient, err := h.fhirService.CreatePatient(actorFromContext(c), &resource)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	c.Header("Location", fhirBaseURL(c)+"/Patient/"+patient.ID)
+	writeFHIR(c, http.StatusCreated, patient)
+}
+
+// UpdatePatient handles PUT Patient/:id. The patient must already exist.
+func (h *FHIRHandler) UpdatePatient(c *gin.Context) {
+	id, ok := fhirID(c)
+	if !ok {
+		return
+	}
+
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeFHIRError(c, fmt.Errorf("%w: %v", services.ErrInvalidFHIRResource, err))
+		return
+	}
+
+	patient, err := h.fhirService.UpdatePatient(actorFromContext(c), id, &resource)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, patient)
+}
+
+// ReadEncounter handles GET Encounter/:id
+func (h *FHIRHandler) ReadEncounter(c *gin.Context) {
+	id, ok := fhirID(c)
+	if !ok {
+		return
+	}
+
+	encounter, err := h.fhirService.ReadEncounter(actorFromContext(c), id)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, encounter)
+}
+
+// SearchEncounters handles GET Encounter?patient= (or subject=), returning
+// the patient's encounters in a searchset Bundle
+func (h *FHIRHandler) SearchEncounters(c *gin.Context) {
+	params := c.Request.URL.Query()
+	if err := checkFHIRSearchParams(params, "patient", "subject"); err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+	patient := params.Get("patient")
+	if patient == "" {
+		patient = params.Get("subject")
+	}
+	if patient == "" {
+		writeFHIRError(c, fmt.Errorf("%w: Encounter search requires patient", services.ErrUnsupportedFHIRSearch))
+		return
+	}
+
+	encounters, err := h.fhirService.SearchEncounters(actorFromContext(c), patient)
+	if err != nil {
+		writeFHIRError(c, err)
+		return
+	}
+
+	base := fhirBaseURL(c)
+	resources := make([]interface{}, len(encounters))
+	fullURLs := make([]string, len(encounters))
+	for i, encounter := range encounters {
+		resources[i] = encounter
+		fullURLs[i] = base + "/Encounter/" + encounter.ID
+	}
+
+	writeFHIR(c, http.StatusOK, fhir.NewSearchBundle(base+"/Encounter?"+params.Encode(), resources, fullURLs))
+}
+
+// fhirID reads the :id path parameter. An ID that is not a number cannot
+// name a resource here, so it is answered with 404.
+func fhirID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		writeFHIR(c, http.StatusNotFound, fhir.NewOperationOutcome(fhir.IssueNotFound, "no resource with id "+c.Param("id")))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// checkFHIRSearchParams refuses search parameters outside allowed, so a
+// client never mistakes an unfiltered result for a filtered one. _format
+// is always accepted; only JSON is served.
+func checkFHIRSearchParams(params url.Values, allowed ...string) error {
+	for name := range params {
+		if name == "_format" {
+			continue
+		}
+		supported := false
+		for _, a := range allowed {
+			supported = supported || name == a
+		}
+		if !supported {
+			return fmt.Errorf("%w: search parameter %q", services.ErrUnsupportedFHIRSearch, name)
+		}
+	}
+	return nil
+}
+
+// fhirBaseURL is the absolute URL of the FHIR API as the client reached it
+func fhirBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + FHIRBasePath
+}
+
+func writeFHIR(c *gin.Context, status int, resource interface{}) {
+	c.Header("Content-Type", fhir.ContentType+"; charset=utf-8")
+	c.JSON(status, resource)
+}
+
+// writeFHIRError answers with an OperationOutcome describing err
+func writeFHIRError(c *gin.Context, err error) {
+	status, code, diagnostics := http.StatusInternalServerError, fhir.IssueException, err.Error()
+
+	var duplicate *services.DuplicatePatientError
+	switch {
+	case errors.As(err, &duplicate):
+		refs := make([]string, len(duplicate.Candidates))
+		for i, candidate := range duplicate.Candidates {
+			refs[i] = "Patient/" + strconv.Itoa(candidate.Patient.ID)
+		}
+		status, code = http.StatusConflict, fhir.IssueDuplicate
+		diagnostics = fmt.Sprintf("%s: %s", err, strings.Join(refs, ", "))
+	case errors.Is(err, sql.ErrNoRows):
+		status, code, diagnostics = http.StatusNotFound, fhir.IssueNotFound, "resource not found"
+	case errors.Is(err, services.ErrUnsupportedFHIRSearch):
+		status, code = http.StatusBadRequest, fhir.IssueNotSupported
+	case errors.Is(err, services.ErrExportLink):
+		status, code = http.StatusForbidden, fhir.IssueForbidden
+	case errors.Is(err, services.ErrInvalidFHIRResource),
+		errors.Is(err, services.ErrInvalidPatientRecord),
+		errors.Is(err, services.ErrInvalidListQuery),
+		errors.Is(err, services.ErrInvalidExport):
+		status, code = http.StatusBadRequest, fhir.IssueInvalid
+	case errors.Is(err, services.ErrIdentifierInUse):
+		status, code = http.StatusConflict, fhir.IssueConflict
+	}
+
+	writeFHIR(c, status, fhir.NewOperationOutcome(code, diagnostics))
+}
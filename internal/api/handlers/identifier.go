@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddIdentifierRequest struct {
+	System string `json:"system" binding:"required"`
+	Value  string `json:"value" binding:"required"`
+}
+
+// GetPatientByIdentifier looks a patient up by ?system=&value=, e.g.
+// system=mrn or system=national_id
+func (h *PatientHandler) GetPatientByIdentifier(c *gin.Context) {
+	patient, err := h.patientService.FindPatientByIdentifier(actorFromContext(c), c.Query("system"), c.Query("value"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidIdentifier):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, patient)
+}
+
+// GetIdentifiers lists a patient's external identifiers
+func (h *PatientHandler) GetIdentifiers(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	identifiers, err := h.patientService.GetIdentifiers(actorFromContext(c), uint(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, identifiers)
+}
+
+// AddIdentifier records an identifier issued to the patient by another system
+func (h *PatientHandler) AddIdentifier(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req AddIdentifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identifier := &models.PatientIdentifier{PatientID: int(id), System: req.System, Value: req.Value}
+	if err := h.patientService.AddIdentifier(actorFromContext(c), identifier); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidIdentifier):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrIdentifierInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, identifier)
+}
+
+// DeleteIdentifier removes one of a patient's external identifiers
+func (h *PatientHandler) DeleteIdentifier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	identifierID, err := strconv.ParseUint(c.Param("identifier_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier ID"})
+		return
+	}
+
+	if err := h.patientService.DeleteIdentifier(actorFromContext(c), uint(id), uint(identifierID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Identifier not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InsuranceHandler struct {
+	insuranceService *services.InsuranceService
+}
+
+func NewInsuranceHandler(insuranceService *services.InsuranceService) *InsuranceHandler {
+	return &InsuranceHandler{insuranceService: insuranceService}
+}
+
+type ClaimRequest struct {
+	// PolicyID defaults to the patient's primary policy
+	PolicyID *int `json:"policy_id"`
+}
+
+// GetPolicies handles listing a patient's insurance policies
+func (h *InsuranceHandler) GetPolicies(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	policies, err := h.insuranceService.GetPolicies(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// CreatePolicy handles adding an insurance policy to a patient
+func (h *InsuranceHandler) CreatePolicy(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var policy models.InsurancePolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy.ID, policy.PatientID = 0, int(patientID)
+	if err := h.insuranceService.CreatePolicy(actorFromContext(c), &policy); err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdatePolicy handles changing one of a patient's insurance policies
+func (h *InsuranceHandler) UpdatePolicy(c *gin.Context) {
+	patientID, policyID, ok := policyParams(c)
+	if !ok {
+		return
+	}
+
+	var policy models.InsurancePolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy.ID, policy.PatientID = int(policyID), int(patientID)
+	if err := h.insuranceService.UpdatePolicy(actorFromContext(c), &policy); err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy handles removing an insurance policy entered in error
+func (h *InsuranceHandler) DeletePolicy(c *gin.Context) {
+	patientID, policyID, ok := policyParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.insuranceService.DeletePolicy(actorFromContext(c), patientID, policyID); err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Insurance policy deleted successfully"})
+}
+
+// CheckEligibility handles checking a policy's coverage on the date query
+// parameter, today when it is absent
+func (h *InsuranceHandler) CheckEligibility(c *gin.Context) {
+	patientID, policyID, ok := policyParams(c)
+	if !ok {
+		return
+	}
+
+	serviceDate, err := parseOptionalTime(c, "date", "2006-01-02")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if serviceDate == nil {
+		today := time.Now()
+		serviceDate = &today
+	}
+
+	result, err := h.insuranceService.CheckEligibility(actorFromContext(c), patientID, policyID, *serviceDate)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateClaim handles billing an invoice to an insurance policy
+func (h *InsuranceHandler) CreateClaim(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	var req ClaimRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claim, err := h.insuranceService.CreateClaim(actorFromContext(c), invoiceID, req.PolicyID)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, claim)
+}
+
+// GetClaims handles listing the claims billed for an invoice
+func (h *InsuranceHandler) GetClaims(c *gin.Context) {
+	invoiceID, ok := uintParam(c, "id", "Invalid invoice ID")
+	if !ok {
+		return
+	}
+
+	claims, err := h.insuranceService.GetClaims(actorFromContext(c), invoiceID)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
+
+// GetClaim handles showing a claim and how its remittance was posted
+func (h *InsuranceHandler) GetClaim(c *gin.Context) {
+	claimID, ok := uintParam(c, "id", "Invalid claim ID")
+	if !ok {
+		return
+	}
+
+	claim, err := h.insuranceService.GetClaim(actorFromContext(c), claimID)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// DownloadClaim handles downloading the 837P file a claim was sent as
+func (h *InsuranceHandler) DownloadClaim(c *gin.Context) {
+	claimID, ok := uintParam(c, "id", "Invalid claim ID")
+	if !ok {
+		return
+	}
+
+	claim, err := h.insuranceService.GetClaim(actorFromContext(c), claimID)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.837"`, claim.ControlNumber))
+	c.Data(http.StatusOK, "application/edi-x12", []byte(claim.Content))
+}
+
+// PostRemittance handles posting a payer's 835 remittance, sent as the
+// request body, against the claims it pays
+func (h *InsuranceHandler) PostRemittance(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.insuranceService.PostRemittance(actorFromContext(c), data)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// policyParams reads the patient and policy IDs from the path
+func policyParams(c *gin.Context) (uint, uint, bool) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return 0, 0, false
+	}
+	policyID, ok := uintParam(c, "policy_id", "Invalid policy ID")
+	return patientID, policyID, ok
+}
+
+func insuranceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidPolicy),
+		errors.Is(err, services.ErrInvalidClaim),
+		errors.Is(err, services.ErrInvalidRemittance):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrPolicyOverlap),
+		errors.Is(err, services.ErrClaimExists),
+		errors.Is(err, services.ErrInvoiceStatus),
+		errors.Is(err, services.ErrPolicyInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabHandler struct {
+	labService *services.LabService
+}
+
+func NewLabHandler(labService *services.LabService) *LabHandler {
+	return &LabHandler{labService: labService}
+}
+
+type CollectSpecimenRequest struct {
+	CollectedAt *time.Time `json:"collected_at"`
+}
+
+type LabResultRequest struct {
+	Value      *float64  `json:"value" binding:"required"`
+	Unit       string    `json:"unit"`
+	Comment    string    `json:"comment"`
+	ResultedAt time.Time `json:"resulted_at"`
+}
+
+// GetCatalog handles listing the orderable tests and their reference ranges
+func (h *LabHandler) GetCatalog(c *gin.Context) {
+	tests, err := h.labService.GetCatalog()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tests)
+}
+
+// GetOrders handles listing a patient's lab orders and results
+func (h *LabHandler) GetOrders(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	orders, err := h.labService.GetOrders(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// CreateOrder handles ordering a test
+func (h *LabHandler) CreateOrder(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var order models.LabOrder
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order.PatientID = int(patientID)
+	if err := h.labService.OrderTest(actorFromContext(c), &order); err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetOrder handles getting a lab order with its result
+func (h *LabHandler) GetOrder(c *gin.Context) {
+	patientID, orderID, ok := labOrderParams(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.labService.GetOrder(actorFromContext(c), patientID, orderID)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CollectSpecimen handles marking an order's specimen collected
+func (h *LabHandler) CollectSpecimen(c *gin.Context) {
+	patientID, orderID, ok := labOrderParams(c)
+	if !ok {
+		return
+	}
+
+	var req CollectSpecimenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	order, err := h.labService.CollectSpecimen(actorFromContext(c), patientID, orderID, req.CollectedAt)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// RecordResult handles entering the result of a collected order
+func (h *LabHandler) RecordResult(c *gin.Context) {
+	patientID, orderID, ok := labOrderParams(c)
+	if !ok {
+		return
+	}
+
+	var req LabResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := &models.LabResult{Value: *req.Value, Unit: req.Unit, Comment: req.Comment, ResultedAt: req.ResultedAt}
+	order, err := h.labService.RecordResult(actorFromContext(c), patientID, orderID, result)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// VerifyResult handles verifying a resulted order
+func (h *LabHandler) VerifyResult(c *gin.Context) {
+	patientID, orderID, ok := labOrderParams(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.labService.VerifyResult(actorFromContext(c), patientID, orderID)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder handles cancelling an order that has no result
+func (h *LabHandler) CancelOrder(c *gin.Context) {
+	patientID, orderID, ok := labOrderParams(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.labService.CancelOrder(actorFromContext(c), patientID, orderID)
+	if err != nil {
+		c.JSON(labErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func labOrderParams(c *gin.Context) (patientID, orderID uint, ok bool) {
+	if patientID, ok = uintParam(c, "id", "Invalid patient ID"); !ok {
+		return
+	}
+	orderID, ok = uintParam(c, "order_id", "Invalid lab order ID")
+	return
+}
+
+func labErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidLabOrder),
+		errors.Is(err, services.ErrInvalidLabResult):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrLabOrderStatus):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
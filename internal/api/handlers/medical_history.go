@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MedicalHistoryHandler struct {
+	historyService *services.MedicalHistoryService
+}
+
+func NewMedicalHistoryHandler(historyService *services.MedicalHistoryService) *MedicalHistoryHandler {
+	return &MedicalHistoryHandler{historyService: historyService}
+}
+
+// GetAllergies handles listing a patient's allergies, active first
+func (h *MedicalHistoryHandler) GetAllergies(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	allergies, err := h.historyService.GetAllergies(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, allergies)
+}
+
+// CreateAllergy handles recording an allergy
+func (h *MedicalHistoryHandler) CreateAllergy(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var allergy models.Allergy
+	if err := c.ShouldBindJSON(&allergy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allergy.PatientID = int(patientID)
+	if err := h.historyService.AddAllergy(actorFromContext(c), &allergy); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, allergy)
+}
+
+// UpdateAllergy handles changing an allergy, including marking it inactive
+func (h *MedicalHistoryHandler) UpdateAllergy(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "allergy_id", "Invalid allergy ID")
+	if !ok {
+		return
+	}
+
+	var allergy models.Allergy
+	if err := c.ShouldBindJSON(&allergy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allergy.ID = int(id)
+	allergy.PatientID = int(patientID)
+	if err := h.historyService.UpdateAllergy(actorFromContext(c), &allergy); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, allergy)
+}
+
+// DeleteAllergy handles removing an allergy entered in error
+func (h *MedicalHistoryHandler) DeleteAllergy(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "allergy_id", "Invalid allergy ID")
+	if !ok {
+		return
+	}
+
+	if err := h.historyService.DeleteAllergy(actorFromContext(c), patientID, id); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetProblems handles listing a patient's problem list
+func (h *MedicalHistoryHandler) GetProblems(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	problems, err := h.historyService.GetProblems(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, problems)
+}
+
+// CreateProblem handles adding an ICD-10 coded problem
+func (h *MedicalHistoryHandler) CreateProblem(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var problem models.Problem
+	if err := c.ShouldBindJSON(&problem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problem.PatientID = int(patientID)
+	if err := h.historyService.AddProblem(actorFromContext(c), &problem); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, problem)
+}
+
+// UpdateProblem handles changing a problem, e.g. marking it resolved
+func (h *MedicalHistoryHandler) UpdateProblem(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "problem_id", "Invalid problem ID")
+	if !ok {
+		return
+	}
+
+	var problem models.Problem
+	if err := c.ShouldBindJSON(&problem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problem.ID = int(id)
+	problem.PatientID = int(patientID)
+	if err := h.historyService.UpdateProblem(actorFromContext(c), &problem); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
+// DeleteProblem handles removing a problem entered in error
+func (h *MedicalHistoryHandler) DeleteProblem(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "problem_id", "Invalid problem ID")
+	if !ok {
+		return
+	}
+
+	if err := h.historyService.DeleteProblem(actorFromContext(c), patientID, id); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetMedications handles listing a patient's medication history
+func (h *MedicalHistoryHandler) GetMedications(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	medications, err := h.historyService.GetMedications(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, medications)
+}
+
+// CreateMedication handles recording a medication taken outside our prescriptions
+func (h *MedicalHistoryHandler) CreateMedication(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var medication models.Medication
+	if err := c.ShouldBindJSON(&medication); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	medication.PatientID = int(patientID)
+	if err := h.historyService.AddMedication(actorFromContext(c), &medication); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, medication)
+}
+
+// UpdateMedication handles changing a medication, e.g. marking it stopped
+func (h *MedicalHistoryHandler) UpdateMedication(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "medication_id", "Invalid medication ID")
+	if !ok {
+		return
+	}
+
+	var medication models.Medication
+	if err := c.ShouldBindJSON(&medication); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	medication.ID = int(id)
+	medication.PatientID = int(patientID)
+	if err := h.historyService.UpdateMedication(actorFromContext(c), &medication); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, medication)
+}
+
+// DeleteMedication handles removing a medication entered in error
+func (h *MedicalHistoryHandler) DeleteMedication(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "medication_id", "Invalid medication ID")
+	if !ok {
+		return
+	}
+
+	if err := h.historyService.DeleteMedication(actorFromContext(c), patientID, id); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func historyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAllergy),
+		errors.Is(err, services.ErrInvalidProblem),
+		errors.Is(err, services.ErrInvalidMedication):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAChallenge), errors.Is(err, services.ErrInvalidMFACode):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAAlreadyEnabled), errors.Is(err, services.ErrMFANotEnrolled),
+		errors.Is(err, services.ErrMFANotEnabled):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// VerifyMFA completes a login with the code from the user's authenticator
+// app, or one of their recovery codes
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, tokens, err := h.authService.VerifyMFA(req.MFAToken, req.Code, c.ClientIP())
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		tooManyAttempts(c, throttled)
+		return
+	}
+	if err != nil {
+		status := mfaErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Could not log in"})
+		} else {
+			c.JSON(status, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	loginSucceeded(c, user, tokens)
+}
+
+// GetMFAStatus tells the caller whether they have MFA enabled
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	status, err := h.authService.GetMFAStatus(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch MFA status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+// EnrollMFA starts setting up the caller's authenticator app
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	enrollment, err := h.authService.EnrollMFA(c.GetInt64("user_id"))
+	if err != nil {
+		status := mfaErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Could not start MFA enrollment"})
+		} else {
+			c.JSON(status, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enrollment,
+		"message": "Add the account to your authenticator app, then confirm with a code from it",
+	})
+}
+
+// ConfirmMFA turns MFA on and returns the caller's recovery codes
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	codes, err := h.authService.ConfirmMFA(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		status := mfaErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Could not enable MFA"})
+		} else {
+			c.JSON(status, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"recovery_codes": codes,
+		"message":        "MFA enabled. Store the recovery codes somewhere safe; they will not be shown again",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		status := mfaErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Could not regenerate recovery codes"})
+		} else {
+			c.JSON(status, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"recovery_codes": codes,
+		"message":        "Recovery codes replaced; the old ones no longer work",
+	})
+}
+
+// ResetMFA removes a user's second factor, e.g. after they lost their phone
+func (h *AuthHandler) ResetMFA(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if _, err := h.userService.GetUserByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.authService.ResetMFA(actorFromContext(c), int64(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset MFA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "MFA reset; the user can log in with their password and enroll again",
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService *services.PasswordService
+}
+
+func NewPasswordHandler(passwordService *services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+func passwordErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWeakPassword), errors.Is(err, services.ErrInvalidResetToken):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrWrongPassword):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNoNotifier):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// passwordError answers with the status for err, without revealing
+// internal errors
+func passwordError(c *gin.Context, err error, message string) {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		tooManyAttempts(c, throttled)
+		return
+	}
+
+	status := passwordErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// ChangePassword sets a new password for the caller, who has to give their
+// current one. The caller's other sessions end.
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err := h.passwordService.ChangePassword(actorFromContext(c), c.GetString("token_id"), c.ClientIP(),
+		req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		passwordError(c, err, "Could not change password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password changed; your other sessions have been logged out",
+	})
+}
+
+// IssueResetToken sends a user a single-use password reset token. The
+// token goes to the user only, never into the response.
+func (h *PasswordHandler) IssueResetToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reset, err := h.passwordService.IssueResetToken(actorFromContext(c), int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		passwordError(c, err, "Could not issue password reset token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":    true,
+		"expires_at": reset.ExpiresAt,
+		"message":    "Password reset token sent to the user",
+	})
+}
+
+// ResetPassword sets a new password with a reset token
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		passwordError(c, err, "Could not reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password reset; log in with the new password",
+	})
+}
@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"hospital-management-system/internal/domain/models"
 	"hospital-management-system/internal/services"
@@ -12,13 +20,15 @@ import (
 
 type PatientHandler struct {
 	patientService *services.PatientService
+	historyService *services.MedicalHistoryService
 }
 
-func NewPatientHandler(patientService *services.PatientService) *PatientHandler {
-	return &PatientHandler{patientService: patientService}
+func NewPatientHandler(patientService *services.PatientService, historyService *services.MedicalHistoryService) *PatientHandler {
+	return &PatientHandler{patientService: patientService, historyService: historyService}
 }
 
-// CreatePatient handles the creation of a new patient
+// CreatePatient handles the creation of a new patient. Possible duplicates
+// are rejected with 409 and the matching records unless ?allow_duplicate=true.
 func (h *PatientHandler) CreatePatient(c *gin.Context) {
 	var patient models.Patient
 	if err := c.ShouldBindJSON(&patient); err != nil {
@@ -26,7 +36,20 @@ func (h *PatientHandler) CreatePatient(c *gin.Context) {
 		return
 	}
 
-	if err := h.patientService.CreatePatient(&patient); err != nil {
+	allowDuplicate := c.Query("allow_duplicate") == "true"
+	if err := h.patientService.CreatePatient(actorFromContext(c), &patient, allowDuplicate); err != nil {
+		var duplicate *services.DuplicatePatientError
+		if errors.As(err, &duplicate) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      err.Error(),
+				"candidates": duplicate.Candidates,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPatientRecord) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -34,7 +57,70 @@ func (h *PatientHandler) CreatePatient(c *gin.Context) {
 	c.JSON(http.StatusCreated, patient)
 }
 
-// GetPatient handles fetching a patient by ID
+// maxImportFileSize bounds the size of an uploaded patient import file
+const maxImportFileSize = 10 << 20
+
+// ImportPatients handles a multipart upload of patients in a CSV or XLSX
+// file (the "file" field). The optional "mapping" field is a JSON object
+// from patient field to column header, and "format" overrides the format
+// taken from the file name. With ?dry_run=true nothing is saved; rows that
+// look like existing patients are refused unless ?allow_duplicate=true.
+// The report lists the outcome of every row.
+func (h *PatientHandler) ImportPatients(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+1<<20)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && header.Size > maxImportFileSize) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d bytes", maxImportFileSize)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	var opts services.PatientImportOptions
+	if v := c.PostForm("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &opts.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object from field to column header"})
+			return
+		}
+	}
+	opts.DryRun = c.Query("dry_run") == "true"
+	opts.AllowDuplicates = c.Query("allow_duplicate") == "true"
+
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.patientService.ImportPatients(actorFromContext(c), format, data, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImport) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetPatient handles fetching a patient by ID, with a summary of their
+// active allergies
 func (h *PatientHandler) GetPatient(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -43,13 +129,19 @@ func (h *PatientHandler) GetPatient(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.patientService.GetPatientByID(uint(id))
+	patient, err := h.patientService.GetPatientByID(actorFromContext(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patient)
+	allergies, err := h.historyService.AllergySummary(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PatientDetail{Patient: patient, Allergies: allergies})
 }
 
 // UpdatePatient handles updating an existing patient
@@ -68,12 +160,17 @@ func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 	}
 
 	patient.ID = int(id)
-	if err := h.patientService.UpdatePatient(&patient); err != nil {
+	saved, err := h.patientService.UpdatePatient(actorFromContext(c), &patient)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPatientRecord) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patient)
+	c.JSON(http.StatusOK, saved)
 }
 
 // DeletePatient handles deleting a patient by ID
@@ -85,7 +182,11 @@ func (h *PatientHandler) DeletePatient(c *gin.Context) {
 		return
 	}
 
-	if err := h.patientService.DeletePatient(uint(id)); err != nil {
+	if err := h.patientService.DeletePatient(actorFromContext(c), uint(id)); err != nil {
+		if errors.Is(err, services.ErrPatientInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -93,13 +194,165 @@ func (h *PatientHandler) DeletePatient(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
-// GetAllPatients handles fetching all patients
+type MergePatientRequest struct {
+	DuplicateID uint `json:"duplicate_id" binding:"required"`
+}
+
+// MergePatient merges the duplicate given in the body into the patient in the path
+func (h *PatientHandler) MergePatient(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req MergePatientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	survivor, err := h.patientService.MergePatients(actorFromContext(c), uint(id), req.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMerge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, survivor)
+}
+
+// GetMergeHistory lists the duplicate records merged into a patient
+func (h *PatientHandler) GetMergeHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	merges, err := h.patientService.GetMergeHistory(actorFromContext(c), uint(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
+
+// SearchPatients handles fuzzy and phonetic patient search via ?q=
+func (h *PatientHandler) SearchPatients(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = n
+	}
+
+	matches, err := h.patientService.SearchPatients(actorFromContext(c), c.Query("q"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"query": c.Query("q"), "results": matches})
+}
+
+// GetAllPatients handles listing patients one page at a time. Supported
+// query parameters: name (prefix of first or last name), gender,
+// dob_from/dob_to (YYYY-MM-DD), created_from/created_to (RFC 3339),
+// sort (created_at, last_name, date_of_birth), order (asc, desc), limit
+// and cursor (next_cursor from the previous page).
 func (h *PatientHandler) GetAllPatients(c *gin.Context) {
-	patients, err := h.patientService.GetAllPatients()
+	query, err := parsePatientListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.patientService.ListPatients(actorFromContext(c), query, c.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, patients)
+	c.JSON(http.StatusOK, page)
+}
+
+func parsePatientListQuery(c *gin.Context) (models.PatientListQuery, error) {
+	query := models.PatientListQuery{
+		Filter: models.PatientFilter{
+			NamePrefix: c.Query("name"),
+			Gender:     c.Query("gender"),
+		},
+		SortBy: c.Query("sort"),
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return query, errors.New("limit must be a positive number")
+		}
+		query.Limit = limit
+	}
+
+	var err error
+	if query.Filter.DOBFrom, err = parseOptionalTime(c, "dob_from", "2006-01-02"); err != nil {
+		return query, err
+	}
+	if query.Filter.DOBTo, err = parseOptionalTime(c, "dob_to", "2006-01-02"); err != nil {
+		return query, err
+	}
+	if query.Filter.CreatedFrom, err = parseOptionalTime(c, "created_from", time.RFC3339); err != nil {
+		return query, err
+	}
+	if query.Filter.CreatedTo, err = parseOptionalTime(c, "created_to", time.RFC3339); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+// parseOptionalTime parses the named query parameter with layout, returning
+// nil when it is absent
+func parseOptionalTime(c *gin.Context, name, layout string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be in %s format", name, layout)
+	}
+	return &t, nil
 }
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patientExportColumns are the columns of CSV and PDF patient exports
+var patientExportColumns = []string{"id", "mrn", "first_name", "last_name", "dob", "gender", "phone", "email", "address", "created_at"}
+
+// patientExporter writes patients in one export format
+type patientExporter interface {
+	begin() error
+	write(patients []models.Patient) error
+	end() error
+}
+
+// ExportPatients handles downloading every patient matching the listing
+// filters (see GetAllPatients) as ?format=csv (the default), json or pdf.
+// Patients are read and written a page at a time rather than loaded at
+// once. An error after the download has started cuts it short.
+func (h *PatientHandler) ExportPatients(c *gin.Context) {
+	query, err := parsePatientListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	var exporter patientExporter
+	var contentType string
+	switch format {
+	case "csv":
+		exporter, contentType = &csvPatientExporter{w: csv.NewWriter(c.Writer)}, "text/csv; charset=utf-8"
+	case "json":
+		exporter, contentType = &jsonPatientExporter{w: c.Writer}, "application/json; charset=utf-8"
+	case "pdf":
+		exporter, contentType = &pdfPatientExporter{out: c.Writer}, "application/pdf"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, json or pdf"})
+		return
+	}
+
+	// Nothing is written until the export is known to be allowed, so that
+	// an early error can still be answered with a status code
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="patients-%s.%s"`, time.Now().UTC().Format("20060102"), format))
+		c.Status(http.StatusOK)
+		return exporter.begin()
+	}
+
+	err = h.patientService.ExportPatients(c.Request.Context(), actorFromContext(c), query, func(patients []models.Patient) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := exporter.write(patients); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = exporter.end()
+	}
+	if err != nil {
+		if started {
+			c.Error(err)
+		} else if errors.Is(err, services.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	}
+}
+
+// patientExportRow is a patient's values in patientExportColumns order
+func patientExportRow(p *models.Patient) []string {
+	return []string{
+		strconv.Itoa(p.ID), p.MRN, p.FirstName, p.LastName, p.DOB.Format("2006-01-02"),
+		p.Gender, p.Phone, p.Email, p.Address, p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type csvPatientExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvPatientExporter) begin() error {
+	return e.w.Write(patientExportColumns)
+}
+
+func (e *csvPatientExporter) write(patients []models.Patient) error {
+	for i := range patients {
+		if err := e.w.Write(patientExportRow(&patients[i])); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvPatientExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonPatientExporter writes a JSON array of patients, one element at a time
+type jsonPatientExporter struct {
+	w     io.Writer
+	first bool
+}
+
+func (e *jsonPatientExporter) begin() error {
+	e.first = true
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonPatientExporter) write(patients []models.Patient) error {
+	for i := range patients {
+		data, err := json.Marshal(&patients[i])
+		if err != nil {
+			return err
+		}
+		if !e.first {
+			data = append([]byte(","), data...)
+		}
+		e.first = false
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonPatientExporter) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// pdfPatientExporter writes patients as a fixed-width table, one per line
+type pdfPatientExporter struct {
+	out io.Writer
+	w   *pdf.Writer
+}
+
+// pdfPatientColumnWidths are the widths, in characters, of the PDF table's
+// columns; together with the gaps they fill pdf.LineWidth
+var pdfPatientColumnWidths = []int{6, 13, 15, 15, 10, 6, 15, 27, 23, 20}
+
+func (e *pdfPatientExporter) begin() error {
+	e.w = pdf.NewWriter(e.out, "Patients exported "+time.Now().UTC().Format("2006-01-02 15:04 MST"))
+	return e.w.Line(pdfTableRow(patientExportColumns))
+}
+
+func (e *pdfPatientExporter) write(patients []models.Patient) error {
+	for i := range patients {
+		if err := e.w.Line(pdfTableRow(patientExportRow(&patients[i]))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *pdfPatientExporter) end() error {
+	return e.w.Close()
+}
+
+// pdfTableRow pads or cuts each value to its column's width
+func pdfTableRow(values []string) string {
+	row := ""
+	for i, v := range values {
+		width := pdfPatientColumnWidths[i]
+		runes := []rune(v)
+		if len(runes) > width {
+			runes = append(runes[:width-1], '~')
+		}
+		row += fmt.Sprintf("%-*s ", width, string(runes))
+	}
+	return row
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrescriptionHandler struct {
+	prescriptionService *services.PrescriptionService
+}
+
+func NewPrescriptionHandler(prescriptionService *services.PrescriptionService) *PrescriptionHandler {
+	return &PrescriptionHandler{prescriptionService: prescriptionService}
+}
+
+// CreatePrescription handles issuing a prescription. If it interacts with
+// the patient's medication or allergies it is rejected with 409 and the
+// warnings unless ?override_warnings=true.
+func (h *PrescriptionHandler) CreatePrescription(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := c.ShouldBindJSON(&prescription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prescription.PatientID = int(patientID)
+	override := c.Query("override_warnings") == "true"
+	warnings, err := h.prescriptionService.CreatePrescription(actorFromContext(c), &prescription, override)
+	if err != nil {
+		var unsafe *services.PrescriptionWarningsError
+		if errors.As(err, &unsafe) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    err.Error(),
+				"warnings": unsafe.Warnings,
+			})
+			return
+		}
+		c.JSON(prescriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"prescription": prescription, "warnings": warnings})
+}
+
+// CheckPrescription returns the warnings a prescription would raise without saving it
+func (h *PrescriptionHandler) CheckPrescription(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := c.ShouldBindJSON(&prescription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prescription.PatientID = int(patientID)
+	warnings, err := h.prescriptionService.CheckPrescription(actorFromContext(c), &prescription)
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"warnings": warnings})
+}
+
+// GetPrescriptions handles listing a patient's prescriptions
+func (h *PrescriptionHandler) GetPrescriptions(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	prescriptions, err := h.prescriptionService.GetPrescriptions(actorFromContext(c), patientID)
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prescriptions)
+}
+
+// GetPrescription handles fetching one of a patient's prescriptions
+func (h *PrescriptionHandler) GetPrescription(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "prescription_id", "Invalid prescription ID")
+	if !ok {
+		return
+	}
+
+	prescription, err := h.prescriptionService.GetPrescription(actorFromContext(c), patientID, id)
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prescription)
+}
+
+// DiscontinuePrescription handles stopping an active prescription
+func (h *PrescriptionHandler) DiscontinuePrescription(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+	id, ok := uintParam(c, "prescription_id", "Invalid prescription ID")
+	if !ok {
+		return
+	}
+
+	prescription, err := h.prescriptionService.DiscontinuePrescription(actorFromContext(c), patientID, id)
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prescription)
+}
+
+func prescriptionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotPrescriber):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidPrescription):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
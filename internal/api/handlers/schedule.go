@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleService *services.ScheduleService
+}
+
+func NewScheduleHandler(scheduleService *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{scheduleService: scheduleService}
+}
+
+// GetWorkingHours returns a doctor's weekly working-hour template
+func (h *ScheduleHandler) GetWorkingHours(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+
+	hours, err := h.scheduleService.GetWorkingHours(doctorID)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// SetWorkingHours replaces a doctor's weekly working-hour template
+func (h *ScheduleHandler) SetWorkingHours(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+	if !canManageSchedule(c, doctorID) {
+		return
+	}
+
+	var hours []models.WorkingHours
+	if err := c.ShouldBindJSON(&hours); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.scheduleService.SetWorkingHours(doctorID, hours); err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// GetExceptions lists a doctor's holidays and leave overlapping ?from=&to=
+func (h *ScheduleHandler) GetExceptions(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+
+	from, to, err := parseTimeRange(c, time.Now().Truncate(24*time.Hour), 90*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exceptions, err := h.scheduleService.GetExceptions(doctorID, from, to)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exceptions)
+}
+
+// CreateException blocks out a period of a doctor's schedule
+func (h *ScheduleHandler) CreateException(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+	if !canManageSchedule(c, doctorID) {
+		return
+	}
+
+	var exception models.ScheduleException
+	if err := c.ShouldBindJSON(&exception); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exception.DoctorID = doctorID
+	if err := h.scheduleService.AddException(&exception); err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, exception)
+}
+
+// DeleteException removes one of a doctor's schedule exceptions
+func (h *ScheduleHandler) DeleteException(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+	if !canManageSchedule(c, doctorID) {
+		return
+	}
+
+	exceptionID, err := strconv.ParseUint(c.Param("exception_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exception ID"})
+		return
+	}
+
+	if err := h.scheduleService.RemoveException(doctorID, uint(exceptionID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exception not found"})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAvailability returns the doctor's free slots between ?from= and ?to=
+func (h *ScheduleHandler) GetAvailability(c *gin.Context) {
+	doctorID, ok := doctorIDParam(c)
+	if !ok {
+		return
+	}
+
+	from, to, err := parseTimeRange(c, time.Now(), 7*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slots, err := h.scheduleService.GetAvailability(doctorID, from, to)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"doctor_id": doctorID,
+		"from":      from,
+		"to":        to,
+		"slots":     slots,
+	})
+}
+
+// doctorIDParam parses the :id path parameter, writing a 400 if it is invalid
+func doctorIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// canManageSchedule stops doctors from editing another doctor's schedule,
+// writing a 403 if they try
+func canManageSchedule(c *gin.Context, doctorID int64) bool {
+	if c.GetString("role") == models.RoleDoctor && c.GetInt64("user_id") != doctorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctors can only manage their own schedule"})
+		return false
+	}
+	return true
+}
+
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidDoctor), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidSchedule):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
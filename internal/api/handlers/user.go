@@ -43,6 +43,13 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
         return
     }
 
+    if _, err := h.userService.GetUserByID(id); err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    // The password is not bound (it is never serialized) and not saved
+    // here; it is changed through the password endpoints
     var user models.User
     if err := c.ShouldBindJSON(&user); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VitalSignsHandler struct {
+	vitalsService *services.VitalSignsService
+}
+
+func NewVitalSignsHandler(vitalsService *services.VitalSignsService) *VitalSignsHandler {
+	return &VitalSignsHandler{vitalsService: vitalsService}
+}
+
+// GetVitals handles the vital signs time series of a patient, optionally
+// limited to RFC 3339 ?from= and ?to= bounds
+func (h *VitalSignsHandler) GetVitals(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	from, err := parseOptionalTime(c, "from", time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseOptionalTime(c, "to", time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	readings, err := h.vitalsService.GetVitals(actorFromContext(c), patientID, from, to)
+	if err != nil {
+		c.JSON(vitalsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, readings)
+}
+
+// CreateVitals handles recording a set of vital signs
+func (h *VitalSignsHandler) CreateVitals(c *gin.Context) {
+	patientID, ok := uintParam(c, "id", "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var vitals models.VitalSigns
+	if err := c.ShouldBindJSON(&vitals); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vitals.PatientID = int(patientID)
+	if err := h.vitalsService.RecordVitals(actorFromContext(c), &vitals); err != nil {
+		c.JSON(vitalsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vitals)
+}
+
+// GetRanges handles listing the normal ranges vital signs are flagged against
+func (h *VitalSignsHandler) GetRanges(c *gin.Context) {
+	ranges, err := h.vitalsService.GetRanges()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ranges)
+}
+
+// UpdateRange handles changing a normal range
+func (h *VitalSignsHandler) UpdateRange(c *gin.Context) {
+	id, ok := uintParam(c, "range_id", "Invalid range ID")
+	if !ok {
+		return
+	}
+
+	var r models.VitalRange
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	r.ID = int(id)
+	if err := h.vitalsService.UpdateRange(&r); err != nil {
+		c.JSON(vitalsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func vitalsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidVitals),
+		errors.Is(err, services.ErrInvalidVitalRange):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,13 +3,20 @@ package middleware
 import (
     "net/http"
     "strings"
+    "time"
 
     "github.com/gin-gonic/gin"
     "hospital-management-system/pkg/utils"
 )
 
+// TokenRevocationChecker reports whether an access token has been revoked
+// before its natural expiry.
+type TokenRevocationChecker interface {
+    IsTokenRevoked(tokenID string) (bool, error)
+}
+
 // AuthMiddleware is a middleware function that checks for a valid JWT token in the request header.
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(revocations TokenRevocationChecker) gin.HandlerFunc {
     return func(c *gin.Context) {
         tokenString := c.Request.Header.Get("Authorization")
         if tokenString == "" {
@@ -25,16 +32,32 @@ func AuthMiddleware() gin.HandlerFunc {
 
         // Validate the token
         claims, err := utils.ValidateToken(tokenString)
-        if err != nil {
+        if err != nil || claims.Id == "" {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
             c.Abort()
             return
         }
 
+        // Reject tokens that were logged out or killed server-side
+        revoked, err := revocations.IsTokenRevoked(claims.Id)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify token"})
+            c.Abort()
+            return
+        }
+        if revoked {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+            c.Abort()
+            return
+        }
+
         // Set the user information in the context
         c.Set("userID", claims.Username)
+        c.Set("user_id", int64(claims.UserID))
         c.Set("role", claims.Role)
+        c.Set("token_id", claims.Id)
+        c.Set("token_expires_at", time.Unix(claims.ExpiresAt, 0))
 
         c.Next()
     }
-}
\ No newline at end of file
+}
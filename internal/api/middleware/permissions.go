@@ -0,0 +1,137 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "hospital-management-system/internal/domain/models"
+)
+
+// Action is something a role may do to a resource.
+type Action string
+
+const (
+    ActionRead   Action = "read"
+    ActionCreate Action = "create"
+    ActionUpdate Action = "update"
+    ActionDelete Action = "delete"
+)
+
+// Resource is a group of endpoints guarded by the same permissions.
+type Resource string
+
+const (
+    ResourcePatients     Resource = "patients"
+    ResourceAppointments Resource = "appointments"
+    ResourceSchedules    Resource = "schedules"
+    ResourceUsers        Resource = "users"
+    ResourceSessions     Resource = "sessions"
+    ResourceAudit        Resource = "audit"
+    // ResourceEncounters covers encounters and their clinical notes
+    ResourceEncounters    Resource = "encounters"
+    ResourcePrescriptions Resource = "prescriptions"
+    // ResourceMedicalHistory covers allergies, the problem list and medication history
+    ResourceMedicalHistory Resource = "medical_history"
+    ResourceVitals         Resource = "vitals"
+    // ResourceVitalRanges covers the normal ranges abnormal vital signs are flagged against
+    ResourceVitalRanges Resource = "vital_ranges"
+    // ResourceLabs covers lab orders, their results and the lab catalog
+    ResourceLabs Resource = "labs"
+    // ResourceBulkExport covers FHIR bulk data export jobs
+    ResourceBulkExport Resource = "bulk_export"
+    // ResourcePatientExport covers downloading patient listings as files
+    ResourcePatientExport Resource = "patient_export"
+    // ResourceWards covers wards, their rooms and beds, the bed board and the census
+    ResourceWards Resource = "wards"
+    // ResourceAdmissions covers admitting, transferring and discharging inpatients
+    ResourceAdmissions Resource = "admissions"
+    // ResourceBilling covers the chargemaster, tax rules, invoices, payments and balances,
+    // and insurance policies, claims and remittances
+    ResourceBilling Resource = "billing"
+    // ResourceMFA covers resetting another user's multi-factor authentication
+    ResourceMFA Resource = "mfa"
+    // ResourceLockouts covers lifting a user's lockout after too many failed logins
+    ResourceLockouts Resource = "lockouts"
+)
+
+var allActions = []Action{ActionRead, ActionCreate, ActionUpdate, ActionDelete}
+
+// Permissions is the permission matrix: role -> resource -> allowed actions.
+// Anything not listed here is denied.
+var Permissions = map[string]map[Resource][]Action{
+    models.RoleAdmin: {
+        ResourcePatients:       allActions,
+        ResourceAppointments:   allActions,
+        ResourceSchedules:      allActions,
+        ResourceUsers:          allActions,
+        ResourceSessions:       allActions,
+        ResourceMFA:            {ActionDelete},
+        ResourceLockouts:       {ActionDelete},
+        ResourceAudit:          {ActionRead},
+        ResourceEncounters:     {ActionRead},
+        ResourcePrescriptions:  {ActionRead},
+        ResourceMedicalHistory: {ActionRead},
+        ResourceVitals:         {ActionRead},
+        ResourceVitalRanges:    {ActionRead, ActionUpdate},
+        ResourceLabs:           {ActionRead},
+        ResourceBulkExport:     {ActionRead, ActionCreate, ActionDelete},
+        ResourcePatientExport:  {ActionRead},
+        ResourceWards:          allActions,
+        ResourceAdmissions:     {ActionRead},
+    },
+    models.RoleReceptionist: {
+        ResourcePatients:       {ActionRead, ActionCreate, ActionUpdate},
+        ResourceAppointments:   allActions,
+        ResourceSchedules:      {ActionRead},
+        ResourceUsers:          {ActionRead},
+        ResourceMedicalHistory: {ActionRead},
+        ResourceVitalRanges:    {ActionRead},
+        ResourceWards:          {ActionRead},
+        ResourceAdmissions:     {ActionRead, ActionCreate, ActionUpdate},
+    },
+    models.RoleDoctor: {
+        ResourcePatients:       {ActionRead, ActionUpdate},
+        ResourceAppointments:   {ActionRead, ActionUpdate},
+        ResourceSchedules:      allActions,
+        ResourceUsers:          {ActionRead},
+        ResourceEncounters:     {ActionRead, ActionCreate, ActionUpdate},
+        ResourcePrescriptions:  {ActionRead, ActionCreate, ActionUpdate},
+        ResourceMedicalHistory: allActions,
+        ResourceVitals:         {ActionRead, ActionCreate},
+        ResourceVitalRanges:    {ActionRead},
+        ResourceLabs:           {ActionRead, ActionCreate, ActionUpdate},
+        ResourceWards:          {ActionRead},
+        ResourceAdmissions:     {ActionRead, ActionCreate, ActionUpdate},
+    },
+    models.RoleCompliance: {
+        ResourceAudit: {ActionRead},
+    },
+    models.RoleBilling: {
+        ResourcePatients: {ActionRead},
+        ResourceBilling:  allActions,
+    },
+}
+
+// HasPermission reports whether role may perform action on resource.
+func HasPermission(role string, resource Resource, action Action) bool {
+    for _, allowed := range Permissions[role][resource] {
+        if allowed == action {
+            return true
+        }
+    }
+    return false
+}
+
+// RequirePermission rejects the request with 403 unless the caller's role,
+// as set by AuthMiddleware, may perform action on resource.
+func RequirePermission(resource Resource, action Action) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if !HasPermission(c.GetString("role"), resource, action) {
+            c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -1,33 +1,163 @@
 package routes
 
 import (
+	"log"
+	"time"
+
 	"hospital-management-system/internal/api/handlers"
 	"hospital-management-system/internal/api/middleware"
 	"hospital-management-system/internal/config"
 	"hospital-management-system/internal/infrastructure/database"
+	"hospital-management-system/internal/infrastructure/mllp"
 	"hospital-management-system/internal/infrastructure/repository"
 	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/x12"
 
 	"github.com/gin-gonic/gin"
 )
 
+// exportSweepInterval is how often bulk exports past their retention
+// period are deleted
+const exportSweepInterval = time.Hour
+
 func SetupRoutes(router *gin.Engine) {
 
 	// Initialize repositories
 	db := database.GetDB()
 	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
 	patientRepo := repository.NewPatientRepository(db)
+	appointmentRepo := repository.NewAppointmentRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	identifierRepo := repository.NewIdentifierRepository(db)
+	encounterRepo := repository.NewEncounterRepository(db)
+	clinicalNoteRepo := repository.NewClinicalNoteRepository(db)
+	allergyRepo := repository.NewAllergyRepository(db)
+	prescriptionRepo := repository.NewPrescriptionRepository(db)
+	drugRepo := repository.NewDrugRepository(db)
+	problemRepo := repository.NewProblemRepository(db)
+	medicationRepo := repository.NewMedicationRepository(db)
+	vitalsRepo := repository.NewVitalSignsRepository(db)
+	labRepo := repository.NewLabRepository(db)
+	exportJobRepo := repository.NewExportJobRepository(db)
+	adtRepo := repository.NewADTRepository(db)
+	billingRepo := repository.NewBillingRepository(db)
+	insuranceRepo := repository.NewInsuranceRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
 
 	// Initialize services
 	cfg := config.LoadConfig()
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
+	auditService := services.NewAuditService(auditRepo)
+	loginThrottle := services.NewLoginThrottle(loginThrottleRepo, auditService, services.LoginThrottleSettings{
+		MaxUsernameFailures: cfg.LoginMaxFailures,
+		MaxIPFailures:       cfg.LoginMaxFailuresPerIP,
+		BaseDelay:           cfg.LoginBackoffBase,
+		LockoutDuration:     cfg.LoginLockoutDuration,
+	})
+	authService := services.NewAuthService(userRepo, tokenRepo, mfaRepo, loginThrottle, auditService, cfg.JWTSecret, cfg.MFAIssuer)
 	userService := services.NewUserService(userRepo)
-	patientService := services.NewPatientService(patientRepo)
+	notifier, err := services.NewNotifier(cfg.PasswordResetNotifier)
+	switch {
+	case err != nil:
+		log.Printf("Password reset tokens cannot be issued: %v", err)
+	case notifier == nil:
+		log.Printf("No PASSWORD_RESET_NOTIFIER is configured; password reset tokens cannot be issued")
+	case cfg.PasswordResetNotifier == services.NotifierLog:
+		log.Printf("Password reset tokens are written to the server log; this is meant for development only")
+	}
+	passwordService := services.NewPasswordService(userRepo, passwordResetRepo, authService, loginThrottle, auditService,
+		notifier, cfg.PasswordResetTTL)
+	mrnGenerator := services.NewMRNGenerator(identifierRepo, cfg.MRNPrefix, cfg.FacilityCode)
+	patientService := services.NewPatientService(patientRepo, identifierRepo, mrnGenerator, auditService)
+	appointmentService := services.NewAppointmentService(appointmentRepo, userRepo, patientRepo)
+	scheduleService := services.NewScheduleService(scheduleRepo, appointmentRepo, userRepo)
+	encounterService := services.NewEncounterService(encounterRepo, clinicalNoteRepo, userRepo, patientRepo, auditService)
+	prescriptionService := services.NewPrescriptionService(prescriptionRepo, drugRepo, allergyRepo, medicationRepo, patientRepo, auditService)
+	historyService := services.NewMedicalHistoryService(allergyRepo, problemRepo, medicationRepo, patientRepo, auditService)
+	vitalsService := services.NewVitalSignsService(vitalsRepo, patientRepo, auditService)
+	labService := services.NewLabService(labRepo, patientRepo, auditService)
+	adtService := services.NewADTService(adtRepo, patientRepo, userRepo, auditService)
+	billingService := services.NewBillingService(billingRepo, patientRepo, encounterRepo, auditService)
+	claimsSettings := services.ClaimsSettings{
+		SenderID:   cfg.ClaimsSenderID,
+		ReceiverID: cfg.ClaimsReceiverID,
+		Submitter:  x12.Party{Name: cfg.BillingProviderName, ID: cfg.ClaimsSenderID},
+		Receiver:   x12.Party{Name: cfg.ClaimsReceiverName, ID: cfg.ClaimsReceiverID},
+		Provider: x12.BillingProvider{
+			Party:   x12.Party{Name: cfg.BillingProviderName, ID: cfg.BillingProviderNPI},
+			TaxID:   cfg.BillingProviderTaxID,
+			Address: cfg.BillingProviderAddress,
+			City:    cfg.BillingProviderCity,
+			State:   cfg.BillingProviderState,
+			Zip:     cfg.BillingProviderZip,
+		},
+		Production: cfg.Environment == "production",
+	}
+	insuranceService := services.NewInsuranceService(insuranceRepo, billingRepo, patientRepo, problemRepo, billingService,
+		services.NewLocalEligibilityChecker(), claimsSettings, auditService)
+	hl7Service := services.NewHL7Service(patientService, labService)
+	fhirService := services.NewFHIRService(patientService, encounterService)
+	exportService := services.NewBulkExportService(exportJobRepo, fhirService, auditService, cfg.ExportDir, cfg.ExportSigningKey, cfg.ExportURLTTL,
+		cfg.ExportRetention)
+
+	if n, err := patientService.AssignMissingMRNs(); err != nil {
+		log.Printf("Failed to assign MRNs to existing patients: %v", err)
+	} else if n > 0 {
+		log.Printf("Assigned MRNs to %d existing patients", n)
+	}
+
+	if n, err := exportService.FailInterruptedExports(); err != nil {
+		log.Printf("Failed to clean up interrupted bulk exports: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted bulk exports as failed", n)
+	}
+	go func() {
+		for ; ; time.Sleep(exportSweepInterval) {
+			if n, err := exportService.DeleteExpiredExports(); err != nil {
+				log.Printf("Failed to delete expired bulk exports: %v", err)
+			} else if n > 0 {
+				log.Printf("Deleted %d expired bulk exports", n)
+			}
+		}
+	}()
+
+	if cfg.MLLPAddr != "" {
+		mllpServer := mllp.NewServer(cfg.MLLPAddr, hl7Service.HandleMessage, mllp.DefaultIdleTimeout)
+		if err := mllpServer.AllowSources(cfg.MLLPAllowedSources); err != nil {
+			log.Fatalf("invalid MLLP_ALLOWED_SOURCES: %v", err)
+		}
+		if len(cfg.MLLPAllowedSources) == 0 {
+			log.Printf("No MLLP_ALLOWED_SOURCES are configured; the MLLP listener accepts connections from anywhere it can be reached")
+		}
+		go func() {
+			log.Printf("HL7 MLLP listener starting on %s", cfg.MLLPAddr)
+			if err := mllpServer.ListenAndServe(); err != nil {
+				log.Printf("HL7 MLLP listener stopped: %v", err)
+			}
+		}()
+	}
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, userService)
 	userHandler := handlers.NewUserHandler(userService)
-	patientHandler := handlers.NewPatientHandler(patientService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	patientHandler := handlers.NewPatientHandler(patientService, historyService)
+	appointmentHandler := handlers.NewAppointmentHandler(appointmentService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	encounterHandler := handlers.NewEncounterHandler(encounterService)
+	prescriptionHandler := handlers.NewPrescriptionHandler(prescriptionService)
+	historyHandler := handlers.NewMedicalHistoryHandler(historyService)
+	vitalsHandler := handlers.NewVitalSignsHandler(vitalsService)
+	labHandler := handlers.NewLabHandler(labService)
+	adtHandler := handlers.NewADTHandler(adtService)
+	billingHandler := handlers.NewBillingHandler(billingService)
+	insuranceHandler := handlers.NewInsuranceHandler(insuranceService)
+	fhirHandler := handlers.NewFHIRHandler(fhirService)
+	exportHandler := handlers.NewBulkExportHandler(exportService)
 
 	// Public routes
 	router.GET("/", authHandler.ShowLoginPage)
@@ -35,24 +165,180 @@ func SetupRoutes(router *gin.Engine) {
 	router.GET("/register", authHandler.ShowRegisterPage)
 	router.POST("/api/auth/login", authHandler.Login)
 	router.POST("/api/auth/register", authHandler.Register)
+	router.POST("/api/auth/refresh", authHandler.Refresh)
+	router.POST("/api/auth/mfa/verify", authHandler.VerifyMFA)
+	router.POST("/api/auth/password-reset", passwordHandler.ResetPassword)
 	router.GET("/dashboard", authHandler.ShowDashboard)
 	router.GET("/api/dashboard", authHandler.ShowDashboard)
+	router.GET(handlers.FHIRBasePath+"/metadata", fhirHandler.Metadata)
+	router.GET(handlers.FHIRBasePath+"/$export-file/:job_id/:file", exportHandler.DownloadFile)
 
-	// Protected routes
+	// Protected routes, each guarded by the permission matrix in middleware.Permissions
+	can := middleware.RequirePermission
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware())
+	api.Use(middleware.AuthMiddleware(authService))
 	{
 		api.POST("/logout", authHandler.Logout)
+		api.POST("/users/me/password", passwordHandler.ChangePassword)
+
+		// Multi-factor authentication of the logged-in user
+		api.GET("/auth/mfa", authHandler.GetMFAStatus)
+		api.POST("/auth/mfa/enroll", authHandler.EnrollMFA)
+		api.POST("/auth/mfa/confirm", authHandler.ConfirmMFA)
+		api.POST("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
 
 		// Patient routes
-		api.GET("/patients", patientHandler.GetAllPatients)
-		api.POST("/patients", patientHandler.CreatePatient)
-		api.GET("/patients/:id", patientHandler.GetPatient)
-		api.PUT("/patients/:id", patientHandler.UpdatePatient)
-		api.DELETE("/patients/:id", patientHandler.DeletePatient)
+		api.GET("/patients", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.GetAllPatients)
+		api.POST("/patients", can(middleware.ResourcePatients, middleware.ActionCreate), patientHandler.CreatePatient)
+		api.POST("/patients/import", can(middleware.ResourcePatients, middleware.ActionCreate), patientHandler.ImportPatients)
+		api.GET("/patients/search", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.SearchPatients)
+		api.GET("/patients/export", can(middleware.ResourcePatientExport, middleware.ActionRead), patientHandler.ExportPatients)
+		api.GET("/patients/by-identifier", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.GetPatientByIdentifier)
+		api.GET("/patients/:id", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.GetPatient)
+		api.PUT("/patients/:id", can(middleware.ResourcePatients, middleware.ActionUpdate), patientHandler.UpdatePatient)
+		api.DELETE("/patients/:id", can(middleware.ResourcePatients, middleware.ActionDelete), patientHandler.DeletePatient)
+		api.POST("/patients/:id/merge", can(middleware.ResourcePatients, middleware.ActionDelete), patientHandler.MergePatient)
+		api.GET("/patients/:id/merges", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.GetMergeHistory)
+		api.GET("/patients/:id/identifiers", can(middleware.ResourcePatients, middleware.ActionRead), patientHandler.GetIdentifiers)
+		api.POST("/patients/:id/identifiers", can(middleware.ResourcePatients, middleware.ActionUpdate), patientHandler.AddIdentifier)
+		api.DELETE("/patients/:id/identifiers/:identifier_id", can(middleware.ResourcePatients, middleware.ActionUpdate), patientHandler.DeleteIdentifier)
+
+		// Allergy, problem list and medication history routes
+		api.GET("/patients/:id/allergies", can(middleware.ResourceMedicalHistory, middleware.ActionRead), historyHandler.GetAllergies)
+		api.POST("/patients/:id/allergies", can(middleware.ResourceMedicalHistory, middleware.ActionCreate), historyHandler.CreateAllergy)
+		api.PUT("/patients/:id/allergies/:allergy_id", can(middleware.ResourceMedicalHistory, middleware.ActionUpdate), historyHandler.UpdateAllergy)
+		api.DELETE("/patients/:id/allergies/:allergy_id", can(middleware.ResourceMedicalHistory, middleware.ActionDelete), historyHandler.DeleteAllergy)
+		api.GET("/patients/:id/problems", can(middleware.ResourceMedicalHistory, middleware.ActionRead), historyHandler.GetProblems)
+		api.POST("/patients/:id/problems", can(middleware.ResourceMedicalHistory, middleware.ActionCreate), historyHandler.CreateProblem)
+		api.PUT("/patients/:id/problems/:problem_id", can(middleware.ResourceMedicalHistory, middleware.ActionUpdate), historyHandler.UpdateProblem)
+		api.DELETE("/patients/:id/problems/:problem_id", can(middleware.ResourceMedicalHistory, middleware.ActionDelete), historyHandler.DeleteProblem)
+		api.GET("/patients/:id/medications", can(middleware.ResourceMedicalHistory, middleware.ActionRead), historyHandler.GetMedications)
+		api.POST("/patients/:id/medications", can(middleware.ResourceMedicalHistory, middleware.ActionCreate), historyHandler.CreateMedication)
+		api.PUT("/patients/:id/medications/:medication_id", can(middleware.ResourceMedicalHistory, middleware.ActionUpdate), historyHandler.UpdateMedication)
+		api.DELETE("/patients/:id/medications/:medication_id", can(middleware.ResourceMedicalHistory, middleware.ActionDelete), historyHandler.DeleteMedication)
+
+		// Encounter and clinical note routes
+		api.GET("/patients/:id/encounters", can(middleware.ResourceEncounters, middleware.ActionRead), encounterHandler.GetEncounters)
+		api.POST("/patients/:id/encounters", can(middleware.ResourceEncounters, middleware.ActionCreate), encounterHandler.CreateEncounter)
+		api.GET("/patients/:id/encounters/:encounter_id", can(middleware.ResourceEncounters, middleware.ActionRead), encounterHandler.GetEncounter)
+		api.PUT("/patients/:id/encounters/:encounter_id", can(middleware.ResourceEncounters, middleware.ActionUpdate), encounterHandler.UpdateEncounter)
+		api.GET("/patients/:id/encounters/:encounter_id/notes", can(middleware.ResourceEncounters, middleware.ActionRead), encounterHandler.GetNotes)
+		api.POST("/patients/:id/encounters/:encounter_id/notes", can(middleware.ResourceEncounters, middleware.ActionCreate), encounterHandler.CreateNote)
+		api.PUT("/patients/:id/encounters/:encounter_id/notes/:note_id", can(middleware.ResourceEncounters, middleware.ActionUpdate), encounterHandler.UpdateNote)
+		api.POST("/patients/:id/encounters/:encounter_id/notes/:note_id/sign", can(middleware.ResourceEncounters, middleware.ActionUpdate), encounterHandler.SignNote)
+		api.POST("/patients/:id/encounters/:encounter_id/notes/:note_id/amendments", can(middleware.ResourceEncounters, middleware.ActionCreate), encounterHandler.AmendNote)
+		api.GET("/patients/:id/encounters/:encounter_id/notes/:note_id/versions", can(middleware.ResourceEncounters, middleware.ActionRead), encounterHandler.GetNoteVersions)
+
+		// Prescription routes
+		api.GET("/patients/:id/prescriptions", can(middleware.ResourcePrescriptions, middleware.ActionRead), prescriptionHandler.GetPrescriptions)
+		api.POST("/patients/:id/prescriptions", can(middleware.ResourcePrescriptions, middleware.ActionCreate), prescriptionHandler.CreatePrescription)
+		api.POST("/patients/:id/prescriptions/check", can(middleware.ResourcePrescriptions, middleware.ActionCreate), prescriptionHandler.CheckPrescription)
+		api.GET("/patients/:id/prescriptions/:prescription_id", can(middleware.ResourcePrescriptions, middleware.ActionRead), prescriptionHandler.GetPrescription)
+		api.POST("/patients/:id/prescriptions/:prescription_id/discontinue", can(middleware.ResourcePrescriptions, middleware.ActionUpdate), prescriptionHandler.DiscontinuePrescription)
+
+		// Vital signs routes
+		api.GET("/patients/:id/vitals", can(middleware.ResourceVitals, middleware.ActionRead), vitalsHandler.GetVitals)
+		api.POST("/patients/:id/vitals", can(middleware.ResourceVitals, middleware.ActionCreate), vitalsHandler.CreateVitals)
+		api.GET("/vitals/ranges", can(middleware.ResourceVitalRanges, middleware.ActionRead), vitalsHandler.GetRanges)
+		api.PUT("/vitals/ranges/:range_id", can(middleware.ResourceVitalRanges, middleware.ActionUpdate), vitalsHandler.UpdateRange)
+
+		// Lab routes
+		api.GET("/labs/catalog", can(middleware.ResourceLabs, middleware.ActionRead), labHandler.GetCatalog)
+		api.GET("/patients/:id/labs", can(middleware.ResourceLabs, middleware.ActionRead), labHandler.GetOrders)
+		api.POST("/patients/:id/labs", can(middleware.ResourceLabs, middleware.ActionCreate), labHandler.CreateOrder)
+		api.GET("/patients/:id/labs/:order_id", can(middleware.ResourceLabs, middleware.ActionRead), labHandler.GetOrder)
+		api.POST("/patients/:id/labs/:order_id/collect", can(middleware.ResourceLabs, middleware.ActionUpdate), labHandler.CollectSpecimen)
+		api.POST("/patients/:id/labs/:order_id/result", can(middleware.ResourceLabs, middleware.ActionUpdate), labHandler.RecordResult)
+		api.POST("/patients/:id/labs/:order_id/verify", can(middleware.ResourceLabs, middleware.ActionUpdate), labHandler.VerifyResult)
+		api.POST("/patients/:id/labs/:order_id/cancel", can(middleware.ResourceLabs, middleware.ActionUpdate), labHandler.CancelOrder)
+
+		// Ward, bed and census routes
+		api.GET("/wards", can(middleware.ResourceWards, middleware.ActionRead), adtHandler.GetWards)
+		api.POST("/wards", can(middleware.ResourceWards, middleware.ActionCreate), adtHandler.CreateWard)
+		api.POST("/wards/:id/rooms", can(middleware.ResourceWards, middleware.ActionCreate), adtHandler.CreateRoom)
+		api.GET("/wards/:id/beds", can(middleware.ResourceWards, middleware.ActionRead), adtHandler.GetBedBoard)
+		api.POST("/wards/:id/beds", can(middleware.ResourceWards, middleware.ActionCreate), adtHandler.CreateBed)
+		api.PUT("/wards/:id/beds/:bed_id", can(middleware.ResourceWards, middleware.ActionUpdate), adtHandler.UpdateBed)
+		api.GET("/wards/:id/beds/:bed_id/history", can(middleware.ResourceWards, middleware.ActionRead), adtHandler.GetBedHistory)
+		api.GET("/census", can(middleware.ResourceWards, middleware.ActionRead), adtHandler.GetCensus)
+
+		// Admission, transfer and discharge routes
+		api.GET("/patients/:id/admissions", can(middleware.ResourceAdmissions, middleware.ActionRead), adtHandler.GetAdmissions)
+		api.POST("/patients/:id/admissions", can(middleware.ResourceAdmissions, middleware.ActionCreate), adtHandler.Admit)
+		api.GET("/patients/:id/admissions/:admission_id", can(middleware.ResourceAdmissions, middleware.ActionRead), adtHandler.GetAdmission)
+		api.POST("/patients/:id/admissions/:admission_id/transfer", can(middleware.ResourceAdmissions, middleware.ActionUpdate), adtHandler.Transfer)
+		api.POST("/patients/:id/admissions/:admission_id/discharge", can(middleware.ResourceAdmissions, middleware.ActionUpdate), adtHandler.Discharge)
+
+		// Billing routes
+		api.GET("/billing/tax-rules", can(middleware.ResourceBilling, middleware.ActionRead), billingHandler.GetTaxRules)
+		api.PUT("/billing/tax-rules/:code", can(middleware.ResourceBilling, middleware.ActionUpdate), billingHandler.SaveTaxRule)
+		api.GET("/billing/chargemaster", can(middleware.ResourceBilling, middleware.ActionRead), billingHandler.GetChargemaster)
+		api.POST("/billing/chargemaster", can(middleware.ResourceBilling, middleware.ActionCreate), billingHandler.CreateChargeItem)
+		api.PUT("/billing/chargemaster/:code", can(middleware.ResourceBilling, middleware.ActionUpdate), billingHandler.UpdateChargeItem)
+		api.GET("/billing/patients/:id/invoices", can(middleware.ResourceBilling, middleware.ActionRead), billingHandler.GetInvoices)
+		api.POST("/billing/patients/:id/invoices", can(middleware.ResourceBilling, middleware.ActionCreate), billingHandler.CreateInvoice)
+		api.GET("/billing/patients/:id/balance", can(middleware.ResourceBilling, middleware.ActionRead), billingHandler.GetBalance)
+		api.GET("/billing/invoices/:id", can(middleware.ResourceBilling, middleware.ActionRead), billingHandler.GetInvoice)
+		api.POST("/billing/invoices/:id/payments", can(middleware.ResourceBilling, middleware.ActionCreate), billingHandler.RecordPayment)
+		api.POST("/billing/invoices/:id/refunds", can(middleware.ResourceBilling, middleware.ActionCreate), billingHandler.RefundPayment)
+		api.POST("/billing/invoices/:id/void", can(middleware.ResourceBilling, middleware.ActionUpdate), billingHandler.VoidInvoice)
+
+		// Insurance, claim and remittance routes
+		api.GET("/billing/patients/:id/policies", can(middleware.ResourceBilling, middleware.ActionRead), insuranceHandler.GetPolicies)
+		api.POST("/billing/patients/:id/policies", can(middleware.ResourceBilling, middleware.ActionCreate), insuranceHandler.CreatePolicy)
+		api.PUT("/billing/patients/:id/policies/:policy_id", can(middleware.ResourceBilling, middleware.ActionUpdate), insuranceHandler.UpdatePolicy)
+		api.DELETE("/billing/patients/:id/policies/:policy_id", can(middleware.ResourceBilling, middleware.ActionDelete), insuranceHandler.DeletePolicy)
+		api.GET("/billing/patients/:id/policies/:policy_id/eligibility", can(middleware.ResourceBilling, middleware.ActionRead), insuranceHandler.CheckEligibility)
+		api.GET("/billing/invoices/:id/claims", can(middleware.ResourceBilling, middleware.ActionRead), insuranceHandler.GetClaims)
+		api.POST("/billing/invoices/:id/claims", can(middleware.ResourceBilling, middleware.ActionCreate), insuranceHandler.CreateClaim)
+		api.GET("/billing/claims/:id", can(middleware.ResourceBilling, middleware.ActionRead), insuranceHandler.GetClaim)
+		api.GET("/billing/claims/:id/837", can(middleware.ResourceBilling, middleware.ActionRead), insuranceHandler.DownloadClaim)
+		api.POST("/billing/remittances", can(middleware.ResourceBilling, middleware.ActionCreate), insuranceHandler.PostRemittance)
+
+		// Appointment routes
+		api.GET("/appointments", can(middleware.ResourceAppointments, middleware.ActionRead), appointmentHandler.GetAllAppointments)
+		api.POST("/appointments", can(middleware.ResourceAppointments, middleware.ActionCreate), appointmentHandler.CreateAppointment)
+		api.GET("/appointments/:id", can(middleware.ResourceAppointments, middleware.ActionRead), appointmentHandler.GetAppointment)
+		api.PUT("/appointments/:id", can(middleware.ResourceAppointments, middleware.ActionUpdate), appointmentHandler.UpdateAppointment)
+		api.DELETE("/appointments/:id", can(middleware.ResourceAppointments, middleware.ActionDelete), appointmentHandler.DeleteAppointment)
+
+		// Doctor schedule routes
+		api.GET("/doctors/:id/working-hours", can(middleware.ResourceSchedules, middleware.ActionRead), scheduleHandler.GetWorkingHours)
+		api.PUT("/doctors/:id/working-hours", can(middleware.ResourceSchedules, middleware.ActionUpdate), scheduleHandler.SetWorkingHours)
+		api.GET("/doctors/:id/exceptions", can(middleware.ResourceSchedules, middleware.ActionRead), scheduleHandler.GetExceptions)
+		api.POST("/doctors/:id/exceptions", can(middleware.ResourceSchedules, middleware.ActionCreate), scheduleHandler.CreateException)
+		api.DELETE("/doctors/:id/exceptions/:exception_id", can(middleware.ResourceSchedules, middleware.ActionDelete), scheduleHandler.DeleteException)
+		api.GET("/doctors/:id/availability", can(middleware.ResourceSchedules, middleware.ActionRead), scheduleHandler.GetAvailability)
+
+		// Audit routes
+		api.GET("/audit", can(middleware.ResourceAudit, middleware.ActionRead), auditHandler.GetAuditTrail)
+		api.GET("/audit/verify", can(middleware.ResourceAudit, middleware.ActionRead), auditHandler.VerifyAuditChain)
 
 		// User routes
-		api.GET("/users/:id", userHandler.GetUser)
-		api.PUT("/users/:id", userHandler.UpdateUser)
+		api.POST("/users", can(middleware.ResourceUsers, middleware.ActionCreate), authHandler.CreateUser)
+		api.GET("/users/:id", can(middleware.ResourceUsers, middleware.ActionRead), userHandler.GetUser)
+		api.PUT("/users/:id", can(middleware.ResourceUsers, middleware.ActionUpdate), userHandler.UpdateUser)
+		api.POST("/users/:id/password-reset", can(middleware.ResourceUsers, middleware.ActionUpdate), passwordHandler.IssueResetToken)
+		api.DELETE("/users/:id/sessions", can(middleware.ResourceSessions, middleware.ActionDelete), authHandler.RevokeSessions)
+		api.DELETE("/users/:id/mfa", can(middleware.ResourceMFA, middleware.ActionDelete), authHandler.ResetMFA)
+		api.DELETE("/users/:id/lockout", can(middleware.ResourceLockouts, middleware.ActionDelete), authHandler.UnlockUser)
+	}
+
+	// FHIR R4 routes. Handler errors are answered with an OperationOutcome;
+	// authentication and permission failures use the usual API error body.
+	fhirAPI := router.Group(handlers.FHIRBasePath)
+	fhirAPI.Use(middleware.AuthMiddleware(authService))
+	{
+		fhirAPI.GET("/Patient", can(middleware.ResourcePatients, middleware.ActionRead), fhirHandler.SearchPatients)
+		fhirAPI.POST("/Patient", can(middleware.ResourcePatients, middleware.ActionCreate), fhirHandler.CreatePatient)
+		fhirAPI.GET("/Patient/:id", can(middleware.ResourcePatients, middleware.ActionRead), fhirHandler.ReadPatient)
+		fhirAPI.PUT("/Patient/:id", can(middleware.ResourcePatients, middleware.ActionUpdate), fhirHandler.UpdatePatient)
+		fhirAPI.GET("/Encounter", can(middleware.ResourceEncounters, middleware.ActionRead), fhirHandler.SearchEncounters)
+		fhirAPI.GET("/Encounter/:id", can(middleware.ResourceEncounters, middleware.ActionRead), fhirHandler.ReadEncounter)
+
+		// Bulk data export; the files are downloaded through signed links
+		fhirAPI.POST("/$export", can(middleware.ResourceBulkExport, middleware.ActionCreate), exportHandler.StartExport)
+		fhirAPI.GET("/$export-status/:job_id", can(middleware.ResourceBulkExport, middleware.ActionRead), exportHandler.GetStatus)
+		fhirAPI.DELETE("/$export-status/:job_id", can(middleware.ResourceBulkExport, middleware.ActionDelete), exportHandler.DeleteExport)
 	}
 }
@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Admission statuses. A patient is admitted into a bed, may be transferred
+// between beds any number of times, and is finally discharged.
+const (
+	AdmissionAdmitted   = "admitted"
+	AdmissionDischarged = "discharged"
+)
+
+// Discharge dispositions: where the patient went on discharge
+const (
+	DispositionHome        = "home"
+	DispositionTransferOut = "transferred_out"
+	DispositionAMA         = "against_medical_advice"
+	DispositionDeceased    = "deceased"
+	DispositionOther       = "other"
+)
+
+// Bed statuses shown on the bed board
+const (
+	BedAvailable    = "available"
+	BedOccupied     = "occupied"
+	BedOutOfService = "out_of_service"
+)
+
+type Ward struct {
+	ID          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type Room struct {
+	ID        int       `json:"id" db:"id"`
+	WardID    int       `json:"ward_id" db:"ward_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Bed is a bed in a room. WardID, RoomName and AdmissionID (the admission
+// currently in the bed, if any) are read along with it.
+type Bed struct {
+	ID           int       `json:"id" db:"id"`
+	RoomID       int       `json:"room_id" db:"room_id"`
+	RoomName     string    `json:"room_name" db:"-"`
+	WardID       int       `json:"ward_id" db:"-"`
+	Label        string    `json:"label" db:"label"`
+	OutOfService bool      `json:"out_of_service" db:"out_of_service"`
+	AdmissionID  *int      `json:"admission_id,omitempty" db:"-"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Admission is an inpatient stay. BedID is the bed the patient is in, or
+// was discharged from.
+type Admission struct {
+	ID                int            `json:"id" db:"id"`
+	PatientID         int            `json:"patient_id" db:"patient_id"`
+	BedID             int            `json:"bed_id" db:"bed_id"`
+	AttendingDoctorID *int64         `json:"attending_doctor_id,omitempty" db:"attending_doctor_id"`
+	Reason            string         `json:"reason" db:"reason"`
+	Status            string         `json:"status" db:"status"`
+	AdmittedBy        int64          `json:"admitted_by" db:"admitted_by"`
+	AdmittedAt        time.Time      `json:"admitted_at" db:"admitted_at"`
+	DischargedBy      *int64         `json:"discharged_by,omitempty" db:"discharged_by"`
+	DischargedAt      *time.Time     `json:"discharged_at,omitempty" db:"discharged_at"`
+	Disposition       string         `json:"disposition,omitempty" db:"disposition"`
+	Occupancies       []BedOccupancy `json:"occupancies,omitempty" db:"-"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// BedOccupancy is one stay of an admission in a bed, from admission or
+// transfer in until transfer out or discharge. An open occupancy has no
+// EndedAt.
+type BedOccupancy struct {
+	ID          int        `json:"id" db:"id"`
+	BedID       int        `json:"bed_id" db:"bed_id"`
+	AdmissionID int        `json:"admission_id" db:"admission_id"`
+	PatientID   int        `json:"patient_id" db:"-"`
+	Reason      string     `json:"reason" db:"reason"`
+	StartedAt   time.Time  `json:"started_at" db:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+}
+
+// BedOccupant is the patient in a bed, as shown on the bed board.
+type BedOccupant struct {
+	AdmissionID       int       `json:"admission_id"`
+	PatientID         int       `json:"patient_id"`
+	MRN               string    `json:"mrn"`
+	FirstName         string    `json:"first_name"`
+	LastName          string    `json:"last_name"`
+	AttendingDoctorID *int64    `json:"attending_doctor_id,omitempty"`
+	Since             time.Time `json:"since"`
+}
+
+// BedBoardEntry is a bed's line on the bed board.
+type BedBoardEntry struct {
+	Bed      Bed          `json:"bed"`
+	Status   string       `json:"status"`
+	Occupant *BedOccupant `json:"occupant,omitempty"`
+}
+
+// WardCensus counts a ward's beds by status. OccupancyRate is the share of
+// in-service beds that are occupied.
+type WardCensus struct {
+	WardID        int     `json:"ward_id"`
+	WardName      string  `json:"ward_name"`
+	Beds          int     `json:"beds"`
+	Occupied      int     `json:"occupied"`
+	Available     int     `json:"available"`
+	OutOfService  int     `json:"out_of_service"`
+	OccupancyRate float64 `json:"occupancy_rate"`
+}
+
+// Census is the hospital's bed census: each ward's counts and their totals.
+type Census struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Wards       []WardCensus `json:"wards"`
+	Total       WardCensus   `json:"total"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Allergy severities
+const (
+	AllergyMild     = "mild"
+	AllergyModerate = "moderate"
+	AllergySevere   = "severe"
+)
+
+// Allergy statuses
+const (
+	AllergyActive   = "active"
+	AllergyInactive = "inactive"
+)
+
+// Allergy is a substance the patient reacts to. Substance is a drug name
+// or a drug class such as "penicillins".
+type Allergy struct {
+	ID         int       `json:"id" db:"id"`
+	PatientID  int       `json:"patient_id" db:"patient_id"`
+	Substance  string    `json:"substance" db:"substance"`
+	Reaction   string    `json:"reaction" db:"reaction"`
+	Severity   string    `json:"severity" db:"severity"`
+	Status     string    `json:"status" db:"status"`
+	RecordedBy int64     `json:"recorded_by" db:"recorded_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
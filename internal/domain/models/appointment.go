@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Appointment statuses
+const (
+	AppointmentScheduled = "scheduled"
+	AppointmentCompleted = "completed"
+	AppointmentCancelled = "cancelled"
+	AppointmentNoShow    = "no_show"
+)
+
+type Appointment struct {
+	ID        int       `json:"id" db:"id"`
+	PatientID int       `json:"patient_id" db:"patient_id"`
+	DoctorID  int64     `json:"doctor_id" db:"doctor_id"`
+	StartTime time.Time `json:"start_time" db:"start_time"`
+	EndTime   time.Time `json:"end_time" db:"end_time"`
+	Status    string    `json:"status" db:"status"`
+	Reason    string    `json:"reason" db:"reason"`
+	Notes     string    `json:"notes" db:"notes"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
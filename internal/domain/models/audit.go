@@ -0,0 +1,84 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditRead   = "read"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditList   = "list"
+	AuditSearch = "search"
+	AuditMerge  = "merge"
+	AuditExport = "export"
+	// AuditExportDownload is a bulk export file fetched through its signed
+	// link
+	AuditExportDownload = "export_download"
+	// Security events on user accounts
+	AuditLockout        = "lockout"
+	AuditUnlock         = "unlock"
+	AuditPasswordChange = "password_change"
+	AuditPasswordReset  = "password_reset"
+	AuditMFAReset       = "mfa_reset"
+)
+
+// Actor identifies who performed an action: a logged-in user taken from the
+// JWT claims, or a named system process.
+type Actor struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// FieldChange is the before and after value of a single field.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry is one link in the append-only audit chain. Hash covers the
+// entry's contents and PrevHash, so altering or removing any entry breaks
+// every hash after it.
+type AuditEntry struct {
+	ID            int64           `json:"id" db:"id"`
+	ActorID       int64           `json:"actor_id" db:"actor_id"`
+	ActorUsername string          `json:"actor_username" db:"actor_username"`
+	ActorRole     string          `json:"actor_role" db:"actor_role"`
+	Action        string          `json:"action" db:"action"`
+	PatientID     *int            `json:"patient_id" db:"patient_id"`
+	Changes       json.RawMessage `json:"changes" db:"changes"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PrevHash      string          `json:"prev_hash" db:"prev_hash"`
+	Hash          string          `json:"hash" db:"hash"`
+}
+
+// ComputeHash returns the SHA-256 over PrevHash and the entry's contents.
+func (e *AuditEntry) ComputeHash() string {
+	patientID := ""
+	if e.PatientID != nil {
+		patientID = strconv.Itoa(*e.PatientID)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{
+		e.PrevHash,
+		strconv.FormatInt(e.ActorID, 10),
+		e.ActorUsername,
+		e.ActorRole,
+		e.Action,
+		patientID,
+		string(e.Changes),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// Invoice statuses. An invoice is issued with a balance, becomes partially
+// paid and then paid as payments come in, and goes back when a payment is
+// refunded. An unpaid invoice can be voided.
+const (
+	InvoiceIssued        = "issued"
+	InvoicePartiallyPaid = "partially_paid"
+	InvoicePaid          = "paid"
+	InvoiceVoid          = "void"
+)
+
+// Invoice payment kinds
+const (
+	PaymentKindPayment = "payment"
+	PaymentKindRefund  = "refund"
+)
+
+// Payment methods
+const (
+	PaymentCash      = "cash"
+	PaymentCard      = "card"
+	PaymentCheck     = "check"
+	PaymentInsurance = "insurance"
+	PaymentOther     = "other"
+)
+
+// TaxRule is a tax rate in basis points: 825 is 8.25%.
+type TaxRule struct {
+	Code      string    `json:"code" db:"code"`
+	Name      string    `json:"name" db:"name"`
+	RateBP    int       `json:"rate_bp" db:"rate_bp"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ChargeItem is a billable service in the chargemaster. Prices are in
+// cents.
+type ChargeItem struct {
+	Code           string    `json:"code" db:"code"`
+	Description    string    `json:"description" db:"description"`
+	UnitPriceCents int64     `json:"unit_price_cents" db:"unit_price_cents"`
+	TaxCode        string    `json:"tax_code" db:"tax_code"`
+	Active         bool      `json:"active" db:"active"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Invoice is a bill to a patient, optionally for one encounter. Amounts are
+// in cents; BalanceCents is what the patient still owes.
+type Invoice struct {
+	ID            int              `json:"id" db:"id"`
+	PatientID     int              `json:"patient_id" db:"patient_id"`
+	EncounterID   *int             `json:"encounter_id,omitempty" db:"encounter_id"`
+	Status        string           `json:"status" db:"status"`
+	SubtotalCents int64            `json:"subtotal_cents" db:"subtotal_cents"`
+	TaxCents      int64            `json:"tax_cents" db:"tax_cents"`
+	TotalCents    int64            `json:"total_cents" db:"total_cents"`
+	PaidCents     int64            `json:"paid_cents" db:"paid_cents"`
+	RefundedCents int64            `json:"refunded_cents" db:"refunded_cents"`
+	BalanceCents  int64            `json:"balance_cents" db:"-"`
+	Notes         string           `json:"notes" db:"notes"`
+	IssuedBy      int64            `json:"issued_by" db:"issued_by"`
+	IssuedAt      time.Time        `json:"issued_at" db:"issued_at"`
+	Lines         []InvoiceLine    `json:"lines" db:"-"`
+	Payments      []InvoicePayment `json:"payments" db:"-"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// InvoiceLine is one chargemaster item on an invoice, with the
+// description, price and tax rate it was billed at.
+type InvoiceLine struct {
+	ID             int    `json:"id" db:"id"`
+	InvoiceID      int    `json:"invoice_id" db:"invoice_id"`
+	ChargeCode     string `json:"charge_code" db:"charge_code"`
+	Description    string `json:"description" db:"description"`
+	Quantity       int    `json:"quantity" db:"quantity"`
+	UnitPriceCents int64  `json:"unit_price_cents" db:"unit_price_cents"`
+	TaxRateBP      int    `json:"tax_rate_bp" db:"tax_rate_bp"`
+	SubtotalCents  int64  `json:"subtotal_cents" db:"subtotal_cents"`
+	TaxCents       int64  `json:"tax_cents" db:"tax_cents"`
+	TotalCents     int64  `json:"total_cents" db:"total_cents"`
+}
+
+// InvoicePayment is a payment against an invoice, or a refund of part or
+// all of one (RefundOf).
+type InvoicePayment struct {
+	ID          int       `json:"id" db:"id"`
+	InvoiceID   int       `json:"invoice_id" db:"invoice_id"`
+	Kind        string    `json:"kind" db:"kind"`
+	AmountCents int64     `json:"amount_cents" db:"amount_cents"`
+	Method      string    `json:"method" db:"method"`
+	Reference   string    `json:"reference" db:"reference"`
+	RefundOf    *int      `json:"refund_of,omitempty" db:"refund_of"`
+	RecordedBy  int64     `json:"recorded_by" db:"recorded_by"`
+	RecordedAt  time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// PatientBalance sums a patient's invoices that are not void.
+type PatientBalance struct {
+	PatientID     int   `json:"patient_id"`
+	InvoicedCents int64 `json:"invoiced_cents"`
+	PaidCents     int64 `json:"paid_cents"`
+	RefundedCents int64 `json:"refunded_cents"`
+	BalanceCents  int64 `json:"balance_cents"`
+	OpenInvoices  int   `json:"open_invoices"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Encounter types
+const (
+	EncounterOutpatient = "outpatient"
+	EncounterInpatient  = "inpatient"
+	EncounterEmergency  = "emergency"
+	EncounterTelehealth = "telehealth"
+)
+
+// Encounter statuses
+const (
+	EncounterInProgress = "in_progress"
+	EncounterFinished   = "finished"
+	EncounterCancelled  = "cancelled"
+)
+
+// Clinical note statuses
+const (
+	NoteDraft  = "draft"
+	NoteSigned = "signed"
+)
+
+// Encounter is one clinical interaction between a doctor and a patient.
+type Encounter struct {
+	ID        int        `json:"id" db:"id"`
+	PatientID int        `json:"patient_id" db:"patient_id"`
+	DoctorID  int64      `json:"doctor_id" db:"doctor_id"`
+	Type      string     `json:"type" db:"type"`
+	Status    string     `json:"status" db:"status"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty" db:"end_time"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ClinicalNote is one version of a progress note on an encounter. All
+// versions of a note share its ID. A draft can be edited by its author
+// until signed; a signed version never changes, and amending it adds the
+// next version.
+type ClinicalNote struct {
+	ID          int64      `json:"id" db:"note_id"`
+	EncounterID int        `json:"encounter_id" db:"encounter_id"`
+	Version     int        `json:"version" db:"version"`
+	AuthorID    int64      `json:"author_id" db:"author_id"`
+	Content     string     `json:"content" db:"content"`
+	Status      string     `json:"status" db:"status"`
+	SignedAt    *time.Time `json:"signed_at,omitempty" db:"signed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Bulk export job statuses
+const (
+	ExportInProgress = "in_progress"
+	ExportCompleted  = "completed"
+	ExportFailed     = "failed"
+)
+
+// ExportOutput is one NDJSON file written by a bulk export.
+type ExportOutput struct {
+	Type  string `json:"type"`
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// ExportJob is a FHIR bulk data export running in the background. Its ID
+// is random so status and file links cannot be guessed.
+type ExportJob struct {
+	ID          string         `json:"id" db:"id"`
+	RequestedBy int64          `json:"requested_by" db:"requested_by"`
+	RequestURL  string         `json:"request_url" db:"request_url"`
+	Types       []string       `json:"types" db:"types"`
+	Since       *time.Time     `json:"since,omitempty" db:"since"`
+	Status      string         `json:"status" db:"status"`
+	Processed   int            `json:"processed" db:"processed"` // resources written so far
+	Output      []ExportOutput `json:"output" db:"output"`
+	Error       string         `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Well-known identifier systems. Other external systems use their own
+// names, e.g. "lab:acme".
+const (
+	IdentifierMRN               = "mrn" // former MRNs, kept after a merge
+	IdentifierNationalID        = "national_id"
+	IdentifierInsuranceMemberID = "insurance_member_id"
+)
+
+// PatientIdentifier is an identifier for a patient issued by another
+// system, such as a national ID or a lab's patient number.
+type PatientIdentifier struct {
+	ID        int       `json:"id" db:"id"`
+	PatientID int       `json:"patient_id" db:"patient_id"`
+	System    string    `json:"system" db:"system"`
+	Value     string    `json:"value" db:"value"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
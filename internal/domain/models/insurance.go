@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// Policy holder relationships: how the patient is related to the
+// subscriber
+const (
+	RelationshipSelf   = "self"
+	RelationshipSpouse = "spouse"
+	RelationshipChild  = "child"
+	RelationshipOther  = "other"
+)
+
+// Claim statuses. A claim is submitted, then paid, partially paid or
+// denied when the payer's remittance is posted.
+const (
+	ClaimSubmitted     = "submitted"
+	ClaimPaid          = "paid"
+	ClaimPartiallyPaid = "partially_paid"
+	ClaimDenied        = "denied"
+)
+
+// InsurancePolicy is a patient's coverage with a payer. Priority 1 is the
+// primary policy, 2 the secondary and 3 the tertiary. The subscriber's
+// name and date of birth are only kept when the patient is not the
+// subscriber.
+type InsurancePolicy struct {
+	ID                  int        `json:"id" db:"id"`
+	PatientID           int        `json:"patient_id" db:"patient_id"`
+	PayerName           string     `json:"payer_name" db:"payer_name"`
+	PayerID             string     `json:"payer_id" db:"payer_id"`
+	MemberID            string     `json:"member_id" db:"member_id"`
+	GroupNumber         string     `json:"group_number" db:"group_number"`
+	Priority            int        `json:"priority" db:"priority"`
+	Relationship        string     `json:"relationship" db:"relationship"`
+	SubscriberFirstName string     `json:"subscriber_first_name,omitempty" db:"subscriber_first_name"`
+	SubscriberLastName  string     `json:"subscriber_last_name,omitempty" db:"subscriber_last_name"`
+	SubscriberDOB       *time.Time `json:"subscriber_dob,omitempty" db:"subscriber_dob"`
+	EffectiveFrom       time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo         *time.Time `json:"effective_to,omitempty" db:"effective_to"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Covers reports whether the policy is in effect on day, counting both
+// the first and the last day of coverage.
+func (p *InsurancePolicy) Covers(day time.Time) bool {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(p.EffectiveFrom.Year(), p.EffectiveFrom.Month(), p.EffectiveFrom.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(from) {
+		return false
+	}
+	if p.EffectiveTo != nil {
+		to := time.Date(p.EffectiveTo.Year(), p.EffectiveTo.Month(), p.EffectiveTo.Day(), 0, 0, 0, 0, time.UTC)
+		return !day.After(to)
+	}
+	return true
+}
+
+// EligibilityResult is a payer's answer to whether a policy covers a
+// patient on a date of service.
+type EligibilityResult struct {
+	PolicyID    int       `json:"policy_id"`
+	ServiceDate time.Time `json:"service_date"`
+	Eligible    bool      `json:"eligible"`
+	Message     string    `json:"message"`
+	// Source names the checker that answered
+	Source    string    `json:"source"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Claim is an invoice billed to an insurance policy as an X12 837P.
+// ControlNumber is sent as the claim's patient control number and comes
+// back on the payer's 835 remittance.
+type Claim struct {
+	ID                         int        `json:"id" db:"id"`
+	InvoiceID                  int        `json:"invoice_id" db:"invoice_id"`
+	PolicyID                   int        `json:"policy_id" db:"policy_id"`
+	PatientID                  int        `json:"patient_id" db:"patient_id"`
+	ControlNumber              string     `json:"control_number" db:"control_number"`
+	Status                     string     `json:"status" db:"status"`
+	ChargeCents                int64      `json:"charge_cents" db:"charge_cents"`
+	PaidCents                  int64      `json:"paid_cents" db:"paid_cents"`
+	PatientResponsibilityCents int64      `json:"patient_responsibility_cents" db:"patient_responsibility_cents"`
+	PayerClaimNumber           string     `json:"payer_claim_number,omitempty" db:"payer_claim_number"`
+	RemittanceTrace            string     `json:"remittance_trace,omitempty" db:"remittance_trace"`
+	Content                    string     `json:"-" db:"content"`
+	CreatedBy                  int64      `json:"created_by" db:"created_by"`
+	CreatedAt                  time.Time  `json:"created_at" db:"created_at"`
+	AdjudicatedAt              *time.Time `json:"adjudicated_at,omitempty" db:"adjudicated_at"`
+}
+
+// RemittanceReport is the outcome of posting an 835: what happened to
+// each claim it paid or denied.
+type RemittanceReport struct {
+	PayerName   string             `json:"payer_name"`
+	TraceNumber string             `json:"trace_number"`
+	PaidCents   int64              `json:"paid_cents"`
+	PaymentDate *time.Time         `json:"payment_date,omitempty"`
+	Posted      int                `json:"posted"`
+	Failed      int                `json:"failed"`
+	Claims      []RemittanceResult `json:"claims"`
+}
+
+// RemittanceResult is the outcome of posting one claim payment. Error is
+// set, and nothing was posted, when the claim could not be matched or
+// paid.
+type RemittanceResult struct {
+	ControlNumber string `json:"control_number"`
+	ClaimID       int    `json:"claim_id,omitempty"`
+	InvoiceID     int    `json:"invoice_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+	PaidCents     int64  `json:"paid_cents"`
+	Error         string `json:"error,omitempty"`
+}
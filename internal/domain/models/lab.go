@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Lab order statuses. An order moves ordered -> collected -> resulted ->
+// verified, and can be cancelled until it has a result.
+const (
+	LabOrdered   = "ordered"
+	LabCollected = "collected"
+	LabResulted  = "resulted"
+	LabVerified  = "verified"
+	LabCancelled = "cancelled"
+)
+
+// Lab result flags
+const (
+	LabFlagLow  = "L"
+	LabFlagHigh = "H"
+)
+
+// LabTest is an entry in the lab catalog.
+type LabTest struct {
+	Code     string              `json:"code" db:"code"` // LOINC code
+	Name     string              `json:"name" db:"name"`
+	Unit     string              `json:"unit" db:"unit"`
+	Specimen string              `json:"specimen" db:"specimen"`
+	Ranges   []LabReferenceRange `json:"reference_ranges"`
+}
+
+// LabReferenceRange is the reference range of a test for patients of a sex
+// and age. An empty Sex applies to everyone. It applies from MinAge up to,
+// but not including, MaxAge; a nil MaxAge has no upper bound. Results
+// beyond the critical limits need urgent attention.
+type LabReferenceRange struct {
+	ID           int      `json:"id" db:"id"`
+	TestCode     string   `json:"test_code" db:"test_code"`
+	Sex          string   `json:"sex,omitempty" db:"sex"`
+	MinAge       int      `json:"min_age" db:"min_age"`
+	MaxAge       *int     `json:"max_age" db:"max_age"`
+	Low          float64  `json:"low" db:"low"`
+	High         float64  `json:"high" db:"high"`
+	CriticalLow  *float64 `json:"critical_low,omitempty" db:"critical_low"`
+	CriticalHigh *float64 `json:"critical_high,omitempty" db:"critical_high"`
+}
+
+// Covers reports whether the range applies to a patient of the given sex
+// and age.
+func (r LabReferenceRange) Covers(sex string, age int) bool {
+	return (r.Sex == "" || r.Sex == sex) && age >= r.MinAge && (r.MaxAge == nil || age < *r.MaxAge)
+}
+
+// LabOrder is a test ordered for a patient.
+type LabOrder struct {
+	ID          int        `json:"id" db:"id"`
+	PatientID   int        `json:"patient_id" db:"patient_id"`
+	TestCode    string     `json:"test_code" db:"test_code"`
+	TestName    string     `json:"test_name" db:"-"`
+	OrderedBy   int64      `json:"ordered_by" db:"ordered_by"`
+	Status      string     `json:"status" db:"status"`
+	Notes       string     `json:"notes" db:"notes"`
+	OrderedAt   time.Time  `json:"ordered_at" db:"ordered_at"`
+	CollectedAt *time.Time `json:"collected_at,omitempty" db:"collected_at"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	VerifiedBy  *int64     `json:"verified_by,omitempty" db:"verified_by"`
+	Result      *LabResult `json:"result,omitempty" db:"-"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// LabResult is the result of a lab order. The reference range it was
+// flagged against is stored with it.
+type LabResult struct {
+	ID            int       `json:"id" db:"id"`
+	OrderID       int       `json:"order_id" db:"order_id"`
+	Value         float64   `json:"value" db:"value"`
+	Unit          string    `json:"unit" db:"unit"`
+	ReferenceLow  *float64  `json:"reference_low,omitempty" db:"reference_low"`
+	ReferenceHigh *float64  `json:"reference_high,omitempty" db:"reference_high"`
+	Flag          string    `json:"flag,omitempty" db:"flag"`
+	Critical      bool      `json:"critical" db:"critical"`
+	Comment       string    `json:"comment" db:"comment"`
+	ResultedBy    int64     `json:"resulted_by" db:"resulted_by"`
+	ResultedAt    time.Time `json:"resulted_at" db:"resulted_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// What failed logins are counted against
+const (
+	ThrottleScopeUsername = "username"
+	ThrottleScopeIP       = "ip"
+)
+
+// LoginThrottle counts the recent failed logins for a username or a client
+// IP. No login is tried for it before LockedUntil.
+type LoginThrottle struct {
+	Scope         string     `json:"scope" db:"scope"`
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
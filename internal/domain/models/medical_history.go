@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Problem statuses
+const (
+	ProblemActive   = "active"
+	ProblemResolved = "resolved"
+	ProblemInactive = "inactive"
+)
+
+// Medication history statuses
+const (
+	MedicationActive  = "active"
+	MedicationStopped = "stopped"
+)
+
+// Problem is an entry on the patient's problem list, coded with ICD-10.
+type Problem struct {
+	ID          int        `json:"id" db:"id"`
+	PatientID   int        `json:"patient_id" db:"patient_id"`
+	ICD10Code   string     `json:"icd10_code" db:"icd10_code"`
+	Description string     `json:"description" db:"description"`
+	OnsetDate   *time.Time `json:"onset_date,omitempty" db:"onset_date"`
+	Status      string     `json:"status" db:"status"`
+	Chronic     bool       `json:"chronic" db:"chronic"`
+	RecordedBy  int64      `json:"recorded_by" db:"recorded_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Medication is a medicine the patient takes or took that was not
+// prescribed here, e.g. reported at intake or prescribed elsewhere.
+type Medication struct {
+	ID         int        `json:"id" db:"id"`
+	PatientID  int        `json:"patient_id" db:"patient_id"`
+	Drug       string     `json:"drug" db:"drug"`
+	Dose       string     `json:"dose" db:"dose"`
+	Frequency  string     `json:"frequency" db:"frequency"`
+	StartDate  *time.Time `json:"start_date,omitempty" db:"start_date"`
+	EndDate    *time.Time `json:"end_date,omitempty" db:"end_date"`
+	Status     string     `json:"status" db:"status"`
+	Source     string     `json:"source" db:"source"`
+	RecordedBy int64      `json:"recorded_by" db:"recorded_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AllergySummary is the allergy block shown with a patient record.
+type AllergySummary struct {
+	Count           int       `json:"count"`
+	HighestSeverity string    `json:"highest_severity,omitempty"`
+	Active          []Allergy `json:"active"`
+}
+
+// PatientDetail is a patient record together with its allergy summary.
+type PatientDetail struct {
+	*Patient
+	Allergies AllergySummary `json:"allergies"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// UserMFA is a user's TOTP second factor. It is created at enrollment and
+// only enforced once Enabled, after the user confirmed a first code.
+type UserMFA struct {
+	UserID       int64      `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MFAEnrollment is handed to the user once, to set up their authenticator
+// app either from the provisioning URI (as a QR code) or the bare secret.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus tells a user whether their second factor is on and how many
+// recovery codes they have left.
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAChallenge is a login that passed the password check and waits for the
+// second factor. The client only ever sees the token; TokenHash is stored.
+type MFAChallenge struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
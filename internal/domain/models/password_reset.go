@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PasswordResetToken lets a user set a new password without knowing the
+// old one. An administrator issues it and it reaches the user through a
+// notifier; it works once, before ExpiresAt.
+type PasswordResetToken struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	IssuedBy  int64      `json:"issued_by" db:"issued_by"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
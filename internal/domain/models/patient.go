@@ -1,9 +1,13 @@
 package models
 
-import "time"
+import (
+    "encoding/json"
+    "time"
+)
 
 type Patient struct {
     ID        int       `json:"id" db:"id"`
+    MRN       string    `json:"mrn" db:"mrn"`
     FirstName string    `json:"first_name" db:"first_name"`
     LastName  string    `json:"last_name" db:"last_name"`
     DOB       time.Time `json:"dob" db:"date_of_birth"`
@@ -13,4 +17,80 @@ type Patient struct {
     Address   string    `json:"address" db:"address"`
     CreatedAt time.Time `json:"created_at" db:"created_at"`
     UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Patient sort fields
+const (
+    PatientSortCreatedAt = "created_at"
+    PatientSortLastName  = "last_name"
+    PatientSortDOB       = "date_of_birth"
+)
+
+// PatientFilter narrows a patient listing. Zero values are ignored; ranges
+// are inclusive of From and exclusive of To.
+type PatientFilter struct {
+    NamePrefix  string
+    Gender      string
+    DOBFrom     *time.Time
+    DOBTo       *time.Time
+    CreatedFrom *time.Time
+    CreatedTo   *time.Time
+}
+
+// PatientCursor marks the last row of a page: its value in the sort column
+// and its ID as a tie-breaker.
+type PatientCursor struct {
+    SortBy    string `json:"s"`
+    SortValue string `json:"v"`
+    ID        int    `json:"id"`
+}
+
+// PatientListQuery is one page request against the patient listing.
+type PatientListQuery struct {
+    Filter   PatientFilter
+    SortBy   string
+    SortDesc bool
+    After    *PatientCursor
+    Limit    int
+}
+
+// PatientPage is one page of a patient listing.
+type PatientPage struct {
+    Data       []Patient `json:"data"`
+    NextCursor string    `json:"next_cursor,omitempty"`
+    Limit      int       `json:"limit"`
+}
+
+// PatientMatch is a patient search hit. The match signals come from the
+// repository; Score and MatchedOn are derived from them when ranking.
+type PatientMatch struct {
+    Patient    Patient  `json:"patient"`
+    Score      float64  `json:"score"`
+    MatchedOn  []string `json:"matched_on"`
+    Similarity float64  `json:"-"` // best trigram similarity of the query to the name
+    Soundex    bool     `json:"-"`
+    Metaphone  bool     `json:"-"`
+    PhoneMatch bool     `json:"-"`
+    EmailMatch bool     `json:"-"`
+    DOBMatch   bool     `json:"-"`
+}
+
+// PatientMerge records a duplicate record folded into a surviving one. The
+// duplicate's last state is kept as a snapshot.
+type PatientMerge struct {
+    ID                int64           `json:"id" db:"id"`
+    SurvivorID        int             `json:"survivor_id" db:"survivor_id"`
+    DuplicateID       int             `json:"duplicate_id" db:"duplicate_id"`
+    DuplicateSnapshot json.RawMessage `json:"duplicate_snapshot" db:"duplicate_snapshot"`
+    MergedBy          int64           `json:"merged_by" db:"merged_by"`
+    MergedAt          time.Time       `json:"merged_at" db:"merged_at"`
+}
+
+// AgeOn returns the patient's age in whole years on the given date.
+func (p *Patient) AgeOn(t time.Time) int {
+    age := t.Year() - p.DOB.Year()
+    if t.Month() < p.DOB.Month() || (t.Month() == p.DOB.Month() && t.Day() < p.DOB.Day()) {
+        age--
+    }
+    return age
 }
\ No newline at end of file
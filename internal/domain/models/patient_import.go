@@ -0,0 +1,46 @@
+package models
+
+// Patient import row statuses
+const (
+	ImportRowValid    = "valid"
+	ImportRowInvalid  = "invalid"
+	ImportRowImported = "imported"
+)
+
+// Patient fields that spreadsheet columns can be mapped to
+const (
+	ImportFieldFirstName = "first_name"
+	ImportFieldLastName  = "last_name"
+	ImportFieldDOB       = "dob"
+	ImportFieldGender    = "gender"
+	ImportFieldPhone     = "phone"
+	ImportFieldEmail     = "email"
+	ImportFieldAddress   = "address"
+)
+
+// ImportFields lists every field a column can be mapped to.
+var ImportFields = []string{
+	ImportFieldFirstName, ImportFieldLastName, ImportFieldDOB, ImportFieldGender,
+	ImportFieldPhone, ImportFieldEmail, ImportFieldAddress,
+}
+
+// PatientImportRow reports on one data row of an import file. Row is the
+// line (or spreadsheet row) it starts on, counting the header as 1.
+type PatientImportRow struct {
+	Row       int      `json:"row"`
+	Status    string   `json:"status"`
+	Errors    []string `json:"errors,omitempty"`
+	PatientID int      `json:"patient_id,omitempty"`
+	MRN       string   `json:"mrn,omitempty"`
+}
+
+// PatientImportReport is the outcome of importing, or dry-running the
+// import of, a file of patients.
+type PatientImportReport struct {
+	DryRun   bool               `json:"dry_run"`
+	Total    int                `json:"total"`
+	Valid    int                `json:"valid"`
+	Invalid  int                `json:"invalid"`
+	Imported int                `json:"imported"`
+	Rows     []PatientImportRow `json:"rows"`
+}
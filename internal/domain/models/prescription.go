@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Prescription statuses
+const (
+	PrescriptionActive       = "active"
+	PrescriptionDiscontinued = "discontinued"
+	PrescriptionCompleted    = "completed"
+)
+
+// Routes of administration
+const (
+	RouteOral       = "oral"
+	RouteIV         = "iv"
+	RouteIM         = "im"
+	RouteSubcut     = "subcutaneous"
+	RouteTopical    = "topical"
+	RouteInhaled    = "inhaled"
+	RouteSublingual = "sublingual"
+	RouteRectal     = "rectal"
+)
+
+// Drug interaction severities, least to most serious
+const (
+	InteractionMinor           = "minor"
+	InteractionModerate        = "moderate"
+	InteractionMajor           = "major"
+	InteractionContraindicated = "contraindicated"
+)
+
+// Prescription warning types
+const (
+	WarningInteraction = "interaction"
+	WarningAllergy     = "allergy"
+)
+
+type Prescription struct {
+	ID           int       `json:"id" db:"id"`
+	PatientID    int       `json:"patient_id" db:"patient_id"`
+	PrescriberID int64     `json:"prescriber_id" db:"prescriber_id"`
+	Drug         string    `json:"drug" db:"drug"`
+	Dose         string    `json:"dose" db:"dose"`
+	Route        string    `json:"route" db:"route"`
+	Frequency    string    `json:"frequency" db:"frequency"`
+	DurationDays int       `json:"duration_days" db:"duration_days"`
+	Instructions string    `json:"instructions" db:"instructions"`
+	Status       string    `json:"status" db:"status"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DrugInteraction is an entry in the local interaction table.
+type DrugInteraction struct {
+	DrugA       string `json:"drug_a" db:"drug_a"`
+	DrugB       string `json:"drug_b" db:"drug_b"`
+	Severity    string `json:"severity" db:"severity"`
+	Description string `json:"description" db:"description"`
+}
+
+// PrescriptionWarning is a safety problem found while checking a new
+// prescription. With is the conflicting drug for an interaction, or the
+// allergy's substance.
+type PrescriptionWarning struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Drug     string `json:"drug"`
+	With     string `json:"with"`
+	Message  string `json:"message"`
+}
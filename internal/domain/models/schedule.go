@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Schedule exception types
+const (
+	ExceptionHoliday = "holiday"
+	ExceptionLeave   = "leave"
+	ExceptionOther   = "other"
+)
+
+// WorkingHours is one session of a doctor's weekly template, e.g. Mondays
+// 09:00-12:30 split into 15 minute slots. Times are wall-clock "HH:MM".
+type WorkingHours struct {
+	ID          int    `json:"id" db:"id"`
+	DoctorID    int64  `json:"doctor_id" db:"doctor_id"`
+	Weekday     int    `json:"weekday" db:"weekday"` // 0 = Sunday, matching time.Weekday
+	StartTime   string `json:"start_time" db:"start_time"`
+	EndTime     string `json:"end_time" db:"end_time"`
+	SlotMinutes int    `json:"slot_minutes" db:"slot_minutes"`
+}
+
+// ScheduleException blocks out part of a doctor's template, such as a
+// public holiday or a period of leave.
+type ScheduleException struct {
+	ID        int       `json:"id" db:"id"`
+	DoctorID  int64     `json:"doctor_id" db:"doctor_id"`
+	StartTime time.Time `json:"start_time" db:"start_time"`
+	EndTime   time.Time `json:"end_time" db:"end_time"`
+	Type      string    `json:"type" db:"type"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Slot is a bookable period in a doctor's calendar.
+type Slot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
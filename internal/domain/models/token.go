@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RefreshToken is a long-lived, single-use credential that can be exchanged
+// for a new access token. Every refresh issues a replacement in the same
+// family, so reuse of an already-rotated token reveals a stolen session.
+type RefreshToken struct {
+	ID            int64      `json:"id" db:"id"`
+	UserID        int64      `json:"user_id" db:"user_id"`
+	TokenHash     string     `json:"-" db:"token_hash"`
+	FamilyID      string     `json:"family_id" db:"family_id"`
+	AccessTokenID string     `json:"-" db:"access_token_id"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TokenPair is what a successful login or refresh hands back to the client.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
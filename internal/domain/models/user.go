@@ -2,6 +2,15 @@ package models
 
 import "time"
 
+// User roles
+const (
+    RoleReceptionist = "receptionist"
+    RoleDoctor       = "doctor"
+    RoleAdmin        = "admin"
+    RoleCompliance   = "compliance"
+    RoleBilling      = "billing"
+)
+
 type User struct {
     ID        int64     `json:"id" db:"id"`
     Username  string    `json:"username" db:"username"`
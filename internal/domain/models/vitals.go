@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Vital sign names, as used by normal ranges and flags
+const (
+	VitalSystolicBP      = "systolic_bp"
+	VitalDiastolicBP     = "diastolic_bp"
+	VitalHeartRate       = "heart_rate"
+	VitalTemperature     = "temperature"
+	VitalSpO2            = "spo2"
+	VitalRespiratoryRate = "respiratory_rate"
+	VitalBMI             = "bmi"
+)
+
+// Vital sign flags
+const (
+	VitalLow  = "low"
+	VitalHigh = "high"
+)
+
+// VitalSigns is one set of measurements taken from a patient. Every
+// measurement is optional; BMI is computed from weight and height.
+type VitalSigns struct {
+	ID              int         `json:"id" db:"id"`
+	PatientID       int         `json:"patient_id" db:"patient_id"`
+	RecordedAt      time.Time   `json:"recorded_at" db:"recorded_at"`
+	SystolicBP      *int        `json:"systolic_bp,omitempty" db:"systolic_bp"`
+	DiastolicBP     *int        `json:"diastolic_bp,omitempty" db:"diastolic_bp"`
+	HeartRate       *int        `json:"heart_rate,omitempty" db:"heart_rate"`
+	Temperature     *float64    `json:"temperature,omitempty" db:"temperature"` // degrees Celsius
+	SpO2            *int        `json:"spo2,omitempty" db:"spo2"`               // percent
+	RespiratoryRate *int        `json:"respiratory_rate,omitempty" db:"respiratory_rate"`
+	WeightKg        *float64    `json:"weight_kg,omitempty" db:"weight_kg"`
+	HeightCm        *float64    `json:"height_cm,omitempty" db:"height_cm"`
+	BMI             *float64    `json:"bmi,omitempty" db:"bmi"`
+	RecordedBy      int64       `json:"recorded_by" db:"recorded_by"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	Flags           []VitalFlag `json:"flags" db:"-"`
+}
+
+// VitalFlag marks a measurement outside the normal range for the
+// patient's age group when it was taken.
+type VitalFlag struct {
+	Vital string  `json:"vital"`
+	Value float64 `json:"value"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Flag  string  `json:"flag"`
+}
+
+// VitalRange is the normal range of a vital sign for an age group. It
+// applies from MinAge up to, but not including, MaxAge; a nil MaxAge has
+// no upper bound.
+type VitalRange struct {
+	ID        int       `json:"id" db:"id"`
+	Vital     string    `json:"vital" db:"vital"`
+	AgeGroup  string    `json:"age_group" db:"age_group"`
+	MinAge    int       `json:"min_age" db:"min_age"`
+	MaxAge    *int      `json:"max_age" db:"max_age"`
+	Low       float64   `json:"low" db:"low"`
+	High      float64   `json:"high" db:"high"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Covers reports whether the range applies to a patient of the given age.
+func (r VitalRange) Covers(age int) bool {
+	return age >= r.MinAge && (r.MaxAge == nil || age < *r.MaxAge)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+var (
+	// ErrBedOccupied is returned when a bed is already taken by another
+	// admission.
+	ErrBedOccupied = errors.New("bed is occupied")
+	// ErrBedOutOfService is returned when admitting or transferring into a
+	// bed that has been taken out of service.
+	ErrBedOutOfService = errors.New("bed is out of service")
+	// ErrPatientAdmitted is returned when admitting a patient who already
+	// has an open admission.
+	ErrPatientAdmitted = errors.New("patient is already admitted")
+	// ErrAdmissionClosed is returned when transferring or discharging an
+	// admission that another request has discharged.
+	ErrAdmissionClosed = errors.New("admission is no longer active")
+)
+
+// ADTRepository defines the methods for interacting with wards, rooms and
+// beds, and the admissions that occupy them. Beds are returned with their
+// room, ward and current admission; admissions with their bed occupancies,
+// oldest first.
+type ADTRepository interface {
+	CreateWard(ward *models.Ward) error
+	FindWards() ([]models.Ward, error)
+	FindWardByID(id uint) (*models.Ward, error)
+	CreateRoom(room *models.Room) error
+	FindRoomByID(id uint) (*models.Room, error)
+	CreateBed(bed *models.Bed) error
+	FindBedByID(id uint) (*models.Bed, error)
+	// UpdateBed saves a bed's label and service status. It returns
+	// ErrBedOccupied if the bed is taken out of service while occupied.
+	UpdateBed(bed *models.Bed) error
+	// FindBedBoard returns every bed in a ward, by room and label, with its
+	// current occupant.
+	FindBedBoard(wardID uint) ([]models.BedBoardEntry, error)
+	// FindBedOccupancies returns a bed's occupancies, most recent first.
+	FindBedOccupancies(bedID uint) ([]models.BedOccupancy, error)
+	// FindCensus counts each ward's beds by status; rates are not filled in.
+	FindCensus() ([]models.WardCensus, error)
+
+	// Admit saves a new admission and opens its occupancy of its bed,
+	// atomically, setting AdmittedAt from the database clock. It returns
+	// ErrBedOccupied or ErrPatientAdmitted if another admission got there
+	// first, and ErrBedOutOfService if the bed was taken out of service.
+	Admit(admission *models.Admission) error
+	FindAdmissionByID(id uint) (*models.Admission, error)
+	// FindAdmissionsByPatient returns a patient's admissions, most recent
+	// first.
+	FindAdmissionsByPatient(patientID uint) ([]models.Admission, error)
+	// FindActiveAdmission returns the patient's open admission, or
+	// sql.ErrNoRows if they are not admitted.
+	FindActiveAdmission(patientID uint) (*models.Admission, error)
+	// Transfer closes the admission's current occupancy and opens one in
+	// bedID, atomically, and moves the admission to that bed. It returns
+	// ErrBedOccupied if the bed has been taken, ErrBedOutOfService if it
+	// was taken out of service and ErrAdmissionClosed if the admission has
+	// been discharged.
+	Transfer(admission *models.Admission, bedID int, reason string) error
+	// Discharge saves the admission's discharge and closes its occupancy,
+	// atomically, setting DischargedAt from the database clock. It returns ErrAdmissionClosed if the admission has been
+	// discharged already.
+	Discharge(admission *models.Admission) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// AllergyRepository defines the methods for interacting with patient allergies.
+type AllergyRepository interface {
+	Create(allergy *models.Allergy) error
+	FindByID(id uint) (*models.Allergy, error)
+	FindByPatient(patientID uint) ([]models.Allergy, error)
+	FindActiveByPatient(patientID uint) ([]models.Allergy, error)
+	Update(allergy *models.Allergy) error
+	Delete(id uint) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// ErrAppointmentOverlap is returned when saving an appointment that
+// overlaps another live appointment of the same doctor.
+var ErrAppointmentOverlap = errors.New("appointment overlaps another of the doctor's")
+
+// AppointmentRepository defines the methods for interacting with appointment data.
+type AppointmentRepository interface {
+	// Create and Update return ErrAppointmentOverlap if the appointment
+	// is not cancelled and overlaps another that is not.
+	Create(appointment *models.Appointment) error
+	FindByID(id uint) (*models.Appointment, error)
+	Update(appointment *models.Appointment) error
+	Delete(id uint) error
+	FindAll() ([]models.Appointment, error)
+	FindByDoctor(doctorID int64, from, to time.Time) ([]models.Appointment, error)
+	FindByPatient(patientID uint) ([]models.Appointment, error)
+	// FindOverlapping returns the doctor's non-cancelled appointments that
+	// intersect [start, end), ignoring the appointment with excludeID.
+	FindOverlapping(doctorID int64, start, end time.Time, excludeID int) ([]models.Appointment, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// AuditRepository appends to and reads the audit chain. Entries are never
+// updated or deleted.
+type AuditRepository interface {
+	// Append links entry to the current head of the chain, filling in its
+	// PrevHash, Hash, CreatedAt and ID.
+	Append(entry *models.AuditEntry) error
+	FindByPatient(patientID uint, limit int) ([]models.AuditEntry, error)
+	FindRecent(limit int) ([]models.AuditEntry, error)
+	// FindAfter returns up to limit entries with ID greater than afterID, in chain order.
+	FindAfter(afterID int64, limit int) ([]models.AuditEntry, error)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// ErrInvoiceOverpaid is returned when a payment would take an invoice past
+// its total, or a refund would give back more than was paid on the invoice
+// or by the payment it refunds.
+var ErrInvoiceOverpaid = errors.New("payment exceeds what is owed or was paid")
+
+// ErrInvoiceStatus is returned when paying a void invoice, or voiding one
+// that is void already or has money paid on it, e.g. because a payment
+// and a void raced.
+var ErrInvoiceStatus = errors.New("invoice status does not allow the change")
+
+// BillingRepository defines the methods for interacting with tax rules,
+// the chargemaster, invoices and their payments. Invoices are returned
+// with their lines and their payments and refunds, oldest first.
+type BillingRepository interface {
+	FindTaxRules() ([]models.TaxRule, error)
+	FindTaxRule(code string) (*models.TaxRule, error)
+	// SaveTaxRule adds the rule, or updates the rule with its code.
+	SaveTaxRule(rule *models.TaxRule) error
+	// FindChargeItems returns the chargemaster by code, inactive items
+	// included.
+	FindChargeItems() ([]models.ChargeItem, error)
+	FindChargeItem(code string) (*models.ChargeItem, error)
+	CreateChargeItem(item *models.ChargeItem) error
+	UpdateChargeItem(item *models.ChargeItem) error
+
+	// CreateInvoice saves an invoice and its lines, atomically.
+	CreateInvoice(invoice *models.Invoice) error
+	FindInvoiceByID(id uint) (*models.Invoice, error)
+	// FindInvoicesByPatient returns a patient's invoices, most recent first.
+	FindInvoicesByPatient(patientID uint) ([]models.Invoice, error)
+	// AddPayment saves a payment or refund and adds it to the invoice's
+	// totals and status, atomically, and appends it to invoice.Payments.
+	// It returns ErrInvoiceOverpaid if the payment would overpay the
+	// invoice or the refund would give back more than was paid, checking
+	// refunds against their payment with the invoice locked, and
+	// ErrInvoiceStatus if the invoice is void.
+	AddPayment(invoice *models.Invoice, payment *models.InvoicePayment) error
+	// VoidInvoice marks the invoice void. It returns ErrInvoiceStatus if
+	// the invoice is void already or has payments that were not refunded.
+	VoidInvoice(invoice *models.Invoice) error
+	// FindPatientBalance sums the patient's invoices that are not void.
+	FindPatientBalance(patientID uint) (*models.PatientBalance, error)
+}
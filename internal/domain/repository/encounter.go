@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// EncounterRepository defines the methods for interacting with encounter data.
+type EncounterRepository interface {
+	Create(encounter *models.Encounter) error
+	FindByID(id uint) (*models.Encounter, error)
+	// FindByPatient returns a patient's encounters, most recent first.
+	FindByPatient(patientID uint) ([]models.Encounter, error)
+	Update(encounter *models.Encounter) error
+}
+
+// ClinicalNoteRepository stores clinical notes as a series of versions.
+type ClinicalNoteRepository interface {
+	// Create stores a new note on note.EncounterID as version 1 and sets its ID.
+	Create(note *models.ClinicalNote) error
+	// CreateVersion stores note as the next version of an existing note.
+	CreateVersion(note *models.ClinicalNote) error
+	// FindLatest returns the newest version of a note.
+	FindLatest(noteID int64) (*models.ClinicalNote, error)
+	// FindLatestByEncounter returns the newest version of each note on an encounter.
+	FindLatestByEncounter(encounterID uint) ([]models.ClinicalNote, error)
+	// FindVersions returns every version of a note, oldest first.
+	FindVersions(noteID int64) ([]models.ClinicalNote, error)
+	// UpdateDraft saves the content of a draft version.
+	UpdateDraft(note *models.ClinicalNote) error
+	// Sign marks a draft version as signed.
+	Sign(note *models.ClinicalNote) error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// ExportJobRepository stores FHIR bulk export jobs.
+type ExportJobRepository interface {
+	Create(job *models.ExportJob) error
+	FindByID(id string) (*models.ExportJob, error)
+	// Update saves the job's status, progress, output and error.
+	Update(job *models.ExportJob) error
+	Delete(id string) error
+	// FailInProgress marks every unfinished job failed with reason and
+	// returns how many there were.
+	FailInProgress(reason string) (int, error)
+	// DeleteFinishedBefore deletes the completed and failed jobs that
+	// finished before t and returns their IDs.
+	DeleteFinishedBefore(t time.Time) ([]string, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// IdentifierRepository manages MRN sequences and external patient identifiers.
+type IdentifierRepository interface {
+	// NextMRNSequence atomically returns the next MRN sequence number for a facility.
+	NextMRNSequence(facilityCode string) (int64, error)
+	Create(identifier *models.PatientIdentifier) error
+	FindByID(id uint) (*models.PatientIdentifier, error)
+	FindByPatient(patientID uint) ([]models.PatientIdentifier, error)
+	FindBySystemValue(system, value string) (*models.PatientIdentifier, error)
+	Delete(id uint) error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// ErrPolicyHasClaims is returned when deleting a policy that claims have
+// been billed to.
+var ErrPolicyHasClaims = errors.New("policy has claims")
+
+// ErrClaimPosted is returned when posting a remittance for a claim that is
+// no longer waiting for one.
+var ErrClaimPosted = errors.New("claim remittance already posted")
+
+// ErrClaimExists is returned when creating a claim for an invoice and
+// policy that already have one the payer has not denied.
+var ErrClaimExists = errors.New("claim already exists")
+
+// InsuranceRepository defines the methods for interacting with patients'
+// insurance policies and the claims billed to them.
+type InsuranceRepository interface {
+	CreatePolicy(policy *models.InsurancePolicy) error
+	FindPolicyByID(id uint) (*models.InsurancePolicy, error)
+	// FindPoliciesByPatient returns a patient's policies by priority, the
+	// most recent coverage first.
+	FindPoliciesByPatient(patientID uint) ([]models.InsurancePolicy, error)
+	UpdatePolicy(policy *models.InsurancePolicy) error
+	// DeletePolicy returns ErrPolicyHasClaims if claims were billed to the
+	// policy.
+	DeletePolicy(id uint) error
+
+	// NextClaimNumber atomically returns the next claim control number.
+	NextClaimNumber() (int64, error)
+	// CreateClaim returns ErrClaimExists if the invoice already has a
+	// claim against the policy that has not been denied.
+	CreateClaim(claim *models.Claim) error
+	FindClaimByID(id uint) (*models.Claim, error)
+	FindClaimByControlNumber(controlNumber string) (*models.Claim, error)
+	// FindClaimsByInvoice returns the claims billed for an invoice, oldest
+	// first.
+	FindClaimsByInvoice(invoiceID uint) ([]models.Claim, error)
+	// PostClaimRemittance saves the claim's status and what its remittance
+	// paid and, unless payment is nil, records payment against invoice
+	// like BillingRepository.AddPayment, in one transaction. It returns
+	// ErrClaimPosted if the claim is no longer submitted.
+	PostClaimRemittance(claim *models.Claim, invoice *models.Invoice, payment *models.InvoicePayment) error
+}
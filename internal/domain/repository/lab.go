@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// LabRepository defines the methods for interacting with the lab catalog,
+// lab orders and their results. Orders are returned with their test name
+// and result, if any.
+type LabRepository interface {
+	// FindTests returns the catalog with each test's reference ranges.
+	FindTests() ([]models.LabTest, error)
+	FindTest(code string) (*models.LabTest, error)
+	CreateOrder(order *models.LabOrder) error
+	FindOrderByID(id uint) (*models.LabOrder, error)
+	FindOrdersByPatient(patientID uint) ([]models.LabOrder, error)
+	// UpdateOrderStatus saves the order's status, collection and
+	// verification details.
+	UpdateOrderStatus(order *models.LabOrder) error
+	// SaveResult stores the result and marks its order resulted, atomically.
+	SaveResult(order *models.LabOrder, result *models.LabResult) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// LoginThrottleRepository counts failed logins per username and client IP.
+type LoginThrottleRepository interface {
+	Find(scope, key string) (*models.LoginThrottle, error)
+	// RecordFailure counts a failed login at the given time and returns the
+	// number of failures so far. Counting starts over if the previous
+	// failure was before resetBefore.
+	RecordFailure(scope, key string, at, resetBefore time.Time) (int, error)
+	// Lock blocks the key until the given time, unless it already is
+	// blocked for longer
+	Lock(scope, key string, until time.Time) error
+	Clear(scope, key string) error
+	// DeleteStale drops keys whose last failure was before the given time
+	// and that are no longer locked
+	DeleteStale(before time.Time) error
+}
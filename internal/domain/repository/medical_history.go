@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// ProblemRepository defines the methods for interacting with the problem list.
+type ProblemRepository interface {
+	Create(problem *models.Problem) error
+	FindByID(id uint) (*models.Problem, error)
+	FindByPatient(patientID uint) ([]models.Problem, error)
+	Update(problem *models.Problem) error
+	Delete(id uint) error
+}
+
+// MedicationRepository defines the methods for interacting with medication history.
+type MedicationRepository interface {
+	Create(medication *models.Medication) error
+	FindByID(id uint) (*models.Medication, error)
+	FindByPatient(patientID uint) ([]models.Medication, error)
+	FindActiveByPatient(patientID uint) ([]models.Medication, error)
+	Update(medication *models.Medication) error
+	Delete(id uint) error
+}
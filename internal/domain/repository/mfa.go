@@ -0,0 +1,29 @@
+package repository
+
+import "hospital-management-system/internal/domain/models"
+
+// MFARepository stores users' TOTP secrets, their recovery codes and the
+// logins waiting for a second factor.
+type MFARepository interface {
+	FindByUser(userID int64) (*models.UserMFA, error)
+	// Save creates or replaces the user's MFA settings
+	Save(mfa *models.UserMFA) error
+	// UseStep records that a code for the given time step was accepted. It
+	// reports false if that step, or a later one, was already used.
+	UseStep(userID int64, step int64) (bool, error)
+	// Delete removes the user's MFA settings, recovery codes and challenges
+	Delete(userID int64) error
+
+	ReplaceRecoveryCodes(userID int64, codeHashes []string) error
+	// UseRecoveryCode marks an unused code as used, reporting false if the
+	// user has no such unused code
+	UseRecoveryCode(userID int64, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID int64) (int, error)
+
+	CreateChallenge(challenge *models.MFAChallenge) error
+	FindChallengeByHash(tokenHash string) (*models.MFAChallenge, error)
+	// IncrementChallengeAttempts counts an attempt and returns the new
+	// total. It returns sql.ErrNoRows once max attempts were made.
+	IncrementChallengeAttempts(id int64, max int) (int, error)
+	DeleteChallenge(id int64) error
+}
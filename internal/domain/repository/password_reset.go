@@ -0,0 +1,13 @@
+package repository
+
+import "hospital-management-system/internal/domain/models"
+
+// PasswordResetRepository stores password reset tokens.
+type PasswordResetRepository interface {
+	// Create stores a new token for the user; their earlier unused tokens
+	// stop working
+	Create(token *models.PasswordResetToken) error
+	FindByHash(tokenHash string) (*models.PasswordResetToken, error)
+	// MarkUsed uses up a token, reporting false if it already was used
+	MarkUsed(id int64) (bool, error)
+}
@@ -1,14 +1,45 @@
 package repository
 
 import (
+	"errors"
+
 	"hospital-management-system/internal/domain/models"
 )
 
+// ErrPatientHasRecords is returned when deleting a patient who has
+// clinical records, such as encounters, prescriptions or admissions, or
+// financial ones, such as invoices and claims, which must be kept.
+var ErrPatientHasRecords = errors.New("patient has clinical or financial records")
+
 // PatientRepository defines the methods for interacting with patient data.
 type PatientRepository interface {
 	Create(patient *models.Patient) error
+	// CreateMany inserts all of patients in one transaction, or none of them.
+	CreateMany(patients []*models.Patient) error
 	FindByID(id uint) (*models.Patient, error)
 	Update(patient *models.Patient) error
+	// Delete removes a patient. It returns ErrPatientHasRecords if the
+	// patient has clinical or financial records.
 	Delete(id uint) error
 	FindAll() ([]models.Patient, error)
+	FindByMRN(mrn string) (*models.Patient, error)
+	// FindWithoutMRN returns patients created before MRNs were assigned.
+	FindWithoutMRN() ([]models.Patient, error)
+	// SetMRN assigns an MRN to a patient that does not have one yet.
+	SetMRN(id uint, mrn string) error
+	// List returns up to query.Limit patients matching the filter, in sort
+	// order, starting after query.After.
+	List(query models.PatientListQuery) ([]models.Patient, error)
+	// Search returns up to limit candidates whose name is similar to or
+	// sounds like q, or whose phone number or email equals q.
+	Search(q string, limit int) ([]models.PatientMatch, error)
+	// FindDuplicateCandidates returns existing patients that share a date of
+	// birth, phone number or email with patient, or have a similar name.
+	FindDuplicateCandidates(patient *models.Patient) ([]models.PatientMatch, error)
+	// Merge saves survivor, repoints every record of the duplicate to it,
+	// keeps the duplicate's MRN as an identifier of the survivor, stores
+	// the merge history and deletes the duplicate, atomically. It returns
+	// ErrPatientAdmitted if both patients have an open admission.
+	Merge(survivor *models.Patient, duplicateID uint, merge *models.PatientMerge) error
+	FindMerges(survivorID uint) ([]models.PatientMerge, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"hospital-management-system/internal/domain/models"
+)
+
+// PrescriptionRepository defines the methods for interacting with prescriptions.
+type PrescriptionRepository interface {
+	Create(prescription *models.Prescription) error
+	FindByID(id uint) (*models.Prescription, error)
+	// FindByPatient returns a patient's prescriptions, newest first.
+	FindByPatient(patientID uint) ([]models.Prescription, error)
+	FindActiveByPatient(patientID uint) ([]models.Prescription, error)
+	UpdateStatus(prescription *models.Prescription) error
+}
+
+// DrugRepository reads the local drug class and interaction tables. Drug
+// names are lower case generic names.
+type DrugRepository interface {
+	// FindClasses returns the classes a drug belongs to, e.g. "penicillins".
+	FindClasses(drug string) ([]string, error)
+	// FindInteractions returns the interactions between drug and any of others.
+	FindInteractions(drug string, others []string) ([]models.DrugInteraction, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// ScheduleRepository defines the methods for interacting with doctors'
+// working-hour templates and schedule exceptions.
+type ScheduleRepository interface {
+	FindWorkingHours(doctorID int64) ([]models.WorkingHours, error)
+	// ReplaceWorkingHours atomically swaps the doctor's whole weekly template.
+	ReplaceWorkingHours(doctorID int64, hours []models.WorkingHours) error
+	CreateException(exception *models.ScheduleException) error
+	FindExceptionByID(id uint) (*models.ScheduleException, error)
+	DeleteException(id uint) error
+	FindExceptions(doctorID int64, from, to time.Time) ([]models.ScheduleException, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// TokenRepository stores refresh tokens and the list of revoked access tokens.
+type TokenRepository interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	FindRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error)
+	FindRefreshTokenByAccessTokenID(accessTokenID string) (*models.RefreshToken, error)
+	FindActiveRefreshTokensByFamily(familyID string) ([]models.RefreshToken, error)
+	FindActiveRefreshTokensByUser(userID int64) ([]models.RefreshToken, error)
+	// RevokeRefreshToken revokes a token, reporting false if it already was
+	// revoked
+	RevokeRefreshToken(id int64) (bool, error)
+	RevokeAccessToken(tokenID string, expiresAt time.Time) error
+	IsAccessTokenRevoked(tokenID string) (bool, error)
+	// DeleteExpired drops refresh tokens and revocations that can no longer be used.
+	DeleteExpired() error
+}
@@ -8,7 +8,10 @@ type UserRepository interface {
 	Create(user *models.User) error
 	FindByID(id int) (*models.User, error)
 	FindByUsername(username string) (*models.User, error)
+	// Update saves the username and role; the password is only ever
+	// changed through UpdatePassword
 	Update(user *models.User) error
+	UpdatePassword(id int64, passwordHash string) error
 	Delete(id int) error
 	FindAll() ([]models.User, error)
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// VitalSignsRepository defines the methods for interacting with vital signs
+// and their normal ranges.
+type VitalSignsRepository interface {
+	Create(vitals *models.VitalSigns) error
+	// FindByPatient returns the patient's readings recorded from from up to,
+	// but not including, to, oldest first. Nil bounds are open.
+	FindByPatient(patientID uint, from, to *time.Time) ([]models.VitalSigns, error)
+	FindRanges() ([]models.VitalRange, error)
+	FindRangeByID(id uint) (*models.VitalRange, error)
+	UpdateRange(r *models.VitalRange) error
+}
@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS appointments (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    doctor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Appointments are instants, so bookings made with different UTC
+    -- offsets still collide in the exclusion constraint below
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'completed', 'cancelled', 'no_show')),
+    reason TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time),
+    -- Backstop for the service-level check: a doctor cannot hold two live bookings at once
+    EXCLUDE USING gist (doctor_id WITH =, tstzrange(start_time, end_time) WITH &&) WHERE (status <> 'cancelled')
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_doctor_start ON appointments (doctor_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_appointments_patient ON appointments (patient_id);
+
+-- Create trigger to update updated_at
+CREATE TRIGGER update_appointments_updated_at BEFORE UPDATE
+ON appointments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
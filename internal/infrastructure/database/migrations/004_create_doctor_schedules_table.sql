@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS doctor_working_hours (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    slot_minutes INTEGER NOT NULL CHECK (slot_minutes > 0),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_doctor_working_hours_doctor ON doctor_working_hours (doctor_id, weekday);
+
+CREATE TABLE IF NOT EXISTS doctor_schedule_exceptions (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Instants, like appointment times, so free slots are compared correctly
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('holiday', 'leave', 'other')),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_doctor_schedule_exceptions_doctor ON doctor_schedule_exceptions (doctor_id, start_time);
@@ -0,0 +1,5 @@
+-- Administrators manage users and sessions. They cannot self-register;
+-- promote an existing account with:
+--   UPDATE users SET role = 'admin' WHERE username = '<username>';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin'));
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER NOT NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    patient_id INTEGER,
+    -- JSON rather than JSONB so the stored text stays byte-identical for hashing
+    changes JSON,
+    created_at TIMESTAMP NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+-- patient_id deliberately has no foreign key: the trail must outlive deleted patients
+CREATE INDEX IF NOT EXISTS idx_audit_log_patient ON audit_log (patient_id, id);
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION prevent_audit_log_modification()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE
+ON audit_log FOR EACH ROW EXECUTE FUNCTION prevent_audit_log_modification();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE
+ON audit_log FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_log_modification();
+
+-- Compliance officers read the audit trail
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin', 'compliance'));
@@ -0,0 +1,8 @@
+-- Keyset pagination indexes: one per sort order, with id as tie-breaker
+CREATE INDEX IF NOT EXISTS idx_patients_created_at_id ON patients (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_id ON patients (last_name, id);
+CREATE INDEX IF NOT EXISTS idx_patients_dob_id ON patients (date_of_birth, id);
+
+-- Case-insensitive name prefix filters
+CREATE INDEX IF NOT EXISTS idx_patients_lower_last_name ON patients (lower(last_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_lower_first_name ON patients (lower(first_name) text_pattern_ops);
@@ -0,0 +1,16 @@
+-- Fuzzy (trigram) and phonetic (Soundex, Double Metaphone) patient search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch;
+
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_trgm ON patients USING gin (lower(first_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_trgm ON patients USING gin (lower(last_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_full_name_trgm ON patients USING gin (lower(first_name || ' ' || last_name) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_dmetaphone ON patients (dmetaphone(first_name));
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_dmetaphone ON patients (dmetaphone(last_name));
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_soundex ON patients (soundex(first_name));
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_soundex ON patients (soundex(last_name));
+
+-- Exact phone (digits only) and email lookups
+CREATE INDEX IF NOT EXISTS idx_patients_phone_digits ON patients (regexp_replace(phone_number, '\D', '', 'g'));
+CREATE INDEX IF NOT EXISTS idx_patients_lower_email ON patients (lower(email));
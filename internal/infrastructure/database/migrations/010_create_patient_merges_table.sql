@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS patient_merges (
+    id SERIAL PRIMARY KEY,
+    survivor_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    -- The duplicate row is deleted on merge, so this is not a foreign key
+    duplicate_id INTEGER NOT NULL,
+    duplicate_snapshot JSONB NOT NULL,
+    merged_by INTEGER NOT NULL,
+    merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_merges_survivor ON patient_merges (survivor_id);
+CREATE INDEX IF NOT EXISTS idx_patient_merges_duplicate ON patient_merges (duplicate_id);
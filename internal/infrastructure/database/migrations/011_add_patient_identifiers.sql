@@ -0,0 +1,19 @@
+-- Medical Record Numbers. Patients created before MRNs existed are given
+-- one when the server starts.
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS mrn VARCHAR(32) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS mrn_sequences (
+    facility_code VARCHAR(10) PRIMARY KEY,
+    last_value BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS patient_identifiers (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    system VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (system, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_identifiers_patient ON patient_identifiers (patient_id);
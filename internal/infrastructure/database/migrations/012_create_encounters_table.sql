@@ -0,0 +1,59 @@
+-- Signed notes and the rest of the clinical record are kept, so a patient
+-- with encounters cannot be deleted
+CREATE TABLE IF NOT EXISTS encounters (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    doctor_id INTEGER NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('outpatient', 'inpatient', 'emergency', 'telehealth')),
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'finished', 'cancelled')),
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time IS NULL OR end_time >= start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounters_patient ON encounters (patient_id, start_time DESC);
+
+CREATE TRIGGER update_encounters_updated_at BEFORE UPDATE
+ON encounters FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- A clinical note; its content lives in clinical_note_versions
+CREATE TABLE IF NOT EXISTS clinical_notes (
+    id BIGSERIAL PRIMARY KEY,
+    encounter_id INTEGER NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_clinical_notes_encounter ON clinical_notes (encounter_id);
+
+CREATE TABLE IF NOT EXISTS clinical_note_versions (
+    id BIGSERIAL PRIMARY KEY,
+    note_id BIGINT NOT NULL REFERENCES clinical_notes(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version > 0),
+    author_id INTEGER NOT NULL REFERENCES users(id),
+    content TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'signed')),
+    signed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (note_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_clinical_note_versions_author ON clinical_note_versions (author_id);
+
+-- Backstop for the service-level check: once signed, a version is never edited
+CREATE OR REPLACE FUNCTION reject_signed_note_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status = 'signed' THEN
+        RAISE EXCEPTION 'signed clinical note versions cannot be changed';
+    END IF;
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER clinical_note_versions_immutable BEFORE UPDATE
+ON clinical_note_versions FOR EACH ROW EXECUTE FUNCTION reject_signed_note_update();
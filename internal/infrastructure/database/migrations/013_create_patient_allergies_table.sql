@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS patient_allergies (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    substance VARCHAR(255) NOT NULL,
+    reaction TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('mild', 'moderate', 'severe')),
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'inactive')),
+    recorded_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_allergies_patient ON patient_allergies (patient_id, status);
+
+CREATE TRIGGER update_patient_allergies_updated_at BEFORE UPDATE
+ON patient_allergies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
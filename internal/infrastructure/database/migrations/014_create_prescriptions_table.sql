@@ -0,0 +1,84 @@
+CREATE TABLE IF NOT EXISTS prescriptions (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    prescriber_id INTEGER NOT NULL REFERENCES users(id),
+    drug VARCHAR(255) NOT NULL,
+    dose VARCHAR(100) NOT NULL,
+    route VARCHAR(20) NOT NULL CHECK (route IN ('oral', 'iv', 'im', 'subcutaneous', 'topical', 'inhaled', 'sublingual', 'rectal')),
+    frequency VARCHAR(100) NOT NULL,
+    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+    instructions TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'discontinued', 'completed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient ON prescriptions (patient_id, status);
+
+CREATE TRIGGER update_prescriptions_updated_at BEFORE UPDATE
+ON prescriptions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Drug classes, so an allergy recorded against a class (e.g. penicillins)
+-- catches every drug in it. Names are lower case generic names.
+CREATE TABLE IF NOT EXISTS drug_classes (
+    drug VARCHAR(255) NOT NULL,
+    class VARCHAR(255) NOT NULL,
+    PRIMARY KEY (drug, class)
+);
+
+-- Local drug-interaction table. Each pair is stored once with drug_a < drug_b.
+CREATE TABLE IF NOT EXISTS drug_interactions (
+    drug_a VARCHAR(255) NOT NULL,
+    drug_b VARCHAR(255) NOT NULL,
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('minor', 'moderate', 'major', 'contraindicated')),
+    description TEXT NOT NULL,
+    PRIMARY KEY (drug_a, drug_b),
+    CHECK (drug_a < drug_b)
+);
+
+INSERT INTO drug_classes (drug, class) VALUES
+    ('amoxicillin', 'penicillins'),
+    ('ampicillin', 'penicillins'),
+    ('penicillin v', 'penicillins'),
+    ('piperacillin', 'penicillins'),
+    ('cephalexin', 'cephalosporins'),
+    ('ceftriaxone', 'cephalosporins'),
+    ('sulfamethoxazole', 'sulfonamides'),
+    ('aspirin', 'nsaids'),
+    ('ibuprofen', 'nsaids'),
+    ('naproxen', 'nsaids'),
+    ('diclofenac', 'nsaids'),
+    ('codeine', 'opioids'),
+    ('morphine', 'opioids'),
+    ('tramadol', 'opioids'),
+    ('oxycodone', 'opioids'),
+    ('lisinopril', 'ace inhibitors'),
+    ('enalapril', 'ace inhibitors'),
+    ('atorvastatin', 'statins'),
+    ('simvastatin', 'statins'),
+    ('sertraline', 'ssris'),
+    ('fluoxetine', 'ssris'),
+    ('citalopram', 'ssris')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO drug_interactions (drug_a, drug_b, severity, description) VALUES
+    ('aspirin', 'warfarin', 'major', 'Increased risk of bleeding'),
+    ('ibuprofen', 'warfarin', 'major', 'Increased risk of bleeding'),
+    ('naproxen', 'warfarin', 'major', 'Increased risk of bleeding'),
+    ('clarithromycin', 'simvastatin', 'contraindicated', 'Raised statin levels; risk of rhabdomyolysis'),
+    ('erythromycin', 'simvastatin', 'contraindicated', 'Raised statin levels; risk of rhabdomyolysis'),
+    ('nitroglycerin', 'sildenafil', 'contraindicated', 'Severe hypotension'),
+    ('fluoxetine', 'tramadol', 'major', 'Risk of serotonin syndrome and seizures'),
+    ('sertraline', 'tramadol', 'major', 'Risk of serotonin syndrome'),
+    ('lisinopril', 'spironolactone', 'major', 'Risk of hyperkalaemia'),
+    ('enalapril', 'spironolactone', 'major', 'Risk of hyperkalaemia'),
+    ('ciprofloxacin', 'theophylline', 'major', 'Raised theophylline levels; risk of seizures'),
+    ('amiodarone', 'digoxin', 'major', 'Raised digoxin levels'),
+    ('methotrexate', 'trimethoprim', 'major', 'Bone marrow suppression'),
+    ('ibuprofen', 'lisinopril', 'moderate', 'Reduced antihypertensive effect and risk of kidney injury'),
+    ('metformin', 'prednisone', 'moderate', 'Raised blood glucose'),
+    ('levothyroxine', 'omeprazole', 'minor', 'Reduced levothyroxine absorption'),
+    ('amoxicillin', 'methotrexate', 'moderate', 'Reduced methotrexate clearance'),
+    ('citalopram', 'omeprazole', 'moderate', 'Raised citalopram levels; QT prolongation'),
+    ('clopidogrel', 'omeprazole', 'moderate', 'Reduced antiplatelet effect of clopidogrel')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS patient_problems (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    icd10_code VARCHAR(10) NOT NULL,
+    description TEXT NOT NULL,
+    onset_date DATE,
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'resolved', 'inactive')),
+    chronic BOOLEAN NOT NULL DEFAULT false,
+    recorded_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_problems_patient ON patient_problems (patient_id, status);
+
+CREATE TRIGGER update_patient_problems_updated_at BEFORE UPDATE
+ON patient_problems FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS patient_medications (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    drug VARCHAR(255) NOT NULL,
+    dose VARCHAR(100) NOT NULL DEFAULT '',
+    frequency VARCHAR(100) NOT NULL DEFAULT '',
+    start_date DATE,
+    end_date DATE,
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'stopped')),
+    source TEXT NOT NULL DEFAULT '',
+    recorded_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date IS NULL OR start_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_medications_patient ON patient_medications (patient_id, status);
+
+CREATE TRIGGER update_patient_medications_updated_at BEFORE UPDATE
+ON patient_medications FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS vital_signs (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    recorded_at TIMESTAMP NOT NULL,
+    systolic_bp INTEGER,
+    diastolic_bp INTEGER,
+    heart_rate INTEGER,
+    temperature NUMERIC(4, 1),
+    spo2 INTEGER CHECK (spo2 BETWEEN 0 AND 100),
+    respiratory_rate INTEGER,
+    weight_kg NUMERIC(5, 1),
+    height_cm NUMERIC(4, 1),
+    bmi NUMERIC(4, 1),
+    recorded_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (diastolic_bp IS NULL OR systolic_bp IS NULL OR diastolic_bp < systolic_bp)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vital_signs_patient ON vital_signs (patient_id, recorded_at);
+
+-- Normal ranges by age group. A range applies from min_age up to, but not
+-- including, max_age (years); a NULL max_age has no upper bound. Readings
+-- outside the range for the patient's age are flagged. Edit these rows to
+-- match local protocols.
+CREATE TABLE IF NOT EXISTS vital_sign_ranges (
+    id SERIAL PRIMARY KEY,
+    vital VARCHAR(30) NOT NULL CHECK (vital IN ('systolic_bp', 'diastolic_bp', 'heart_rate', 'temperature', 'spo2', 'respiratory_rate', 'bmi')),
+    age_group VARCHAR(30) NOT NULL,
+    min_age INTEGER NOT NULL DEFAULT 0 CHECK (min_age >= 0),
+    max_age INTEGER,
+    low NUMERIC(5, 1) NOT NULL,
+    high NUMERIC(5, 1) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (vital, age_group),
+    CHECK (max_age IS NULL OR max_age > min_age),
+    CHECK (low < high)
+);
+
+CREATE TRIGGER update_vital_sign_ranges_updated_at BEFORE UPDATE
+ON vital_sign_ranges FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO vital_sign_ranges (vital, age_group, min_age, max_age, low, high) VALUES
+    ('systolic_bp', 'infant', 0, 1, 70, 100),
+    ('systolic_bp', 'child', 1, 12, 80, 120),
+    ('systolic_bp', 'adolescent', 12, 18, 90, 130),
+    ('systolic_bp', 'adult', 18, NULL, 90, 139),
+    ('diastolic_bp', 'infant', 0, 1, 45, 70),
+    ('diastolic_bp', 'child', 1, 12, 50, 80),
+    ('diastolic_bp', 'adolescent', 12, 18, 55, 85),
+    ('diastolic_bp', 'adult', 18, NULL, 60, 89),
+    ('heart_rate', 'infant', 0, 1, 100, 160),
+    ('heart_rate', 'child', 1, 12, 70, 120),
+    ('heart_rate', 'adolescent', 12, 18, 60, 100),
+    ('heart_rate', 'adult', 18, NULL, 60, 100),
+    ('respiratory_rate', 'infant', 0, 1, 30, 60),
+    ('respiratory_rate', 'child', 1, 12, 18, 30),
+    ('respiratory_rate', 'adolescent', 12, 18, 12, 20),
+    ('respiratory_rate', 'adult', 18, NULL, 12, 20),
+    ('temperature', 'all', 0, NULL, 36.0, 37.9),
+    ('spo2', 'all', 0, NULL, 95, 100),
+    ('bmi', 'adult', 18, NULL, 18.5, 24.9)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,93 @@
+-- Lab catalog, keyed by LOINC code
+CREATE TABLE IF NOT EXISTS lab_tests (
+    code VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    unit VARCHAR(30) NOT NULL,
+    specimen VARCHAR(50) NOT NULL
+);
+
+-- Reference ranges by sex and age. A NULL sex applies to everyone; a range
+-- applies from min_age up to, but not including, max_age (years), and a
+-- NULL max_age has no upper bound. Sex-specific ranges take precedence.
+CREATE TABLE IF NOT EXISTS lab_reference_ranges (
+    id SERIAL PRIMARY KEY,
+    test_code VARCHAR(20) NOT NULL REFERENCES lab_tests(code) ON DELETE CASCADE,
+    sex VARCHAR(10) CHECK (sex IN ('male', 'female')),
+    min_age INTEGER NOT NULL DEFAULT 0 CHECK (min_age >= 0),
+    max_age INTEGER,
+    low NUMERIC(10, 3) NOT NULL,
+    high NUMERIC(10, 3) NOT NULL,
+    critical_low NUMERIC(10, 3),
+    critical_high NUMERIC(10, 3),
+    CHECK (max_age IS NULL OR max_age > min_age),
+    CHECK (low < high),
+    CHECK (critical_low IS NULL OR critical_low < low),
+    CHECK (critical_high IS NULL OR critical_high > high)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_reference_ranges_test ON lab_reference_ranges (test_code);
+
+CREATE TABLE IF NOT EXISTS lab_orders (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    test_code VARCHAR(20) NOT NULL REFERENCES lab_tests(code),
+    ordered_by INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'ordered' CHECK (status IN ('ordered', 'collected', 'resulted', 'verified', 'cancelled')),
+    notes TEXT NOT NULL DEFAULT '',
+    ordered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    collected_at TIMESTAMP,
+    verified_at TIMESTAMP,
+    verified_by INTEGER REFERENCES users(id),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_orders_patient ON lab_orders (patient_id, ordered_at);
+
+CREATE TRIGGER update_lab_orders_updated_at BEFORE UPDATE
+ON lab_orders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS lab_results (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL UNIQUE REFERENCES lab_orders(id) ON DELETE CASCADE,
+    value NUMERIC(12, 3) NOT NULL,
+    unit VARCHAR(30) NOT NULL,
+    reference_low NUMERIC(10, 3),
+    reference_high NUMERIC(10, 3),
+    flag VARCHAR(1) NOT NULL DEFAULT '' CHECK (flag IN ('', 'L', 'H')),
+    critical BOOLEAN NOT NULL DEFAULT false,
+    comment TEXT NOT NULL DEFAULT '',
+    resulted_by INTEGER REFERENCES users(id),
+    resulted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO lab_tests (code, name, unit, specimen) VALUES
+    ('2345-7', 'Glucose', 'mg/dL', 'serum'),
+    ('2951-2', 'Sodium', 'mmol/L', 'serum'),
+    ('2823-3', 'Potassium', 'mmol/L', 'serum'),
+    ('2160-0', 'Creatinine', 'mg/dL', 'serum'),
+    ('718-7', 'Hemoglobin', 'g/dL', 'blood'),
+    ('6690-2', 'White blood cell count', '10*3/uL', 'blood'),
+    ('777-3', 'Platelet count', '10*3/uL', 'blood'),
+    ('4548-4', 'Hemoglobin A1c', '%', 'blood'),
+    ('3016-3', 'Thyroid stimulating hormone', 'mIU/L', 'serum')
+ON CONFLICT DO NOTHING;
+
+-- Seeded only when the table is empty, so edited ranges are kept
+INSERT INTO lab_reference_ranges (test_code, sex, min_age, max_age, low, high, critical_low, critical_high)
+SELECT * FROM (VALUES
+    ('2345-7', NULL, 0, NULL, 70, 99, 40, 500),
+    ('2951-2', NULL, 0, NULL, 136, 145, 120, 160),
+    ('2823-3', NULL, 0, 18, 3.4, 4.7, 2.5, 6.5),
+    ('2823-3', NULL, 18, NULL, 3.5, 5.1, 2.5, 6.5),
+    ('2160-0', NULL, 0, 18, 0.3, 0.7, NULL, NULL),
+    ('2160-0', 'male', 18, NULL, 0.74, 1.35, NULL, 10),
+    ('2160-0', 'female', 18, NULL, 0.59, 1.04, NULL, 10),
+    ('718-7', NULL, 0, 18, 11.0, 14.5, 7, 20),
+    ('718-7', 'male', 18, NULL, 13.5, 17.5, 7, 20),
+    ('718-7', 'female', 18, NULL, 12.0, 15.5, 7, 20),
+    ('6690-2', NULL, 0, NULL, 4.5, 11.0, 2, 30),
+    ('777-3', NULL, 0, NULL, 150, 450, 50, 1000),
+    ('4548-4', NULL, 0, NULL, 4.0, 5.6, NULL, NULL),
+    ('3016-3', NULL, 0, NULL, 0.4, 4.0, NULL, NULL)
+) AS seed (test_code, sex, min_age, max_age, low, high, critical_low, critical_high)
+WHERE NOT EXISTS (SELECT 1 FROM lab_reference_ranges);
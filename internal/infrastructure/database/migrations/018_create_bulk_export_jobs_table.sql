@@ -0,0 +1,18 @@
+-- FHIR bulk data export jobs. The NDJSON files they produce live under
+-- EXPORT_DIR/<id>/ and are removed with the job, which happens
+-- EXPORT_RETENTION after it finishes unless its owner deletes it first.
+CREATE TABLE IF NOT EXISTS bulk_export_jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    requested_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    request_url TEXT NOT NULL,
+    types TEXT[] NOT NULL,
+    since TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'completed', 'failed')),
+    processed INTEGER NOT NULL DEFAULT 0,
+    output JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_export_jobs_status ON bulk_export_jobs (status);
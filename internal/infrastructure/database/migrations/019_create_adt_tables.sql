@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS wards (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id SERIAL PRIMARY KEY,
+    ward_id INTEGER NOT NULL REFERENCES wards(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (ward_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS beds (
+    id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    label VARCHAR(20) NOT NULL,
+    out_of_service BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (room_id, label)
+);
+
+-- bed_id is the bed the patient is in now; earlier beds are in
+-- bed_occupancies
+CREATE TABLE IF NOT EXISTS admissions (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    bed_id INTEGER NOT NULL REFERENCES beds(id),
+    attending_doctor_id INTEGER REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'admitted' CHECK (status IN ('admitted', 'discharged')),
+    admitted_by INTEGER NOT NULL REFERENCES users(id),
+    admitted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    discharged_by INTEGER REFERENCES users(id),
+    discharged_at TIMESTAMPTZ,
+    disposition VARCHAR(30) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A patient can only be admitted once at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admissions_active_patient ON admissions (patient_id) WHERE status = 'admitted';
+CREATE INDEX IF NOT EXISTS idx_admissions_patient ON admissions (patient_id, admitted_at);
+
+CREATE TRIGGER update_admissions_updated_at BEFORE UPDATE
+ON admissions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Every stay of an admission in a bed. The open row (ended_at NULL) is the
+-- bed's current occupant, and there can only be one per bed. Stays start
+-- and end at the database's NOW().
+CREATE TABLE IF NOT EXISTS bed_occupancies (
+    id SERIAL PRIMARY KEY,
+    bed_id INTEGER NOT NULL REFERENCES beds(id),
+    admission_id INTEGER NOT NULL REFERENCES admissions(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMPTZ,
+    CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bed_occupancies_open_bed ON bed_occupancies (bed_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_bed_occupancies_bed ON bed_occupancies (bed_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_bed_occupancies_admission ON bed_occupancies (admission_id, started_at);
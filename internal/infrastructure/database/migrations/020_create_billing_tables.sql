@@ -0,0 +1,106 @@
+-- Billing staff manage the chargemaster, invoices and payments. Like
+-- admins, they cannot self-register:
+--   UPDATE users SET role = 'billing' WHERE username = '<username>';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin', 'compliance', 'billing'));
+
+-- Tax rates in basis points (825 = 8.25%)
+CREATE TABLE IF NOT EXISTS tax_rules (
+    code VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    rate_bp INTEGER NOT NULL CHECK (rate_bp BETWEEN 0 AND 10000),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_tax_rules_updated_at BEFORE UPDATE
+ON tax_rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The chargemaster: every billable service with its price in cents.
+-- Inactive items stay for old invoices but cannot be billed.
+CREATE TABLE IF NOT EXISTS charge_items (
+    code VARCHAR(20) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL,
+    unit_price_cents BIGINT NOT NULL CHECK (unit_price_cents >= 0),
+    tax_code VARCHAR(20) NOT NULL REFERENCES tax_rules(code),
+    active BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_charge_items_updated_at BEFORE UPDATE
+ON charge_items FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Amounts are in cents. paid_cents and refunded_cents are running totals
+-- of the invoice's payments and refunds; the checks keep an invoice from
+-- being overpaid or refunded more than was paid. Financial records are
+-- kept, so a patient with invoices cannot be deleted.
+CREATE TABLE IF NOT EXISTS invoices (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    encounter_id INTEGER REFERENCES encounters(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'issued' CHECK (status IN ('issued', 'partially_paid', 'paid', 'void')),
+    subtotal_cents BIGINT NOT NULL,
+    tax_cents BIGINT NOT NULL,
+    total_cents BIGINT NOT NULL,
+    paid_cents BIGINT NOT NULL DEFAULT 0,
+    refunded_cents BIGINT NOT NULL DEFAULT 0,
+    notes TEXT NOT NULL DEFAULT '',
+    issued_by INTEGER NOT NULL REFERENCES users(id),
+    issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (total_cents = subtotal_cents + tax_cents),
+    CONSTRAINT invoices_not_overpaid CHECK (paid_cents - refunded_cents <= total_cents),
+    CONSTRAINT invoices_not_overrefunded CHECK (refunded_cents <= paid_cents)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_patient ON invoices (patient_id, issued_at);
+
+CREATE TRIGGER update_invoices_updated_at BEFORE UPDATE
+ON invoices FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Lines keep the description, price and tax rate they were billed at
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    charge_code VARCHAR(20) NOT NULL REFERENCES charge_items(code),
+    description VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price_cents BIGINT NOT NULL,
+    tax_rate_bp INTEGER NOT NULL,
+    subtotal_cents BIGINT NOT NULL,
+    tax_cents BIGINT NOT NULL,
+    total_cents BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice ON invoice_lines (invoice_id);
+
+-- Payments and refunds. A refund gives back part or all of one payment.
+CREATE TABLE IF NOT EXISTS invoice_payments (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('payment', 'refund')),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    method VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'card', 'check', 'insurance', 'other')),
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    refund_of INTEGER REFERENCES invoice_payments(id),
+    recorded_by INTEGER NOT NULL REFERENCES users(id),
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((kind = 'refund') = (refund_of IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_payments_invoice ON invoice_payments (invoice_id, recorded_at);
+
+INSERT INTO tax_rules (code, name, rate_bp) VALUES
+    ('exempt', 'Exempt medical service', 0),
+    ('standard', 'Standard sales tax', 825)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO charge_items (code, description, unit_price_cents, tax_code) VALUES
+    ('99203', 'Office visit, new patient, low complexity', 16500, 'exempt'),
+    ('99213', 'Office visit, established patient, low complexity', 11000, 'exempt'),
+    ('99285', 'Emergency department visit, high severity', 95000, 'exempt'),
+    ('99223', 'Initial hospital inpatient care, per day', 42000, 'exempt'),
+    ('85025', 'Complete blood count with differential', 3500, 'exempt'),
+    ('80048', 'Basic metabolic panel', 4200, 'exempt'),
+    ('71046', 'Chest X-ray, 2 views', 18000, 'exempt'),
+    ('E0114', 'Crutches, underarm, pair', 6500, 'standard')
+ON CONFLICT DO NOTHING;
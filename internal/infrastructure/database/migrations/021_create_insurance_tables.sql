@@ -0,0 +1,55 @@
+-- Like invoices, policies and claims keep their patient from being deleted
+CREATE TABLE IF NOT EXISTS insurance_policies (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    payer_name VARCHAR(100) NOT NULL,
+    payer_id VARCHAR(80) NOT NULL,
+    member_id VARCHAR(80) NOT NULL,
+    group_number VARCHAR(50) NOT NULL DEFAULT '',
+    priority SMALLINT NOT NULL CHECK (priority BETWEEN 1 AND 3),
+    relationship VARCHAR(10) NOT NULL DEFAULT 'self' CHECK (relationship IN ('self', 'spouse', 'child', 'other')),
+    subscriber_first_name VARCHAR(100) NOT NULL DEFAULT '',
+    subscriber_last_name VARCHAR(100) NOT NULL DEFAULT '',
+    subscriber_dob DATE,
+    effective_from DATE NOT NULL,
+    effective_to DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (effective_to IS NULL OR effective_to >= effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_insurance_policies_patient ON insurance_policies (patient_id, priority);
+
+CREATE TRIGGER update_insurance_policies_updated_at BEFORE UPDATE
+ON insurance_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Numbers each claim file; used as its interchange control number and,
+-- prefixed, as the claim's patient control number
+CREATE SEQUENCE IF NOT EXISTS claim_control_seq;
+
+-- content is the 837P the claim was sent as. The remittance columns are
+-- filled in when the payer's 835 is posted.
+CREATE TABLE IF NOT EXISTS claims (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    policy_id INTEGER NOT NULL REFERENCES insurance_policies(id),
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE RESTRICT,
+    control_number VARCHAR(20) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'paid', 'partially_paid', 'denied')),
+    charge_cents BIGINT NOT NULL,
+    paid_cents BIGINT NOT NULL DEFAULT 0,
+    patient_responsibility_cents BIGINT NOT NULL DEFAULT 0,
+    payer_claim_number VARCHAR(50) NOT NULL DEFAULT '',
+    remittance_trace VARCHAR(50) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    adjudicated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_claims_invoice ON claims (invoice_id);
+
+-- An invoice can only be claimed from a policy again once the payer has
+-- denied the earlier claim.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_claims_open_invoice_policy ON claims (invoice_id, policy_id)
+    WHERE status <> 'denied';
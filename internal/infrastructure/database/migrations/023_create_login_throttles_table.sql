@@ -0,0 +1,14 @@
+-- Recent failed logins per username and per client IP. No login is tried
+-- for a key before locked_until; failures counts since the last success,
+-- and starts over when the last failure is older than the lockout period.
+-- The times come from the app's clock and keep their zone.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('username', 'ip')),
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure ON login_throttles (last_failure_at);
@@ -0,0 +1,182 @@
+// Package mllp is a TCP listener for HL7 v2 messages sent with the Minimal
+// Lower Layer Protocol.
+package mllp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"hospital-management-system/pkg/hl7"
+)
+
+// DefaultIdleTimeout is how long a connection may stay idle before it is
+// closed.
+const DefaultIdleTimeout = 5 * time.Minute
+
+// Handler processes one message and returns the acknowledgment to send
+// back.
+type Handler func(msg []byte) []byte
+
+// Server reads framed messages from each connection in turn, passing them
+// to its handler and writing back the replies. Senders wait for the reply
+// to one message before sending the next.
+type Server struct {
+	addr        string
+	handler     Handler
+	idleTimeout time.Duration
+	// allowed, if set, are the networks connections are accepted from
+	allowed []*net.IPNet
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer creates a server for addr. Connections idle for longer than
+// idleTimeout are closed; zero means never.
+func NewServer(addr string, handler Handler, idleTimeout time.Duration) *Server {
+	return &Server{
+		addr:        addr,
+		handler:     handler,
+		idleTimeout: idleTimeout,
+		conns:       map[net.Conn]struct{}{},
+	}
+}
+
+// AllowSources limits the server to connections from sources, which are
+// addresses or CIDR ranges; connections from anywhere else are closed
+// unread. With no sources every connection is accepted. It must be called
+// before the server starts.
+func (s *Server) AllowSources(sources []string) error {
+	var allowed []*net.IPNet
+	for _, source := range sources {
+		if ip := net.ParseIP(source); ip != nil {
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			allowed = append(allowed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return fmt.Errorf("invalid source %q", source)
+		}
+		allowed = append(allowed, network)
+	}
+	s.allowed = allowed
+	return nil
+}
+
+// ListenAndServe listens on the server's address and serves connections
+// until Close is called.
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called. It returns nil
+// after Close.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return nil
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		if !s.allows(conn.RemoteAddr()) {
+			log.Printf("MLLP connection from %s refused: not an allowed source", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops accepting connections and closes the open ones.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *Server) allows(addr net.Addr) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, network := range s.allowed {
+		if network.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		if s.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+		}
+		msg, err := hl7.ReadFrame(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("MLLP connection from %s closed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		if err := hl7.WriteFrame(conn, s.handler(msg)); err != nil {
+			log.Printf("MLLP reply to %s failed: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+const bedColumns = `b.id, b.room_id, rm.name, rm.ward_id, b.label, b.out_of_service, b.created_at, o.admission_id`
+
+const bedFrom = ` FROM beds b JOIN rooms rm ON rm.id = b.room_id
+              LEFT JOIN bed_occupancies o ON o.bed_id = b.id AND o.ended_at IS NULL`
+
+const admissionColumns = `id, patient_id, bed_id, attending_doctor_id, reason, status, admitted_by, admitted_at,
+	discharged_by, discharged_at, disposition, updated_at`
+
+type ADTRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewADTRepository(db *sql.DB) repository.ADTRepository {
+	return &ADTRepositoryImpl{db: db}
+}
+
+func (r *ADTRepositoryImpl) CreateWard(ward *models.Ward) error {
+	query := `INSERT INTO wards (name, description, created_at) VALUES ($1, $2, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, ward.Name, ward.Description).Scan(&ward.ID, &ward.CreatedAt)
+}
+
+func (r *ADTRepositoryImpl) FindWards() ([]models.Ward, error) {
+	rows, err := r.db.Query(`SELECT id, name, description, created_at FROM wards ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wards := []models.Ward{}
+	for rows.Next() {
+		var w models.Ward
+		if err := rows.Scan(&w.ID, &w.Name, &w.Description, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		wards = append(wards, w)
+	}
+
+	return wards, rows.Err()
+}
+
+func (r *ADTRepositoryImpl) FindWardByID(id uint) (*models.Ward, error) {
+	ward := &models.Ward{}
+	err := r.db.QueryRow(`SELECT id, name, description, created_at FROM wards WHERE id = $1`, id).Scan(
+		&ward.ID, &ward.Name, &ward.Description, &ward.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return ward, nil
+}
+
+func (r *ADTRepositoryImpl) CreateRoom(room *models.Room) error {
+	query := `INSERT INTO rooms (ward_id, name, created_at) VALUES ($1, $2, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, room.WardID, room.Name).Scan(&room.ID, &room.CreatedAt)
+}
+
+func (r *ADTRepositoryImpl) FindRoomByID(id uint) (*models.Room, error) {
+	room := &models.Room{}
+	err := r.db.QueryRow(`SELECT id, ward_id, name, created_at FROM rooms WHERE id = $1`, id).Scan(
+		&room.ID, &room.WardID, &room.Name, &room.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (r *ADTRepositoryImpl) CreateBed(bed *models.Bed) error {
+	query := `INSERT INTO beds (room_id, label, out_of_service, created_at) VALUES ($1, $2, $3, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, bed.RoomID, bed.Label, bed.OutOfService).Scan(&bed.ID, &bed.CreatedAt)
+}
+
+func (r *ADTRepositoryImpl) FindBedByID(id uint) (*models.Bed, error) {
+	bed := &models.Bed{}
+	err := r.db.QueryRow(`SELECT `+bedColumns+bedFrom+` WHERE b.id = $1`, id).Scan(
+		&bed.ID, &bed.RoomID, &bed.RoomName, &bed.WardID, &bed.Label, &bed.OutOfService, &bed.CreatedAt, &bed.AdmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bed, nil
+}
+
+func (r *ADTRepositoryImpl) UpdateBed(bed *models.Bed) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The lock makes admissions and transfers into the bed wait, so the
+	// occupancy check below cannot miss one
+	if _, err := tx.Exec(`SELECT id FROM beds WHERE id = $1 FOR UPDATE`, bed.ID); err != nil {
+		return err
+	}
+	if bed.OutOfService {
+		var occupied bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM bed_occupancies WHERE bed_id = $1 AND ended_at IS NULL)`,
+			bed.ID).Scan(&occupied)
+		if err != nil {
+			return err
+		}
+		if occupied {
+			return repository.ErrBedOccupied
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE beds SET label = $1, out_of_service = $2 WHERE id = $3`, bed.Label, bed.OutOfService, bed.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ADTRepositoryImpl) FindBedBoard(wardID uint) ([]models.BedBoardEntry, error) {
+	query := `SELECT ` + bedColumns + `, a.patient_id, COALESCE(p.mrn, ''), p.first_name, p.last_name,
+              a.attending_doctor_id, o.started_at` + bedFrom + `
+              LEFT JOIN admissions a ON a.id = o.admission_id
+              LEFT JOIN patients p ON p.id = a.patient_id
+              WHERE rm.ward_id = $1 ORDER BY rm.name, b.label`
+
+	rows, err := r.db.Query(query, wardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	board := []models.BedBoardEntry{}
+	for rows.Next() {
+		var (
+			e                 models.BedBoardEntry
+			patientID         sql.NullInt64
+			mrn, first, last  sql.NullString
+			attendingDoctorID *int64
+			since             sql.NullTime
+		)
+		err := rows.Scan(&e.Bed.ID, &e.Bed.RoomID, &e.Bed.RoomName, &e.Bed.WardID, &e.Bed.Label, &e.Bed.OutOfService,
+			&e.Bed.CreatedAt, &e.Bed.AdmissionID, &patientID, &mrn, &first, &last, &attendingDoctorID, &since)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case e.Bed.AdmissionID != nil:
+			e.Status = models.BedOccupied
+			e.Occupant = &models.BedOccupant{
+				AdmissionID:       *e.Bed.AdmissionID,
+				PatientID:         int(patientID.Int64),
+				MRN:               mrn.String,
+				FirstName:         first.String,
+				LastName:          last.String,
+				AttendingDoctorID: attendingDoctorID,
+				Since:             since.Time,
+			}
+		case e.Bed.OutOfService:
+			e.Status = models.BedOutOfService
+		default:
+			e.Status = models.BedAvailable
+		}
+		board = append(board, e)
+	}
+
+	return board, rows.Err()
+}
+
+func (r *ADTRepositoryImpl) FindBedOccupancies(bedID uint) ([]models.BedOccupancy, error) {
+	query := `SELECT o.id, o.bed_id, o.admission_id, a.patient_id, o.reason, o.started_at, o.ended_at
+              FROM bed_occupancies o JOIN admissions a ON a.id = o.admission_id
+              WHERE o.bed_id = $1 ORDER BY o.started_at DESC, o.id DESC`
+
+	return r.findOccupancies(query, bedID)
+}
+
+func (r *ADTRepositoryImpl) findOccupancies(query string, args ...interface{}) ([]models.BedOccupancy, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occupancies := []models.BedOccupancy{}
+	for rows.Next() {
+		var o models.BedOccupancy
+		if err := rows.Scan(&o.ID, &o.BedID, &o.AdmissionID, &o.PatientID, &o.Reason, &o.StartedAt, &o.EndedAt); err != nil {
+			return nil, err
+		}
+		occupancies = append(occupancies, o)
+	}
+
+	return occupancies, rows.Err()
+}
+
+func (r *ADTRepositoryImpl) FindCensus() ([]models.WardCensus, error) {
+	query := `SELECT w.id, w.name,
+              COUNT(b.id),
+              COUNT(o.id),
+              COUNT(b.id) FILTER (WHERE o.id IS NULL AND NOT b.out_of_service),
+              COUNT(b.id) FILTER (WHERE o.id IS NULL AND b.out_of_service)
+              FROM wards w
+              LEFT JOIN rooms rm ON rm.ward_id = w.id
+              LEFT JOIN beds b ON b.room_id = rm.id
+              LEFT JOIN bed_occupancies o ON o.bed_id = b.id AND o.ended_at IS NULL
+              GROUP BY w.id, w.name ORDER BY w.name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	census := []models.WardCensus{}
+	for rows.Next() {
+		var w models.WardCensus
+		if err := rows.Scan(&w.WardID, &w.WardName, &w.Beds, &w.Occupied, &w.Available, &w.OutOfService); err != nil {
+			return nil, err
+		}
+		census = append(census, w)
+	}
+
+	return census, rows.Err()
+}
+
+func (r *ADTRepositoryImpl) Admit(admission *models.Admission) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO admissions (patient_id, bed_id, attending_doctor_id, reason, status, admitted_by, admitted_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) RETURNING id, admitted_at, updated_at`
+	err = tx.QueryRow(query, admission.PatientID, admission.BedID, admission.AttendingDoctorID, admission.Reason,
+		admission.Status, admission.AdmittedBy).Scan(&admission.ID, &admission.AdmittedAt, &admission.UpdatedAt)
+	if err != nil {
+		return adtConflict(err)
+	}
+
+	occupancy := models.BedOccupancy{BedID: admission.BedID, AdmissionID: admission.ID, PatientID: admission.PatientID,
+		Reason: "admission"}
+	if err := openOccupancy(tx, &occupancy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	admission.Occupancies = []models.BedOccupancy{occupancy}
+	return nil
+}
+
+func (r *ADTRepositoryImpl) FindAdmissionByID(id uint) (*models.Admission, error) {
+	admissions, err := r.findAdmissions(`SELECT `+admissionColumns+` FROM admissions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(admissions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &admissions[0], nil
+}
+
+func (r *ADTRepositoryImpl) FindAdmissionsByPatient(patientID uint) ([]models.Admission, error) {
+	query := `SELECT ` + admissionColumns + ` FROM admissions WHERE patient_id = $1 ORDER BY admitted_at DESC, id DESC`
+
+	return r.findAdmissions(query, patientID)
+}
+
+func (r *ADTRepositoryImpl) FindActiveAdmission(patientID uint) (*models.Admission, error) {
+	admissions, err := r.findAdmissions(`SELECT `+admissionColumns+` FROM admissions WHERE patient_id = $1 AND status = $2`,
+		patientID, models.AdmissionAdmitted)
+	if err != nil {
+		return nil, err
+	}
+	if len(admissions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &admissions[0], nil
+}
+
+// findAdmissions runs query for admissions and attaches their occupancies
+func (r *ADTRepositoryImpl) findAdmissions(query string, args ...interface{}) ([]models.Admission, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	admissions := []models.Admission{}
+	index := map[int]int{}
+	var ids []int64
+	for rows.Next() {
+		var a models.Admission
+		err := rows.Scan(&a.ID, &a.PatientID, &a.BedID, &a.AttendingDoctorID, &a.Reason, &a.Status, &a.AdmittedBy,
+			&a.AdmittedAt, &a.DischargedBy, &a.DischargedAt, &a.Disposition, &a.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.Occupancies = []models.BedOccupancy{}
+		index[a.ID] = len(admissions)
+		ids = append(ids, int64(a.ID))
+		admissions = append(admissions, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(admissions) == 0 {
+		return admissions, nil
+	}
+
+	occupancies, err := r.findOccupancies(`SELECT o.id, o.bed_id, o.admission_id, a.patient_id, o.reason, o.started_at, o.ended_at
+              FROM bed_occupancies o JOIN admissions a ON a.id = o.admission_id
+              WHERE o.admission_id = ANY($1) ORDER BY o.started_at, o.id`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range occupancies {
+		a := &admissions[index[o.AdmissionID]]
+		a.Occupancies = append(a.Occupancies, o)
+	}
+
+	return admissions, nil
+}
+
+func (r *ADTRepositoryImpl) Transfer(admission *models.Admission, bedID int, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the admission first, as Discharge does, so that a transfer and
+	// a discharge of it run one after the other
+	var id int
+	err = tx.QueryRow(`SELECT id FROM admissions WHERE id = $1 AND status = 'admitted' FOR UPDATE`, admission.ID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrAdmissionClosed
+	}
+	if err != nil {
+		return err
+	}
+
+	var now sql.NullTime
+	err = tx.QueryRow(`UPDATE bed_occupancies SET ended_at = NOW() WHERE admission_id = $1 AND ended_at IS NULL RETURNING ended_at`,
+		admission.ID).Scan(&now)
+	if err != nil {
+		return err
+	}
+
+	occupancy := models.BedOccupancy{BedID: bedID, AdmissionID: admission.ID, PatientID: admission.PatientID,
+		Reason: reason}
+	if err := openOccupancy(tx, &occupancy); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`UPDATE admissions SET bed_id = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`,
+		bedID, admission.ID).Scan(&admission.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i := range admission.Occupancies {
+		if admission.Occupancies[i].EndedAt == nil {
+			admission.Occupancies[i].EndedAt = &now.Time
+		}
+	}
+	admission.BedID = bedID
+	admission.Occupancies = append(admission.Occupancies, occupancy)
+	return nil
+}
+
+func (r *ADTRepositoryImpl) Discharge(admission *models.Admission) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE admissions SET status = $1, discharged_by = $2, discharged_at = NOW(), disposition = $3, updated_at = NOW()
+              WHERE id = $4 AND status = 'admitted' RETURNING discharged_at, updated_at`
+	err = tx.QueryRow(query, admission.Status, admission.DischargedBy, admission.Disposition,
+		admission.ID).Scan(&admission.DischargedAt, &admission.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrAdmissionClosed
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE bed_occupancies SET ended_at = NOW() WHERE admission_id = $1 AND ended_at IS NULL`,
+		admission.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i := range admission.Occupancies {
+		if admission.Occupancies[i].EndedAt == nil {
+			admission.Occupancies[i].EndedAt = admission.DischargedAt
+		}
+	}
+	return nil
+}
+
+// openOccupancy starts an occupancy at NOW(). Admissions, transfers and
+// discharges all take their times from the database clock, which NOW()
+// holds still within a transaction, so a stay never ends before it starts.
+//
+// The bed is locked against UpdateBed first, so it cannot be taken out of
+// service between the check and the insert.
+func openOccupancy(tx *sql.Tx, occupancy *models.BedOccupancy) error {
+	var outOfService bool
+	err := tx.QueryRow(`SELECT out_of_service FROM beds WHERE id = $1 FOR SHARE`, occupancy.BedID).Scan(&outOfService)
+	if err != nil {
+		return err
+	}
+	if outOfService {
+		return repository.ErrBedOutOfService
+	}
+
+	query := `INSERT INTO bed_occupancies (bed_id, admission_id, reason, started_at) VALUES ($1, $2, $3, NOW()) RETURNING id, started_at`
+
+	err = tx.QueryRow(query, occupancy.BedID, occupancy.AdmissionID, occupancy.Reason).Scan(&occupancy.ID, &occupancy.StartedAt)
+	return adtConflict(err)
+}
+
+// adtConflict turns a violation of the one-admission-per-patient or
+// one-patient-per-bed indexes into the matching repository error
+func adtConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "idx_bed_occupancies_open_bed":
+			return repository.ErrBedOccupied
+		case "idx_admissions_active_patient":
+			return repository.ErrPatientAdmitted
+		}
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const allergyColumns = `id, patient_id, substance, reaction, severity, status, COALESCE(recorded_by, 0), created_at, updated_at`
+
+type AllergyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAllergyRepository(db *sql.DB) repository.AllergyRepository {
+	return &AllergyRepositoryImpl{db: db}
+}
+
+func (r *AllergyRepositoryImpl) Create(allergy *models.Allergy) error {
+	query := `INSERT INTO patient_allergies (patient_id, substance, reaction, severity, status, recorded_by, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, allergy.PatientID, allergy.Substance, allergy.Reaction, allergy.Severity,
+		allergy.Status, allergy.RecordedBy).Scan(&allergy.ID, &allergy.CreatedAt, &allergy.UpdatedAt)
+}
+
+func (r *AllergyRepositoryImpl) FindByID(id uint) (*models.Allergy, error) {
+	rows, err := r.db.Query(`SELECT `+allergyColumns+` FROM patient_allergies WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies, err := scanAllergies(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(allergies) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &allergies[0], nil
+}
+
+func (r *AllergyRepositoryImpl) FindByPatient(patientID uint) ([]models.Allergy, error) {
+	query := `SELECT ` + allergyColumns + ` FROM patient_allergies WHERE patient_id = $1 ORDER BY status, id`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAllergies(rows)
+}
+
+func (r *AllergyRepositoryImpl) FindActiveByPatient(patientID uint) ([]models.Allergy, error) {
+	query := `SELECT ` + allergyColumns + ` FROM patient_allergies 
+              WHERE patient_id = $1 AND status = 'active' ORDER BY id`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAllergies(rows)
+}
+
+func (r *AllergyRepositoryImpl) Update(allergy *models.Allergy) error {
+	query := `UPDATE patient_allergies SET substance = $1, reaction = $2, severity = $3, status = $4, updated_at = NOW() 
+              WHERE id = $5 RETURNING updated_at`
+
+	return r.db.QueryRow(query, allergy.Substance, allergy.Reaction, allergy.Severity, allergy.Status,
+		allergy.ID).Scan(&allergy.UpdatedAt)
+}
+
+func (r *AllergyRepositoryImpl) Delete(id uint) error {
+	query := `DELETE FROM patient_allergies WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func scanAllergies(rows *sql.Rows) ([]models.Allergy, error) {
+	allergies := []models.Allergy{}
+	for rows.Next() {
+		var allergy models.Allergy
+		err := rows.Scan(&allergy.ID, &allergy.PatientID, &allergy.Substance, &allergy.Reaction, &allergy.Severity,
+			&allergy.Status, &allergy.RecordedBy, &allergy.CreatedAt, &allergy.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		allergies = append(allergies, allergy)
+	}
+
+	return allergies, rows.Err()
+}
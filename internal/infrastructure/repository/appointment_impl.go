@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+const appointmentColumns = `id, patient_id, doctor_id, start_time, end_time, status, reason, notes, created_at, updated_at`
+
+type AppointmentRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAppointmentRepository(db *sql.DB) repository.AppointmentRepository {
+	return &AppointmentRepositoryImpl{db: db}
+}
+
+func (r *AppointmentRepositoryImpl) Create(appointment *models.Appointment) error {
+	query := `INSERT INTO appointments (patient_id, doctor_id, start_time, end_time, status, reason, notes, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(query, appointment.PatientID, appointment.DoctorID, appointment.StartTime,
+		appointment.EndTime, appointment.Status, appointment.Reason, appointment.Notes).Scan(
+		&appointment.ID, &appointment.CreatedAt, &appointment.UpdatedAt)
+
+	return appointmentOverlap(err)
+}
+
+func (r *AppointmentRepositoryImpl) FindByID(id uint) (*models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments WHERE id = $1`
+
+	appointment := &models.Appointment{}
+	err := r.db.QueryRow(query, id).Scan(
+		&appointment.ID, &appointment.PatientID, &appointment.DoctorID, &appointment.StartTime,
+		&appointment.EndTime, &appointment.Status, &appointment.Reason, &appointment.Notes,
+		&appointment.CreatedAt, &appointment.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+func (r *AppointmentRepositoryImpl) Update(appointment *models.Appointment) error {
+	query := `UPDATE appointments SET patient_id = $1, doctor_id = $2, start_time = $3, end_time = $4, 
+              status = $5, reason = $6, notes = $7, updated_at = NOW() 
+              WHERE id = $8`
+
+	_, err := r.db.Exec(query, appointment.PatientID, appointment.DoctorID, appointment.StartTime,
+		appointment.EndTime, appointment.Status, appointment.Reason, appointment.Notes, appointment.ID)
+
+	return appointmentOverlap(err)
+}
+
+func (r *AppointmentRepositoryImpl) Delete(id uint) error {
+	query := `DELETE FROM appointments WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *AppointmentRepositoryImpl) FindAll() ([]models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments ORDER BY start_time DESC`
+	return r.query(query)
+}
+
+func (r *AppointmentRepositoryImpl) FindByDoctor(doctorID int64, from, to time.Time) ([]models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments 
+              WHERE doctor_id = $1 AND start_time < $3 AND end_time > $2 
+              ORDER BY start_time`
+	return r.query(query, doctorID, from, to)
+}
+
+func (r *AppointmentRepositoryImpl) FindByPatient(patientID uint) ([]models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments WHERE patient_id = $1 ORDER BY start_time DESC`
+	return r.query(query, patientID)
+}
+
+func (r *AppointmentRepositoryImpl) FindOverlapping(doctorID int64, start, end time.Time, excludeID int) ([]models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments 
+              WHERE doctor_id = $1 AND start_time < $3 AND end_time > $2 
+              AND status <> 'cancelled' AND id <> $4 
+              ORDER BY start_time`
+	return r.query(query, doctorID, start, end, excludeID)
+}
+
+func (r *AppointmentRepositoryImpl) query(query string, args ...interface{}) ([]models.Appointment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appointments []models.Appointment
+	for rows.Next() {
+		var appointment models.Appointment
+		err := rows.Scan(
+			&appointment.ID, &appointment.PatientID, &appointment.DoctorID, &appointment.StartTime,
+			&appointment.EndTime, &appointment.Status, &appointment.Reason, &appointment.Notes,
+			&appointment.CreatedAt, &appointment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, appointment)
+	}
+
+	return appointments, rows.Err()
+}
+
+// appointmentOverlap turns a violation of the appointments' exclusion
+// constraint, which catches double bookings that race the service's check,
+// into repository.ErrAppointmentOverlap
+func appointmentOverlap(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23P01" {
+		return repository.ErrAppointmentOverlap
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const auditColumns = `id, actor_id, actor_username, actor_role, action, patient_id, changes, created_at, prev_hash, hash`
+
+// auditChainLock is the advisory lock key that serialises appends to the chain
+const auditChainLock = 0x61756469
+
+type AuditRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) repository.AuditRepository {
+	return &AuditRepositoryImpl{db: db}
+}
+
+func (r *AuditRepositoryImpl) Append(entry *models.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only one writer may read the head and extend the chain at a time
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, auditChainLock); err != nil {
+		return err
+	}
+
+	var prevHash string
+	err = tx.QueryRow(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	entry.PrevHash = prevHash
+	// Postgres keeps microseconds; truncate so the hash can be recomputed from the stored row
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Hash = entry.ComputeHash()
+
+	var changes interface{}
+	if len(entry.Changes) > 0 {
+		changes = string(entry.Changes)
+	}
+
+	query := `INSERT INTO audit_log (actor_id, actor_username, actor_role, action, patient_id, changes, created_at, prev_hash, hash) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	err = tx.QueryRow(query, entry.ActorID, entry.ActorUsername, entry.ActorRole, entry.Action,
+		entry.PatientID, changes, entry.CreatedAt, entry.PrevHash, entry.Hash).Scan(&entry.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AuditRepositoryImpl) FindByPatient(patientID uint, limit int) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE patient_id = $1 ORDER BY id DESC LIMIT $2`
+	return r.query(query, patientID, limit)
+}
+
+func (r *AuditRepositoryImpl) FindRecent(limit int) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log ORDER BY id DESC LIMIT $1`
+	return r.query(query, limit)
+}
+
+func (r *AuditRepositoryImpl) FindAfter(afterID int64, limit int) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`
+	return r.query(query, afterID, limit)
+}
+
+func (r *AuditRepositoryImpl) query(query string, args ...interface{}) ([]models.AuditEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var (
+			entry     models.AuditEntry
+			patientID sql.NullInt64
+			changes   []byte
+		)
+		err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.ActorUsername, &entry.ActorRole, &entry.Action,
+			&patientID, &changes, &entry.CreatedAt, &entry.PrevHash, &entry.Hash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if patientID.Valid {
+			id := int(patientID.Int64)
+			entry.PatientID = &id
+		}
+		if changes != nil {
+			entry.Changes = append([]byte(nil), changes...)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
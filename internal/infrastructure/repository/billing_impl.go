@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+const invoiceColumns = `id, patient_id, encounter_id, status, subtotal_cents, tax_cents, total_cents, paid_cents,
+	refunded_cents, notes, issued_by, issued_at, updated_at`
+
+type BillingRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewBillingRepository(db *sql.DB) repository.BillingRepository {
+	return &BillingRepositoryImpl{db: db}
+}
+
+func (r *BillingRepositoryImpl) FindTaxRules() ([]models.TaxRule, error) {
+	rows, err := r.db.Query(`SELECT code, name, rate_bp, updated_at FROM tax_rules ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.TaxRule{}
+	for rows.Next() {
+		var t models.TaxRule
+		if err := rows.Scan(&t.Code, &t.Name, &t.RateBP, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, t)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *BillingRepositoryImpl) FindTaxRule(code string) (*models.TaxRule, error) {
+	rule := &models.TaxRule{}
+	err := r.db.QueryRow(`SELECT code, name, rate_bp, updated_at FROM tax_rules WHERE code = $1`, code).Scan(
+		&rule.Code, &rule.Name, &rule.RateBP, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *BillingRepositoryImpl) SaveTaxRule(rule *models.TaxRule) error {
+	query := `INSERT INTO tax_rules (code, name, rate_bp, updated_at) VALUES ($1, $2, $3, NOW())
+              ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, rate_bp = EXCLUDED.rate_bp
+              RETURNING updated_at`
+
+	return r.db.QueryRow(query, rule.Code, rule.Name, rule.RateBP).Scan(&rule.UpdatedAt)
+}
+
+func (r *BillingRepositoryImpl) FindChargeItems() ([]models.ChargeItem, error) {
+	rows, err := r.db.Query(`SELECT code, description, unit_price_cents, tax_code, active, updated_at FROM charge_items ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChargeItem{}
+	for rows.Next() {
+		var i models.ChargeItem
+		if err := rows.Scan(&i.Code, &i.Description, &i.UnitPriceCents, &i.TaxCode, &i.Active, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *BillingRepositoryImpl) FindChargeItem(code string) (*models.ChargeItem, error) {
+	item := &models.ChargeItem{}
+	query := `SELECT code, description, unit_price_cents, tax_code, active, updated_at FROM charge_items WHERE code = $1`
+	err := r.db.QueryRow(query, code).Scan(
+		&item.Code, &item.Description, &item.UnitPriceCents, &item.TaxCode, &item.Active, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (r *BillingRepositoryImpl) CreateChargeItem(item *models.ChargeItem) error {
+	query := `INSERT INTO charge_items (code, description, unit_price_cents, tax_code, active, updated_at)
+              VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING updated_at`
+
+	return r.db.QueryRow(query, item.Code, item.Description, item.UnitPriceCents, item.TaxCode, item.Active).Scan(&item.UpdatedAt)
+}
+
+func (r *BillingRepositoryImpl) UpdateChargeItem(item *models.ChargeItem) error {
+	query := `UPDATE charge_items SET description = $1, unit_price_cents = $2, tax_code = $3, active = $4
+              WHERE code = $5 RETURNING updated_at`
+
+	return r.db.QueryRow(query, item.Description, item.UnitPriceCents, item.TaxCode, item.Active, item.Code).Scan(&item.UpdatedAt)
+}
+
+func (r *BillingRepositoryImpl) CreateInvoice(invoice *models.Invoice) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO invoices (patient_id, encounter_id, status, subtotal_cents, tax_cents, total_cents, notes,
+              issued_by, issued_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW()) RETURNING id, updated_at`
+	err = tx.QueryRow(query, invoice.PatientID, invoice.EncounterID, invoice.Status, invoice.SubtotalCents,
+		invoice.TaxCents, invoice.TotalCents, invoice.Notes, invoice.IssuedBy, invoice.IssuedAt).Scan(&invoice.ID, &invoice.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO invoice_lines (invoice_id, charge_code, description, quantity, unit_price_cents,
+              tax_rate_bp, subtotal_cents, tax_cents, total_cents)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range invoice.Lines {
+		l := &invoice.Lines[i]
+		l.InvoiceID = invoice.ID
+		err := stmt.QueryRow(l.InvoiceID, l.ChargeCode, l.Description, l.Quantity, l.UnitPriceCents, l.TaxRateBP,
+			l.SubtotalCents, l.TaxCents, l.TotalCents).Scan(&l.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BillingRepositoryImpl) FindInvoiceByID(id uint) (*models.Invoice, error) {
+	invoices, err := r.findInvoices(`SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &invoices[0], nil
+}
+
+func (r *BillingRepositoryImpl) FindInvoicesByPatient(patientID uint) ([]models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE patient_id = $1 ORDER BY issued_at DESC, id DESC`
+
+	return r.findInvoices(query, patientID)
+}
+
+// findInvoices runs query for invoices and attaches their lines and
+// payments
+func (r *BillingRepositoryImpl) findInvoices(query string, args ...interface{}) ([]models.Invoice, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	index := map[int]int{}
+	var ids []int64
+	for rows.Next() {
+		var i models.Invoice
+		err := rows.Scan(&i.ID, &i.PatientID, &i.EncounterID, &i.Status, &i.SubtotalCents, &i.TaxCents, &i.TotalCents,
+			&i.PaidCents, &i.RefundedCents, &i.Notes, &i.IssuedBy, &i.IssuedAt, &i.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		i.BalanceCents = i.TotalCents - i.PaidCents + i.RefundedCents
+		i.Lines, i.Payments = []models.InvoiceLine{}, []models.InvoicePayment{}
+		index[i.ID] = len(invoices)
+		ids = append(ids, int64(i.ID))
+		invoices = append(invoices, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return invoices, nil
+	}
+
+	lineRows, err := r.db.Query(`SELECT id, invoice_id, charge_code, description, quantity, unit_price_cents, tax_rate_bp,
+              subtotal_cents, tax_cents, total_cents
+              FROM invoice_lines WHERE invoice_id = ANY($1) ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer lineRows.Close()
+
+	for lineRows.Next() {
+		var l models.InvoiceLine
+		err := lineRows.Scan(&l.ID, &l.InvoiceID, &l.ChargeCode, &l.Description, &l.Quantity, &l.UnitPriceCents,
+			&l.TaxRateBP, &l.SubtotalCents, &l.TaxCents, &l.TotalCents)
+		if err != nil {
+			return nil, err
+		}
+		i := &invoices[index[l.InvoiceID]]
+		i.Lines = append(i.Lines, l)
+	}
+	if err := lineRows.Err(); err != nil {
+		return nil, err
+	}
+
+	paymentRows, err := r.db.Query(`SELECT id, invoice_id, kind, amount_cents, method, reference, refund_of, recorded_by, recorded_at
+              FROM invoice_payments WHERE invoice_id = ANY($1) ORDER BY recorded_at, id`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	for paymentRows.Next() {
+		var p models.InvoicePayment
+		err := paymentRows.Scan(&p.ID, &p.InvoiceID, &p.Kind, &p.AmountCents, &p.Method, &p.Reference, &p.RefundOf,
+			&p.RecordedBy, &p.RecordedAt)
+		if err != nil {
+			return nil, err
+		}
+		i := &invoices[index[p.InvoiceID]]
+		i.Payments = append(i.Payments, p)
+	}
+
+	return invoices, paymentRows.Err()
+}
+
+func (r *BillingRepositoryImpl) AddPayment(invoice *models.Invoice, payment *models.InvoicePayment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addPayment(tx, invoice, payment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addPayment saves a payment or refund of the invoice and adds it to the
+// invoice's totals and status within tx
+func addPayment(tx *sql.Tx, invoice *models.Invoice, payment *models.InvoicePayment) error {
+	if payment.Kind == models.PaymentKindRefund {
+		if err := checkRefundable(tx, invoice.ID, payment); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO invoice_payments (invoice_id, kind, amount_cents, method, reference, refund_of, recorded_by, recorded_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	err := tx.QueryRow(query, invoice.ID, payment.Kind, payment.AmountCents, payment.Method, payment.Reference,
+		payment.RefundOf, payment.RecordedBy, payment.RecordedAt).Scan(&payment.ID)
+	if err != nil {
+		return err
+	}
+
+	var paid, refunded int64
+	if payment.Kind == models.PaymentKindRefund {
+		refunded = payment.AmountCents
+	} else {
+		paid = payment.AmountCents
+	}
+
+	// The status is worked out from the new totals, so that concurrent
+	// payments cannot leave it stale
+	query = `UPDATE invoices SET paid_cents = paid_cents + $1, refunded_cents = refunded_cents + $2,
+              status = CASE
+                  WHEN paid_cents + $1 - refunded_cents - $2 >= total_cents THEN 'paid'
+                  WHEN paid_cents + $1 - refunded_cents - $2 > 0 THEN 'partially_paid'
+                  ELSE 'issued' END
+              WHERE id = $3 AND status <> 'void' RETURNING paid_cents, refunded_cents, status, updated_at`
+	err = tx.QueryRow(query, paid, refunded, invoice.ID).Scan(
+		&invoice.PaidCents, &invoice.RefundedCents, &invoice.Status, &invoice.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrInvoiceStatus
+	}
+	if err != nil {
+		return invoiceOverpaid(err)
+	}
+
+	invoice.BalanceCents = invoice.TotalCents - invoice.PaidCents + invoice.RefundedCents
+	payment.InvoiceID = invoice.ID
+	invoice.Payments = append(invoice.Payments, *payment)
+	return nil
+}
+
+// checkRefundable locks the invoice and checks the refund against what is
+// left of the payment it refunds. The invoice checks only cover its totals,
+// so without the lock two refunds of one payment could both pass.
+func checkRefundable(tx *sql.Tx, invoiceID int, refund *models.InvoicePayment) error {
+	if _, err := tx.Exec(`SELECT id FROM invoices WHERE id = $1 FOR UPDATE`, invoiceID); err != nil {
+		return err
+	}
+
+	query := `SELECT amount_cents, (SELECT COALESCE(SUM(amount_cents), 0) FROM invoice_payments WHERE refund_of = $1)
+              FROM invoice_payments WHERE id = $1 AND invoice_id = $2 AND kind = 'payment'`
+	var paid, refunded int64
+	if err := tx.QueryRow(query, refund.RefundOf, invoiceID).Scan(&paid, &refunded); err != nil {
+		return err
+	}
+	if refunded+refund.AmountCents > paid {
+		return repository.ErrInvoiceOverpaid
+	}
+	return nil
+}
+
+func (r *BillingRepositoryImpl) VoidInvoice(invoice *models.Invoice) error {
+	query := `UPDATE invoices SET status = 'void'
+              WHERE id = $1 AND status <> 'void' AND paid_cents = refunded_cents RETURNING status, updated_at`
+
+	err := r.db.QueryRow(query, invoice.ID).Scan(&invoice.Status, &invoice.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrInvoiceStatus
+	}
+	return err
+}
+
+func (r *BillingRepositoryImpl) FindPatientBalance(patientID uint) (*models.PatientBalance, error) {
+	query := `SELECT COALESCE(SUM(total_cents), 0), COALESCE(SUM(paid_cents), 0), COALESCE(SUM(refunded_cents), 0),
+              COUNT(*) FILTER (WHERE status IN ('issued', 'partially_paid'))
+              FROM invoices WHERE patient_id = $1 AND status <> 'void'`
+
+	balance := &models.PatientBalance{PatientID: int(patientID)}
+	err := r.db.QueryRow(query, patientID).Scan(
+		&balance.InvoicedCents, &balance.PaidCents, &balance.RefundedCents, &balance.OpenInvoices)
+	if err != nil {
+		return nil, err
+	}
+	balance.BalanceCents = balance.InvoicedCents - balance.PaidCents + balance.RefundedCents
+
+	return balance, nil
+}
+
+// invoiceOverpaid turns a violation of the invoice payment checks into
+// repository.ErrInvoiceOverpaid
+func invoiceOverpaid(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+		switch pqErr.Constraint {
+		case "invoices_not_overpaid", "invoices_not_overrefunded":
+			return repository.ErrInvoiceOverpaid
+		}
+	}
+	return err
+}
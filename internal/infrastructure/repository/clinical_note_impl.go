@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const clinicalNoteColumns = `n.id, n.encounter_id, v.version, v.author_id, v.content, v.status, v.signed_at, v.created_at, v.updated_at`
+
+type ClinicalNoteRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewClinicalNoteRepository(db *sql.DB) repository.ClinicalNoteRepository {
+	return &ClinicalNoteRepositoryImpl{db: db}
+}
+
+func (r *ClinicalNoteRepositoryImpl) Create(note *models.ClinicalNote) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO clinical_notes (encounter_id, created_at) VALUES ($1, NOW()) RETURNING id`,
+		note.EncounterID).Scan(&note.ID)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(insertNoteVersionQuery, note.ID, note.Version, note.AuthorID, note.Content, note.Status).Scan(
+		&note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateVersion relies on the unique (note_id, version) constraint to stop
+// two concurrent amendments taking the same version number
+func (r *ClinicalNoteRepositoryImpl) CreateVersion(note *models.ClinicalNote) error {
+	return r.db.QueryRow(insertNoteVersionQuery, note.ID, note.Version, note.AuthorID, note.Content, note.Status).Scan(
+		&note.CreatedAt, &note.UpdatedAt)
+}
+
+const insertNoteVersionQuery = `INSERT INTO clinical_note_versions (note_id, version, author_id, content, status, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING created_at, updated_at`
+
+func (r *ClinicalNoteRepositoryImpl) FindLatest(noteID int64) (*models.ClinicalNote, error) {
+	query := `SELECT ` + clinicalNoteColumns + ` 
+              FROM clinical_notes n JOIN clinical_note_versions v ON v.note_id = n.id 
+              WHERE n.id = $1 ORDER BY v.version DESC LIMIT 1`
+
+	rows, err := r.db.Query(query, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes, err := scanClinicalNotes(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &notes[0], nil
+}
+
+func (r *ClinicalNoteRepositoryImpl) FindLatestByEncounter(encounterID uint) ([]models.ClinicalNote, error) {
+	query := `SELECT DISTINCT ON (n.id) ` + clinicalNoteColumns + ` 
+              FROM clinical_notes n JOIN clinical_note_versions v ON v.note_id = n.id 
+              WHERE n.encounter_id = $1 ORDER BY n.id, v.version DESC`
+
+	rows, err := r.db.Query(query, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClinicalNotes(rows)
+}
+
+func (r *ClinicalNoteRepositoryImpl) FindVersions(noteID int64) ([]models.ClinicalNote, error) {
+	query := `SELECT ` + clinicalNoteColumns + ` 
+              FROM clinical_notes n JOIN clinical_note_versions v ON v.note_id = n.id 
+              WHERE n.id = $1 ORDER BY v.version`
+
+	rows, err := r.db.Query(query, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClinicalNotes(rows)
+}
+
+func (r *ClinicalNoteRepositoryImpl) UpdateDraft(note *models.ClinicalNote) error {
+	query := `UPDATE clinical_note_versions SET content = $1 
+              WHERE note_id = $2 AND version = $3 AND status = 'draft' RETURNING updated_at`
+
+	return r.db.QueryRow(query, note.Content, note.ID, note.Version).Scan(&note.UpdatedAt)
+}
+
+func (r *ClinicalNoteRepositoryImpl) Sign(note *models.ClinicalNote) error {
+	query := `UPDATE clinical_note_versions SET status = 'signed', signed_at = NOW() 
+              WHERE note_id = $1 AND version = $2 AND status = 'draft' RETURNING signed_at, updated_at`
+
+	err := r.db.QueryRow(query, note.ID, note.Version).Scan(&note.SignedAt, &note.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	note.Status = models.NoteSigned
+	return nil
+}
+
+func scanClinicalNotes(rows *sql.Rows) ([]models.ClinicalNote, error) {
+	notes := []models.ClinicalNote{}
+	for rows.Next() {
+		var note models.ClinicalNote
+		err := rows.Scan(&note.ID, &note.EncounterID, &note.Version, &note.AuthorID, &note.Content,
+			&note.Status, &note.SignedAt, &note.CreatedAt, &note.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const encounterColumns = `id, patient_id, doctor_id, type, status, start_time, end_time, reason, created_at, updated_at`
+
+type EncounterRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewEncounterRepository(db *sql.DB) repository.EncounterRepository {
+	return &EncounterRepositoryImpl{db: db}
+}
+
+func (r *EncounterRepositoryImpl) Create(encounter *models.Encounter) error {
+	query := `INSERT INTO encounters (patient_id, doctor_id, type, status, start_time, end_time, reason, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, encounter.PatientID, encounter.DoctorID, encounter.Type, encounter.Status,
+		encounter.StartTime, encounter.EndTime, encounter.Reason).Scan(
+		&encounter.ID, &encounter.CreatedAt, &encounter.UpdatedAt)
+}
+
+func (r *EncounterRepositoryImpl) FindByID(id uint) (*models.Encounter, error) {
+	query := `SELECT ` + encounterColumns + ` FROM encounters WHERE id = $1`
+
+	encounter := &models.Encounter{}
+	err := r.db.QueryRow(query, id).Scan(
+		&encounter.ID, &encounter.PatientID, &encounter.DoctorID, &encounter.Type, &encounter.Status,
+		&encounter.StartTime, &encounter.EndTime, &encounter.Reason, &encounter.CreatedAt, &encounter.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return encounter, nil
+}
+
+func (r *EncounterRepositoryImpl) FindByPatient(patientID uint) ([]models.Encounter, error) {
+	query := `SELECT ` + encounterColumns + ` FROM encounters WHERE patient_id = $1 ORDER BY start_time DESC, id DESC`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	encounters := []models.Encounter{}
+	for rows.Next() {
+		var encounter models.Encounter
+		err := rows.Scan(
+			&encounter.ID, &encounter.PatientID, &encounter.DoctorID, &encounter.Type, &encounter.Status,
+			&encounter.StartTime, &encounter.EndTime, &encounter.Reason, &encounter.CreatedAt, &encounter.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		encounters = append(encounters, encounter)
+	}
+
+	return encounters, rows.Err()
+}
+
+func (r *EncounterRepositoryImpl) Update(encounter *models.Encounter) error {
+	query := `UPDATE encounters SET type = $1, status = $2, start_time = $3, end_time = $4, reason = $5, updated_at = NOW() 
+              WHERE id = $6 RETURNING updated_at`
+
+	return r.db.QueryRow(query, encounter.Type, encounter.Status, encounter.StartTime, encounter.EndTime,
+		encounter.Reason, encounter.ID).Scan(&encounter.UpdatedAt)
+}
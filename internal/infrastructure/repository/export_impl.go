@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+type ExportJobRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewExportJobRepository(db *sql.DB) repository.ExportJobRepository {
+	return &ExportJobRepositoryImpl{db: db}
+}
+
+func (r *ExportJobRepositoryImpl) Create(job *models.ExportJob) error {
+	query := `INSERT INTO bulk_export_jobs (id, requested_by, request_url, types, since, status) 
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	return r.db.QueryRow(query, job.ID, job.RequestedBy, job.RequestURL, pq.Array(job.Types), job.Since, job.Status).Scan(&job.CreatedAt)
+}
+
+func (r *ExportJobRepositoryImpl) FindByID(id string) (*models.ExportJob, error) {
+	query := `SELECT id, requested_by, request_url, types, since, status, processed, output, error, created_at, completed_at 
+              FROM bulk_export_jobs WHERE id = $1`
+
+	var (
+		job    models.ExportJob
+		output []byte
+	)
+	err := r.db.QueryRow(query, id).Scan(&job.ID, &job.RequestedBy, &job.RequestURL, pq.Array(&job.Types), &job.Since,
+		&job.Status, &job.Processed, &output, &job.Error, &job.CreatedAt, &job.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(output, &job.Output); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *ExportJobRepositoryImpl) Update(job *models.ExportJob) error {
+	output, err := json.Marshal(job.Output)
+	if err != nil {
+		return err
+	}
+	if job.Output == nil {
+		output = []byte("[]")
+	}
+
+	query := `UPDATE bulk_export_jobs SET status = $1, processed = $2, output = $3, error = $4, completed_at = $5 
+              WHERE id = $6`
+
+	_, err = r.db.Exec(query, job.Status, job.Processed, string(output), job.Error, job.CompletedAt, job.ID)
+	return err
+}
+
+func (r *ExportJobRepositoryImpl) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM bulk_export_jobs WHERE id = $1`, id)
+	return err
+}
+
+func (r *ExportJobRepositoryImpl) FailInProgress(reason string) (int, error) {
+	query := `UPDATE bulk_export_jobs SET status = 'failed', error = $1, completed_at = NOW() 
+              WHERE status = 'in_progress'`
+
+	result, err := r.db.Exec(query, reason)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (r *ExportJobRepositoryImpl) DeleteFinishedBefore(t time.Time) ([]string, error) {
+	rows, err := r.db.Query(`DELETE FROM bulk_export_jobs WHERE status <> 'in_progress' AND completed_at < $1 RETURNING id`, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
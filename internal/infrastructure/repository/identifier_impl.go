@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+type IdentifierRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewIdentifierRepository(db *sql.DB) repository.IdentifierRepository {
+	return &IdentifierRepositoryImpl{db: db}
+}
+
+func (r *IdentifierRepositoryImpl) NextMRNSequence(facilityCode string) (int64, error) {
+	query := `INSERT INTO mrn_sequences (facility_code, last_value) VALUES ($1, 1) 
+              ON CONFLICT (facility_code) DO UPDATE SET last_value = mrn_sequences.last_value + 1 
+              RETURNING last_value`
+
+	var next int64
+	err := r.db.QueryRow(query, facilityCode).Scan(&next)
+	return next, err
+}
+
+func (r *IdentifierRepositoryImpl) Create(identifier *models.PatientIdentifier) error {
+	query := `INSERT INTO patient_identifiers (patient_id, system, value, created_at) 
+              VALUES ($1, $2, $3, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, identifier.PatientID, identifier.System, identifier.Value).Scan(
+		&identifier.ID, &identifier.CreatedAt)
+}
+
+func (r *IdentifierRepositoryImpl) FindByID(id uint) (*models.PatientIdentifier, error) {
+	query := `SELECT id, patient_id, system, value, created_at FROM patient_identifiers WHERE id = $1`
+
+	identifier := &models.PatientIdentifier{}
+	err := r.db.QueryRow(query, id).Scan(
+		&identifier.ID, &identifier.PatientID, &identifier.System, &identifier.Value, &identifier.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return identifier, nil
+}
+
+func (r *IdentifierRepositoryImpl) FindByPatient(patientID uint) ([]models.PatientIdentifier, error) {
+	query := `SELECT id, patient_id, system, value, created_at 
+              FROM patient_identifiers WHERE patient_id = $1 ORDER BY system, value`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identifiers := []models.PatientIdentifier{}
+	for rows.Next() {
+		var identifier models.PatientIdentifier
+		err := rows.Scan(&identifier.ID, &identifier.PatientID, &identifier.System, &identifier.Value, &identifier.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		identifiers = append(identifiers, identifier)
+	}
+
+	return identifiers, rows.Err()
+}
+
+func (r *IdentifierRepositoryImpl) FindBySystemValue(system, value string) (*models.PatientIdentifier, error) {
+	query := `SELECT id, patient_id, system, value, created_at 
+              FROM patient_identifiers WHERE system = $1 AND value = $2`
+
+	identifier := &models.PatientIdentifier{}
+	err := r.db.QueryRow(query, system, value).Scan(
+		&identifier.ID, &identifier.PatientID, &identifier.System, &identifier.Value, &identifier.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return identifier, nil
+}
+
+func (r *IdentifierRepositoryImpl) Delete(id uint) error {
+	query := `DELETE FROM patient_identifiers WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+const policyColumns = `id, patient_id, payer_name, payer_id, member_id, group_number, priority, relationship,
+	subscriber_first_name, subscriber_last_name, subscriber_dob, effective_from, effective_to, created_at, updated_at`
+
+const claimColumns = `id, invoice_id, policy_id, patient_id, control_number, status, charge_cents, paid_cents,
+	patient_responsibility_cents, payer_claim_number, remittance_trace, content, created_by, created_at, adjudicated_at`
+
+type InsuranceRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewInsuranceRepository(db *sql.DB) repository.InsuranceRepository {
+	return &InsuranceRepositoryImpl{db: db}
+}
+
+func (r *InsuranceRepositoryImpl) CreatePolicy(policy *models.InsurancePolicy) error {
+	query := `INSERT INTO insurance_policies (patient_id, payer_name, payer_id, member_id, group_number, priority,
+              relationship, subscriber_first_name, subscriber_last_name, subscriber_dob, effective_from, effective_to,
+              created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, policy.PatientID, policy.PayerName, policy.PayerID, policy.MemberID, policy.GroupNumber,
+		policy.Priority, policy.Relationship, policy.SubscriberFirstName, policy.SubscriberLastName, policy.SubscriberDOB,
+		policy.EffectiveFrom, policy.EffectiveTo).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+}
+
+func (r *InsuranceRepositoryImpl) FindPolicyByID(id uint) (*models.InsurancePolicy, error) {
+	rows, err := r.db.Query(`SELECT `+policyColumns+` FROM insurance_policies WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies, err := scanPolicies(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &policies[0], nil
+}
+
+func (r *InsuranceRepositoryImpl) FindPoliciesByPatient(patientID uint) ([]models.InsurancePolicy, error) {
+	query := `SELECT ` + policyColumns + ` FROM insurance_policies WHERE patient_id = $1
+              ORDER BY priority, effective_from DESC, id DESC`
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPolicies(rows)
+}
+
+func (r *InsuranceRepositoryImpl) UpdatePolicy(policy *models.InsurancePolicy) error {
+	query := `UPDATE insurance_policies SET payer_name = $1, payer_id = $2, member_id = $3, group_number = $4,
+              priority = $5, relationship = $6, subscriber_first_name = $7, subscriber_last_name = $8,
+              subscriber_dob = $9, effective_from = $10, effective_to = $11
+              WHERE id = $12 RETURNING updated_at`
+
+	return r.db.QueryRow(query, policy.PayerName, policy.PayerID, policy.MemberID, policy.GroupNumber, policy.Priority,
+		policy.Relationship, policy.SubscriberFirstName, policy.SubscriberLastName, policy.SubscriberDOB,
+		policy.EffectiveFrom, policy.EffectiveTo, policy.ID).Scan(&policy.UpdatedAt)
+}
+
+func (r *InsuranceRepositoryImpl) DeletePolicy(id uint) error {
+	_, err := r.db.Exec(`DELETE FROM insurance_policies WHERE id = $1`, id)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.ErrPolicyHasClaims
+	}
+	return err
+}
+
+func scanPolicies(rows *sql.Rows) ([]models.InsurancePolicy, error) {
+	policies := []models.InsurancePolicy{}
+	for rows.Next() {
+		var p models.InsurancePolicy
+		err := rows.Scan(&p.ID, &p.PatientID, &p.PayerName, &p.PayerID, &p.MemberID, &p.GroupNumber, &p.Priority,
+			&p.Relationship, &p.SubscriberFirstName, &p.SubscriberLastName, &p.SubscriberDOB, &p.EffectiveFrom,
+			&p.EffectiveTo, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+func (r *InsuranceRepositoryImpl) NextClaimNumber() (int64, error) {
+	var next int64
+	err := r.db.QueryRow(`SELECT nextval('claim_control_seq')`).Scan(&next)
+	return next, err
+}
+
+func (r *InsuranceRepositoryImpl) CreateClaim(claim *models.Claim) error {
+	query := `INSERT INTO claims (invoice_id, policy_id, patient_id, control_number, status, charge_cents, content,
+              created_by, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+
+	err := r.db.QueryRow(query, claim.InvoiceID, claim.PolicyID, claim.PatientID, claim.ControlNumber, claim.Status,
+		claim.ChargeCents, claim.Content, claim.CreatedBy, claim.CreatedAt).Scan(&claim.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_claims_open_invoice_policy" {
+		return repository.ErrClaimExists
+	}
+	return err
+}
+
+func (r *InsuranceRepositoryImpl) FindClaimByID(id uint) (*models.Claim, error) {
+	return r.findClaim(`SELECT `+claimColumns+` FROM claims WHERE id = $1`, id)
+}
+
+func (r *InsuranceRepositoryImpl) FindClaimByControlNumber(controlNumber string) (*models.Claim, error) {
+	return r.findClaim(`SELECT `+claimColumns+` FROM claims WHERE control_number = $1`, controlNumber)
+}
+
+func (r *InsuranceRepositoryImpl) findClaim(query string, arg interface{}) (*models.Claim, error) {
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims, err := scanClaims(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &claims[0], nil
+}
+
+func (r *InsuranceRepositoryImpl) FindClaimsByInvoice(invoiceID uint) ([]models.Claim, error) {
+	rows, err := r.db.Query(`SELECT `+claimColumns+` FROM claims WHERE invoice_id = $1 ORDER BY created_at, id`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClaims(rows)
+}
+
+func (r *InsuranceRepositoryImpl) PostClaimRemittance(claim *models.Claim, invoice *models.Invoice, payment *models.InvoicePayment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The claim is taken first, so that of two postings of the same
+	// remittance only one gets to record the payment
+	query := `UPDATE claims SET status = $1, paid_cents = $2, patient_responsibility_cents = $3, payer_claim_number = $4,
+              remittance_trace = $5, adjudicated_at = $6 WHERE id = $7 AND status = 'submitted'`
+	result, err := tx.Exec(query, claim.Status, claim.PaidCents, claim.PatientResponsibilityCents, claim.PayerClaimNumber,
+		claim.RemittanceTrace, claim.AdjudicatedAt, claim.ID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrClaimPosted
+	}
+
+	if payment != nil {
+		if err := addPayment(tx, invoice, payment); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func scanClaims(rows *sql.Rows) ([]models.Claim, error) {
+	claims := []models.Claim{}
+	for rows.Next() {
+		var c models.Claim
+		err := rows.Scan(&c.ID, &c.InvoiceID, &c.PolicyID, &c.PatientID, &c.ControlNumber, &c.Status, &c.ChargeCents,
+			&c.PaidCents, &c.PatientResponsibilityCents, &c.PayerClaimNumber, &c.RemittanceTrace, &c.Content,
+			&c.CreatedBy, &c.CreatedAt, &c.AdjudicatedAt)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, c)
+	}
+
+	return claims, rows.Err()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const labOrderColumns = `o.id, o.patient_id, o.test_code, t.name, o.ordered_by, o.status, o.notes, o.ordered_at, 
+	o.collected_at, o.verified_at, o.verified_by, o.updated_at, 
+	r.id, r.value, r.unit, r.reference_low, r.reference_high, r.flag, r.critical, r.comment, COALESCE(r.resulted_by, 0), r.resulted_at`
+
+const labOrderFrom = ` FROM lab_orders o JOIN lab_tests t ON t.code = o.test_code 
+              LEFT JOIN lab_results r ON r.order_id = o.id`
+
+type LabRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLabRepository(db *sql.DB) repository.LabRepository {
+	return &LabRepositoryImpl{db: db}
+}
+
+func (r *LabRepositoryImpl) FindTests() ([]models.LabTest, error) {
+	return r.findTests(`SELECT code, name, unit, specimen FROM lab_tests ORDER BY name`)
+}
+
+func (r *LabRepositoryImpl) FindTest(code string) (*models.LabTest, error) {
+	tests, err := r.findTests(`SELECT code, name, unit, specimen FROM lab_tests WHERE code = $1`, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(tests) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &tests[0], nil
+}
+
+// findTests runs query for tests and attaches their reference ranges
+func (r *LabRepositoryImpl) findTests(query string, args ...interface{}) ([]models.LabTest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tests := []models.LabTest{}
+	index := map[string]int{}
+	for rows.Next() {
+		t := models.LabTest{Ranges: []models.LabReferenceRange{}}
+		if err := rows.Scan(&t.Code, &t.Name, &t.Unit, &t.Specimen); err != nil {
+			return nil, err
+		}
+		index[t.Code] = len(tests)
+		tests = append(tests, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tests) == 0 {
+		return tests, nil
+	}
+
+	rangeRows, err := r.db.Query(`SELECT id, test_code, COALESCE(sex, ''), min_age, max_age, low, high, critical_low, critical_high 
+              FROM lab_reference_ranges ORDER BY test_code, sex NULLS FIRST, min_age`)
+	if err != nil {
+		return nil, err
+	}
+	defer rangeRows.Close()
+
+	for rangeRows.Next() {
+		var rr models.LabReferenceRange
+		err := rangeRows.Scan(&rr.ID, &rr.TestCode, &rr.Sex, &rr.MinAge, &rr.MaxAge, &rr.Low, &rr.High,
+			&rr.CriticalLow, &rr.CriticalHigh)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[rr.TestCode]; ok {
+			tests[i].Ranges = append(tests[i].Ranges, rr)
+		}
+	}
+
+	return tests, rangeRows.Err()
+}
+
+func (r *LabRepositoryImpl) CreateOrder(order *models.LabOrder) error {
+	query := `INSERT INTO lab_orders (patient_id, test_code, ordered_by, status, notes, ordered_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, updated_at`
+
+	return r.db.QueryRow(query, order.PatientID, order.TestCode, order.OrderedBy, order.Status, order.Notes,
+		order.OrderedAt).Scan(&order.ID, &order.UpdatedAt)
+}
+
+func (r *LabRepositoryImpl) FindOrderByID(id uint) (*models.LabOrder, error) {
+	rows, err := r.db.Query(`SELECT `+labOrderColumns+labOrderFrom+` WHERE o.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders, err := scanLabOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &orders[0], nil
+}
+
+func (r *LabRepositoryImpl) FindOrdersByPatient(patientID uint) ([]models.LabOrder, error) {
+	query := `SELECT ` + labOrderColumns + labOrderFrom + ` WHERE o.patient_id = $1 ORDER BY o.ordered_at DESC, o.id DESC`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLabOrders(rows)
+}
+
+func (r *LabRepositoryImpl) UpdateOrderStatus(order *models.LabOrder) error {
+	query := `UPDATE lab_orders SET status = $1, collected_at = $2, verified_at = $3, verified_by = $4, updated_at = NOW() 
+              WHERE id = $5 RETURNING updated_at`
+
+	return r.db.QueryRow(query, order.Status, order.CollectedAt, order.VerifiedAt, order.VerifiedBy, order.ID).Scan(
+		&order.UpdatedAt)
+}
+
+func (r *LabRepositoryImpl) SaveResult(order *models.LabOrder, result *models.LabResult) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO lab_results (order_id, value, unit, reference_low, reference_high, flag, critical, comment, resulted_by, resulted_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), $10) RETURNING id`
+	err = tx.QueryRow(query, result.OrderID, result.Value, result.Unit, result.ReferenceLow, result.ReferenceHigh,
+		result.Flag, result.Critical, result.Comment, result.ResultedBy, result.ResultedAt).Scan(&result.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`UPDATE lab_orders SET status = $1, collected_at = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at`,
+		order.Status, order.CollectedAt, order.ID).Scan(&order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanLabOrders(rows *sql.Rows) ([]models.LabOrder, error) {
+	orders := []models.LabOrder{}
+	for rows.Next() {
+		var (
+			o          models.LabOrder
+			resultID   sql.NullInt64
+			value      sql.NullFloat64
+			unit, flag sql.NullString
+			comment    sql.NullString
+			critical   sql.NullBool
+			resultedAt sql.NullTime
+			result     models.LabResult
+		)
+		err := rows.Scan(&o.ID, &o.PatientID, &o.TestCode, &o.TestName, &o.OrderedBy, &o.Status, &o.Notes, &o.OrderedAt,
+			&o.CollectedAt, &o.VerifiedAt, &o.VerifiedBy, &o.UpdatedAt,
+			&resultID, &value, &unit, &result.ReferenceLow, &result.ReferenceHigh, &flag, &critical, &comment,
+			&result.ResultedBy, &resultedAt)
+		if err != nil {
+			return nil, err
+		}
+		if resultID.Valid {
+			result.ID = int(resultID.Int64)
+			result.OrderID = o.ID
+			result.Value = value.Float64
+			result.Unit = unit.String
+			result.Flag = flag.String
+			result.Critical = critical.Bool
+			result.Comment = comment.String
+			result.ResultedAt = resultedAt.Time
+			o.Result = &result
+		}
+		orders = append(orders, o)
+	}
+
+	return orders, rows.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+type LoginThrottleRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) repository.LoginThrottleRepository {
+	return &LoginThrottleRepositoryImpl{db: db}
+}
+
+func (r *LoginThrottleRepositoryImpl) Find(scope, key string) (*models.LoginThrottle, error) {
+	query := `SELECT scope, key, failures, last_failure_at, locked_until FROM login_throttles WHERE scope = $1 AND key = $2`
+
+	throttle := &models.LoginThrottle{}
+	err := r.db.QueryRow(query, scope, key).Scan(
+		&throttle.Scope, &throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
+
+func (r *LoginThrottleRepositoryImpl) RecordFailure(scope, key string, at, resetBefore time.Time) (int, error) {
+	// Counting in a single statement keeps concurrent failures from being lost
+	query := `INSERT INTO login_throttles (scope, key, failures, last_failure_at) VALUES ($1, $2, 1, $3)
+              ON CONFLICT (scope, key) DO UPDATE SET
+                  failures = CASE WHEN login_throttles.last_failure_at < $4 THEN 1 ELSE login_throttles.failures + 1 END,
+                  last_failure_at = EXCLUDED.last_failure_at
+              RETURNING failures`
+
+	var failures int
+	err := r.db.QueryRow(query, scope, key, at, resetBefore).Scan(&failures)
+	return failures, err
+}
+
+func (r *LoginThrottleRepositoryImpl) Lock(scope, key string, until time.Time) error {
+	// A short backoff stored after a concurrent lockout must not cut it short
+	query := `UPDATE login_throttles SET locked_until = GREATEST(COALESCE(locked_until, $1), $1)
+              WHERE scope = $2 AND key = $3`
+	_, err := r.db.Exec(query, until, scope, key)
+	return err
+}
+
+func (r *LoginThrottleRepositoryImpl) Clear(scope, key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	return err
+}
+
+func (r *LoginThrottleRepositoryImpl) DeleteStale(before time.Time) error {
+	query := `DELETE FROM login_throttles WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)`
+	_, err := r.db.Exec(query, before)
+	return err
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const problemColumns = `id, patient_id, icd10_code, description, onset_date, status, chronic, COALESCE(recorded_by, 0), created_at, updated_at`
+
+type ProblemRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewProblemRepository(db *sql.DB) repository.ProblemRepository {
+	return &ProblemRepositoryImpl{db: db}
+}
+
+func (r *ProblemRepositoryImpl) Create(problem *models.Problem) error {
+	query := `INSERT INTO patient_problems (patient_id, icd10_code, description, onset_date, status, chronic, recorded_by, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, problem.PatientID, problem.ICD10Code, problem.Description, problem.OnsetDate,
+		problem.Status, problem.Chronic, problem.RecordedBy).Scan(&problem.ID, &problem.CreatedAt, &problem.UpdatedAt)
+}
+
+func (r *ProblemRepositoryImpl) FindByID(id uint) (*models.Problem, error) {
+	rows, err := r.db.Query(`SELECT `+problemColumns+` FROM patient_problems WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	problems, err := scanProblems(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &problems[0], nil
+}
+
+func (r *ProblemRepositoryImpl) FindByPatient(patientID uint) ([]models.Problem, error) {
+	query := `SELECT ` + problemColumns + ` FROM patient_problems 
+              WHERE patient_id = $1 ORDER BY status, onset_date DESC NULLS LAST, id`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProblems(rows)
+}
+
+func (r *ProblemRepositoryImpl) Update(problem *models.Problem) error {
+	query := `UPDATE patient_problems SET icd10_code = $1, description = $2, onset_date = $3, status = $4, chronic = $5, updated_at = NOW() 
+              WHERE id = $6 RETURNING updated_at`
+
+	return r.db.QueryRow(query, problem.ICD10Code, problem.Description, problem.OnsetDate, problem.Status,
+		problem.Chronic, problem.ID).Scan(&problem.UpdatedAt)
+}
+
+func (r *ProblemRepositoryImpl) Delete(id uint) error {
+	query := `DELETE FROM patient_problems WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func scanProblems(rows *sql.Rows) ([]models.Problem, error) {
+	problems := []models.Problem{}
+	for rows.Next() {
+		var p models.Problem
+		err := rows.Scan(&p.ID, &p.PatientID, &p.ICD10Code, &p.Description, &p.OnsetDate, &p.Status,
+			&p.Chronic, &p.RecordedBy, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, p)
+	}
+
+	return problems, rows.Err()
+}
+
+const medicationColumns = `id, patient_id, drug, dose, frequency, start_date, end_date, status, source, COALESCE(recorded_by, 0), created_at, updated_at`
+
+type MedicationRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewMedicationRepository(db *sql.DB) repository.MedicationRepository {
+	return &MedicationRepositoryImpl{db: db}
+}
+
+func (r *MedicationRepositoryImpl) Create(medication *models.Medication) error {
+	query := `INSERT INTO patient_medications (patient_id, drug, dose, frequency, start_date, end_date, status, source, recorded_by, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, medication.PatientID, medication.Drug, medication.Dose, medication.Frequency,
+		medication.StartDate, medication.EndDate, medication.Status, medication.Source, medication.RecordedBy).Scan(
+		&medication.ID, &medication.CreatedAt, &medication.UpdatedAt)
+}
+
+func (r *MedicationRepositoryImpl) FindByID(id uint) (*models.Medication, error) {
+	rows, err := r.db.Query(`SELECT `+medicationColumns+` FROM patient_medications WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	medications, err := scanMedications(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(medications) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &medications[0], nil
+}
+
+func (r *MedicationRepositoryImpl) FindByPatient(patientID uint) ([]models.Medication, error) {
+	query := `SELECT ` + medicationColumns + ` FROM patient_medications 
+              WHERE patient_id = $1 ORDER BY status, start_date DESC NULLS LAST, id`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMedications(rows)
+}
+
+func (r *MedicationRepositoryImpl) FindActiveByPatient(patientID uint) ([]models.Medication, error) {
+	query := `SELECT ` + medicationColumns + ` FROM patient_medications 
+              WHERE patient_id = $1 AND status = 'active' ORDER BY id`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMedications(rows)
+}
+
+func (r *MedicationRepositoryImpl) Update(medication *models.Medication) error {
+	query := `UPDATE patient_medications SET drug = $1, dose = $2, frequency = $3, start_date = $4, end_date = $5, 
+              status = $6, source = $7, updated_at = NOW() 
+              WHERE id = $8 RETURNING updated_at`
+
+	return r.db.QueryRow(query, medication.Drug, medication.Dose, medication.Frequency, medication.StartDate,
+		medication.EndDate, medication.Status, medication.Source, medication.ID).Scan(&medication.UpdatedAt)
+}
+
+func (r *MedicationRepositoryImpl) Delete(id uint) error {
+	query := `DELETE FROM patient_medications WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func scanMedications(rows *sql.Rows) ([]models.Medication, error) {
+	medications := []models.Medication{}
+	for rows.Next() {
+		var m models.Medication
+		err := rows.Scan(&m.ID, &m.PatientID, &m.Drug, &m.Dose, &m.Frequency, &m.StartDate, &m.EndDate,
+			&m.Status, &m.Source, &m.RecordedBy, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		medications = append(medications, m)
+	}
+
+	return medications, rows.Err()
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+type MFARepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) repository.MFARepository {
+	return &MFARepositoryImpl{db: db}
+}
+
+func (r *MFARepositoryImpl) FindByUser(userID int64) (*models.UserMFA, error) {
+	query := `SELECT user_id, secret, enabled, last_used_step, enabled_at, created_at FROM user_mfa WHERE user_id = $1`
+
+	mfa := &models.UserMFA{}
+	err := r.db.QueryRow(query, userID).Scan(
+		&mfa.UserID, &mfa.Secret, &mfa.Enabled, &mfa.LastUsedStep, &mfa.EnabledAt, &mfa.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return mfa, nil
+}
+
+func (r *MFARepositoryImpl) Save(mfa *models.UserMFA) error {
+	query := `INSERT INTO user_mfa (user_id, secret, enabled, last_used_step, enabled_at, created_at)
+              VALUES ($1, $2, $3, $4, $5, NOW())
+              ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, enabled = EXCLUDED.enabled,
+                  last_used_step = EXCLUDED.last_used_step, enabled_at = EXCLUDED.enabled_at
+              RETURNING created_at`
+
+	return r.db.QueryRow(query, mfa.UserID, mfa.Secret, mfa.Enabled, mfa.LastUsedStep, mfa.EnabledAt).Scan(&mfa.CreatedAt)
+}
+
+func (r *MFARepositoryImpl) UseStep(userID int64, step int64) (bool, error) {
+	// The comparison happens in the UPDATE so that two concurrent logins
+	// cannot both spend the same code
+	result, err := r.db.Exec(`UPDATE user_mfa SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`, step, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *MFARepositoryImpl) Delete(userID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM mfa_challenges WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepositoryImpl) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(`INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepositoryImpl) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *MFARepositoryImpl) CountUnusedRecoveryCodes(userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *MFARepositoryImpl) CreateChallenge(challenge *models.MFAChallenge) error {
+	// Housekeeping: abandoned logins need not stay around
+	if _, err := r.db.Exec(`DELETE FROM mfa_challenges WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_challenges (user_id, token_hash, attempts, expires_at, created_at)
+              VALUES ($1, $2, 0, $3, NOW()) RETURNING id, created_at`
+	return r.db.QueryRow(query, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt).Scan(&challenge.ID, &challenge.CreatedAt)
+}
+
+func (r *MFARepositoryImpl) FindChallengeByHash(tokenHash string) (*models.MFAChallenge, error) {
+	query := `SELECT id, user_id, token_hash, attempts, expires_at, created_at FROM mfa_challenges WHERE token_hash = $1`
+
+	challenge := &models.MFAChallenge{}
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&challenge.ID, &challenge.UserID, &challenge.TokenHash, &challenge.Attempts, &challenge.ExpiresAt, &challenge.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (r *MFARepositoryImpl) IncrementChallengeAttempts(id int64, max int) (int, error) {
+	var attempts int
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1 AND attempts < $2 RETURNING attempts`
+	err := r.db.QueryRow(query, id, max).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *MFARepositoryImpl) DeleteChallenge(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM mfa_challenges WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+type PasswordResetRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) repository.PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{db: db}
+}
+
+func (r *PasswordResetRepositoryImpl) Create(token *models.PasswordResetToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Earlier tokens are spent rather than deleted, so they are still
+	// found but refused
+	_, err = tx.Exec(`UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO password_reset_tokens (user_id, token_hash, issued_by, expires_at, created_at)
+              VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at`
+	err = tx.QueryRow(query, token.UserID, token.TokenHash, token.IssuedBy, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PasswordResetRepositoryImpl) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	query := `SELECT id, user_id, token_hash, issued_by, expires_at, used_at, created_at
+              FROM password_reset_tokens WHERE token_hash = $1`
+
+	token := &models.PasswordResetToken{}
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.IssuedBy, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *PasswordResetRepositoryImpl) MarkUsed(id int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
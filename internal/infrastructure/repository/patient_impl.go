@@ -2,8 +2,16 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
 	"hospital-management-system/internal/domain/models"
 	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
 )
 
 type PatientRepositoryImpl struct {
@@ -15,24 +23,49 @@ func NewPatientRepository(db *sql.DB) repository.PatientRepository {
 }
 
 func (r *PatientRepositoryImpl) Create(patient *models.Patient) error {
-	query := `INSERT INTO patients (first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id`
+	query := `INSERT INTO patients (mrn, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at) 
+              VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING id`
 
-	err := r.db.QueryRow(query, patient.FirstName, patient.LastName, patient.DOB,
+	err := r.db.QueryRow(query, patient.MRN, patient.FirstName, patient.LastName, patient.DOB,
 		patient.Gender, patient.Phone, patient.Email, patient.Address).Scan(&patient.ID)
 
 	return err
 }
 
+func (r *PatientRepositoryImpl) CreateMany(patients []*models.Patient) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO patients (mrn, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at) 
+              VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING id, created_at, updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, patient := range patients {
+		err := stmt.QueryRow(patient.MRN, patient.FirstName, patient.LastName, patient.DOB,
+			patient.Gender, patient.Phone, patient.Email, patient.Address).Scan(&patient.ID, &patient.CreatedAt, &patient.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (r *PatientRepositoryImpl) FindByID(id uint) (*models.Patient, error) {
-	query := `SELECT id, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at 
+	query := `SELECT id, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at, COALESCE(mrn, '') 
               FROM patients WHERE id = $1`
 
 	patient := &models.Patient{}
 	err := r.db.QueryRow(query, id).Scan(
 		&patient.ID, &patient.FirstName, &patient.LastName, &patient.DOB,
 		&patient.Gender, &patient.Phone, &patient.Email, &patient.Address,
-		&patient.CreatedAt, &patient.UpdatedAt,
+		&patient.CreatedAt, &patient.UpdatedAt, &patient.MRN,
 	)
 
 	if err != nil {
@@ -56,11 +89,16 @@ func (r *PatientRepositoryImpl) Update(patient *models.Patient) error {
 func (r *PatientRepositoryImpl) Delete(id uint) error {
 	query := `DELETE FROM patients WHERE id = $1`
 	_, err := r.db.Exec(query, id)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repository.ErrPatientHasRecords
+	}
 	return err
 }
 
 func (r *PatientRepositoryImpl) FindAll() ([]models.Patient, error) {
-	query := `SELECT id, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at 
+	query := `SELECT id, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at, COALESCE(mrn, '') 
               FROM patients ORDER BY created_at DESC`
 
 	rows, err := r.db.Query(query)
@@ -75,7 +113,7 @@ func (r *PatientRepositoryImpl) FindAll() ([]models.Patient, error) {
 		err := rows.Scan(
 			&patient.ID, &patient.FirstName, &patient.LastName, &patient.DOB,
 			&patient.Gender, &patient.Phone, &patient.Email, &patient.Address,
-			&patient.CreatedAt, &patient.UpdatedAt,
+			&patient.CreatedAt, &patient.UpdatedAt, &patient.MRN,
 		)
 		if err != nil {
 			return nil, err
@@ -85,3 +123,362 @@ func (r *PatientRepositoryImpl) FindAll() ([]models.Patient, error) {
 
 	return patients, nil
 }
+
+const patientColumns = `id, first_name, last_name, date_of_birth, gender, phone_number, email, address, created_at, updated_at, COALESCE(mrn, '')`
+
+// patientSortColumns maps the public sort fields onto indexed columns
+var patientSortColumns = map[string]string{
+	models.PatientSortCreatedAt: "created_at",
+	models.PatientSortLastName:  "last_name",
+	models.PatientSortDOB:       "date_of_birth",
+}
+
+func (r *PatientRepositoryImpl) FindByMRN(mrn string) (*models.Patient, error) {
+	rows, err := r.db.Query(`SELECT `+patientColumns+` FROM patients WHERE mrn = $1`, mrn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patients, err := scanPatients(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(patients) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &patients[0], nil
+}
+
+func (r *PatientRepositoryImpl) FindWithoutMRN() ([]models.Patient, error) {
+	rows, err := r.db.Query(`SELECT ` + patientColumns + ` FROM patients WHERE mrn IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPatients(rows)
+}
+
+func (r *PatientRepositoryImpl) SetMRN(id uint, mrn string) error {
+	_, err := r.db.Exec(`UPDATE patients SET mrn = $1 WHERE id = $2 AND mrn IS NULL`, mrn, id)
+	return err
+}
+
+func (r *PatientRepositoryImpl) List(q models.PatientListQuery) ([]models.Patient, error) {
+	column, ok := patientSortColumns[q.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", q.SortBy)
+	}
+
+	where, args := patientFilterClause(q.Filter)
+
+	direction, comparison := "ASC", ">"
+	if q.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.After != nil {
+		value, err := patientCursorValue(q.SortBy, q.After.SortValue)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value, q.After.ID)
+		where = append(where, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
+	}
+
+	query := `SELECT ` + patientColumns + ` FROM patients`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, q.Limit)
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT $%d`, column, direction, direction, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPatients(rows)
+}
+
+// patientFilterClause turns a filter into WHERE conditions and their
+// positional arguments
+func patientFilterClause(f models.PatientFilter) ([]string, []interface{}) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.NamePrefix != "" {
+		add(`(lower(first_name) LIKE $%[1]d OR lower(last_name) LIKE $%[1]d)`, escapeLike(strings.ToLower(f.NamePrefix))+"%")
+	}
+	if f.Gender != "" {
+		add(`lower(gender) = lower($%d)`, f.Gender)
+	}
+	if f.DOBFrom != nil {
+		add(`date_of_birth >= $%d`, *f.DOBFrom)
+	}
+	if f.DOBTo != nil {
+		add(`date_of_birth < $%d`, *f.DOBTo)
+	}
+	if f.CreatedFrom != nil {
+		add(`created_at >= $%d`, *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add(`created_at < $%d`, *f.CreatedTo)
+	}
+
+	return where, args
+}
+
+// patientCursorValue converts a cursor's sort value back into the type of
+// its column
+func patientCursorValue(sortBy, value string) (interface{}, error) {
+	switch sortBy {
+	case models.PatientSortCreatedAt:
+		return time.Parse(time.RFC3339Nano, value)
+	case models.PatientSortDOB:
+		return time.Parse("2006-01-02", value)
+	default:
+		return value, nil
+	}
+}
+
+// escapeLike escapes LIKE wildcards so user input only matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func scanPatients(rows *sql.Rows) ([]models.Patient, error) {
+	patients := []models.Patient{}
+	for rows.Next() {
+		var patient models.Patient
+		err := rows.Scan(
+			&patient.ID, &patient.FirstName, &patient.LastName, &patient.DOB,
+			&patient.Gender, &patient.Phone, &patient.Email, &patient.Address,
+			&patient.CreatedAt, &patient.UpdatedAt, &patient.MRN,
+		)
+		if err != nil {
+			return nil, err
+		}
+		patients = append(patients, patient)
+	}
+
+	return patients, rows.Err()
+}
+
+func (r *PatientRepositoryImpl) Search(q string, limit int) ([]models.PatientMatch, error) {
+	text := strings.ToLower(strings.TrimSpace(q))
+	tokens := strings.FieldsFunc(text, func(c rune) bool { return !unicode.IsLetter(c) })
+
+	digits := strings.Map(func(c rune) rune {
+		if unicode.IsDigit(c) {
+			return c
+		}
+		return -1
+	}, text)
+	if len(digits) < 7 {
+		digits = ""
+	}
+
+	email := ""
+	if strings.Contains(text, "@") {
+		email = text
+	}
+
+	query := `SELECT ` + patientColumns + `,
+              GREATEST(similarity(lower(first_name || ' ' || last_name), $1),
+                       similarity(lower(first_name), $1),
+                       similarity(lower(last_name), $1)) AS sim,
+              (soundex(first_name) IN (SELECT soundex(t) FROM unnest($2::text[]) t)
+                OR soundex(last_name) IN (SELECT soundex(t) FROM unnest($2::text[]) t)) AS soundex_match,
+              (dmetaphone(first_name) IN (SELECT dmetaphone(t) FROM unnest($2::text[]) t)
+                OR dmetaphone(last_name) IN (SELECT dmetaphone(t) FROM unnest($2::text[]) t)) AS metaphone_match,
+              COALESCE($3 <> '' AND regexp_replace(phone_number, '\D', '', 'g') = $3, false) AS phone_match,
+              COALESCE($4 <> '' AND lower(email) = $4, false) AS email_match
+              FROM patients
+              WHERE lower(first_name || ' ' || last_name) % $1
+                 OR lower(first_name) % $1
+                 OR lower(last_name) % $1
+                 OR dmetaphone(first_name) IN (SELECT dmetaphone(t) FROM unnest($2::text[]) t)
+                 OR dmetaphone(last_name) IN (SELECT dmetaphone(t) FROM unnest($2::text[]) t)
+                 OR soundex(first_name) IN (SELECT soundex(t) FROM unnest($2::text[]) t)
+                 OR soundex(last_name) IN (SELECT soundex(t) FROM unnest($2::text[]) t)
+                 OR ($3 <> '' AND regexp_replace(phone_number, '\D', '', 'g') = $3)
+                 OR ($4 <> '' AND lower(email) = $4)
+              ORDER BY sim DESC, id
+              LIMIT $5`
+
+	rows, err := r.db.Query(query, text, pq.Array(tokens), digits, email, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []models.PatientMatch
+	for rows.Next() {
+		var match models.PatientMatch
+		patient := &match.Patient
+		err := rows.Scan(
+			&patient.ID, &patient.FirstName, &patient.LastName, &patient.DOB,
+			&patient.Gender, &patient.Phone, &patient.Email, &patient.Address,
+			&patient.CreatedAt, &patient.UpdatedAt, &patient.MRN,
+			&match.Similarity, &match.Soundex, &match.Metaphone, &match.PhoneMatch, &match.EmailMatch,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, rows.Err()
+}
+
+// patientReferences lists every table/column that points at a patient.
+// Merge repoints all of them from the duplicate to the survivor, so new
+// patient-owned tables must be added here.
+var patientReferences = []struct{ table, column string }{
+	{"appointments", "patient_id"},
+	{"patient_merges", "survivor_id"},
+	{"patient_identifiers", "patient_id"},
+	{"encounters", "patient_id"},
+	{"patient_allergies", "patient_id"},
+	{"prescriptions", "patient_id"},
+	{"patient_problems", "patient_id"},
+	{"patient_medications", "patient_id"},
+	{"vital_signs", "patient_id"},
+	{"lab_orders", "patient_id"},
+	{"admissions", "patient_id"},
+	{"invoices", "patient_id"},
+	{"insurance_policies", "patient_id"},
+	{"claims", "patient_id"},
+}
+
+func (r *PatientRepositoryImpl) FindDuplicateCandidates(patient *models.Patient) ([]models.PatientMatch, error) {
+	digits := strings.Map(func(c rune) rune {
+		if unicode.IsDigit(c) {
+			return c
+		}
+		return -1
+	}, patient.Phone)
+	fullName := strings.ToLower(patient.FirstName + " " + patient.LastName)
+
+	query := `SELECT ` + patientColumns + `,
+              similarity(lower(first_name || ' ' || last_name), $1) AS sim,
+              (soundex(first_name) = soundex($2) AND soundex(last_name) = soundex($3)) AS soundex_match,
+              (dmetaphone(first_name) = dmetaphone($2) AND dmetaphone(last_name) = dmetaphone($3)) AS metaphone_match,
+              COALESCE($5 <> '' AND regexp_replace(phone_number, '\D', '', 'g') = $5, false) AS phone_match,
+              COALESCE($6 <> '' AND lower(email) = lower($6), false) AS email_match,
+              date_of_birth = $4 AS dob_match
+              FROM patients
+              WHERE id <> $7 AND (
+                 date_of_birth = $4
+                 OR lower(first_name || ' ' || last_name) % $1
+                 OR ($5 <> '' AND regexp_replace(phone_number, '\D', '', 'g') = $5)
+                 OR ($6 <> '' AND lower(email) = lower($6)))
+              ORDER BY sim DESC, id
+              LIMIT 50`
+
+	rows, err := r.db.Query(query, fullName, patient.FirstName, patient.LastName, patient.DOB,
+		digits, patient.Email, patient.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []models.PatientMatch
+	for rows.Next() {
+		var match models.PatientMatch
+		p := &match.Patient
+		err := rows.Scan(
+			&p.ID, &p.FirstName, &p.LastName, &p.DOB, &p.Gender, &p.Phone, &p.Email, &p.Address,
+			&p.CreatedAt, &p.UpdatedAt, &p.MRN,
+			&match.Similarity, &match.Soundex, &match.Metaphone, &match.PhoneMatch, &match.EmailMatch, &match.DOBMatch,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, rows.Err()
+}
+
+func (r *PatientRepositoryImpl) Merge(survivor *models.Patient, duplicateID uint, merge *models.PatientMerge) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE patients SET first_name = $1, last_name = $2, date_of_birth = $3, 
+              gender = $4, phone_number = $5, email = $6, address = $7, updated_at = NOW() 
+              WHERE id = $8`,
+		survivor.FirstName, survivor.LastName, survivor.DOB, survivor.Gender,
+		survivor.Phone, survivor.Email, survivor.Address, survivor.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range patientReferences {
+		query := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s = $2`, ref.table, ref.column, ref.column)
+		if _, err := tx.Exec(query, survivor.ID, duplicateID); err != nil {
+			// Repointing an open admission to a survivor who has one too
+			// breaks the one-admission-per-patient index
+			return adtConflict(err)
+		}
+	}
+
+	// Keep the duplicate's MRN resolvable through the survivor
+	_, err = tx.Exec(`INSERT INTO patient_identifiers (patient_id, system, value)
+              SELECT $1, $2, mrn FROM patients WHERE id = $3 AND mrn IS NOT NULL AND mrn <> ''`,
+		survivor.ID, models.IdentifierMRN, duplicateID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`INSERT INTO patient_merges (survivor_id, duplicate_id, duplicate_snapshot, merged_by, merged_at) 
+              VALUES ($1, $2, $3, $4, NOW()) RETURNING id, merged_at`,
+		merge.SurvivorID, merge.DuplicateID, string(merge.DuplicateSnapshot), merge.MergedBy).Scan(&merge.ID, &merge.MergedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM patients WHERE id = $1`, duplicateID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PatientRepositoryImpl) FindMerges(survivorID uint) ([]models.PatientMerge, error) {
+	query := `SELECT id, survivor_id, duplicate_id, duplicate_snapshot, merged_by, merged_at 
+              FROM patient_merges WHERE survivor_id = $1 ORDER BY merged_at DESC`
+
+	rows, err := r.db.Query(query, survivorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := []models.PatientMerge{}
+	for rows.Next() {
+		var merge models.PatientMerge
+		var snapshot []byte
+		err := rows.Scan(&merge.ID, &merge.SurvivorID, &merge.DuplicateID, &snapshot, &merge.MergedBy, &merge.MergedAt)
+		if err != nil {
+			return nil, err
+		}
+		merge.DuplicateSnapshot = snapshot
+		merges = append(merges, merge)
+	}
+
+	return merges, rows.Err()
+}
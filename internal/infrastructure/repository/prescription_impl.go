@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+
+	"github.com/lib/pq"
+)
+
+const prescriptionColumns = `id, patient_id, prescriber_id, drug, dose, route, frequency, duration_days, instructions, status, created_at, updated_at`
+
+type PrescriptionRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPrescriptionRepository(db *sql.DB) repository.PrescriptionRepository {
+	return &PrescriptionRepositoryImpl{db: db}
+}
+
+func (r *PrescriptionRepositoryImpl) Create(prescription *models.Prescription) error {
+	query := `INSERT INTO prescriptions (patient_id, prescriber_id, drug, dose, route, frequency, duration_days, instructions, status, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, prescription.PatientID, prescription.PrescriberID, prescription.Drug, prescription.Dose,
+		prescription.Route, prescription.Frequency, prescription.DurationDays, prescription.Instructions, prescription.Status).Scan(
+		&prescription.ID, &prescription.CreatedAt, &prescription.UpdatedAt)
+}
+
+func (r *PrescriptionRepositoryImpl) FindByID(id uint) (*models.Prescription, error) {
+	rows, err := r.db.Query(`SELECT `+prescriptionColumns+` FROM prescriptions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prescriptions, err := scanPrescriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(prescriptions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &prescriptions[0], nil
+}
+
+func (r *PrescriptionRepositoryImpl) FindByPatient(patientID uint) ([]models.Prescription, error) {
+	query := `SELECT ` + prescriptionColumns + ` FROM prescriptions WHERE patient_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPrescriptions(rows)
+}
+
+func (r *PrescriptionRepositoryImpl) FindActiveByPatient(patientID uint) ([]models.Prescription, error) {
+	query := `SELECT ` + prescriptionColumns + ` FROM prescriptions 
+              WHERE patient_id = $1 AND status = 'active' ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPrescriptions(rows)
+}
+
+func (r *PrescriptionRepositoryImpl) UpdateStatus(prescription *models.Prescription) error {
+	query := `UPDATE prescriptions SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
+	return r.db.QueryRow(query, prescription.Status, prescription.ID).Scan(&prescription.UpdatedAt)
+}
+
+func scanPrescriptions(rows *sql.Rows) ([]models.Prescription, error) {
+	prescriptions := []models.Prescription{}
+	for rows.Next() {
+		var p models.Prescription
+		err := rows.Scan(&p.ID, &p.PatientID, &p.PrescriberID, &p.Drug, &p.Dose, &p.Route, &p.Frequency,
+			&p.DurationDays, &p.Instructions, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		prescriptions = append(prescriptions, p)
+	}
+
+	return prescriptions, rows.Err()
+}
+
+type DrugRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewDrugRepository(db *sql.DB) repository.DrugRepository {
+	return &DrugRepositoryImpl{db: db}
+}
+
+func (r *DrugRepositoryImpl) FindClasses(drug string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT class FROM drug_classes WHERE drug = $1 ORDER BY class`, drug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := []string{}
+	for rows.Next() {
+		var class string
+		if err := rows.Scan(&class); err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, rows.Err()
+}
+
+func (r *DrugRepositoryImpl) FindInteractions(drug string, others []string) ([]models.DrugInteraction, error) {
+	query := `SELECT drug_a, drug_b, severity, description FROM drug_interactions 
+              WHERE (drug_a = $1 AND drug_b = ANY($2)) OR (drug_b = $1 AND drug_a = ANY($2))`
+
+	rows, err := r.db.Query(query, drug, pq.Array(others))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interactions := []models.DrugInteraction{}
+	for rows.Next() {
+		var interaction models.DrugInteraction
+		if err := rows.Scan(&interaction.DrugA, &interaction.DrugB, &interaction.Severity, &interaction.Description); err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, interaction)
+	}
+
+	return interactions, rows.Err()
+}
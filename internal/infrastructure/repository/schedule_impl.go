@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+type ScheduleRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) repository.ScheduleRepository {
+	return &ScheduleRepositoryImpl{db: db}
+}
+
+func (r *ScheduleRepositoryImpl) FindWorkingHours(doctorID int64) ([]models.WorkingHours, error) {
+	query := `SELECT id, doctor_id, weekday, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), slot_minutes 
+              FROM doctor_working_hours WHERE doctor_id = $1 ORDER BY weekday, start_time`
+
+	rows, err := r.db.Query(query, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hours []models.WorkingHours
+	for rows.Next() {
+		var h models.WorkingHours
+		err := rows.Scan(&h.ID, &h.DoctorID, &h.Weekday, &h.StartTime, &h.EndTime, &h.SlotMinutes)
+		if err != nil {
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+
+	return hours, rows.Err()
+}
+
+func (r *ScheduleRepositoryImpl) ReplaceWorkingHours(doctorID int64, hours []models.WorkingHours) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM doctor_working_hours WHERE doctor_id = $1`, doctorID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO doctor_working_hours (doctor_id, weekday, start_time, end_time, slot_minutes) 
+              VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	for i := range hours {
+		hours[i].DoctorID = doctorID
+		err := tx.QueryRow(query, doctorID, hours[i].Weekday, hours[i].StartTime,
+			hours[i].EndTime, hours[i].SlotMinutes).Scan(&hours[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ScheduleRepositoryImpl) CreateException(exception *models.ScheduleException) error {
+	query := `INSERT INTO doctor_schedule_exceptions (doctor_id, start_time, end_time, type, reason, created_at) 
+              VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, exception.DoctorID, exception.StartTime, exception.EndTime,
+		exception.Type, exception.Reason).Scan(&exception.ID, &exception.CreatedAt)
+}
+
+func (r *ScheduleRepositoryImpl) FindExceptionByID(id uint) (*models.ScheduleException, error) {
+	query := `SELECT id, doctor_id, start_time, end_time, type, reason, created_at 
+              FROM doctor_schedule_exceptions WHERE id = $1`
+
+	exception := &models.ScheduleException{}
+	err := r.db.QueryRow(query, id).Scan(
+		&exception.ID, &exception.DoctorID, &exception.StartTime, &exception.EndTime,
+		&exception.Type, &exception.Reason, &exception.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return exception, nil
+}
+
+func (r *ScheduleRepositoryImpl) DeleteException(id uint) error {
+	query := `DELETE FROM doctor_schedule_exceptions WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *ScheduleRepositoryImpl) FindExceptions(doctorID int64, from, to time.Time) ([]models.ScheduleException, error) {
+	query := `SELECT id, doctor_id, start_time, end_time, type, reason, created_at 
+              FROM doctor_schedule_exceptions 
+              WHERE doctor_id = $1 AND start_time < $3 AND end_time > $2 
+              ORDER BY start_time`
+
+	rows, err := r.db.Query(query, doctorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exceptions []models.ScheduleException
+	for rows.Next() {
+		var e models.ScheduleException
+		err := rows.Scan(&e.ID, &e.DoctorID, &e.StartTime, &e.EndTime, &e.Type, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		exceptions = append(exceptions, e)
+	}
+
+	return exceptions, rows.Err()
+}
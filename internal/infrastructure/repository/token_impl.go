@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const refreshTokenColumns = `id, user_id, token_hash, family_id, access_token_id, expires_at, revoked_at, created_at`
+
+type TokenRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) repository.TokenRepository {
+	return &TokenRepositoryImpl{db: db}
+}
+
+func (r *TokenRepositoryImpl) CreateRefreshToken(token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_token_id, expires_at, created_at) 
+              VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, token.UserID, token.TokenHash, token.FamilyID,
+		token.AccessTokenID, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *TokenRepositoryImpl) FindRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+	return r.findOne(query, tokenHash)
+}
+
+func (r *TokenRepositoryImpl) FindRefreshTokenByAccessTokenID(accessTokenID string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE access_token_id = $1`
+	return r.findOne(query, accessTokenID)
+}
+
+func (r *TokenRepositoryImpl) FindActiveRefreshTokensByFamily(familyID string) ([]models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens 
+              WHERE family_id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+	return r.findMany(query, familyID)
+}
+
+func (r *TokenRepositoryImpl) FindActiveRefreshTokensByUser(userID int64) ([]models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens 
+              WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+	return r.findMany(query, userID)
+}
+
+func (r *TokenRepositoryImpl) RevokeRefreshToken(id int64) (bool, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TokenRepositoryImpl) RevokeAccessToken(tokenID string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (token_id, expires_at, revoked_at) VALUES ($1, $2, NOW()) 
+              ON CONFLICT (token_id) DO NOTHING`
+	_, err := r.db.Exec(query, tokenID, expiresAt)
+	return err
+}
+
+func (r *TokenRepositoryImpl) IsAccessTokenRevoked(tokenID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = $1)`
+
+	var revoked bool
+	err := r.db.QueryRow(query, tokenID).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *TokenRepositoryImpl) DeleteExpired() error {
+	if _, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	return err
+}
+
+func (r *TokenRepositoryImpl) findOne(query string, arg interface{}) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	err := r.db.QueryRow(query, arg).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID, &token.AccessTokenID,
+		&token.ExpiresAt, &token.RevokedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *TokenRepositoryImpl) findMany(query string, arg interface{}) ([]models.RefreshToken, error) {
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.RefreshToken
+	for rows.Next() {
+		var token models.RefreshToken
+		err := rows.Scan(
+			&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID, &token.AccessTokenID,
+			&token.ExpiresAt, &token.RevokedAt, &token.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
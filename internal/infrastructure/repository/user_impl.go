@@ -53,9 +53,16 @@ func (r *UserRepositoryImpl) FindByUsername(username string) (*models.User, erro
 }
 
 func (r *UserRepositoryImpl) Update(user *models.User) error {
-	query := `UPDATE users SET username = $1, password = $2, role = $3, updated_at = NOW() WHERE id = $4`
+	query := `UPDATE users SET username = $1, role = $2, updated_at = NOW() WHERE id = $3`
 
-	_, err := r.db.Exec(query, user.Username, user.Password, user.Role, user.ID)
+	_, err := r.db.Exec(query, user.Username, user.Role, user.ID)
+	return err
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(id int64, passwordHash string) error {
+	query := `UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2`
+
+	_, err := r.db.Exec(query, passwordHash, id)
 	return err
 }
 
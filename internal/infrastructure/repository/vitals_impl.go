@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+const vitalSignsColumns = `id, patient_id, recorded_at, systolic_bp, diastolic_bp, heart_rate, temperature, spo2, 
+	respiratory_rate, weight_kg, height_cm, bmi, COALESCE(recorded_by, 0), created_at`
+
+const vitalRangeColumns = `id, vital, age_group, min_age, max_age, low, high, updated_at`
+
+type VitalSignsRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewVitalSignsRepository(db *sql.DB) repository.VitalSignsRepository {
+	return &VitalSignsRepositoryImpl{db: db}
+}
+
+func (r *VitalSignsRepositoryImpl) Create(v *models.VitalSigns) error {
+	query := `INSERT INTO vital_signs (patient_id, recorded_at, systolic_bp, diastolic_bp, heart_rate, temperature, spo2, 
+              respiratory_rate, weight_kg, height_cm, bmi, recorded_by, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, 0), NOW()) RETURNING id, created_at`
+
+	return r.db.QueryRow(query, v.PatientID, v.RecordedAt, v.SystolicBP, v.DiastolicBP, v.HeartRate, v.Temperature,
+		v.SpO2, v.RespiratoryRate, v.WeightKg, v.HeightCm, v.BMI, v.RecordedBy).Scan(&v.ID, &v.CreatedAt)
+}
+
+func (r *VitalSignsRepositoryImpl) FindByPatient(patientID uint, from, to *time.Time) ([]models.VitalSigns, error) {
+	query := `SELECT ` + vitalSignsColumns + ` FROM vital_signs 
+              WHERE patient_id = $1 
+              AND ($2::timestamp IS NULL OR recorded_at >= $2) 
+              AND ($3::timestamp IS NULL OR recorded_at < $3) 
+              ORDER BY recorded_at, id`
+
+	rows, err := r.db.Query(query, patientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []models.VitalSigns{}
+	for rows.Next() {
+		var v models.VitalSigns
+		err := rows.Scan(&v.ID, &v.PatientID, &v.RecordedAt, &v.SystolicBP, &v.DiastolicBP, &v.HeartRate,
+			&v.Temperature, &v.SpO2, &v.RespiratoryRate, &v.WeightKg, &v.HeightCm, &v.BMI, &v.RecordedBy, &v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, v)
+	}
+
+	return readings, rows.Err()
+}
+
+func (r *VitalSignsRepositoryImpl) FindRanges() ([]models.VitalRange, error) {
+	rows, err := r.db.Query(`SELECT ` + vitalRangeColumns + ` FROM vital_sign_ranges ORDER BY vital, min_age`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanVitalRanges(rows)
+}
+
+func (r *VitalSignsRepositoryImpl) FindRangeByID(id uint) (*models.VitalRange, error) {
+	rows, err := r.db.Query(`SELECT `+vitalRangeColumns+` FROM vital_sign_ranges WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranges, err := scanVitalRanges(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &ranges[0], nil
+}
+
+func (r *VitalSignsRepositoryImpl) UpdateRange(vr *models.VitalRange) error {
+	query := `UPDATE vital_sign_ranges SET min_age = $1, max_age = $2, low = $3, high = $4, updated_at = NOW() 
+              WHERE id = $5 RETURNING updated_at`
+
+	return r.db.QueryRow(query, vr.MinAge, vr.MaxAge, vr.Low, vr.High, vr.ID).Scan(&vr.UpdatedAt)
+}
+
+func scanVitalRanges(rows *sql.Rows) ([]models.VitalRange, error) {
+	ranges := []models.VitalRange{}
+	for rows.Next() {
+		var vr models.VitalRange
+		if err := rows.Scan(&vr.ID, &vr.Vital, &vr.AgeGroup, &vr.MinAge, &vr.MaxAge, &vr.Low, &vr.High, &vr.UpdatedAt); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, vr)
+	}
+
+	return ranges, rows.Err()
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidWard      = errors.New("invalid ward, room or bed")
+	ErrInvalidAdmission = errors.New("invalid admission")
+	// ErrAdmissionStatus is returned when transferring or discharging an
+	// admission that has already been discharged.
+	ErrAdmissionStatus = errors.New("admission is not active")
+	// ErrBedUnavailable is returned when a bed is occupied or out of
+	// service.
+	ErrBedUnavailable = errors.New("bed is not available")
+	// ErrAlreadyAdmitted is returned when admitting a patient who is
+	// already admitted.
+	ErrAlreadyAdmitted = errors.New("patient is already admitted")
+)
+
+// ADTService manages wards, rooms and beds, and admits, transfers and
+// discharges patients. A bed holds at most one patient and a patient at
+// most one admission at a time.
+type ADTService struct {
+	repo        repository.ADTRepository
+	patientRepo repository.PatientRepository
+	userRepo    repository.UserRepository
+	audit       *AuditService
+}
+
+func NewADTService(repo repository.ADTRepository, patientRepo repository.PatientRepository, userRepo repository.UserRepository, audit *AuditService) *ADTService {
+	return &ADTService{
+		repo:        repo,
+		patientRepo: patientRepo,
+		userRepo:    userRepo,
+		audit:       audit,
+	}
+}
+
+// CreateWard adds a ward.
+func (s *ADTService) CreateWard(ward *models.Ward) error {
+	ward.Name = strings.TrimSpace(ward.Name)
+	if ward.Name == "" {
+		return fmt.Errorf("%w: ward name is required", ErrInvalidWard)
+	}
+	return s.repo.CreateWard(ward)
+}
+
+// GetWards lists the wards by name.
+func (s *ADTService) GetWards() ([]models.Ward, error) {
+	return s.repo.FindWards()
+}
+
+// CreateRoom adds a room to a ward.
+func (s *ADTService) CreateRoom(wardID uint, room *models.Room) error {
+	if _, err := s.repo.FindWardByID(wardID); err != nil {
+		return err
+	}
+	room.WardID = int(wardID)
+	room.Name = strings.TrimSpace(room.Name)
+	if room.Name == "" {
+		return fmt.Errorf("%w: room name is required", ErrInvalidWard)
+	}
+	return s.repo.CreateRoom(room)
+}
+
+// CreateBed adds a bed to one of a ward's rooms.
+func (s *ADTService) CreateBed(wardID uint, bed *models.Bed) error {
+	room, err := s.repo.FindRoomByID(uint(bed.RoomID))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && room.WardID != int(wardID)) {
+		return fmt.Errorf("%w: room %d is not in ward %d", ErrInvalidWard, bed.RoomID, wardID)
+	}
+	if err != nil {
+		return err
+	}
+	bed.Label = strings.TrimSpace(bed.Label)
+	if bed.Label == "" {
+		return fmt.Errorf("%w: bed label is required", ErrInvalidWard)
+	}
+	if err := s.repo.CreateBed(bed); err != nil {
+		return err
+	}
+	bed.RoomName, bed.WardID, bed.AdmissionID = room.Name, room.WardID, nil
+	return nil
+}
+
+// UpdateBed relabels a ward's bed or takes it in or out of service. An
+// occupied bed cannot be taken out of service.
+func (s *ADTService) UpdateBed(wardID, bedID uint, label string, outOfService bool) (*models.Bed, error) {
+	bed, err := s.findBed(wardID, bedID)
+	if err != nil {
+		return nil, err
+	}
+	if label = strings.TrimSpace(label); label != "" {
+		bed.Label = label
+	}
+	if outOfService && bed.AdmissionID != nil {
+		return nil, fmt.Errorf("%w: bed %s is occupied", ErrBedUnavailable, bed.Label)
+	}
+	bed.OutOfService = outOfService
+	if err := s.repo.UpdateBed(bed); err != nil {
+		return nil, adtRepositoryError(err)
+	}
+	return bed, nil
+}
+
+// GetBedBoard returns every bed in a ward with its status and occupant.
+// Viewing the board is recorded as a listing of patient records.
+func (s *ADTService) GetBedBoard(actor models.Actor, wardID uint) ([]models.BedBoardEntry, error) {
+	if _, err := s.repo.FindWardByID(wardID); err != nil {
+		return nil, err
+	}
+	board, err := s.repo.FindBedBoard(wardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditList, 0); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// GetBedHistory returns a ward's bed's occupancies, most recent first.
+func (s *ADTService) GetBedHistory(actor models.Actor, wardID, bedID uint) ([]models.BedOccupancy, error) {
+	if _, err := s.findBed(wardID, bedID); err != nil {
+		return nil, err
+	}
+	history, err := s.repo.FindBedOccupancies(bedID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditList, 0); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetCensus counts each ward's beds by status, with hospital totals.
+func (s *ADTService) GetCensus() (*models.Census, error) {
+	wards, err := s.repo.FindCensus()
+	if err != nil {
+		return nil, err
+	}
+
+	census := &models.Census{GeneratedAt: time.Now(), Wards: wards, Total: models.WardCensus{WardName: "total"}}
+	for i := range wards {
+		w := &wards[i]
+		w.OccupancyRate = occupancyRate(w)
+		census.Total.Beds += w.Beds
+		census.Total.Occupied += w.Occupied
+		census.Total.Available += w.Available
+		census.Total.OutOfService += w.OutOfService
+	}
+	census.Total.OccupancyRate = occupancyRate(&census.Total)
+	return census, nil
+}
+
+// Admit admits a patient into an available bed on behalf of the acting
+// user. The attending doctor, when given, must be a doctor.
+func (s *ADTService) Admit(actor models.Actor, admission *models.Admission) error {
+	if _, err := s.patientRepo.FindByID(uint(admission.PatientID)); err != nil {
+		return err
+	}
+	if admission.AttendingDoctorID != nil {
+		doctor, err := s.userRepo.FindByID(int(*admission.AttendingDoctorID))
+		if err != nil || doctor.Role != models.RoleDoctor {
+			return ErrInvalidDoctor
+		}
+	}
+	if _, err := s.repo.FindActiveAdmission(uint(admission.PatientID)); err == nil {
+		return ErrAlreadyAdmitted
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := s.checkBedAvailable(admission.BedID); err != nil {
+		return err
+	}
+
+	admission.Reason = strings.TrimSpace(admission.Reason)
+	admission.Status = models.AdmissionAdmitted
+	admission.AdmittedBy = actor.UserID
+	admission.AdmittedAt = time.Now()
+	admission.DischargedBy, admission.DischargedAt, admission.Disposition = nil, nil, ""
+
+	if err := s.repo.Admit(admission); err != nil {
+		return adtRepositoryError(err)
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, admission.PatientID)
+}
+
+// GetAdmissions lists a patient's admissions, most recent first.
+func (s *ADTService) GetAdmissions(actor models.Actor, patientID uint) ([]models.Admission, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	admissions, err := s.repo.FindAdmissionsByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return admissions, nil
+}
+
+// GetAdmission returns one of a patient's admissions with its bed history.
+func (s *ADTService) GetAdmission(actor models.Actor, patientID, admissionID uint) (*models.Admission, error) {
+	admission, err := s.findAdmission(patientID, admissionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, admission.PatientID); err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// Transfer moves an admitted patient to another available bed.
+func (s *ADTService) Transfer(actor models.Actor, patientID, admissionID uint, bedID int, reason string) (*models.Admission, error) {
+	admission, err := s.findAdmission(patientID, admissionID)
+	if err != nil {
+		return nil, err
+	}
+	if admission.Status != models.AdmissionAdmitted {
+		return nil, fmt.Errorf("%w: %s admission cannot be transferred", ErrAdmissionStatus, admission.Status)
+	}
+	if bedID == admission.BedID {
+		return nil, fmt.Errorf("%w: patient is already in bed %d", ErrInvalidAdmission, bedID)
+	}
+	if err := s.checkBedAvailable(bedID); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "transfer"
+	}
+	if err := s.repo.Transfer(admission, bedID, reason); err != nil {
+		return nil, adtRepositoryError(err)
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, admission.PatientID); err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// Discharge discharges an admitted patient, freeing their bed. The
+// disposition defaults to home.
+func (s *ADTService) Discharge(actor models.Actor, patientID, admissionID uint, disposition string) (*models.Admission, error) {
+	admission, err := s.findAdmission(patientID, admissionID)
+	if err != nil {
+		return nil, err
+	}
+	if admission.Status != models.AdmissionAdmitted {
+		return nil, fmt.Errorf("%w: %s admission cannot be discharged", ErrAdmissionStatus, admission.Status)
+	}
+	if disposition == "" {
+		disposition = models.DispositionHome
+	}
+	if !isValidDisposition(disposition) {
+		return nil, fmt.Errorf("%w: disposition must be home, transferred_out, against_medical_advice, deceased or other", ErrInvalidAdmission)
+	}
+
+	now := time.Now()
+	admission.Status = models.AdmissionDischarged
+	admission.DischargedBy = &actor.UserID
+	admission.DischargedAt = &now
+	admission.Disposition = disposition
+	if err := s.repo.Discharge(admission); err != nil {
+		return nil, adtRepositoryError(err)
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, admission.PatientID); err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// findBed loads a bed, treating a bed in another ward as not found
+func (s *ADTService) findBed(wardID, bedID uint) (*models.Bed, error) {
+	bed, err := s.repo.FindBedByID(bedID)
+	if err != nil {
+		return nil, err
+	}
+	if bed.WardID != int(wardID) {
+		return nil, sql.ErrNoRows
+	}
+	return bed, nil
+}
+
+// findAdmission loads an admission, treating another patient's admission
+// as not found
+func (s *ADTService) findAdmission(patientID, admissionID uint) (*models.Admission, error) {
+	admission, err := s.repo.FindAdmissionByID(admissionID)
+	if err != nil {
+		return nil, err
+	}
+	if admission.PatientID != int(patientID) {
+		return nil, sql.ErrNoRows
+	}
+	return admission, nil
+}
+
+func (s *ADTService) checkBedAvailable(bedID int) error {
+	bed, err := s.repo.FindBedByID(uint(bedID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: bed %d does not exist", ErrInvalidAdmission, bedID)
+	}
+	if err != nil {
+		return err
+	}
+	if bed.OutOfService {
+		return fmt.Errorf("%w: bed %s is out of service", ErrBedUnavailable, bed.Label)
+	}
+	if bed.AdmissionID != nil {
+		return fmt.Errorf("%w: bed %s is occupied", ErrBedUnavailable, bed.Label)
+	}
+	return nil
+}
+
+// adtRepositoryError maps the repository's conflicts, which can only
+// happen when another request takes the bed or admits the patient first,
+// onto the service's errors
+func adtRepositoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrBedOccupied), errors.Is(err, repository.ErrBedOutOfService):
+		return fmt.Errorf("%w: %v", ErrBedUnavailable, err)
+	case errors.Is(err, repository.ErrPatientAdmitted):
+		return ErrAlreadyAdmitted
+	case errors.Is(err, repository.ErrAdmissionClosed):
+		return fmt.Errorf("%w: %v", ErrAdmissionStatus, err)
+	}
+	return err
+}
+
+// occupancyRate is the share of a ward's in-service beds that are occupied
+func occupancyRate(w *models.WardCensus) float64 {
+	inService := w.Beds - w.OutOfService
+	if inService <= 0 {
+		return 0
+	}
+	return float64(w.Occupied) / float64(inService)
+}
+
+func isValidDisposition(disposition string) bool {
+	switch disposition {
+	case models.DispositionHome, models.DispositionTransferOut, models.DispositionAMA,
+		models.DispositionDeceased, models.DispositionOther:
+		return true
+	}
+	return false
+}
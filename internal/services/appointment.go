@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrAppointmentConflict = errors.New("doctor already has an appointment in this time slot")
+	ErrInvalidAppointment  = errors.New("invalid appointment")
+	ErrInvalidDoctor       = errors.New("doctor not found")
+	ErrInvalidPatient      = errors.New("patient not found")
+)
+
+type AppointmentService struct {
+	repo        repository.AppointmentRepository
+	userRepo    repository.UserRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewAppointmentService(repo repository.AppointmentRepository, userRepo repository.UserRepository, patientRepo repository.PatientRepository) *AppointmentService {
+	return &AppointmentService{
+		repo:        repo,
+		userRepo:    userRepo,
+		patientRepo: patientRepo,
+	}
+}
+
+func (s *AppointmentService) CreateAppointment(appointment *models.Appointment) error {
+	if appointment.Status == "" {
+		appointment.Status = models.AppointmentScheduled
+	}
+	if err := s.validate(appointment); err != nil {
+		return err
+	}
+	return appointmentConflict(s.repo.Create(appointment))
+}
+
+func (s *AppointmentService) GetAppointmentByID(id uint) (*models.Appointment, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *AppointmentService) UpdateAppointment(appointment *models.Appointment) error {
+	if _, err := s.repo.FindByID(uint(appointment.ID)); err != nil {
+		return err
+	}
+	if appointment.Status == "" {
+		appointment.Status = models.AppointmentScheduled
+	}
+	if err := s.validate(appointment); err != nil {
+		return err
+	}
+	return appointmentConflict(s.repo.Update(appointment))
+}
+
+func (s *AppointmentService) DeleteAppointment(id uint) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *AppointmentService) GetAllAppointments() ([]models.Appointment, error) {
+	return s.repo.FindAll()
+}
+
+func (s *AppointmentService) GetAppointmentsByPatient(patientID uint) ([]models.Appointment, error) {
+	return s.repo.FindByPatient(patientID)
+}
+
+func (s *AppointmentService) GetAppointmentsByDoctor(doctorID int64, from, to time.Time) ([]models.Appointment, error) {
+	return s.repo.FindByDoctor(doctorID, from, to)
+}
+
+// validate checks the appointment's fields, that the patient and doctor
+// exist, and that the doctor is free for the requested slot.
+func (s *AppointmentService) validate(appointment *models.Appointment) error {
+	if appointment.PatientID == 0 || appointment.DoctorID == 0 {
+		return fmt.Errorf("%w: patient_id and doctor_id are required", ErrInvalidAppointment)
+	}
+	if appointment.StartTime.IsZero() || appointment.EndTime.IsZero() {
+		return fmt.Errorf("%w: start_time and end_time are required", ErrInvalidAppointment)
+	}
+	if !appointment.EndTime.After(appointment.StartTime) {
+		return fmt.Errorf("%w: end_time must be after start_time", ErrInvalidAppointment)
+	}
+	if !isValidAppointmentStatus(appointment.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidAppointment, appointment.Status)
+	}
+
+	if _, err := s.patientRepo.FindByID(uint(appointment.PatientID)); err != nil {
+		return ErrInvalidPatient
+	}
+	doctor, err := s.userRepo.FindByID(int(appointment.DoctorID))
+	if err != nil || doctor.Role != models.RoleDoctor {
+		return ErrInvalidDoctor
+	}
+
+	// Cancelled appointments free the slot, so they never conflict
+	if appointment.Status == models.AppointmentCancelled {
+		return nil
+	}
+	overlapping, err := s.repo.FindOverlapping(appointment.DoctorID, appointment.StartTime, appointment.EndTime, appointment.ID)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) > 0 {
+		return ErrAppointmentConflict
+	}
+	return nil
+}
+
+// appointmentConflict reports a double booking the database caught, when
+// another booking for the slot was saved after validate looked
+func appointmentConflict(err error) error {
+	if errors.Is(err, repository.ErrAppointmentOverlap) {
+		return ErrAppointmentConflict
+	}
+	return err
+}
+
+func isValidAppointmentStatus(status string) bool {
+	switch status {
+	case models.AppointmentScheduled, models.AppointmentCompleted, models.AppointmentCancelled, models.AppointmentNoShow:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+// auditVerifyBatch is how many entries VerifyChain reads at a time
+const auditVerifyBatch = 1000
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// RecordPatientAccess appends an entry for an action on a patient record.
+// before and after may be nil; for writes the field-level differences
+// between them are stored. patientID is 0 for actions on no single patient.
+func (s *AuditService) RecordPatientAccess(actor models.Actor, action string, patientID int, before, after *models.Patient) error {
+	entry := &models.AuditEntry{
+		ActorID:       actor.UserID,
+		ActorUsername: actor.Username,
+		ActorRole:     actor.Role,
+		Action:        action,
+	}
+	if patientID != 0 {
+		entry.PatientID = &patientID
+	}
+
+	if before != nil || after != nil {
+		changes, err := json.Marshal(DiffPatients(before, after))
+		if err != nil {
+			return err
+		}
+		entry.Changes = changes
+	}
+
+	return s.repo.Append(entry)
+}
+
+// RecordAccess records an action on a patient's record, such as reading
+// their encounters or paying an invoice, without field changes. The
+// services call it for every access to patient data and refuse the access
+// when it cannot be recorded.
+func (s *AuditService) RecordAccess(actor models.Actor, action string, patientID int) error {
+	if err := s.RecordPatientAccess(actor, action, patientID, nil, nil); err != nil {
+		return auditError(err)
+	}
+	return nil
+}
+
+// RecordAccountEvent appends an entry for a security event on a user
+// account rather than a patient record, such as a login lockout or a bulk
+// export download. details is stored in place of field changes.
+func (s *AuditService) RecordAccountEvent(actor models.Actor, action string, details map[string]interface{}) error {
+	changes, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Append(&models.AuditEntry{
+		ActorID:       actor.UserID,
+		ActorUsername: actor.Username,
+		ActorRole:     actor.Role,
+		Action:        action,
+		Changes:       changes,
+	})
+}
+
+// GetPatientTrail returns the most recent entries for a patient, newest first.
+func (s *AuditService) GetPatientTrail(patientID uint, limit int) ([]models.AuditEntry, error) {
+	return s.repo.FindByPatient(patientID, limit)
+}
+
+// GetRecent returns the most recent entries across all patients, newest first.
+func (s *AuditService) GetRecent(limit int) ([]models.AuditEntry, error) {
+	return s.repo.FindRecent(limit)
+}
+
+// VerifyChain walks the whole chain and returns the ID of the first entry
+// whose hash or link does not match, or 0 if the chain is intact.
+func (s *AuditService) VerifyChain() (int64, int, error) {
+	var (
+		lastID   int64
+		prevHash string
+		checked  int
+	)
+	for {
+		entries, err := s.repo.FindAfter(lastID, auditVerifyBatch)
+		if err != nil {
+			return 0, checked, err
+		}
+		if len(entries) == 0 {
+			return 0, checked, nil
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+				return entry.ID, checked, nil
+			}
+			prevHash = entry.Hash
+			lastID = entry.ID
+			checked++
+		}
+	}
+}
+
+// DiffPatients returns the fields that differ between before and after,
+// keyed by their JSON name. Bookkeeping timestamps are ignored.
+func DiffPatients(before, after *models.Patient) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+
+	t := reflect.TypeOf(models.Patient{})
+	var beforeValue, afterValue reflect.Value
+	if before != nil {
+		beforeValue = reflect.ValueOf(*before)
+	}
+	if after != nil {
+		afterValue = reflect.ValueOf(*after)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "created_at" || name == "updated_at" {
+			continue
+		}
+
+		var change models.FieldChange
+		if beforeValue.IsValid() {
+			change.Before = beforeValue.Field(i).Interface()
+		}
+		if afterValue.IsValid() {
+			change.After = afterValue.Field(i).Interface()
+		}
+		if beforeValue.IsValid() && afterValue.IsValid() && sameValue(change.Before, change.After) {
+			continue
+		}
+		changes[name] = change
+	}
+
+	return changes
+}
+
+func sameValue(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// auditError wraps a failure to write the audit trail. Access to patient
+// data is refused when it cannot be recorded.
+func auditError(err error) error {
+	return fmt.Errorf("could not record audit entry: %w", err)
+}
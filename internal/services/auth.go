@@ -2,20 +2,43 @@ package services
 
 import (
 	"errors"
+	"time"
+
 	"hospital-management-system/internal/domain/models"
 	"hospital-management-system/internal/domain/repository"
 	"hospital-management-system/pkg/utils"
 )
 
+// refreshTokenTTL is how long a session can stay idle before the user has
+// to log in again
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+)
+
 type AuthService struct {
-	userRepo repository.UserRepository
-	secret   string
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
+	mfaRepo   repository.MFARepository
+	throttle  *LoginThrottle
+	audit     *AuditService
+	secret    string
+	// mfaIssuer names this system in users' authenticator apps
+	mfaIssuer string
 }
 
-func NewAuthService(userRepo repository.UserRepository, secret string) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, mfaRepo repository.MFARepository,
+	throttle *LoginThrottle, audit *AuditService, secret, mfaIssuer string) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		secret:   secret,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		mfaRepo:   mfaRepo,
+		throttle:  throttle,
+		audit:     audit,
+		secret:    secret,
+		mfaIssuer: mfaIssuer,
 	}
 }
 
@@ -36,31 +59,169 @@ func (s *AuthService) Register(user *models.User) error {
 
 // Original Login method (for backward compatibility)
 func (s *AuthService) Login(username, password string) (string, error) {
-	_, token, err := s.LoginWithUser(username, password)
+	_, tokens, err := s.LoginWithUser(username, password, "")
 	if err != nil {
 		return "", err
 	}
-	return token, nil
+	return tokens.AccessToken, nil
 }
 
-// New method that returns both user and token
-func (s *AuthService) LoginWithUser(username, password string) (*models.User, string, error) {
+// LoginWithUser checks the user's password and starts a new session. For
+// users with MFA enabled the password alone is not enough: no session is
+// started and the error is an *MFARequiredError carrying the challenge to
+// complete with VerifyMFA.
+//
+// Failed logins are throttled per username and per client IP; while either
+// is blocked the error is a *LoginThrottledError and the password is not
+// even checked.
+func (s *AuthService) LoginWithUser(username, password, clientIP string) (*models.User, *models.TokenPair, error) {
+	if err := s.throttle.Check(username, clientIP); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userRepo.FindByUsername(username)
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, nil, s.loginFailed(username, clientIP)
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return nil, "", errors.New("invalid credentials")
+		return nil, nil, s.loginFailed(username, clientIP)
+	}
+
+	enabled, err := s.mfaEnabled(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enabled {
+		// The failures are only forgotten once the second factor passed too
+		return nil, nil, s.startMFAChallenge(user)
+	}
+
+	return s.loginSucceeded(user)
+}
+
+// UnlockUser lifts a lockout of the user's username after too many failed
+// logins
+func (s *AuthService) UnlockUser(actor models.Actor, user *models.User) error {
+	return s.throttle.Unlock(actor, user.Username)
+}
+
+// loginSucceeded starts a session for a user who passed every factor and
+// forgets the failed logins of their username
+func (s *AuthService) loginSucceeded(user *models.User) (*models.User, *models.TokenPair, error) {
+	_, tokens, err := s.startSession(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.throttle.Success(user.Username); err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+func (s *AuthService) loginFailed(username, clientIP string) error {
+	if err := s.throttle.Failure(username, clientIP); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// token is rotated out; presenting it again revokes the whole session.
+func (s *AuthService) Refresh(refreshToken string) (*models.User, *models.TokenPair, error) {
+	stored, err := s.tokenRepo.FindRefreshTokenByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		// An already-rotated token is being replayed, so assume it was stolen
+		if err := s.revokeFamily(stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindByID(int(stored.UserID))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.tokenRepo.RevokeRefreshToken(stored.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !revoked {
+		// Another refresh rotated the token out after we read it, which is
+		// a replay as well
+		if err := s.revokeFamily(stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	tokens, err := s.issueTokens(user, stored.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Logout ends the session the given access token belongs to.
+func (s *AuthService) Logout(accessTokenID string, expiresAt time.Time) error {
+	if err := s.tokenRepo.RevokeAccessToken(accessTokenID, expiresAt); err != nil {
+		return err
+	}
+
+	if stored, err := s.tokenRepo.FindRefreshTokenByAccessTokenID(accessTokenID); err == nil {
+		if err := s.revokeFamily(stored.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	// Housekeeping: nothing that has expired needs to stay on the list
+	return s.tokenRepo.DeleteExpired()
+}
+
+// RevokeUserSessions kills every live session of a user, e.g. after a
+// token has been stolen or the account has been compromised.
+func (s *AuthService) RevokeUserSessions(userID int64) error {
+	tokens, err := s.tokenRepo.FindActiveRefreshTokensByUser(userID)
+	if err != nil {
+		return err
+	}
+	return s.revokeTokens(tokens)
+}
+
+// RevokeOtherSessions kills every live session of a user except the one
+// the given access token belongs to, e.g. after they changed their password
+func (s *AuthService) RevokeOtherSessions(userID int64, accessTokenID string) error {
+	keep := ""
+	if current, err := s.tokenRepo.FindRefreshTokenByAccessTokenID(accessTokenID); err == nil {
+		keep = current.FamilyID
 	}
 
-	// Generate token using the available function
-	token, err := utils.GenerateToken(user.Username, user.Role)
+	tokens, err := s.tokenRepo.FindActiveRefreshTokensByUser(userID)
 	if err != nil {
-		return nil, "", err
+		return err
+	}
+	var others []models.RefreshToken
+	for _, token := range tokens {
+		if token.FamilyID != keep {
+			others = append(others, token)
+		}
 	}
+	return s.revokeTokens(others)
+}
 
-	return user, token, nil
+// IsTokenRevoked reports whether the access token with the given ID has
+// been revoked.
+func (s *AuthService) IsTokenRevoked(tokenID string) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(tokenID)
 }
 
 func (s *AuthService) ValidateToken(token string) (*models.User, error) {
@@ -69,11 +230,93 @@ func (s *AuthService) ValidateToken(token string) (*models.User, error) {
 		return nil, err
 	}
 
+	revoked, err := s.IsTokenRevoked(claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+
 	// Create user object with token data
 	user := &models.User{
+		ID:       int64(claims.UserID),
 		Username: claims.Username,
 		Role:     claims.Role,
 	}
 
 	return user, nil
 }
+
+// startSession issues the first token pair of a new session family
+func (s *AuthService) startSession(user *models.User) (*models.User, *models.TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(user, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// issueTokens creates an access token and a matching refresh token in the
+// given session family
+func (s *AuthService) issueTokens(user *models.User, familyID string) (*models.TokenPair, error) {
+	accessToken, err := utils.GenerateTokenWithUserID(int(user.ID), user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := utils.ValidateToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepo.CreateRefreshToken(&models.RefreshToken{
+		UserID:        user.ID,
+		TokenHash:     utils.HashToken(refreshToken),
+		FamilyID:      familyID,
+		AccessTokenID: claims.Id,
+		ExpiresAt:     time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL / time.Second),
+	}, nil
+}
+
+func (s *AuthService) revokeFamily(familyID string) error {
+	tokens, err := s.tokenRepo.FindActiveRefreshTokensByFamily(familyID)
+	if err != nil {
+		return err
+	}
+	return s.revokeTokens(tokens)
+}
+
+// revokeTokens revokes refresh tokens together with the access tokens that
+// were issued alongside them
+func (s *AuthService) revokeTokens(tokens []models.RefreshToken) error {
+	for _, token := range tokens {
+		if _, err := s.tokenRepo.RevokeRefreshToken(token.ID); err != nil {
+			return err
+		}
+		expiresAt := token.CreatedAt.Add(utils.AccessTokenTTL)
+		if err := s.tokenRepo.RevokeAccessToken(token.AccessTokenID, expiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidCharge  = errors.New("invalid chargemaster item or tax rule")
+	ErrInvalidInvoice = errors.New("invalid invoice")
+	ErrInvalidPayment = errors.New("invalid payment")
+	// ErrInvoiceStatus is returned when paying or voiding an invoice in a
+	// status that does not allow it.
+	ErrInvoiceStatus = errors.New("invoice cannot be changed in its status")
+	// ErrPaymentExceedsBalance is returned when a payment is more than the
+	// invoice's balance, or a refund more than is left of its payment.
+	ErrPaymentExceedsBalance = errors.New("amount exceeds what is owed or refundable")
+)
+
+// BillingService manages the chargemaster and bills patients: it issues
+// invoices priced from the chargemaster, records payments and refunds
+// against them, and works out balances. Amounts are in cents.
+type BillingService struct {
+	repo          repository.BillingRepository
+	patientRepo   repository.PatientRepository
+	encounterRepo repository.EncounterRepository
+	audit         *AuditService
+}
+
+func NewBillingService(repo repository.BillingRepository, patientRepo repository.PatientRepository, encounterRepo repository.EncounterRepository, audit *AuditService) *BillingService {
+	return &BillingService{
+		repo:          repo,
+		patientRepo:   patientRepo,
+		encounterRepo: encounterRepo,
+		audit:         audit,
+	}
+}
+
+// GetTaxRules returns the tax rules by code.
+func (s *BillingService) GetTaxRules() ([]models.TaxRule, error) {
+	return s.repo.FindTaxRules()
+}
+
+// SaveTaxRule adds a tax rule or changes the rate of an existing one.
+// Invoices already issued keep the rate they were billed at.
+func (s *BillingService) SaveTaxRule(rule *models.TaxRule) error {
+	rule.Code = strings.TrimSpace(rule.Code)
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Code == "" || rule.Name == "" {
+		return fmt.Errorf("%w: tax rule code and name are required", ErrInvalidCharge)
+	}
+	if rule.RateBP < 0 || rule.RateBP > 10000 {
+		return fmt.Errorf("%w: rate_bp must be between 0 and 10000", ErrInvalidCharge)
+	}
+	return s.repo.SaveTaxRule(rule)
+}
+
+// GetChargemaster returns every chargemaster item by code, inactive ones
+// included.
+func (s *BillingService) GetChargemaster() ([]models.ChargeItem, error) {
+	return s.repo.FindChargeItems()
+}
+
+// CreateChargeItem adds a service to the chargemaster.
+func (s *BillingService) CreateChargeItem(item *models.ChargeItem) error {
+	item.Code = strings.TrimSpace(item.Code)
+	if item.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidCharge)
+	}
+	if _, err := s.repo.FindChargeItem(item.Code); err == nil {
+		return fmt.Errorf("%w: %s is already in the chargemaster", ErrInvalidCharge, item.Code)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := s.validateChargeItem(item); err != nil {
+		return err
+	}
+	return s.repo.CreateChargeItem(item)
+}
+
+// UpdateChargeItem changes a chargemaster item's description, price, tax
+// rule or whether it can be billed. Invoices already issued keep the
+// price they were billed at.
+func (s *BillingService) UpdateChargeItem(item *models.ChargeItem) error {
+	if _, err := s.repo.FindChargeItem(item.Code); err != nil {
+		return err
+	}
+	if err := s.validateChargeItem(item); err != nil {
+		return err
+	}
+	return s.repo.UpdateChargeItem(item)
+}
+
+func (s *BillingService) validateChargeItem(item *models.ChargeItem) error {
+	item.Description = strings.TrimSpace(item.Description)
+	if item.Description == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidCharge)
+	}
+	if item.UnitPriceCents < 0 {
+		return fmt.Errorf("%w: unit_price_cents must not be negative", ErrInvalidCharge)
+	}
+	_, err := s.repo.FindTaxRule(item.TaxCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: unknown tax_code %q", ErrInvalidCharge, item.TaxCode)
+	}
+	return err
+}
+
+// CreateInvoice issues an invoice to a patient on behalf of the acting
+// user. Each line needs only a chargemaster code and a quantity; its
+// description, price and tax are filled in from the chargemaster. The
+// encounter, when given, must be the patient's.
+func (s *BillingService) CreateInvoice(actor models.Actor, invoice *models.Invoice) error {
+	if _, err := s.patientRepo.FindByID(uint(invoice.PatientID)); err != nil {
+		return err
+	}
+	if invoice.EncounterID != nil {
+		encounter, err := s.encounterRepo.FindByID(uint(*invoice.EncounterID))
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && encounter.PatientID != invoice.PatientID) {
+			return fmt.Errorf("%w: encounter %d is not the patient's", ErrInvalidInvoice, *invoice.EncounterID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(invoice.Lines) == 0 {
+		return fmt.Errorf("%w: an invoice needs at least one line", ErrInvalidInvoice)
+	}
+
+	invoice.SubtotalCents, invoice.TaxCents = 0, 0
+	for i := range invoice.Lines {
+		line := &invoice.Lines[i]
+		if err := s.priceLine(line); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		invoice.SubtotalCents += line.SubtotalCents
+		invoice.TaxCents += line.TaxCents
+	}
+	invoice.TotalCents = invoice.SubtotalCents + invoice.TaxCents
+	invoice.PaidCents, invoice.RefundedCents, invoice.BalanceCents = 0, 0, invoice.TotalCents
+	invoice.Status = models.InvoiceIssued
+	if invoice.TotalCents == 0 {
+		invoice.Status = models.InvoicePaid
+	}
+	invoice.Notes = strings.TrimSpace(invoice.Notes)
+	invoice.IssuedBy = actor.UserID
+	invoice.IssuedAt = time.Now()
+	invoice.Payments = []models.InvoicePayment{}
+
+	if err := s.repo.CreateInvoice(invoice); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, invoice.PatientID)
+}
+
+// priceLine fills in a line from its chargemaster item and tax rule
+func (s *BillingService) priceLine(line *models.InvoiceLine) error {
+	line.ChargeCode = strings.TrimSpace(line.ChargeCode)
+	item, err := s.repo.FindChargeItem(line.ChargeCode)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !item.Active) {
+		return fmt.Errorf("%w: %q is not a billable chargemaster code", ErrInvalidInvoice, line.ChargeCode)
+	}
+	if err != nil {
+		return err
+	}
+	if line.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidInvoice)
+	}
+	rule, err := s.repo.FindTaxRule(item.TaxCode)
+	if err != nil {
+		return err
+	}
+
+	line.Description = item.Description
+	line.UnitPriceCents = item.UnitPriceCents
+	line.TaxRateBP = rule.RateBP
+	line.SubtotalCents = item.UnitPriceCents * int64(line.Quantity)
+	line.TaxCents = TaxCents(line.SubtotalCents, rule.RateBP)
+	line.TotalCents = line.SubtotalCents + line.TaxCents
+	return nil
+}
+
+// GetInvoices lists a patient's invoices, most recent first.
+func (s *BillingService) GetInvoices(actor models.Actor, patientID uint) ([]models.Invoice, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	invoices, err := s.repo.FindInvoicesByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// GetInvoice returns an invoice with its lines and payments.
+func (s *BillingService) GetInvoice(actor models.Actor, invoiceID uint) (*models.Invoice, error) {
+	invoice, err := s.repo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, invoice.PatientID); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// GetBalance sums what a patient has been billed and has paid, leaving out
+// void invoices.
+func (s *BillingService) GetBalance(actor models.Actor, patientID uint) (*models.PatientBalance, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	balance, err := s.repo.FindPatientBalance(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// RecordPayment records a full or partial payment of an invoice's
+// balance.
+func (s *BillingService) RecordPayment(actor models.Actor, invoiceID uint, payment *models.InvoicePayment) (*models.Invoice, error) {
+	invoice, err := s.checkPayment(invoiceID, payment)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, s.addPayment(actor, invoice, payment)
+}
+
+// checkPayment returns the invoice a payment is for, after checking that
+// the invoice can take it
+func (s *BillingService) checkPayment(invoiceID uint, payment *models.InvoicePayment) (*models.Invoice, error) {
+	invoice, err := s.repo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == models.InvoiceVoid || invoice.Status == models.InvoicePaid {
+		return nil, fmt.Errorf("%w: %s invoice cannot be paid", ErrInvoiceStatus, invoice.Status)
+	}
+	if err := validatePayment(payment); err != nil {
+		return nil, err
+	}
+	if payment.AmountCents > invoice.BalanceCents {
+		return nil, fmt.Errorf("%w: the balance is %d cents", ErrPaymentExceedsBalance, invoice.BalanceCents)
+	}
+
+	payment.Kind = models.PaymentKindPayment
+	payment.RefundOf = nil
+	return invoice, nil
+}
+
+// RefundPayment gives back part or all of one of an invoice's payments,
+// by the payment's method unless another is given.
+func (s *BillingService) RefundPayment(actor models.Actor, invoiceID uint, refund *models.InvoicePayment) (*models.Invoice, error) {
+	invoice, err := s.repo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.RefundOf == nil {
+		return nil, fmt.Errorf("%w: refund_of must name the payment being refunded", ErrInvalidPayment)
+	}
+
+	var original *models.InvoicePayment
+	var refunded int64
+	for i := range invoice.Payments {
+		p := &invoice.Payments[i]
+		if p.ID == *refund.RefundOf && p.Kind == models.PaymentKindPayment {
+			original = p
+		}
+		if p.RefundOf != nil && *p.RefundOf == *refund.RefundOf {
+			refunded += p.AmountCents
+		}
+	}
+	if original == nil {
+		return nil, fmt.Errorf("%w: payment %d is not a payment of invoice %d", ErrInvalidPayment, *refund.RefundOf, invoice.ID)
+	}
+	if refund.Method == "" {
+		refund.Method = original.Method
+	}
+	if err := validatePayment(refund); err != nil {
+		return nil, err
+	}
+	if refundable := original.AmountCents - refunded; refund.AmountCents > refundable {
+		return nil, fmt.Errorf("%w: %d cents of payment %d can be refunded", ErrPaymentExceedsBalance, refundable, original.ID)
+	}
+
+	refund.Kind = models.PaymentKindRefund
+	return invoice, s.addPayment(actor, invoice, refund)
+}
+
+func (s *BillingService) addPayment(actor models.Actor, invoice *models.Invoice, payment *models.InvoicePayment) error {
+	payment.Reference = strings.TrimSpace(payment.Reference)
+	payment.RecordedBy = actor.UserID
+	payment.RecordedAt = time.Now()
+	if err := s.repo.AddPayment(invoice, payment); err != nil {
+		return paymentError(err)
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, invoice.PatientID)
+}
+
+// paymentError turns the repository's refusal of a payment into the
+// matching service error
+func paymentError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInvoiceOverpaid):
+		return fmt.Errorf("%w: %v", ErrPaymentExceedsBalance, err)
+	case errors.Is(err, repository.ErrInvoiceStatus):
+		return fmt.Errorf("%w: void invoice cannot be paid", ErrInvoiceStatus)
+	}
+	return err
+}
+
+// VoidInvoice cancels an invoice that has nothing paid on it, once any
+// payments have been refunded.
+func (s *BillingService) VoidInvoice(actor models.Actor, invoiceID uint) (*models.Invoice, error) {
+	invoice, err := s.repo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == models.InvoiceVoid {
+		return nil, fmt.Errorf("%w: invoice is already void", ErrInvoiceStatus)
+	}
+	if invoice.PaidCents != invoice.RefundedCents {
+		return nil, fmt.Errorf("%w: refund its payments before voiding it", ErrInvoiceStatus)
+	}
+
+	// Checked again when saving, in case a payment came in meanwhile
+	if err := s.repo.VoidInvoice(invoice); err != nil {
+		if errors.Is(err, repository.ErrInvoiceStatus) {
+			return nil, fmt.Errorf("%w: refund its payments before voiding it", ErrInvoiceStatus)
+		}
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, invoice.PatientID); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// TaxCents is the tax on amount cents at rateBP basis points, rounded half
+// up to the cent.
+func TaxCents(amount int64, rateBP int) int64 {
+	return (amount*int64(rateBP) + 5000) / 10000
+}
+
+func validatePayment(payment *models.InvoicePayment) error {
+	if payment.AmountCents <= 0 {
+		return fmt.Errorf("%w: amount_cents must be positive", ErrInvalidPayment)
+	}
+	switch payment.Method {
+	case models.PaymentCash, models.PaymentCard, models.PaymentCheck, models.PaymentInsurance, models.PaymentOther:
+		return nil
+	}
+	return fmt.Errorf("%w: method must be cash, card, check, insurance or other", ErrInvalidPayment)
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidExport = errors.New("invalid export request")
+	// ErrExportLink is returned for a download link with a bad signature,
+	// past its expiry, or naming a file the export does not have.
+	ErrExportLink = errors.New("invalid or expired export link")
+)
+
+// ExportResourceTypes are the FHIR resource types a bulk export can write,
+// in the order they are written.
+var ExportResourceTypes = []string{"Patient", "Encounter"}
+
+// exportLinkActor records downloads through signed links, which are made
+// without an access token
+var exportLinkActor = models.Actor{Username: "export-link", Role: "system"}
+
+// BulkExportService runs FHIR bulk data exports: each job pages through
+// every patient in the background and writes one NDJSON file per resource
+// type, to be downloaded through signed links. Finished exports are kept
+// for retention.
+type BulkExportService struct {
+	jobs      repository.ExportJobRepository
+	fhir      *FHIRService
+	audit     *AuditService
+	dir       string
+	key       []byte
+	urlTTL    time.Duration
+	retention time.Duration
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewBulkExportService(jobs repository.ExportJobRepository, fhir *FHIRService, audit *AuditService, dir, signingKey string, urlTTL, retention time.Duration) *BulkExportService {
+	return &BulkExportService{
+		jobs:      jobs,
+		fhir:      fhir,
+		audit:     audit,
+		dir:       dir,
+		key:       []byte(signingKey),
+		urlTTL:    urlTTL,
+		retention: retention,
+		running:   map[string]context.CancelFunc{},
+	}
+}
+
+// StartExport records a new export of the given resource types (all of
+// them when empty) and starts it in the background. With since, only
+// resources changed since then are written.
+func (s *BulkExportService) StartExport(actor models.Actor, requestURL string, types []string, since *time.Time) (*models.ExportJob, error) {
+	types, err := exportTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newExportID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ExportJob{
+		ID:          id,
+		RequestedBy: actor.UserID,
+		RequestURL:  requestURL,
+		Types:       types,
+		Since:       since,
+		Status:      models.ExportInProgress,
+	}
+	if err := s.jobs.Create(job); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditExport, 0); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+	s.wg.Add(1)
+	go s.run(ctx, *job)
+
+	return job, nil
+}
+
+// GetExport returns an export started by actor.
+func (s *BulkExportService) GetExport(actor models.Actor, id string) (*models.ExportJob, error) {
+	job, err := s.jobs.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.RequestedBy != actor.UserID {
+		return nil, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+// DeleteExport cancels an export started by actor if it is still running,
+// and removes it and its files.
+func (s *BulkExportService) DeleteExport(actor models.Actor, id string) error {
+	job, err := s.GetExport(actor, id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.running[job.ID]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	if err := s.jobs.Delete(job.ID); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.jobDir(job.ID))
+}
+
+// Wait blocks until every running export has finished.
+func (s *BulkExportService) Wait() {
+	s.wg.Wait()
+}
+
+// SignFile returns the expiry (Unix seconds) and signature of a download
+// link for one of an export's files.
+func (s *BulkExportService) SignFile(jobID, file string) (int64, string) {
+	expires := time.Now().Add(s.urlTTL).Unix()
+	return expires, s.signature(jobID, file, expires)
+}
+
+// OpenFile checks a download link and opens the file it names. Each
+// download is recorded in the audit trail against the user the link was
+// issued to, with client the address it came from; it is refused if it
+// cannot be recorded.
+func (s *BulkExportService) OpenFile(jobID, file string, expires int64, signature, client string) (*os.File, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.signature(jobID, file, expires))) || time.Now().Unix() > expires {
+		return nil, ErrExportLink
+	}
+
+	job, err := s.jobs.FindByID(jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExportLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.CompletedAt != nil && time.Since(*job.CompletedAt) > s.retention {
+		return nil, ErrExportLink
+	}
+	for _, output := range job.Output {
+		if output.File != file {
+			continue
+		}
+		actor := exportLinkActor
+		actor.UserID = job.RequestedBy
+		err := s.audit.RecordAccountEvent(actor, models.AuditExportDownload, map[string]interface{}{
+			"job_id": job.ID,
+			"file":   output.File,
+			"client": client,
+		})
+		if err != nil {
+			return nil, auditError(err)
+		}
+		return os.Open(filepath.Join(s.jobDir(job.ID), output.File))
+	}
+	return nil, ErrExportLink
+}
+
+// DeleteExpiredExports removes the exports that finished more than the
+// retention period ago, along with their files. It returns how many there
+// were.
+func (s *BulkExportService) DeleteExpiredExports() (int, error) {
+	ids, err := s.jobs.DeleteFinishedBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := os.RemoveAll(s.jobDir(id)); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// FailInterruptedExports marks exports cut short by a restart as failed.
+// It returns how many there were.
+func (s *BulkExportService) FailInterruptedExports() (int, error) {
+	return s.jobs.FailInProgress("interrupted by a server restart")
+}
+
+func (s *BulkExportService) signature(jobID, file string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(jobID + "/" + file + "/" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *BulkExportService) jobDir(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// run writes the export's files and saves how it ended. A cancelled
+// export has already been deleted, so nothing is saved for it.
+func (s *BulkExportService) run(ctx context.Context, job models.ExportJob) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	err := s.export(ctx, &job)
+	if ctx.Err() != nil {
+		os.RemoveAll(s.jobDir(job.ID))
+		return
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = models.ExportCompleted
+	if err != nil {
+		os.RemoveAll(s.jobDir(job.ID))
+		job.Status, job.Output, job.Error = models.ExportFailed, nil, err.Error()
+	}
+	if err := s.jobs.Update(&job); err != nil {
+		log.Printf("Failed to save bulk export %s: %v", job.ID, err)
+	}
+}
+
+func (s *BulkExportService) export(ctx context.Context, job *models.ExportJob) error {
+	dir := s.jobDir(job.ID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	for _, resourceType := range job.Types {
+		output := models.ExportOutput{Type: resourceType, File: resourceType + ".ndjson"}
+		f, err := os.Create(filepath.Join(dir, output.File))
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+
+		query := models.PatientListQuery{SortBy: models.PatientSortCreatedAt}
+		err = s.fhir.patients.eachPatientPage(ctx, query, func(patients []models.Patient) error {
+			n, err := s.writeResources(enc, resourceType, patients, job.Since)
+			if err != nil {
+				return err
+			}
+			output.Count += n
+			job.Processed += n
+			return s.jobs.Update(job)
+		})
+		if err == nil {
+			err = w.Flush()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		job.Output = append(job.Output, output)
+	}
+	return nil
+}
+
+// writeResources writes the resources of one type for a page of patients,
+// one per line, and returns how many it wrote
+func (s *BulkExportService) writeResources(enc *json.Encoder, resourceType string, patients []models.Patient, since *time.Time) (int, error) {
+	changed := func(t time.Time) bool { return since == nil || !t.Before(*since) }
+
+	n := 0
+	for i := range patients {
+		switch resourceType {
+		case "Patient":
+			if !changed(patients[i].UpdatedAt) {
+				continue
+			}
+			resource, err := s.fhir.toFHIRPatient(&patients[i])
+			if err != nil {
+				return n, err
+			}
+			if err := enc.Encode(resource); err != nil {
+				return n, err
+			}
+			n++
+		case "Encounter":
+			encounters, err := s.fhir.encounters.patientEncounters(uint(patients[i].ID))
+			if err != nil {
+				return n, err
+			}
+			for j := range encounters {
+				if !changed(encounters[j].UpdatedAt) {
+					continue
+				}
+				if err := enc.Encode(toFHIREncounter(&encounters[j])); err != nil {
+					return n, err
+				}
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// exportTypes checks the requested resource types, defaulting to all of
+// them, and puts them in export order
+func exportTypes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return ExportResourceTypes, nil
+	}
+	want := map[string]bool{}
+	for _, t := range requested {
+		t = strings.TrimSpace(t)
+		known := false
+		for _, supported := range ExportResourceTypes {
+			known = known || t == supported
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: cannot export resource type %q", ErrInvalidExport, t)
+		}
+		want[t] = true
+	}
+
+	var types []string
+	for _, t := range ExportResourceTypes {
+		if want[t] {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+func newExportID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
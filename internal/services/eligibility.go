@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// EligibilityChecker asks a payer whether a policy covers a patient on a
+// date of service. A real checker would send an X12 270 inquiry to the
+// payer and read its 271 response.
+type EligibilityChecker interface {
+	CheckEligibility(patient *models.Patient, policy *models.InsurancePolicy, serviceDate time.Time) (*models.EligibilityResult, error)
+}
+
+// LocalEligibilityChecker answers eligibility checks without contacting
+// any payer: a policy is eligible on the days it is in effect, unless its
+// member ID starts with "INACTIVE", which stands in for coverage the payer
+// has terminated. It is meant for development and tests.
+type LocalEligibilityChecker struct{}
+
+func NewLocalEligibilityChecker() *LocalEligibilityChecker {
+	return &LocalEligibilityChecker{}
+}
+
+func (LocalEligibilityChecker) CheckEligibility(patient *models.Patient, policy *models.InsurancePolicy, serviceDate time.Time) (*models.EligibilityResult, error) {
+	result := &models.EligibilityResult{
+		PolicyID:    policy.ID,
+		ServiceDate: serviceDate,
+		Source:      "local",
+		CheckedAt:   time.Now(),
+	}
+	switch {
+	case !policy.Covers(serviceDate):
+		result.Message = fmt.Sprintf("coverage is not in effect on %s", serviceDate.Format("2006-01-02"))
+	case strings.HasPrefix(strings.ToUpper(policy.MemberID), "INACTIVE"):
+		result.Message = "the payer reports the coverage as inactive"
+	default:
+		result.Eligible = true
+		result.Message = fmt.Sprintf("active coverage with %s", policy.PayerName)
+	}
+	return result, nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidEncounter = errors.New("invalid encounter")
+	ErrInvalidNote      = errors.New("invalid clinical note")
+	// ErrNoteLocked is returned when editing or signing a signed note, or
+	// amending a note whose latest version is still a draft.
+	ErrNoteLocked = errors.New("clinical note is locked")
+	// ErrNotNoteAuthor is returned when anyone but its author edits or signs a draft.
+	ErrNotNoteAuthor = errors.New("only the author can change a draft note")
+)
+
+// EncounterService manages encounters and their clinical notes.
+type EncounterService struct {
+	repo        repository.EncounterRepository
+	notes       repository.ClinicalNoteRepository
+	userRepo    repository.UserRepository
+	patientRepo repository.PatientRepository
+	audit       *AuditService
+}
+
+func NewEncounterService(repo repository.EncounterRepository, notes repository.ClinicalNoteRepository, userRepo repository.UserRepository, patientRepo repository.PatientRepository, audit *AuditService) *EncounterService {
+	return &EncounterService{
+		repo:        repo,
+		notes:       notes,
+		userRepo:    userRepo,
+		patientRepo: patientRepo,
+		audit:       audit,
+	}
+}
+
+// CreateEncounter opens an encounter for a patient. The doctor defaults to
+// the acting doctor and the start time to now.
+func (s *EncounterService) CreateEncounter(actor models.Actor, encounter *models.Encounter) error {
+	if _, err := s.patientRepo.FindByID(uint(encounter.PatientID)); err != nil {
+		return err
+	}
+	if encounter.DoctorID == 0 && actor.Role == models.RoleDoctor {
+		encounter.DoctorID = actor.UserID
+	}
+	if encounter.Status == "" {
+		encounter.Status = models.EncounterInProgress
+	}
+	if encounter.StartTime.IsZero() {
+		encounter.StartTime = time.Now()
+	}
+	if err := s.validate(encounter); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(encounter); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, encounter.PatientID)
+}
+
+// GetEncounters lists a patient's encounters, most recent first.
+func (s *EncounterService) GetEncounters(actor models.Actor, patientID uint) ([]models.Encounter, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	encounters, err := s.repo.FindByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return encounters, nil
+}
+
+func (s *EncounterService) GetEncounter(actor models.Actor, patientID, encounterID uint) (*models.Encounter, error) {
+	encounter, err := s.findEncounter(patientID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return encounter, nil
+}
+
+// GetEncounterByID returns an encounter when the patient it belongs to is
+// not known.
+func (s *EncounterService) GetEncounterByID(actor models.Actor, encounterID uint) (*models.Encounter, error) {
+	encounter, err := s.repo.FindByID(encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return encounter, nil
+}
+
+// patientEncounters returns a patient's encounters without recording the
+// access; bulk exports record theirs once for the whole export.
+func (s *EncounterService) patientEncounters(patientID uint) ([]models.Encounter, error) {
+	return s.repo.FindByPatient(patientID)
+}
+
+// UpdateEncounter changes an open encounter. Finishing it without an end
+// time stamps it with the current time; finished and cancelled encounters
+// can no longer be changed.
+func (s *EncounterService) UpdateEncounter(actor models.Actor, encounter *models.Encounter) error {
+	existing, err := s.findEncounter(uint(encounter.PatientID), uint(encounter.ID))
+	if err != nil {
+		return err
+	}
+	if existing.Status != models.EncounterInProgress {
+		return fmt.Errorf("%w: a %s encounter cannot be changed", ErrInvalidEncounter, existing.Status)
+	}
+
+	// Who the encounter is with and when it was created are fixed
+	encounter.DoctorID = existing.DoctorID
+	encounter.CreatedAt = existing.CreatedAt
+	if encounter.Type == "" {
+		encounter.Type = existing.Type
+	}
+	if encounter.Status == "" {
+		encounter.Status = existing.Status
+	}
+	if encounter.StartTime.IsZero() {
+		encounter.StartTime = existing.StartTime
+	}
+	if encounter.Status == models.EncounterFinished && encounter.EndTime == nil {
+		now := time.Now()
+		encounter.EndTime = &now
+	}
+	if err := s.validate(encounter); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(encounter); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, encounter.PatientID)
+}
+
+// AddNote starts a new draft note on an encounter, authored by actor.
+func (s *EncounterService) AddNote(actor models.Actor, patientID, encounterID uint, content string) (*models.ClinicalNote, error) {
+	encounter, err := s.findEncounter(patientID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if encounter.Status == models.EncounterCancelled {
+		return nil, fmt.Errorf("%w: the encounter was cancelled", ErrInvalidNote)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%w: content is required", ErrInvalidNote)
+	}
+
+	note := &models.ClinicalNote{
+		EncounterID: encounter.ID,
+		Version:     1,
+		AuthorID:    actor.UserID,
+		Content:     content,
+		Status:      models.NoteDraft,
+	}
+	if err := s.notes.Create(note); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditCreate, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// GetNotes returns the latest version of every note on an encounter.
+func (s *EncounterService) GetNotes(actor models.Actor, patientID, encounterID uint) ([]models.ClinicalNote, error) {
+	encounter, err := s.findEncounter(patientID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.notes.FindLatestByEncounter(uint(encounter.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// GetNoteVersions returns every version of a note, oldest first.
+func (s *EncounterService) GetNoteVersions(actor models.Actor, patientID, encounterID uint, noteID int64) ([]models.ClinicalNote, error) {
+	encounter, _, err := s.findNote(patientID, encounterID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.notes.FindVersions(noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// EditNote replaces the content of a draft. Only the draft's author may edit it.
+func (s *EncounterService) EditNote(actor models.Actor, patientID, encounterID uint, noteID int64, content string) (*models.ClinicalNote, error) {
+	encounter, note, err := s.findDraft(actor, patientID, encounterID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%w: content is required", ErrInvalidNote)
+	}
+
+	note.Content = content
+	if err := s.notes.UpdateDraft(note); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// SignNote signs a draft, after which it can only be changed by amendment.
+// Only the draft's author may sign it.
+func (s *EncounterService) SignNote(actor models.Actor, patientID, encounterID uint, noteID int64) (*models.ClinicalNote, error) {
+	encounter, note, err := s.findDraft(actor, patientID, encounterID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notes.Sign(note); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// AmendNote adds a new draft version to a signed note, authored by actor.
+// The signed versions stay unchanged in the note's history.
+func (s *EncounterService) AmendNote(actor models.Actor, patientID, encounterID uint, noteID int64, content string) (*models.ClinicalNote, error) {
+	encounter, latest, err := s.findNote(patientID, encounterID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if latest.Status != models.NoteSigned {
+		return nil, fmt.Errorf("%w: version %d is still a draft; edit it instead", ErrNoteLocked, latest.Version)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%w: content is required", ErrInvalidNote)
+	}
+
+	amendment := &models.ClinicalNote{
+		ID:          latest.ID,
+		EncounterID: latest.EncounterID,
+		Version:     latest.Version + 1,
+		AuthorID:    actor.UserID,
+		Content:     content,
+		Status:      models.NoteDraft,
+	}
+	if err := s.notes.CreateVersion(amendment); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	return amendment, nil
+}
+
+// findEncounter loads an encounter, treating one that belongs to another
+// patient as not found
+func (s *EncounterService) findEncounter(patientID, encounterID uint) (*models.Encounter, error) {
+	encounter, err := s.repo.FindByID(encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if encounter.PatientID != int(patientID) {
+		return nil, sql.ErrNoRows
+	}
+	return encounter, nil
+}
+
+// findNote loads the latest version of a note on the given encounter
+func (s *EncounterService) findNote(patientID, encounterID uint, noteID int64) (*models.Encounter, *models.ClinicalNote, error) {
+	encounter, err := s.findEncounter(patientID, encounterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	note, err := s.notes.FindLatest(noteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if note.EncounterID != encounter.ID {
+		return nil, nil, sql.ErrNoRows
+	}
+	return encounter, note, nil
+}
+
+// findDraft loads the latest version of a note and checks actor may change it
+func (s *EncounterService) findDraft(actor models.Actor, patientID, encounterID uint, noteID int64) (*models.Encounter, *models.ClinicalNote, error) {
+	encounter, note, err := s.findNote(patientID, encounterID, noteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if note.Status != models.NoteDraft {
+		return nil, nil, fmt.Errorf("%w: version %d is signed; amend it instead", ErrNoteLocked, note.Version)
+	}
+	if note.AuthorID != actor.UserID {
+		return nil, nil, ErrNotNoteAuthor
+	}
+	return encounter, note, nil
+}
+
+func (s *EncounterService) validate(encounter *models.Encounter) error {
+	if !isValidEncounterType(encounter.Type) {
+		return fmt.Errorf("%w: type must be outpatient, inpatient, emergency or telehealth", ErrInvalidEncounter)
+	}
+	if !isValidEncounterStatus(encounter.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidEncounter, encounter.Status)
+	}
+	if encounter.EndTime != nil && encounter.EndTime.Before(encounter.StartTime) {
+		return fmt.Errorf("%w: end_time must not be before start_time", ErrInvalidEncounter)
+	}
+	doctor, err := s.userRepo.FindByID(int(encounter.DoctorID))
+	if err != nil || doctor.Role != models.RoleDoctor {
+		return ErrInvalidDoctor
+	}
+	return nil
+}
+
+func isValidEncounterType(t string) bool {
+	switch t {
+	case models.EncounterOutpatient, models.EncounterInpatient, models.EncounterEmergency, models.EncounterTelehealth:
+		return true
+	}
+	return false
+}
+
+func isValidEncounterStatus(status string) bool {
+	switch status {
+	case models.EncounterInProgress, models.EncounterFinished, models.EncounterCancelled:
+		return true
+	}
+	return false
+}
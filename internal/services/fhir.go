@@ -0,0 +1,507 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/pkg/fhir"
+)
+
+var (
+	// ErrInvalidFHIRResource is returned for a resource body or search
+	// parameter that cannot be mapped onto the API's records.
+	ErrInvalidFHIRResource = errors.New("invalid FHIR resource")
+	// ErrUnsupportedFHIRSearch is returned for search parameters the
+	// server does not implement.
+	ErrUnsupportedFHIRSearch = errors.New("unsupported FHIR search")
+)
+
+// FHIRIdentifierSystemPrefix turns an identifier system into the URI used
+// in FHIR Identifier.system, e.g. "urn:hms:identifier:national_id".
+// Systems that are already URIs are used as they are.
+const FHIRIdentifierSystemPrefix = "urn:hms:identifier:"
+
+const (
+	fhirDateLayout        = "2006-01-02"
+	fhirIdentifierTypeURI = "http://terminology.hl7.org/CodeSystem/v2-0203"
+	fhirActCodeURI        = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+)
+
+// fhirEncounterClasses maps encounter types to v3 ActCode encounter classes
+var fhirEncounterClasses = map[string]fhir.Coding{
+	models.EncounterOutpatient: {System: fhirActCodeURI, Code: "AMB", Display: "ambulatory"},
+	models.EncounterInpatient:  {System: fhirActCodeURI, Code: "IMP", Display: "inpatient encounter"},
+	models.EncounterEmergency:  {System: fhirActCodeURI, Code: "EMER", Display: "emergency"},
+	models.EncounterTelehealth: {System: fhirActCodeURI, Code: "VR", Display: "virtual"},
+}
+
+// fhirEncounterStatuses maps encounter statuses to FHIR Encounter.status
+var fhirEncounterStatuses = map[string]string{
+	models.EncounterInProgress: "in-progress",
+	models.EncounterFinished:   "finished",
+	models.EncounterCancelled:  "cancelled",
+}
+
+// FHIRPatientSearch holds the supported Patient search parameters. Each
+// BirthDate entry is a FHIR date search value such as "1985-02-14",
+// "ge1980" or "lt1990-06"; all of them must match.
+type FHIRPatientSearch struct {
+	Name       string
+	BirthDate  []string
+	Identifier string
+	Count      int
+	Cursor     string
+}
+
+// FHIRService maps patients and encounters to and from FHIR R4 resources.
+// It goes through PatientService and EncounterService, so the same rules
+// and audit trail apply as on the REST API.
+type FHIRService struct {
+	patients   *PatientService
+	encounters *EncounterService
+}
+
+func NewFHIRService(patients *PatientService, encounters *EncounterService) *FHIRService {
+	return &FHIRService{patients: patients, encounters: encounters}
+}
+
+// ReadPatient returns a patient as a FHIR Patient.
+func (s *FHIRService) ReadPatient(actor models.Actor, id uint) (*fhir.Patient, error) {
+	patient, err := s.patients.GetPatientByID(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toFHIRPatient(patient)
+}
+
+// SearchPatients returns the patients matching search and the cursor of
+// the next page, if there is one. An identifier search returns at most
+// one patient; an identifier without a system is taken to be an MRN.
+func (s *FHIRService) SearchPatients(actor models.Actor, search FHIRPatientSearch) ([]*fhir.Patient, string, error) {
+	filter := models.PatientFilter{NamePrefix: strings.TrimSpace(search.Name)}
+	for _, value := range search.BirthDate {
+		if err := applyFHIRBirthDate(&filter, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var (
+		patients []models.Patient
+		next     string
+	)
+	if search.Identifier != "" {
+		patient, err := s.findByFHIRIdentifier(actor, search.Identifier)
+		if err != nil {
+			return nil, "", err
+		}
+		if patient != nil && matchesPatientFilter(patient, filter) {
+			patients = append(patients, *patient)
+		}
+	} else {
+		query := models.PatientListQuery{Filter: filter, SortBy: models.PatientSortLastName, Limit: search.Count}
+		page, err := s.patients.ListPatients(actor, query, search.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		patients, next = page.Data, page.NextCursor
+	}
+
+	resources := make([]*fhir.Patient, len(patients))
+	for i := range patients {
+		resource, err := s.toFHIRPatient(&patients[i])
+		if err != nil {
+			return nil, "", err
+		}
+		resources[i] = resource
+	}
+	return resources, next, nil
+}
+
+// CreatePatient registers the patient described by resource, with the same
+// required fields and duplicate check as CreatePatient. Identifiers other
+// than the MRN are recorded with it; the MRN is always assigned here.
+func (s *FHIRService) CreatePatient(actor models.Actor, resource *fhir.Patient) (*fhir.Patient, error) {
+	patient, identifiers, err := fromFHIRPatient(resource)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkIdentifiersFree(0, identifiers); err != nil {
+		return nil, err
+	}
+	if err := s.patients.CreatePatient(actor, patient, false); err != nil {
+		return nil, err
+	}
+	if err := s.addIdentifiers(actor, patient.ID, identifiers); err != nil {
+		return nil, err
+	}
+	return s.toFHIRPatient(patient)
+}
+
+// UpdatePatient replaces the demographics of an existing patient with
+// those in resource. Identifiers in resource that the patient does not
+// have yet are added; existing ones are kept.
+func (s *FHIRService) UpdatePatient(actor models.Actor, id uint, resource *fhir.Patient) (*fhir.Patient, error) {
+	if resource.ID != strconv.FormatUint(uint64(id), 10) {
+		return nil, fmt.Errorf("%w: resource id must match the id in the URL", ErrInvalidFHIRResource)
+	}
+	patient, identifiers, err := fromFHIRPatient(resource)
+	if err != nil {
+		return nil, err
+	}
+	patient.ID = int(id)
+	if err := s.patients.checkExists(id); err != nil {
+		return nil, err
+	}
+	if err := s.checkIdentifiersFree(patient.ID, identifiers); err != nil {
+		return nil, err
+	}
+	updated, err := s.patients.UpdatePatient(actor, patient)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addIdentifiers(actor, patient.ID, identifiers); err != nil {
+		return nil, err
+	}
+	return s.toFHIRPatient(updated)
+}
+
+// ReadEncounter returns an encounter as a FHIR Encounter.
+func (s *FHIRService) ReadEncounter(actor models.Actor, id uint) (*fhir.Encounter, error) {
+	encounter, err := s.encounters.GetEncounterByID(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return toFHIREncounter(encounter), nil
+}
+
+// SearchEncounters returns a patient's encounters, most recent first.
+// patient is a patient ID or a "Patient/<id>" reference.
+func (s *FHIRService) SearchEncounters(actor models.Actor, patient string) ([]*fhir.Encounter, error) {
+	patientID, err := strconv.ParseUint(strings.TrimPrefix(patient, "Patient/"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: patient must be a patient ID or Patient/<id> reference", ErrInvalidFHIRResource)
+	}
+	encounters, err := s.encounters.GetEncounters(actor, uint(patientID))
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]*fhir.Encounter, len(encounters))
+	for i := range encounters {
+		resources[i] = toFHIREncounter(&encounters[i])
+	}
+	return resources, nil
+}
+
+// findByFHIRIdentifier resolves a "system|value" or bare value token, or
+// returns nil when no patient has the identifier
+func (s *FHIRService) findByFHIRIdentifier(actor models.Actor, token string) (*models.Patient, error) {
+	system, value := models.IdentifierMRN, token
+	if i := strings.Index(token, "|"); i >= 0 {
+		value = token[i+1:]
+		if uri := token[:i]; uri != "" {
+			system = identifierSystemFromFHIR(uri)
+		}
+	}
+	patient, err := s.patients.FindPatientByIdentifier(actor, system, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errors.Is(err, ErrInvalidIdentifier) {
+		return nil, fmt.Errorf("%w: identifier must be [system|]value", ErrInvalidFHIRResource)
+	}
+	return patient, err
+}
+
+// checkIdentifiersFree makes sure no identifier belongs to a patient other
+// than patientID, so nothing is saved when one is taken
+func (s *FHIRService) checkIdentifiersFree(patientID int, identifiers []models.PatientIdentifier) error {
+	for _, id := range identifiers {
+		existing, err := s.patients.identifiers.FindBySystemValue(id.System, id.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if existing.PatientID != patientID {
+			return fmt.Errorf("%w: %s %s is already assigned to a patient", ErrIdentifierInUse, id.System, id.Value)
+		}
+	}
+	return nil
+}
+
+// addIdentifiers records the identifiers the patient does not have yet
+func (s *FHIRService) addIdentifiers(actor models.Actor, patientID int, identifiers []models.PatientIdentifier) error {
+	existing, err := s.patients.identifiers.FindByPatient(uint(patientID))
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for _, id := range existing {
+		have[id.System+"|"+id.Value] = true
+	}
+	for _, id := range identifiers {
+		if have[id.System+"|"+id.Value] {
+			continue
+		}
+		id.PatientID = patientID
+		if err := s.patients.AddIdentifier(actor, &id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toFHIRPatient maps a patient, its MRN and its other identifiers to a
+// FHIR Patient
+func (s *FHIRService) toFHIRPatient(patient *models.Patient) (*fhir.Patient, error) {
+	identifiers, err := s.patients.identifiers.FindByPatient(uint(patient.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &fhir.Patient{
+		ResourceType: "Patient",
+		ID:           strconv.Itoa(patient.ID),
+		Name:         []fhir.HumanName{{Use: "official", Family: patient.LastName, Given: strings.Fields(patient.FirstName)}},
+		Gender:       fhirGender(patient.Gender),
+	}
+	if !patient.UpdatedAt.IsZero() {
+		resource.Meta = &fhir.Meta{LastUpdated: patient.UpdatedAt.UTC().Format(time.RFC3339)}
+	}
+	if patient.MRN != "" {
+		resource.Identifier = append(resource.Identifier, fhir.Identifier{
+			Use:    "usual",
+			Type:   &fhir.CodeableConcept{Coding: []fhir.Coding{{System: fhirIdentifierTypeURI, Code: "MR"}}},
+			System: identifierSystemToFHIR(models.IdentifierMRN),
+			Value:  patient.MRN,
+		})
+	}
+	for _, id := range identifiers {
+		use := ""
+		if id.System == models.IdentifierMRN {
+			use = "old" // absorbed in a merge
+		}
+		resource.Identifier = append(resource.Identifier, fhir.Identifier{Use: use, System: identifierSystemToFHIR(id.System), Value: id.Value})
+	}
+	if patient.Phone != "" {
+		resource.Telecom = append(resource.Telecom, fhir.ContactPoint{System: fhir.ContactPhone, Value: patient.Phone})
+	}
+	if patient.Email != "" {
+		resource.Telecom = append(resource.Telecom, fhir.ContactPoint{System: fhir.ContactEmail, Value: patient.Email})
+	}
+	if !patient.DOB.IsZero() {
+		resource.BirthDate = patient.DOB.Format(fhirDateLayout)
+	}
+	if patient.Address != "" {
+		resource.Address = []fhir.Address{{Text: patient.Address}}
+	}
+	return resource, nil
+}
+
+// fromFHIRPatient maps a FHIR Patient to a patient and the identifiers to
+// record for it. MRN identifiers are dropped: MRNs are assigned here.
+func fromFHIRPatient(resource *fhir.Patient) (*models.Patient, []models.PatientIdentifier, error) {
+	if resource.ResourceType != "Patient" {
+		return nil, nil, fmt.Errorf("%w: resourceType must be Patient", ErrInvalidFHIRResource)
+	}
+	if len(resource.Name) == 0 {
+		return nil, nil, fmt.Errorf("%w: a name is required", ErrInvalidFHIRResource)
+	}
+
+	// Prefer the official name, then the first one given
+	name := resource.Name[0]
+	for _, n := range resource.Name {
+		if n.Use == "official" {
+			name = n
+			break
+		}
+	}
+	patient := &models.Patient{
+		FirstName: strings.TrimSpace(strings.Join(name.Given, " ")),
+		LastName:  strings.TrimSpace(name.Family),
+	}
+
+	switch resource.Gender {
+	case "", "male", "female", "other", "unknown":
+		patient.Gender = resource.Gender
+	default:
+		return nil, nil, fmt.Errorf("%w: gender must be male, female, other or unknown", ErrInvalidFHIRResource)
+	}
+	if resource.BirthDate != "" {
+		dob, err := time.Parse(fhirDateLayout, resource.BirthDate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: birthDate must be a full YYYY-MM-DD date", ErrInvalidFHIRResource)
+		}
+		patient.DOB = dob
+	}
+
+	for _, contact := range resource.Telecom {
+		value := strings.TrimSpace(contact.Value)
+		switch {
+		case contact.System == fhir.ContactPhone && patient.Phone == "":
+			patient.Phone = value
+		case contact.System == fhir.ContactEmail && patient.Email == "":
+			patient.Email = value
+		}
+	}
+
+	if len(resource.Address) > 0 {
+		address := resource.Address[0]
+		patient.Address = strings.TrimSpace(address.Text)
+		if patient.Address == "" {
+			var parts []string
+			for _, part := range append(address.Line, address.City, address.State, address.PostalCode, address.Country) {
+				if part = strings.TrimSpace(part); part != "" {
+					parts = append(parts, part)
+				}
+			}
+			patient.Address = strings.Join(parts, ", ")
+		}
+	}
+
+	var identifiers []models.PatientIdentifier
+	for _, id := range resource.Identifier {
+		system, value := identifierSystemFromFHIR(id.System), strings.TrimSpace(id.Value)
+		if system == models.IdentifierMRN {
+			continue
+		}
+		if system == "" || value == "" {
+			return nil, nil, fmt.Errorf("%w: every identifier needs a system and a value", ErrInvalidFHIRResource)
+		}
+		identifiers = append(identifiers, models.PatientIdentifier{System: system, Value: value})
+	}
+	return patient, identifiers, nil
+}
+
+func toFHIREncounter(encounter *models.Encounter) *fhir.Encounter {
+	resource := &fhir.Encounter{
+		ResourceType: "Encounter",
+		ID:           strconv.Itoa(encounter.ID),
+		Status:       fhirEncounterStatuses[encounter.Status],
+		Class:        fhirEncounterClasses[encounter.Type],
+		Subject:      &fhir.Reference{Reference: "Patient/" + strconv.Itoa(encounter.PatientID)},
+		Period:       &fhir.Period{Start: encounter.StartTime.Format(time.RFC3339)},
+	}
+	if resource.Status == "" {
+		resource.Status = "unknown"
+	}
+	if !encounter.UpdatedAt.IsZero() {
+		resource.Meta = &fhir.Meta{LastUpdated: encounter.UpdatedAt.UTC().Format(time.RFC3339)}
+	}
+	if encounter.DoctorID != 0 {
+		resource.Participant = []fhir.EncounterParticipant{{
+			Individual: &fhir.Reference{Reference: "Practitioner/" + strconv.FormatInt(encounter.DoctorID, 10)},
+		}}
+	}
+	if encounter.EndTime != nil {
+		resource.Period.End = encounter.EndTime.Format(time.RFC3339)
+	}
+	if encounter.Reason != "" {
+		resource.ReasonCode = []fhir.CodeableConcept{{Text: encounter.Reason}}
+	}
+	return resource
+}
+
+// fhirGender maps a stored gender to the FHIR administrative gender codes
+func fhirGender(gender string) string {
+	switch g := strings.ToLower(gender); g {
+	case "male", "female", "other", "unknown":
+		return g
+	case "":
+		return ""
+	}
+	return "other"
+}
+
+func identifierSystemToFHIR(system string) string {
+	if strings.HasPrefix(system, "urn:") || strings.Contains(system, "://") {
+		return system
+	}
+	return FHIRIdentifierSystemPrefix + system
+}
+
+func identifierSystemFromFHIR(uri string) string {
+	return strings.TrimPrefix(strings.TrimSpace(uri), FHIRIdentifierSystemPrefix)
+}
+
+// applyFHIRBirthDate narrows filter by one birthdate search value: an
+// optional eq, ge, gt, le or lt prefix and a YYYY, YYYY-MM or YYYY-MM-DD
+// date, which stands for the whole year, month or day
+func applyFHIRBirthDate(filter *models.PatientFilter, value string) error {
+	prefix := "eq"
+	if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+		prefix, value = value[:2], value[2:]
+	}
+
+	var start, end time.Time
+	for _, p := range []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if len(value) != len(p.layout) {
+			continue
+		}
+		if t, err := time.Parse(p.layout, value); err == nil {
+			start, end = t, p.next(t)
+		}
+		break
+	}
+	if start.IsZero() {
+		return fmt.Errorf("%w: birthdate must be YYYY, YYYY-MM or YYYY-MM-DD with an optional eq, ge, gt, le or lt prefix", ErrInvalidFHIRResource)
+	}
+
+	// DOBFrom is inclusive and DOBTo exclusive
+	from := func(t time.Time) {
+		if filter.DOBFrom == nil || t.After(*filter.DOBFrom) {
+			filter.DOBFrom = &t
+		}
+	}
+	to := func(t time.Time) {
+		if filter.DOBTo == nil || t.Before(*filter.DOBTo) {
+			filter.DOBTo = &t
+		}
+	}
+	switch prefix {
+	case "eq":
+		from(start)
+		to(end)
+	case "ge":
+		from(start)
+	case "gt":
+		from(end)
+	case "lt":
+		to(start)
+	case "le":
+		to(end)
+	default:
+		return fmt.Errorf("%w: birthdate prefix %q", ErrUnsupportedFHIRSearch, prefix)
+	}
+	return nil
+}
+
+// matchesPatientFilter applies the name and birth date parts of filter to
+// a patient found by identifier
+func matchesPatientFilter(patient *models.Patient, filter models.PatientFilter) bool {
+	if prefix := strings.ToLower(filter.NamePrefix); prefix != "" &&
+		!strings.HasPrefix(strings.ToLower(patient.FirstName), prefix) &&
+		!strings.HasPrefix(strings.ToLower(patient.LastName), prefix) {
+		return false
+	}
+	if filter.DOBFrom != nil && patient.DOB.Before(*filter.DOBFrom) {
+		return false
+	}
+	if filter.DOBTo != nil && !patient.DOB.Before(*filter.DOBTo) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/pkg/hl7"
+)
+
+var (
+	// ErrUnsupportedHL7Message is returned for message types the interface
+	// does not handle; they are rejected with AR.
+	ErrUnsupportedHL7Message = errors.New("unsupported HL7 message")
+	// ErrInvalidHL7Message is returned for supported messages that cannot
+	// be applied; they are answered with AE.
+	ErrInvalidHL7Message = errors.New("invalid HL7 message")
+)
+
+// hl7Actor is recorded in the audit trail for changes made by HL7 messages
+var hl7Actor = models.Actor{Username: "hl7-interface", Role: "interface"}
+
+// hl7IdentifierTypes maps PID-3 identifier type codes (HL7 table 0203) to
+// identifier systems
+var hl7IdentifierTypes = map[string]string{
+	"MR": models.IdentifierMRN,
+	"NI": models.IdentifierNationalID,
+	"SS": models.IdentifierNationalID,
+	"MB": models.IdentifierInsuranceMemberID,
+}
+
+// hl7Sexes maps PID-8 administrative sex codes to patient genders
+var hl7Sexes = map[string]string{"M": "male", "F": "female", "O": "other", "A": "other", "N": "other"}
+
+// HL7Service applies HL7 v2 messages from external systems: ADT^A01 and
+// ADT^A08 register and update patients, and ORU^R01 records lab results.
+type HL7Service struct {
+	patients *PatientService
+	labs     *LabService
+	// loc is the time zone of timestamps sent without an offset
+	loc *time.Location
+}
+
+func NewHL7Service(patients *PatientService, labs *LabService) *HL7Service {
+	return &HL7Service{patients: patients, labs: labs, loc: time.Local}
+}
+
+// HandleMessage applies one message and returns its acknowledgment: AA
+// when it was applied, AE when it could not be, and AR when it is not a
+// supported message.
+func (s *HL7Service) HandleMessage(data []byte) []byte {
+	msg, err := hl7.Parse(data)
+	if err != nil {
+		return hl7.NewACK(nil, hl7.AckReject, err.Error())
+	}
+
+	if err := s.apply(msg); err != nil {
+		code := hl7.AckError
+		if errors.Is(err, ErrUnsupportedHL7Message) {
+			code = hl7.AckReject
+		}
+		return hl7.NewACK(msg, code, err.Error())
+	}
+	return hl7.NewACK(msg, hl7.AckAccept, "")
+}
+
+func (s *HL7Service) apply(msg *hl7.Message) error {
+	messageType, trigger := msg.Type()
+	switch {
+	case messageType == "ADT" && (trigger == "A01" || trigger == "A08"):
+		return s.applyADT(msg, trigger)
+	case messageType == "ORU" && trigger == "R01":
+		return s.applyORU(msg)
+	default:
+		return fmt.Errorf("%w: %s^%s", ErrUnsupportedHL7Message, messageType, trigger)
+	}
+}
+
+// applyADT updates the patient matching the PID-3 identifiers, or on A01
+// registers a new one, and records any new identifiers.
+func (s *HL7Service) applyADT(msg *hl7.Message, trigger string) error {
+	pid := msg.Segment("PID")
+	if pid == nil {
+		return fmt.Errorf("%w: PID segment is required", ErrInvalidHL7Message)
+	}
+	incoming, err := s.patientFromPID(pid)
+	if err != nil {
+		return err
+	}
+	identifiers := identifiersFromPID(pid)
+
+	patient, err := s.findPatient(identifiers)
+	if err != nil {
+		return err
+	}
+
+	if patient == nil {
+		if trigger != "A01" {
+			return fmt.Errorf("%w: no patient matches the PID-3 identifiers", ErrInvalidHL7Message)
+		}
+		if incoming.DOB.IsZero() {
+			return fmt.Errorf("%w: PID-7 date of birth is required to register a patient", ErrInvalidHL7Message)
+		}
+		if err := s.patients.CreatePatient(hl7Actor, incoming, false); err != nil {
+			var duplicate *DuplicatePatientError
+			if errors.As(err, &duplicate) {
+				ids := make([]string, len(duplicate.Candidates))
+				for i, c := range duplicate.Candidates {
+					ids[i] = strconv.Itoa(c.Patient.ID)
+				}
+				return fmt.Errorf("%w: %v (patient %s)", ErrInvalidHL7Message, err, strings.Join(ids, ", "))
+			}
+			return fmt.Errorf("%w: %v", ErrInvalidHL7Message, err)
+		}
+		patient = incoming
+	} else {
+		mergeADTFields(patient, incoming)
+		if _, err := s.patients.UpdatePatient(hl7Actor, patient); err != nil {
+			return err
+		}
+	}
+
+	return s.addIdentifiers(patient.ID, identifiers)
+}
+
+// applyORU records the results in each OBR group against the lab order
+// named in OBR-2. Every group is checked before any is applied.
+func (s *HL7Service) applyORU(msg *hl7.Message) error {
+	pid := msg.Segment("PID")
+	if pid == nil {
+		return fmt.Errorf("%w: PID segment is required", ErrInvalidHL7Message)
+	}
+	patient, err := s.findPatient(identifiersFromPID(pid))
+	if err != nil {
+		return err
+	}
+	if patient == nil {
+		return fmt.Errorf("%w: no patient matches the PID-3 identifiers", ErrInvalidHL7Message)
+	}
+
+	groups, err := oruGroups(msg)
+	if err != nil {
+		return err
+	}
+	catalog, err := s.labs.GetCatalog()
+	if err != nil {
+		return err
+	}
+	units := make(map[string]string, len(catalog))
+	for _, test := range catalog {
+		units[test.Code] = test.Unit
+	}
+
+	// every group is checked before anything is written, so a bad result
+	// later in the message does not leave earlier specimens half-processed
+	results := make([]oruResult, len(groups))
+	seen := map[int]bool{}
+	for i, group := range groups {
+		if results[i], err = s.prepareResult(patient.ID, group, units); err != nil {
+			return err
+		}
+		id := results[i].order.ID
+		if seen[id] {
+			return fmt.Errorf("%w: order %d is reported twice", ErrInvalidHL7Message, id)
+		}
+		seen[id] = true
+	}
+
+	for _, r := range results {
+		if r.applied {
+			continue
+		}
+		if r.order.Status == models.LabOrdered {
+			if _, err := s.labs.CollectSpecimen(hl7Actor, uint(patient.ID), uint(r.order.ID), r.collectedAt); err != nil {
+				return err
+			}
+		}
+		if _, err := s.labs.RecordResult(hl7Actor, uint(patient.ID), uint(r.order.ID), r.result); err != nil {
+			return fmt.Errorf("%w: order %d: %v", ErrInvalidHL7Message, r.order.ID, err)
+		}
+	}
+	return nil
+}
+
+// oruGroup is an OBR segment with the OBX and NTE segments after it
+type oruGroup struct {
+	obr *hl7.Segment
+	obx []*hl7.Segment
+	// notes holds the NTE comments for each OBX
+	notes map[*hl7.Segment][]string
+}
+
+func oruGroups(msg *hl7.Message) ([]*oruGroup, error) {
+	var (
+		groups  []*oruGroup
+		lastOBX *hl7.Segment
+	)
+	for _, seg := range msg.Segments {
+		switch seg.Name {
+		case "OBR":
+			groups = append(groups, &oruGroup{obr: seg, notes: map[*hl7.Segment][]string{}})
+			lastOBX = nil
+		case "OBX":
+			if len(groups) == 0 {
+				return nil, fmt.Errorf("%w: OBX before any OBR", ErrInvalidHL7Message)
+			}
+			group := groups[len(groups)-1]
+			group.obx = append(group.obx, seg)
+			lastOBX = seg
+		case "NTE":
+			if lastOBX != nil {
+				group := groups[len(groups)-1]
+				group.notes[lastOBX] = append(group.notes[lastOBX], seg.Field(3))
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("%w: no OBR segment", ErrInvalidHL7Message)
+	}
+	return groups, nil
+}
+
+type oruResult struct {
+	order       *models.LabOrder
+	collectedAt *time.Time
+	result      *models.LabResult
+	// applied is set when the order already has this result, from an
+	// earlier transmission of the message
+	applied bool
+}
+
+// prepareResult finds the order an OBR group reports on and reads its
+// numeric result from the OBX for the ordered test. units maps each
+// catalog test code to the unit its results are reported in.
+//
+// The order must be waiting for a result. An order that already has the
+// same result is marked applied rather than refused, so that a sender
+// retransmitting a message after a failure gets it accepted without the
+// results it did record being written twice.
+func (s *HL7Service) prepareResult(patientID int, group *oruGroup, units map[string]string) (oruResult, error) {
+	placer := group.obr.Component(2, 1)
+	orderID, err := strconv.ParseUint(placer, 10, 32)
+	if err != nil {
+		return oruResult{}, fmt.Errorf("%w: OBR-2 placer order number %q is not a lab order ID", ErrInvalidHL7Message, placer)
+	}
+	order, err := s.labs.GetOrder(hl7Actor, uint(patientID), uint(orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return oruResult{}, fmt.Errorf("%w: patient has no lab order %d", ErrInvalidHL7Message, orderID)
+	}
+	if err != nil {
+		return oruResult{}, err
+	}
+
+	var obx *hl7.Segment
+	for _, seg := range group.obx {
+		if seg.Component(3, 1) == order.TestCode {
+			obx = seg
+			break
+		}
+	}
+	if obx == nil {
+		return oruResult{}, fmt.Errorf("%w: no OBX for test %s in order %d", ErrInvalidHL7Message, order.TestCode, order.ID)
+	}
+	if valueType := obx.Field(2); valueType != "NM" {
+		return oruResult{}, fmt.Errorf("%w: OBX-2 value type %q is not NM", ErrInvalidHL7Message, valueType)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(obx.Field(5)), 64)
+	if err != nil {
+		return oruResult{}, fmt.Errorf("%w: OBX-5 %q is not a number", ErrInvalidHL7Message, obx.Field(5))
+	}
+	unit := obx.Component(6, 1)
+	if unit != "" && !strings.EqualFold(strings.TrimSpace(unit), units[order.TestCode]) {
+		return oruResult{}, fmt.Errorf("%w: OBX-6 unit %q does not match %s", ErrInvalidHL7Message, unit, units[order.TestCode])
+	}
+
+	prepared := oruResult{
+		order: order,
+		result: &models.LabResult{
+			Value:   value,
+			Unit:    unit,
+			Comment: strings.Join(group.notes[obx], "\n"),
+		},
+	}
+	if v := group.obr.Component(7, 1); v != "" {
+		t, err := hl7.ParseTime(v, s.loc)
+		if err != nil {
+			return oruResult{}, err
+		}
+		prepared.collectedAt = &t
+	}
+	if v := obx.Component(14, 1); v != "" {
+		if prepared.result.ResultedAt, err = hl7.ParseTime(v, s.loc); err != nil {
+			return oruResult{}, err
+		}
+	}
+
+	switch order.Status {
+	case models.LabOrdered, models.LabCollected:
+	case models.LabResulted, models.LabVerified:
+		if !sameLabResult(order.Result, prepared.result) {
+			return oruResult{}, fmt.Errorf("%w: order %d already has a different result", ErrInvalidHL7Message, order.ID)
+		}
+		prepared.applied = true
+	default:
+		return oruResult{}, fmt.Errorf("%w: order %d is %s", ErrInvalidHL7Message, order.ID, order.Status)
+	}
+	return prepared, nil
+}
+
+// sameLabResult reports whether a stored result has the value, and the
+// result time if one was sent, of a result from a message
+func sameLabResult(stored, sent *models.LabResult) bool {
+	if stored == nil || stored.Value != sent.Value {
+		return false
+	}
+	return sent.ResultedAt.IsZero() || stored.ResultedAt.Equal(sent.ResultedAt)
+}
+
+// findPatient returns the patient matching the first identifier that
+// matches anyone, or nil when none does
+func (s *HL7Service) findPatient(identifiers []models.PatientIdentifier) (*models.Patient, error) {
+	for _, id := range identifiers {
+		patient, err := s.patients.FindPatientByIdentifier(hl7Actor, id.System, id.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return patient, nil
+	}
+	return nil, nil
+}
+
+// addIdentifiers records the identifiers the patient does not have yet.
+// MRNs are assigned here and are never added from a message.
+func (s *HL7Service) addIdentifiers(patientID int, identifiers []models.PatientIdentifier) error {
+	existing, err := s.patients.GetIdentifiers(hl7Actor, uint(patientID))
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for _, id := range existing {
+		have[id.System+"|"+id.Value] = true
+	}
+
+	for _, id := range identifiers {
+		if id.System == models.IdentifierMRN || have[id.System+"|"+id.Value] {
+			continue
+		}
+		id.PatientID = patientID
+		if err := s.patients.AddIdentifier(hl7Actor, &id); err != nil {
+			if errors.Is(err, ErrIdentifierInUse) {
+				return fmt.Errorf("%w: %v", ErrInvalidHL7Message, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// identifiersFromPID reads the PID-3 identifiers of known types, MRNs first
+func identifiersFromPID(pid *hl7.Segment) []models.PatientIdentifier {
+	var mrns, others []models.PatientIdentifier
+	for _, rep := range pid.Repetitions(3) {
+		value := strings.TrimSpace(pid.RepetitionComponent(rep, 1))
+		system, ok := hl7IdentifierTypes[pid.RepetitionComponent(rep, 5)]
+		if value == "" || !ok {
+			continue
+		}
+		id := models.PatientIdentifier{System: system, Value: value}
+		if system == models.IdentifierMRN {
+			mrns = append(mrns, id)
+		} else {
+			others = append(others, id)
+		}
+	}
+	return append(mrns, others...)
+}
+
+// patientFromPID reads the demographics in a PID segment. Fields the
+// sender left empty are left zero.
+func (s *HL7Service) patientFromPID(pid *hl7.Segment) (*models.Patient, error) {
+	patient := &models.Patient{
+		LastName:  strings.TrimSpace(pid.Component(5, 1)),
+		FirstName: strings.TrimSpace(pid.Component(5, 2)),
+		Gender:    hl7Sexes[pid.Component(8, 1)],
+	}
+	if patient.FirstName == "" || patient.LastName == "" {
+		return nil, fmt.Errorf("%w: PID-5 must have a family and a given name", ErrInvalidHL7Message)
+	}
+
+	if v := pid.Component(7, 1); v != "" {
+		dob, err := hl7.ParseTime(v, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		patient.DOB = time.Date(dob.Year(), dob.Month(), dob.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	if reps := pid.Repetitions(11); len(reps) > 0 {
+		var parts []string
+		for c := 1; c <= 6; c++ {
+			if part := strings.TrimSpace(pid.RepetitionComponent(reps[0], c)); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		patient.Address = strings.Join(parts, ", ")
+	}
+
+	for _, rep := range pid.Repetitions(13) {
+		if pid.RepetitionComponent(rep, 2) == "NET" || pid.RepetitionComponent(rep, 3) == "Internet" {
+			if patient.Email == "" {
+				patient.Email = strings.TrimSpace(pid.RepetitionComponent(rep, 4))
+			}
+			continue
+		}
+		if patient.Phone == "" {
+			phone := pid.RepetitionComponent(rep, 1)
+			if phone == "" {
+				phone = pid.RepetitionComponent(rep, 6) + pid.RepetitionComponent(rep, 7)
+			}
+			patient.Phone = strings.TrimSpace(phone)
+		}
+	}
+	return patient, nil
+}
+
+// mergeADTFields copies the demographics sent in an update onto the
+// patient, keeping what the sender left empty
+func mergeADTFields(patient, incoming *models.Patient) {
+	patient.FirstName, patient.LastName = incoming.FirstName, incoming.LastName
+	if !incoming.DOB.IsZero() {
+		patient.DOB = incoming.DOB
+	}
+	if incoming.Gender != "" {
+		patient.Gender = incoming.Gender
+	}
+	if incoming.Phone != "" {
+		patient.Phone = incoming.Phone
+	}
+	if incoming.Email != "" {
+		patient.Email = incoming.Email
+	}
+	if incoming.Address != "" {
+		patient.Address = incoming.Address
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+var (
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrIdentifierInUse   = errors.New("identifier already in use")
+)
+
+// FindPatientByIdentifier resolves a patient from an identifier. The "mrn"
+// system matches the patient's current MRN first, then any MRN it absorbed
+// in a merge.
+func (s *PatientService) FindPatientByIdentifier(actor models.Actor, system, value string) (*models.Patient, error) {
+	system, value = strings.TrimSpace(system), strings.TrimSpace(value)
+	if system == "" || value == "" {
+		return nil, fmt.Errorf("%w: system and value are required", ErrInvalidIdentifier)
+	}
+
+	var patient *models.Patient
+	if system == models.IdentifierMRN {
+		p, err := s.repo.FindByMRN(value)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		patient = p
+	}
+	if patient == nil {
+		identifier, err := s.identifiers.FindBySystemValue(system, value)
+		if err != nil {
+			return nil, err
+		}
+		patient, err = s.repo.FindByID(uint(identifier.PatientID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.record(actor, models.AuditRead, patient.ID, nil, nil); err != nil {
+		return nil, err
+	}
+	return patient, nil
+}
+
+// GetIdentifiers lists the external identifiers recorded for a patient.
+func (s *PatientService) GetIdentifiers(actor models.Actor, patientID uint) ([]models.PatientIdentifier, error) {
+	if _, err := s.repo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	identifiers, err := s.identifiers.FindByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.record(actor, models.AuditRead, int(patientID), nil, nil); err != nil {
+		return nil, err
+	}
+	return identifiers, nil
+}
+
+// AddIdentifier records an identifier issued to the patient by another
+// system. Each system/value pair can belong to only one patient.
+func (s *PatientService) AddIdentifier(actor models.Actor, identifier *models.PatientIdentifier) error {
+	identifier.System = strings.TrimSpace(identifier.System)
+	identifier.Value = strings.TrimSpace(identifier.Value)
+	if identifier.System == "" || identifier.Value == "" {
+		return fmt.Errorf("%w: system and value are required", ErrInvalidIdentifier)
+	}
+
+	patient, err := s.repo.FindByID(uint(identifier.PatientID))
+	if err != nil {
+		return err
+	}
+	existing, err := s.identifiers.FindBySystemValue(identifier.System, identifier.Value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s %s is already assigned to a patient", ErrIdentifierInUse, identifier.System, identifier.Value)
+	}
+
+	if err := s.identifiers.Create(identifier); err != nil {
+		return err
+	}
+	return s.record(actor, models.AuditUpdate, patient.ID, nil, nil)
+}
+
+// DeleteIdentifier removes one of the patient's external identifiers.
+func (s *PatientService) DeleteIdentifier(actor models.Actor, patientID, identifierID uint) error {
+	identifier, err := s.identifiers.FindByID(identifierID)
+	if err != nil {
+		return err
+	}
+	if identifier.PatientID != int(patientID) {
+		return sql.ErrNoRows
+	}
+	if err := s.identifiers.Delete(identifierID); err != nil {
+		return err
+	}
+	return s.record(actor, models.AuditUpdate, identifier.PatientID, nil, nil)
+}
+
+// AssignMissingMRNs gives an MRN to every patient registered before MRNs
+// were introduced. It returns how many patients were updated.
+func (s *PatientService) AssignMissingMRNs() (int, error) {
+	patients, err := s.repo.FindWithoutMRN()
+	if err != nil {
+		return 0, err
+	}
+	for i, patient := range patients {
+		mrn, err := s.mrn.Next()
+		if err != nil {
+			return i, err
+		}
+		if err := s.repo.SetMRN(uint(patient.ID), mrn); err != nil {
+			return i, err
+		}
+	}
+	return len(patients), nil
+}
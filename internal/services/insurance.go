@@ -0,0 +1,513 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+	"hospital-management-system/pkg/x12"
+)
+
+var (
+	ErrInvalidPolicy = errors.New("invalid insurance policy")
+	// ErrPolicyOverlap is returned when a policy's coverage overlaps
+	// another of the patient's policies with the same priority.
+	ErrPolicyOverlap = errors.New("patient already has a policy with this priority for these dates")
+	// ErrPolicyInUse is returned when deleting a policy that claims were
+	// billed to.
+	ErrPolicyInUse  = errors.New("policy cannot be deleted once claims are billed to it")
+	ErrInvalidClaim = errors.New("invalid claim")
+	// ErrClaimExists is returned when an invoice has already been billed to
+	// a policy and the claim was not denied.
+	ErrClaimExists       = errors.New("invoice has already been claimed from this policy")
+	ErrInvalidRemittance = errors.New("invalid remittance")
+)
+
+// ClaimsSettings are the parties claim files are sent from and to.
+type ClaimsSettings struct {
+	// SenderID and ReceiverID identify the hospital and the clearinghouse
+	// in the interchange envelope
+	SenderID   string
+	ReceiverID string
+	Submitter  x12.Party
+	Receiver   x12.Party
+	Provider   x12.BillingProvider
+	// Production marks claim files as production data rather than tests
+	Production bool
+}
+
+// InsuranceService manages patients' insurance policies, checks their
+// eligibility, bills invoices to them as X12 837P claims, and posts the
+// payers' 835 remittances back against the invoices.
+type InsuranceService struct {
+	repo        repository.InsuranceRepository
+	billingRepo repository.BillingRepository
+	patientRepo repository.PatientRepository
+	problemRepo repository.ProblemRepository
+	billing     *BillingService
+	eligibility EligibilityChecker
+	settings    ClaimsSettings
+	audit       *AuditService
+}
+
+func NewInsuranceService(repo repository.InsuranceRepository, billingRepo repository.BillingRepository, patientRepo repository.PatientRepository, problemRepo repository.ProblemRepository, billing *BillingService, eligibility EligibilityChecker, settings ClaimsSettings, audit *AuditService) *InsuranceService {
+	return &InsuranceService{
+		repo:        repo,
+		billingRepo: billingRepo,
+		patientRepo: patientRepo,
+		problemRepo: problemRepo,
+		billing:     billing,
+		eligibility: eligibility,
+		settings:    settings,
+		audit:       audit,
+	}
+}
+
+// GetPolicies lists a patient's policies by priority.
+func (s *InsuranceService) GetPolicies(actor models.Actor, patientID uint) ([]models.InsurancePolicy, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	policies, err := s.repo.FindPoliciesByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CreatePolicy adds a policy to a patient.
+func (s *InsuranceService) CreatePolicy(actor models.Actor, policy *models.InsurancePolicy) error {
+	if _, err := s.patientRepo.FindByID(uint(policy.PatientID)); err != nil {
+		return err
+	}
+	if err := s.validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.repo.CreatePolicy(policy); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, policy.PatientID)
+}
+
+// UpdatePolicy changes a patient's policy. Claims already sent keep the
+// details they were sent with.
+func (s *InsuranceService) UpdatePolicy(actor models.Actor, policy *models.InsurancePolicy) error {
+	existing, err := s.findPolicy(uint(policy.PatientID), uint(policy.ID))
+	if err != nil {
+		return err
+	}
+	policy.CreatedAt = existing.CreatedAt
+	if err := s.validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePolicy(policy); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, policy.PatientID)
+}
+
+// DeletePolicy removes a policy entered in error. Policies that claims
+// were billed to cannot be deleted; end their coverage instead.
+func (s *InsuranceService) DeletePolicy(actor models.Actor, patientID, policyID uint) error {
+	if _, err := s.findPolicy(patientID, policyID); err != nil {
+		return err
+	}
+	if err := s.repo.DeletePolicy(policyID); err != nil {
+		if errors.Is(err, repository.ErrPolicyHasClaims) {
+			return fmt.Errorf("%w: end its coverage instead", ErrPolicyInUse)
+		}
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditDelete, int(patientID))
+}
+
+// CheckEligibility asks the payer whether a patient's policy covers them
+// on a date of service.
+func (s *InsuranceService) CheckEligibility(actor models.Actor, patientID, policyID uint, serviceDate time.Time) (*models.EligibilityResult, error) {
+	policy, err := s.findPolicy(patientID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	patient, err := s.patientRepo.FindByID(patientID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.eligibility.CheckEligibility(patient, policy, serviceDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findPolicy returns the patient's policy, or sql.ErrNoRows if it is
+// another patient's
+func (s *InsuranceService) findPolicy(patientID, policyID uint) (*models.InsurancePolicy, error) {
+	policy, err := s.repo.FindPolicyByID(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.PatientID != int(patientID) {
+		return nil, sql.ErrNoRows
+	}
+	return policy, nil
+}
+
+func (s *InsuranceService) validatePolicy(policy *models.InsurancePolicy) error {
+	policy.PayerName = strings.TrimSpace(policy.PayerName)
+	policy.PayerID = strings.TrimSpace(policy.PayerID)
+	policy.MemberID = strings.TrimSpace(policy.MemberID)
+	policy.GroupNumber = strings.TrimSpace(policy.GroupNumber)
+	if policy.PayerName == "" || policy.PayerID == "" || policy.MemberID == "" {
+		return fmt.Errorf("%w: payer_name, payer_id and member_id are required", ErrInvalidPolicy)
+	}
+	if policy.Priority < 1 || policy.Priority > 3 {
+		return fmt.Errorf("%w: priority must be 1 (primary), 2 (secondary) or 3 (tertiary)", ErrInvalidPolicy)
+	}
+
+	if policy.Relationship == "" {
+		policy.Relationship = models.RelationshipSelf
+	}
+	switch policy.Relationship {
+	case models.RelationshipSelf:
+		policy.SubscriberFirstName, policy.SubscriberLastName, policy.SubscriberDOB = "", "", nil
+	case models.RelationshipSpouse, models.RelationshipChild, models.RelationshipOther:
+		policy.SubscriberFirstName = strings.TrimSpace(policy.SubscriberFirstName)
+		policy.SubscriberLastName = strings.TrimSpace(policy.SubscriberLastName)
+		if policy.SubscriberFirstName == "" || policy.SubscriberLastName == "" || policy.SubscriberDOB == nil {
+			return fmt.Errorf("%w: the subscriber's name and date of birth are required when the patient is not the subscriber", ErrInvalidPolicy)
+		}
+	default:
+		return fmt.Errorf("%w: relationship must be self, spouse, child or other", ErrInvalidPolicy)
+	}
+
+	if policy.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective_from is required", ErrInvalidPolicy)
+	}
+	if policy.EffectiveTo != nil && policy.EffectiveTo.Before(policy.EffectiveFrom) {
+		return fmt.Errorf("%w: effective_to must not be before effective_from", ErrInvalidPolicy)
+	}
+
+	others, err := s.repo.FindPoliciesByPatient(uint(policy.PatientID))
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID != policy.ID && other.Priority == policy.Priority && coverageOverlaps(policy, &other) {
+			return fmt.Errorf("%w: policy %d with %s", ErrPolicyOverlap, other.ID, other.PayerName)
+		}
+	}
+	return nil
+}
+
+// coverageOverlaps reports whether two policies are in effect on any same
+// day; a policy without an end date runs on indefinitely
+func coverageOverlaps(a, b *models.InsurancePolicy) bool {
+	return (b.EffectiveTo == nil || !a.EffectiveFrom.After(*b.EffectiveTo)) &&
+		(a.EffectiveTo == nil || !b.EffectiveFrom.After(*a.EffectiveTo))
+}
+
+// CreateClaim bills an invoice to one of the patient's policies as an
+// 837P claim file, which is kept for download and sending to the
+// clearinghouse. Without a policy the patient's primary policy in effect
+// on the invoice's date is used. The claim is coded with the patient's
+// active problems, so at least one must be on their problem list.
+func (s *InsuranceService) CreateClaim(actor models.Actor, invoiceID uint, policyID *int) (*models.Claim, error) {
+	invoice, err := s.billingRepo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceIssued && invoice.Status != models.InvoicePartiallyPaid {
+		return nil, fmt.Errorf("%w: %s invoice cannot be claimed", ErrInvoiceStatus, invoice.Status)
+	}
+	patient, err := s.patientRepo.FindByID(uint(invoice.PatientID))
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.claimPolicy(invoice, policyID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.repo.FindClaimsByInvoice(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range claims {
+		if c.PolicyID == policy.ID && c.Status != models.ClaimDenied {
+			return nil, fmt.Errorf("%w: claim %s", ErrClaimExists, c.ControlNumber)
+		}
+	}
+
+	problems, err := s.problemRepo.FindByPatient(uint(invoice.PatientID))
+	if err != nil {
+		return nil, err
+	}
+	var diagnoses []string
+	for _, p := range problems {
+		if p.Status == models.ProblemActive {
+			diagnoses = append(diagnoses, p.ICD10Code)
+		}
+	}
+	if len(diagnoses) == 0 {
+		return nil, fmt.Errorf("%w: the patient has no active problems to code the claim with", ErrInvalidClaim)
+	}
+
+	number, err := s.repo.NextClaimNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	claim := &models.Claim{
+		InvoiceID:     invoice.ID,
+		PolicyID:      policy.ID,
+		PatientID:     invoice.PatientID,
+		ControlNumber: fmt.Sprintf("CLM%09d", number),
+		Status:        models.ClaimSubmitted,
+		ChargeCents:   invoice.TotalCents,
+		CreatedBy:     actor.UserID,
+		CreatedAt:     now,
+	}
+
+	professional := professionalClaim(claim, invoice, patient, policy, diagnoses)
+	set := x12.Build837P(1, s.settings.Submitter, s.settings.Receiver, s.settings.Provider, []x12.ProfessionalClaim{professional}, now)
+	interchange := x12.Interchange{
+		SenderID:      s.settings.SenderID,
+		ReceiverID:    s.settings.ReceiverID,
+		ControlNumber: int(number % 1000000000),
+		Time:          now,
+		Production:    s.settings.Production,
+	}
+	claim.Content = string(x12.Encode(interchange.Wrap("HC", x12.Version837P, set)))
+
+	if err := s.repo.CreateClaim(claim); err != nil {
+		if errors.Is(err, repository.ErrClaimExists) {
+			return nil, fmt.Errorf("%w: invoice %d, policy %d", ErrClaimExists, invoice.ID, policy.ID)
+		}
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditCreate, claim.PatientID); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// claimPolicy returns the policy to bill an invoice to: the one asked
+// for, which must be the patient's and in effect on the invoice's date, or
+// else the patient's highest priority policy in effect then
+func (s *InsuranceService) claimPolicy(invoice *models.Invoice, policyID *int) (*models.InsurancePolicy, error) {
+	if policyID != nil {
+		policy, err := s.repo.FindPolicyByID(uint(*policyID))
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && policy.PatientID != invoice.PatientID) {
+			return nil, fmt.Errorf("%w: policy %d is not the patient's", ErrInvalidClaim, *policyID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !policy.Covers(invoice.IssuedAt) {
+			return nil, fmt.Errorf("%w: policy %d is not in effect on the invoice's date", ErrInvalidClaim, policy.ID)
+		}
+		return policy, nil
+	}
+
+	policies, err := s.repo.FindPoliciesByPatient(uint(invoice.PatientID))
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].Covers(invoice.IssuedAt) {
+			return &policies[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: the patient has no policy in effect on the invoice's date", ErrInvalidClaim)
+}
+
+// professionalClaim maps an invoice to an 837P claim, one service line per
+// invoice line, each charged with its tax
+func professionalClaim(claim *models.Claim, invoice *models.Invoice, patient *models.Patient, policy *models.InsurancePolicy, diagnoses []string) x12.ProfessionalClaim {
+	person := x12.Person{FirstName: patient.FirstName, LastName: patient.LastName, DOB: patient.DOB, Gender: patient.Gender}
+	pc := x12.ProfessionalClaim{
+		ControlNumber: claim.ControlNumber,
+		Payer:         x12.Party{Name: policy.PayerName, ID: policy.PayerID},
+		PayerSequence: [...]string{"P", "S", "T"}[policy.Priority-1],
+		MemberID:      policy.MemberID,
+		GroupNumber:   policy.GroupNumber,
+		Subscriber:    person,
+		Diagnoses:     diagnoses,
+	}
+	if policy.Relationship != models.RelationshipSelf {
+		pc.Subscriber = x12.Person{FirstName: policy.SubscriberFirstName, LastName: policy.SubscriberLastName, DOB: *policy.SubscriberDOB}
+		pc.Patient = &person
+		pc.Relationship = map[string]string{
+			models.RelationshipSpouse: "01",
+			models.RelationshipChild:  "19",
+			models.RelationshipOther:  "G8",
+		}[policy.Relationship]
+	}
+	for _, l := range invoice.Lines {
+		pc.Lines = append(pc.Lines, x12.ServiceLine{
+			ProcedureCode: l.ChargeCode,
+			ChargeCents:   l.TotalCents,
+			Units:         l.Quantity,
+			Date:          invoice.IssuedAt,
+		})
+	}
+	return pc
+}
+
+// GetClaims lists the claims billed for an invoice, oldest first.
+func (s *InsuranceService) GetClaims(actor models.Actor, invoiceID uint) ([]models.Claim, error) {
+	invoice, err := s.billingRepo.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.repo.FindClaimsByInvoice(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, invoice.PatientID); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// GetClaim returns a claim with the 837P it was sent as.
+func (s *InsuranceService) GetClaim(actor models.Actor, claimID uint) (*models.Claim, error) {
+	claim, err := s.repo.FindClaimByID(claimID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, claim.PatientID); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// PostRemittance reads a payer's 835 remittance and posts each claim
+// payment in it to the claim's invoice as an insurance payment. Claims
+// are matched by their control number. A claim that cannot be matched,
+// was already posted or cannot be paid is reported and skipped, so the
+// rest of the remittance is still posted and posting it again posts
+// nothing twice.
+func (s *InsuranceService) PostRemittance(actor models.Actor, data []byte) (*models.RemittanceReport, error) {
+	segments, err := x12.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRemittance, err)
+	}
+	remit, err := x12.ParseRemittance(segments)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRemittance, err)
+	}
+
+	report := &models.RemittanceReport{
+		PayerName:   remit.PayerName,
+		TraceNumber: remit.TraceNumber,
+		PaidCents:   remit.PaidCents,
+		Claims:      []models.RemittanceResult{},
+	}
+	if !remit.PaymentDate.IsZero() {
+		report.PaymentDate = &remit.PaymentDate
+	}
+	for _, payment := range remit.Claims {
+		result, err := s.postClaimPayment(actor, remit, payment)
+		if err != nil {
+			return nil, err
+		}
+		if result.Error == "" {
+			report.Posted++
+		} else {
+			report.Failed++
+		}
+		report.Claims = append(report.Claims, result)
+	}
+	return report, nil
+}
+
+// postClaimPayment posts one claim's payment. Problems with the claim are
+// reported in the result; only failures to save are returned as errors.
+func (s *InsuranceService) postClaimPayment(actor models.Actor, remit *x12.Remittance, payment x12.ClaimPayment) (models.RemittanceResult, error) {
+	result := models.RemittanceResult{ControlNumber: payment.ControlNumber, PaidCents: payment.PaidCents}
+	claim, err := s.repo.FindClaimByControlNumber(payment.ControlNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Error = "no claim has this control number"
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.ClaimID, result.InvoiceID = claim.ID, claim.InvoiceID
+	if claim.Status != models.ClaimSubmitted {
+		result.Status = claim.Status
+		result.Error = "the claim's remittance has already been posted"
+		return result, nil
+	}
+
+	switch payment.Status {
+	case x12.ClaimDenied:
+		claim.Status = models.ClaimDenied
+	case x12.ClaimProcessedPrimary, x12.ClaimProcessedSecondary, x12.ClaimProcessedTertiary:
+		switch {
+		case payment.PaidCents < 0:
+			result.Error = "negative claim payments are not supported"
+			return result, nil
+		case payment.PaidCents >= claim.ChargeCents:
+			claim.Status = models.ClaimPaid
+		case payment.PaidCents > 0:
+			claim.Status = models.ClaimPartiallyPaid
+		default:
+			claim.Status = models.ClaimDenied
+		}
+	default:
+		result.Error = fmt.Sprintf("claim status %q is not supported", payment.Status)
+		return result, nil
+	}
+
+	now := time.Now()
+	var invoice *models.Invoice
+	var paid *models.InvoicePayment
+	if claim.Status != models.ClaimDenied {
+		paid = &models.InvoicePayment{
+			AmountCents: payment.PaidCents,
+			Method:      models.PaymentInsurance,
+			Reference:   strings.TrimSpace(fmt.Sprintf("%s %s %s", remit.PayerName, remit.TraceNumber, payment.PayerClaimNumber)),
+			RecordedBy:  actor.UserID,
+			RecordedAt:  now,
+		}
+		invoice, err = s.billing.checkPayment(uint(claim.InvoiceID), paid)
+		if errors.Is(err, ErrInvoiceStatus) || errors.Is(err, ErrPaymentExceedsBalance) || errors.Is(err, ErrInvalidPayment) {
+			result.Error = err.Error()
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	claim.PaidCents = payment.PaidCents
+	claim.PatientResponsibilityCents = payment.PatientResponsibilityCents
+	claim.PayerClaimNumber = payment.PayerClaimNumber
+	claim.RemittanceTrace = remit.TraceNumber
+	claim.AdjudicatedAt = &now
+	err = paymentError(s.repo.PostClaimRemittance(claim, invoice, paid))
+	switch {
+	case errors.Is(err, repository.ErrClaimPosted):
+		result.Error = "the claim's remittance has already been posted"
+		return result, nil
+	case errors.Is(err, ErrPaymentExceedsBalance), errors.Is(err, ErrInvoiceStatus):
+		result.Error = err.Error()
+		return result, nil
+	case err != nil:
+		return result, err
+	}
+	result.Status = claim.Status
+	return result, s.audit.RecordAccess(actor, models.AuditUpdate, claim.PatientID)
+}
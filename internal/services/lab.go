@@ -0,0 +1,270 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidLabOrder  = errors.New("invalid lab order")
+	ErrInvalidLabResult = errors.New("invalid lab result")
+	// ErrLabOrderStatus is returned when an order cannot move to the
+	// requested status from the one it is in.
+	ErrLabOrderStatus = errors.New("lab order cannot change to that status")
+)
+
+// labTransitions lists the statuses each lab order status can move to
+var labTransitions = map[string][]string{
+	models.LabOrdered:   {models.LabCollected, models.LabCancelled},
+	models.LabCollected: {models.LabResulted, models.LabCancelled},
+	models.LabResulted:  {models.LabVerified},
+}
+
+// LabService manages lab orders and their results.
+type LabService struct {
+	repo        repository.LabRepository
+	patientRepo repository.PatientRepository
+	audit       *AuditService
+}
+
+func NewLabService(repo repository.LabRepository, patientRepo repository.PatientRepository, audit *AuditService) *LabService {
+	return &LabService{
+		repo:        repo,
+		patientRepo: patientRepo,
+		audit:       audit,
+	}
+}
+
+// GetCatalog returns the orderable tests with their reference ranges.
+func (s *LabService) GetCatalog() ([]models.LabTest, error) {
+	return s.repo.FindTests()
+}
+
+// OrderTest orders a catalog test for a patient on behalf of the acting
+// doctor.
+func (s *LabService) OrderTest(actor models.Actor, order *models.LabOrder) error {
+	if _, err := s.patientRepo.FindByID(uint(order.PatientID)); err != nil {
+		return err
+	}
+	order.TestCode = strings.TrimSpace(order.TestCode)
+	test, err := s.repo.FindTest(order.TestCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %q is not in the lab catalog", ErrInvalidLabOrder, order.TestCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	order.TestName = test.Name
+	order.OrderedBy = actor.UserID
+	order.Status = models.LabOrdered
+	order.OrderedAt = time.Now()
+	order.CollectedAt, order.VerifiedAt, order.VerifiedBy, order.Result = nil, nil, nil, nil
+
+	if err := s.repo.CreateOrder(order); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, order.PatientID)
+}
+
+// GetOrders lists a patient's lab orders with their results, newest first.
+func (s *LabService) GetOrders(actor models.Actor, patientID uint) ([]models.LabOrder, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	orders, err := s.repo.FindOrdersByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOrder returns one of a patient's lab orders.
+func (s *LabService) GetOrder(actor models.Actor, patientID, orderID uint) (*models.LabOrder, error) {
+	order, err := s.findOrder(patientID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, order.PatientID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CollectSpecimen marks an order's specimen as collected. collectedAt
+// defaults to now.
+func (s *LabService) CollectSpecimen(actor models.Actor, patientID, orderID uint, collectedAt *time.Time) (*models.LabOrder, error) {
+	order, err := s.findOrder(patientID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLabTransition(order, models.LabCollected); err != nil {
+		return nil, err
+	}
+
+	if collectedAt == nil {
+		now := time.Now()
+		collectedAt = &now
+	}
+	order.Status = models.LabCollected
+	order.CollectedAt = collectedAt
+	if err := s.repo.UpdateOrderStatus(order); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, order.PatientID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// RecordResult stores the result of a collected order and flags it
+// against the reference range for the patient's sex and age: H or L when
+// outside the range, and critical when beyond its critical limits. A unit,
+// when given, must be the catalog unit for the test.
+func (s *LabService) RecordResult(actor models.Actor, patientID, orderID uint, result *models.LabResult) (*models.LabOrder, error) {
+	order, err := s.findOrder(patientID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLabTransition(order, models.LabResulted); err != nil {
+		return nil, err
+	}
+	patient, err := s.patientRepo.FindByID(patientID)
+	if err != nil {
+		return nil, err
+	}
+	test, err := s.repo.FindTest(order.TestCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Unit != "" && !strings.EqualFold(strings.TrimSpace(result.Unit), test.Unit) {
+		return nil, fmt.Errorf("%w: %s is reported in %s, not %s", ErrInvalidLabResult, test.Name, test.Unit, result.Unit)
+	}
+	result.OrderID = order.ID
+	result.Unit = test.Unit
+	result.ResultedBy = actor.UserID
+	if result.ResultedAt.IsZero() {
+		result.ResultedAt = time.Now()
+	}
+	if order.CollectedAt != nil && result.ResultedAt.Before(*order.CollectedAt) {
+		return nil, fmt.Errorf("%w: resulted_at must not be before the specimen was collected", ErrInvalidLabResult)
+	}
+	FlagLabResult(result, test.Ranges, patient.Gender, patient.AgeOn(result.ResultedAt))
+
+	order.Status = models.LabResulted
+	if err := s.repo.SaveResult(order, result); err != nil {
+		return nil, err
+	}
+	order.Result = result
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, order.PatientID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// VerifyResult marks a resulted order as verified by the acting user.
+func (s *LabService) VerifyResult(actor models.Actor, patientID, orderID uint) (*models.LabOrder, error) {
+	order, err := s.findOrder(patientID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLabTransition(order, models.LabVerified); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	order.Status = models.LabVerified
+	order.VerifiedAt = &now
+	order.VerifiedBy = &actor.UserID
+	if err := s.repo.UpdateOrderStatus(order); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, order.PatientID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelOrder cancels an order that has no result yet.
+func (s *LabService) CancelOrder(actor models.Actor, patientID, orderID uint) (*models.LabOrder, error) {
+	order, err := s.findOrder(patientID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLabTransition(order, models.LabCancelled); err != nil {
+		return nil, err
+	}
+
+	order.Status = models.LabCancelled
+	if err := s.repo.UpdateOrderStatus(order); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, order.PatientID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// findOrder loads an order, treating another patient's order as not found
+func (s *LabService) findOrder(patientID, orderID uint) (*models.LabOrder, error) {
+	order, err := s.repo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.PatientID != int(patientID) {
+		return nil, sql.ErrNoRows
+	}
+	return order, nil
+}
+
+func checkLabTransition(order *models.LabOrder, to string) error {
+	for _, allowed := range labTransitions[order.Status] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s order cannot become %s", ErrLabOrderStatus, order.Status, to)
+}
+
+// FlagLabResult sets result's reference range and flags from the range
+// that applies to a patient of the given sex and age, preferring a
+// sex-specific range. Results with no applicable range are not flagged.
+func FlagLabResult(result *models.LabResult, ranges []models.LabReferenceRange, sex string, age int) {
+	result.ReferenceLow, result.ReferenceHigh = nil, nil
+	result.Flag, result.Critical = "", false
+
+	var match *models.LabReferenceRange
+	for i := range ranges {
+		r := &ranges[i]
+		if !r.Covers(sex, age) {
+			continue
+		}
+		if match == nil || (match.Sex == "" && r.Sex != "") {
+			match = r
+		}
+	}
+	if match == nil {
+		return
+	}
+
+	low, high := match.Low, match.High
+	result.ReferenceLow, result.ReferenceHigh = &low, &high
+	switch {
+	case result.Value < match.Low:
+		result.Flag = models.LabFlagLow
+		result.Critical = match.CriticalLow != nil && result.Value < *match.CriticalLow
+	case result.Value > match.High:
+		result.Flag = models.LabFlagHigh
+		result.Critical = match.CriticalHigh != nil && result.Value > *match.CriticalHigh
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+// throttleActor is who lockouts are recorded in the audit trail as
+var throttleActor = models.Actor{Username: "login-throttle", Role: "system"}
+
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+
+// LoginThrottledError is returned instead of checking the password while a
+// username or client IP is blocked. RetryAfter is how long it still is.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyLoginAttempts
+}
+
+// LoginThrottleSettings are the thresholds of the login throttle. Every
+// failed login blocks its username and client IP for BaseDelay, doubling
+// with each further failure; MaxUsernameFailures failures for a username,
+// or MaxIPFailures from an IP, lock it out for LockoutDuration. Failures
+// are forgotten once none happened for LockoutDuration.
+type LoginThrottleSettings struct {
+	MaxUsernameFailures int
+	MaxIPFailures       int
+	BaseDelay           time.Duration
+	LockoutDuration     time.Duration
+}
+
+// LoginThrottle slows down password guessing against the login by
+// username and by client IP
+type LoginThrottle struct {
+	repo     repository.LoginThrottleRepository
+	audit    *AuditService
+	settings LoginThrottleSettings
+}
+
+func NewLoginThrottle(repo repository.LoginThrottleRepository, audit *AuditService, settings LoginThrottleSettings) *LoginThrottle {
+	return &LoginThrottle{repo: repo, audit: audit, settings: settings}
+}
+
+// Check returns a *LoginThrottledError if the username or the IP may not
+// try to log in yet. An empty IP is not checked.
+func (t *LoginThrottle) Check(username, ip string) error {
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range throttleKeys(username, ip) {
+		throttle, err := t.repo.Find(key.scope, key.key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.Sub(now) > wait {
+			wait = throttle.LockedUntil.Sub(now)
+		}
+	}
+
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failure counts a failed login against the username and the IP and
+// blocks both for a while. Reaching the limit locks them out, which is
+// recorded in the audit trail.
+func (t *LoginThrottle) Failure(username, ip string) error {
+	now := time.Now()
+	for _, key := range throttleKeys(username, ip) {
+		failures, err := t.repo.RecordFailure(key.scope, key.key, now, now.Add(-t.settings.LockoutDuration))
+		if err != nil {
+			return err
+		}
+
+		limit := t.settings.MaxUsernameFailures
+		if key.scope == models.ThrottleScopeIP {
+			limit = t.settings.MaxIPFailures
+		}
+		if failures < limit {
+			if err := t.repo.Lock(key.scope, key.key, now.Add(t.backoff(failures))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		lockedUntil := now.Add(t.settings.LockoutDuration)
+		if err := t.repo.Lock(key.scope, key.key, lockedUntil); err != nil {
+			return err
+		}
+		if failures == limit {
+			err := t.audit.RecordAccountEvent(throttleActor, models.AuditLockout, map[string]interface{}{
+				"scope":        key.scope,
+				"key":          key.key,
+				"failures":     failures,
+				"locked_until": lockedUntil.UTC(),
+			})
+			if err != nil {
+				return auditError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Success forgets the username's failures. Those of the IP are kept, so
+// that logging in to one account does not reset guessing at others.
+func (t *LoginThrottle) Success(username string) error {
+	if err := t.repo.Clear(models.ThrottleScopeUsername, username); err != nil {
+		return err
+	}
+	// Housekeeping: keys nobody failed on lately need not stay around
+	return t.repo.DeleteStale(time.Now().Add(-t.settings.LockoutDuration))
+}
+
+// Unlock lifts a lockout of the username before it runs out
+func (t *LoginThrottle) Unlock(actor models.Actor, username string) error {
+	if err := t.repo.Clear(models.ThrottleScopeUsername, username); err != nil {
+		return err
+	}
+
+	err := t.audit.RecordAccountEvent(actor, models.AuditUnlock, map[string]interface{}{
+		"scope": models.ThrottleScopeUsername,
+		"key":   username,
+	})
+	if err != nil {
+		return auditError(err)
+	}
+	return nil
+}
+
+// backoff is how long the given number of failures blocks the next try,
+// doubling each time up to the lockout duration
+func (t *LoginThrottle) backoff(failures int) time.Duration {
+	delay := t.settings.BaseDelay
+	for i := 1; i < failures && delay < t.settings.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > t.settings.LockoutDuration {
+		return t.settings.LockoutDuration
+	}
+	return delay
+}
+
+type throttleKey struct {
+	scope, key string
+}
+
+func throttleKeys(username, ip string) []throttleKey {
+	keys := []throttleKey{{models.ThrottleScopeUsername, username}}
+	if ip != "" {
+		keys = append(keys, throttleKey{models.ThrottleScopeIP, ip})
+	}
+	return keys
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+	"hospital-management-system/pkg/utils"
+)
+
+var (
+	ErrInvalidAllergy    = errors.New("invalid allergy")
+	ErrInvalidProblem    = errors.New("invalid problem")
+	ErrInvalidMedication = errors.New("invalid medication")
+)
+
+// MedicalHistoryService manages a patient's allergies, problem list and
+// medication history.
+type MedicalHistoryService struct {
+	allergies   repository.AllergyRepository
+	problems    repository.ProblemRepository
+	medications repository.MedicationRepository
+	patientRepo repository.PatientRepository
+	audit       *AuditService
+	validator   *utils.Validator
+}
+
+func NewMedicalHistoryService(allergies repository.AllergyRepository, problems repository.ProblemRepository, medications repository.MedicationRepository, patientRepo repository.PatientRepository, audit *AuditService) *MedicalHistoryService {
+	return &MedicalHistoryService{
+		allergies:   allergies,
+		problems:    problems,
+		medications: medications,
+		patientRepo: patientRepo,
+		audit:       audit,
+		validator:   utils.NewValidator(),
+	}
+}
+
+// AllergySummary returns the patient's active allergies for display with
+// their record. It is not audited on its own: callers show it alongside a
+// patient read that already is.
+func (s *MedicalHistoryService) AllergySummary(patientID uint) (models.AllergySummary, error) {
+	active, err := s.allergies.FindActiveByPatient(patientID)
+	if err != nil {
+		return models.AllergySummary{}, err
+	}
+
+	summary := models.AllergySummary{Count: len(active), Active: active}
+	rank := map[string]int{models.AllergyMild: 1, models.AllergyModerate: 2, models.AllergySevere: 3}
+	for _, a := range active {
+		if rank[a.Severity] > rank[summary.HighestSeverity] {
+			summary.HighestSeverity = a.Severity
+		}
+	}
+	return summary, nil
+}
+
+func (s *MedicalHistoryService) GetAllergies(actor models.Actor, patientID uint) ([]models.Allergy, error) {
+	if err := s.readPatient(actor, patientID); err != nil {
+		return nil, err
+	}
+	return s.allergies.FindByPatient(patientID)
+}
+
+// AddAllergy records an allergy; status defaults to active.
+func (s *MedicalHistoryService) AddAllergy(actor models.Actor, allergy *models.Allergy) error {
+	if _, err := s.patientRepo.FindByID(uint(allergy.PatientID)); err != nil {
+		return err
+	}
+	if allergy.Status == "" {
+		allergy.Status = models.AllergyActive
+	}
+	allergy.RecordedBy = actor.UserID
+	if err := validateAllergy(allergy); err != nil {
+		return err
+	}
+
+	if err := s.allergies.Create(allergy); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, allergy.PatientID)
+}
+
+func (s *MedicalHistoryService) UpdateAllergy(actor models.Actor, allergy *models.Allergy) error {
+	existing, err := s.allergies.FindByID(uint(allergy.ID))
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != allergy.PatientID {
+		return sql.ErrNoRows
+	}
+	allergy.RecordedBy = existing.RecordedBy
+	allergy.CreatedAt = existing.CreatedAt
+	if allergy.Status == "" {
+		allergy.Status = existing.Status
+	}
+	if err := validateAllergy(allergy); err != nil {
+		return err
+	}
+
+	if err := s.allergies.Update(allergy); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, allergy.PatientID)
+}
+
+// DeleteAllergy removes an allergy entered in error. Allergies that no
+// longer apply should be marked inactive instead.
+func (s *MedicalHistoryService) DeleteAllergy(actor models.Actor, patientID, id uint) error {
+	existing, err := s.allergies.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != int(patientID) {
+		return sql.ErrNoRows
+	}
+	if err := s.allergies.Delete(id); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, existing.PatientID)
+}
+
+func (s *MedicalHistoryService) GetProblems(actor models.Actor, patientID uint) ([]models.Problem, error) {
+	if err := s.readPatient(actor, patientID); err != nil {
+		return nil, err
+	}
+	return s.problems.FindByPatient(patientID)
+}
+
+// AddProblem adds an ICD-10 coded entry to the problem list; status
+// defaults to active.
+func (s *MedicalHistoryService) AddProblem(actor models.Actor, problem *models.Problem) error {
+	if _, err := s.patientRepo.FindByID(uint(problem.PatientID)); err != nil {
+		return err
+	}
+	if problem.Status == "" {
+		problem.Status = models.ProblemActive
+	}
+	problem.RecordedBy = actor.UserID
+	if err := s.validateProblem(problem); err != nil {
+		return err
+	}
+
+	if err := s.problems.Create(problem); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, problem.PatientID)
+}
+
+func (s *MedicalHistoryService) UpdateProblem(actor models.Actor, problem *models.Problem) error {
+	existing, err := s.problems.FindByID(uint(problem.ID))
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != problem.PatientID {
+		return sql.ErrNoRows
+	}
+	problem.RecordedBy = existing.RecordedBy
+	problem.CreatedAt = existing.CreatedAt
+	if problem.Status == "" {
+		problem.Status = existing.Status
+	}
+	if err := s.validateProblem(problem); err != nil {
+		return err
+	}
+
+	if err := s.problems.Update(problem); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, problem.PatientID)
+}
+
+func (s *MedicalHistoryService) DeleteProblem(actor models.Actor, patientID, id uint) error {
+	existing, err := s.problems.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != int(patientID) {
+		return sql.ErrNoRows
+	}
+	if err := s.problems.Delete(id); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, existing.PatientID)
+}
+
+func (s *MedicalHistoryService) GetMedications(actor models.Actor, patientID uint) ([]models.Medication, error) {
+	if err := s.readPatient(actor, patientID); err != nil {
+		return nil, err
+	}
+	return s.medications.FindByPatient(patientID)
+}
+
+// AddMedication records a medication taken outside our prescriptions;
+// status defaults to active, or stopped when an end date is given.
+func (s *MedicalHistoryService) AddMedication(actor models.Actor, medication *models.Medication) error {
+	if _, err := s.patientRepo.FindByID(uint(medication.PatientID)); err != nil {
+		return err
+	}
+	if medication.Status == "" {
+		medication.Status = models.MedicationActive
+		if medication.EndDate != nil {
+			medication.Status = models.MedicationStopped
+		}
+	}
+	medication.RecordedBy = actor.UserID
+	if err := validateMedication(medication); err != nil {
+		return err
+	}
+
+	if err := s.medications.Create(medication); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditCreate, medication.PatientID)
+}
+
+func (s *MedicalHistoryService) UpdateMedication(actor models.Actor, medication *models.Medication) error {
+	existing, err := s.medications.FindByID(uint(medication.ID))
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != medication.PatientID {
+		return sql.ErrNoRows
+	}
+	medication.RecordedBy = existing.RecordedBy
+	medication.CreatedAt = existing.CreatedAt
+	if medication.Status == "" {
+		medication.Status = existing.Status
+	}
+	if err := validateMedication(medication); err != nil {
+		return err
+	}
+
+	if err := s.medications.Update(medication); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, medication.PatientID)
+}
+
+func (s *MedicalHistoryService) DeleteMedication(actor models.Actor, patientID, id uint) error {
+	existing, err := s.medications.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if existing.PatientID != int(patientID) {
+		return sql.ErrNoRows
+	}
+	if err := s.medications.Delete(id); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditUpdate, existing.PatientID)
+}
+
+// readPatient checks the patient exists and records the read
+func (s *MedicalHistoryService) readPatient(actor models.Actor, patientID uint) error {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return err
+	}
+	return s.audit.RecordAccess(actor, models.AuditRead, int(patientID))
+}
+
+func validateAllergy(a *models.Allergy) error {
+	a.Substance = strings.TrimSpace(a.Substance)
+	if a.Substance == "" {
+		return fmt.Errorf("%w: substance is required", ErrInvalidAllergy)
+	}
+	switch a.Severity {
+	case models.AllergyMild, models.AllergyModerate, models.AllergySevere:
+	default:
+		return fmt.Errorf("%w: severity must be mild, moderate or severe", ErrInvalidAllergy)
+	}
+	switch a.Status {
+	case models.AllergyActive, models.AllergyInactive:
+	default:
+		return fmt.Errorf("%w: status must be active or inactive", ErrInvalidAllergy)
+	}
+	return nil
+}
+
+func (s *MedicalHistoryService) validateProblem(p *models.Problem) error {
+	p.ICD10Code = strings.ToUpper(strings.TrimSpace(p.ICD10Code))
+	if !s.validator.IsValidICD10(p.ICD10Code) {
+		return fmt.Errorf("%w: %q is not an ICD-10 code", ErrInvalidProblem, p.ICD10Code)
+	}
+	if strings.TrimSpace(p.Description) == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidProblem)
+	}
+	switch p.Status {
+	case models.ProblemActive, models.ProblemResolved, models.ProblemInactive:
+	default:
+		return fmt.Errorf("%w: status must be active, resolved or inactive", ErrInvalidProblem)
+	}
+	return nil
+}
+
+func validateMedication(m *models.Medication) error {
+	m.Drug = strings.TrimSpace(m.Drug)
+	if m.Drug == "" {
+		return fmt.Errorf("%w: drug is required", ErrInvalidMedication)
+	}
+	if m.StartDate != nil && m.EndDate != nil && m.EndDate.Before(*m.StartDate) {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidMedication)
+	}
+	switch m.Status {
+	case models.MedicationActive, models.MedicationStopped:
+	default:
+		return fmt.Errorf("%w: status must be active or stopped", ErrInvalidMedication)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/pkg/utils"
+)
+
+const (
+	// mfaChallengeTTL is how long a user has to enter their code after the
+	// password was accepted
+	mfaChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts wrong codes end a challenge; the user has to start
+	// over with their password
+	maxMFAAttempts = 5
+	// recoveryCodeCount recovery codes are handed out at a time, each of
+	// recoveryCodeLength characters
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrMFARequired         = errors.New("multi-factor authentication required")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired MFA challenge")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnabled   = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnrolled      = errors.New("multi-factor authentication enrollment has not been started")
+	ErrMFANotEnabled       = errors.New("multi-factor authentication is not enabled")
+)
+
+// MFARequiredError is returned by LoginWithUser when the password was right
+// but the user still has to pass their second factor. Token identifies the
+// login to VerifyMFA and expires after ExpiresIn seconds.
+type MFARequiredError struct {
+	Token     string
+	ExpiresIn int64
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// VerifyMFA completes a login started by LoginWithUser. The code is either
+// the current code from the user's authenticator app or one of their
+// recovery codes, which is used up. Wrong codes count as failed logins and
+// are throttled like them.
+func (s *AuthService) VerifyMFA(mfaToken, code, clientIP string) (*models.User, *models.TokenPair, error) {
+	challenge, err := s.mfaRepo.FindChallengeByHash(utils.HashToken(mfaToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrInvalidMFAChallenge
+		}
+		return nil, nil, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		if err := s.mfaRepo.DeleteChallenge(challenge.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.userRepo.FindByID(int(challenge.UserID))
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	if err := s.throttle.Check(user.Username, clientIP); err != nil {
+		return nil, nil, err
+	}
+
+	// The attempt is counted before the code is checked, so that guesses
+	// sent in parallel cannot get past the limit
+	attempts, err := s.mfaRepo.IncrementChallengeAttempts(challenge.ID, maxMFAAttempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if err := s.mfaRepo.DeleteChallenge(challenge.ID); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, ErrInvalidMFAChallenge
+		}
+		return nil, nil, err
+	}
+	mfa, err := s.mfaRepo.FindByUser(user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// An administrator reset the user's MFA in the meantime
+			return nil, nil, ErrInvalidMFAChallenge
+		}
+		return nil, nil, err
+	}
+
+	ok, err := s.checkTOTP(mfa, code)
+	if err == nil && !ok && !isTOTPCode(code) {
+		ok, err = s.mfaRepo.UseRecoveryCode(user.ID, hashRecoveryCode(code))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		if attempts >= maxMFAAttempts {
+			if err := s.mfaRepo.DeleteChallenge(challenge.ID); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := s.throttle.Failure(user.Username, clientIP); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidMFACode
+	}
+
+	if err := s.mfaRepo.DeleteChallenge(challenge.ID); err != nil {
+		return nil, nil, err
+	}
+	return s.loginSucceeded(user)
+}
+
+// GetMFAStatus tells whether the user has MFA enabled and how many of their
+// recovery codes are left
+func (s *AuthService) GetMFAStatus(userID int64) (*models.MFAStatus, error) {
+	mfa, err := s.mfaRepo.FindByUser(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.MFAStatus{}, nil
+		}
+		return nil, err
+	}
+	if !mfa.Enabled {
+		return &models.MFAStatus{}, nil
+	}
+
+	remaining, err := s.mfaRepo.CountUnusedRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAStatus{Enabled: true, EnabledAt: mfa.EnabledAt, RecoveryCodesRemaining: remaining}, nil
+}
+
+// EnrollMFA generates a new TOTP secret for the user. MFA is not enforced
+// until the user confirms a code from their authenticator app with
+// ConfirmMFA; enrolling again before that replaces the secret.
+func (s *AuthService) EnrollMFA(userID int64) (*models.MFAEnrollment, error) {
+	enabled, err := s.mfaEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	user, err := s.userRepo.FindByID(int(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.Save(&models.UserMFA{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmMFA turns MFA on once the user proved their authenticator app
+// produces the right codes. It returns the user's recovery codes, which
+// are not stored in the clear and so cannot be shown again.
+func (s *AuthService) ConfirmMFA(userID int64, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.FindByUser(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	mfa.Enabled = true
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = step
+	if err := s.mfaRepo.Save(mfa); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(userID)
+}
+
+// RegenerateRecoveryCodes replaces all of the user's recovery codes, used
+// or not. It takes a current authenticator code, so that a stolen session
+// alone cannot be turned into a way past MFA.
+func (s *AuthService) RegenerateRecoveryCodes(userID int64, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.FindByUser(userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	ok, err := s.checkTOTP(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	return s.newRecoveryCodes(userID)
+}
+
+// ResetMFA removes a user's second factor, e.g. after they lost their
+// phone. They log in with their password alone until they enroll again.
+// Their sessions end, in case whoever has the phone also got in.
+func (s *AuthService) ResetMFA(actor models.Actor, userID int64) error {
+	user, err := s.userRepo.FindByID(int(userID))
+	if err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Delete(userID); err != nil {
+		return err
+	}
+	if err := s.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+
+	err = s.audit.RecordAccountEvent(actor, models.AuditMFAReset, map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+	})
+	if err != nil {
+		return auditError(err)
+	}
+	return nil
+}
+
+func (s *AuthService) mfaEnabled(userID int64) (bool, error) {
+	mfa, err := s.mfaRepo.FindByUser(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return mfa.Enabled, nil
+}
+
+// startMFAChallenge records a login waiting for its second factor
+func (s *AuthService) startMFAChallenge(user *models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.mfaRepo.CreateChallenge(&models.MFAChallenge{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return &MFARequiredError{Token: token, ExpiresIn: int64(mfaChallengeTTL / time.Second)}
+}
+
+// checkTOTP accepts a valid authenticator code once; a code for a time
+// step that was already used is refused
+func (s *AuthService) checkTOTP(mfa *models.UserMFA, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+	if !ok || step <= mfa.LastUsedStep {
+		return false, nil
+	}
+	return s.mfaRepo.UseStep(mfa.UserID, step)
+}
+
+// newRecoveryCodes replaces the user's recovery codes with fresh ones and
+// returns them, formatted for reading, e.g. "ABCDE-FGHJK"
+func (s *AuthService) newRecoveryCodes(userID int64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(b)[:recoveryCodeLength]
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code the way it is stored. Recovery
+// codes are random enough that a fast hash will do, as for refresh tokens;
+// case, dashes and spaces do not matter.
+func hashRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return utils.HashToken(code)
+}
+
+// isTOTPCode reports whether code looks like an authenticator code rather
+// than a recovery code
+func isTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != utils.TOTPDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
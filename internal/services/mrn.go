@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"hospital-management-system/internal/domain/repository"
+	"hospital-management-system/pkg/utils"
+)
+
+// mrnSequenceDigits is the zero-padded width of the per-facility sequence
+const mrnSequenceDigits = 7
+
+// MRNGenerator issues Medical Record Numbers of the form
+// <prefix><facility><sequence><check digit>, e.g. MRN0100000427. The
+// sequence is kept per facility and the check digit is Luhn over the
+// facility and sequence digits.
+type MRNGenerator struct {
+	repo     repository.IdentifierRepository
+	prefix   string
+	facility string
+}
+
+func NewMRNGenerator(repo repository.IdentifierRepository, prefix, facility string) *MRNGenerator {
+	return &MRNGenerator{repo: repo, prefix: prefix, facility: facility}
+}
+
+// Next reserves the facility's next sequence number and formats it as an MRN.
+func (g *MRNGenerator) Next() (string, error) {
+	seq, err := g.repo.NextMRNSequence(g.facility)
+	if err != nil {
+		return "", err
+	}
+	return FormatMRN(g.prefix, g.facility, seq), nil
+}
+
+// FormatMRN builds the MRN for a facility's sequence number.
+func FormatMRN(prefix, facility string, seq int64) string {
+	body := fmt.Sprintf("%s%0*d", facility, mrnSequenceDigits, seq)
+	return fmt.Sprintf("%s%s%d", prefix, body, utils.LuhnCheckDigit(body))
+}
+
+// IsValidMRN reports whether mrn carries prefix and a correct check digit.
+func IsValidMRN(prefix, mrn string) bool {
+	if !strings.HasPrefix(mrn, prefix) {
+		return false
+	}
+	body := strings.TrimPrefix(mrn, prefix)
+	for _, c := range body {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return utils.IsValidLuhn(body)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// Notifier delivers messages to users outside the application, e.g. by
+// email or SMS, for things they must receive without being logged in.
+type Notifier interface {
+	NotifyPasswordReset(user *models.User, token string, expiresAt time.Time) error
+}
+
+// NotifierLog selects the LogNotifier in NewNotifier
+const NotifierLog = "log"
+
+// NewNotifier returns the notifier of the given kind. An empty kind means
+// no notifier, which is returned as nil.
+func NewNotifier(kind string) (Notifier, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case NotifierLog:
+		return NewLogNotifier(log.Default()), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+// LogNotifier writes notifications to a log instead of delivering them. It
+// is meant for development: the log ends up holding live reset tokens, so
+// production should wire in a notifier that reaches the users.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) NotifyPasswordReset(user *models.User, token string, expiresAt time.Time) error {
+	n.logger.Printf("password reset for %s (user %d): token %s, valid until %s",
+		user.Username, user.ID, token, expiresAt.UTC().Format(time.RFC3339))
+	return nil
+}
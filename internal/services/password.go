@@ -0,0 +1,187 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+	"hospital-management-system/pkg/utils"
+)
+
+var (
+	ErrWrongPassword     = errors.New("current password is incorrect")
+	ErrWeakPassword      = errors.New("password must be at least 8 characters with upper, lower, number and special character")
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrNoNotifier        = errors.New("password reset tokens cannot be delivered: no notifier is configured")
+)
+
+// PasswordService changes users' passwords, either knowing the current one
+// or with a reset token an administrator had sent to them
+type PasswordService struct {
+	userRepo  repository.UserRepository
+	resetRepo repository.PasswordResetRepository
+	auth      *AuthService
+	throttle  *LoginThrottle
+	audit     *AuditService
+	// notifier delivers reset tokens; it is nil when none is configured
+	notifier Notifier
+	// resetTTL is how long a reset token works after it was issued
+	resetTTL time.Duration
+}
+
+func NewPasswordService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository, auth *AuthService,
+	throttle *LoginThrottle, audit *AuditService, notifier Notifier, resetTTL time.Duration) *PasswordService {
+	return &PasswordService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		auth:      auth,
+		throttle:  throttle,
+		audit:     audit,
+		notifier:  notifier,
+		resetTTL:  resetTTL,
+	}
+}
+
+// ChangePassword sets a new password for the logged-in user, who has to
+// give their current one. Wrong current passwords count as failed logins,
+// so that a stolen session cannot be used to guess the password. Every
+// other session of the user ends; the one making the change stays.
+func (s *PasswordService) ChangePassword(actor models.Actor, accessTokenID, clientIP, currentPassword, newPassword string) error {
+	if !utils.NewValidator().IsPasswordStrong(newPassword) {
+		return ErrWeakPassword
+	}
+	if err := s.throttle.Check(actor.Username, clientIP); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(int(actor.UserID))
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(currentPassword, user.Password) {
+		if err := s.throttle.Failure(actor.Username, clientIP); err != nil {
+			return err
+		}
+		return ErrWrongPassword
+	}
+
+	if err := s.setPassword(user, newPassword); err != nil {
+		return err
+	}
+	if err := s.auth.RevokeOtherSessions(user.ID, accessTokenID); err != nil {
+		return err
+	}
+
+	return s.recordPasswordChange(actor, user, "current_password")
+}
+
+// IssueResetToken sends the user a single-use token to set a new password
+// with, e.g. when they forgot theirs. Earlier tokens stop working. Without
+// a notifier there is no way to send the token, so none is issued.
+func (s *PasswordService) IssueResetToken(actor models.Actor, userID int64) (*models.PasswordResetToken, error) {
+	if s.notifier == nil {
+		return nil, ErrNoNotifier
+	}
+
+	user, err := s.userRepo.FindByID(int(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	reset := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		IssuedBy:  actor.UserID,
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err := s.resetRepo.Create(reset); err != nil {
+		return nil, err
+	}
+
+	if err := s.notifier.NotifyPasswordReset(user, token, reset.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.RecordAccountEvent(actor, models.AuditPasswordReset, map[string]interface{}{
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"expires_at": reset.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return nil, auditError(err)
+	}
+	return reset, nil
+}
+
+// ResetPassword sets a new password with a reset token and uses the token
+// up. Every session of the user ends.
+func (s *PasswordService) ResetPassword(token, newPassword string) error {
+	if !utils.NewValidator().IsPasswordStrong(newPassword) {
+		// Checked first, so that a weak password does not spend the token
+		return ErrWeakPassword
+	}
+
+	reset, err := s.resetRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(int(reset.UserID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	used, err := s.resetRepo.MarkUsed(reset.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		// Another request got there first
+		return ErrInvalidResetToken
+	}
+
+	if err := s.setPassword(user, newPassword); err != nil {
+		return err
+	}
+	if err := s.auth.RevokeUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	actor := models.Actor{UserID: user.ID, Username: user.Username, Role: user.Role}
+	return s.recordPasswordChange(actor, user, "reset_token")
+}
+
+func (s *PasswordService) setPassword(user *models.User, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdatePassword(user.ID, hash)
+}
+
+func (s *PasswordService) recordPasswordChange(actor models.Actor, user *models.User, via string) error {
+	err := s.audit.RecordAccountEvent(actor, models.AuditPasswordChange, map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"via":      via,
+	})
+	if err != nil {
+		return auditError(err)
+	}
+	return nil
+}
@@ -1,51 +1,272 @@
 package services
 
 import (
+    "encoding/base64"
+    "encoding/json"
     "errors"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "strings"
+    "time"
+
     "hospital-management-system/internal/domain/models"
     "hospital-management-system/internal/domain/repository"
+    "hospital-management-system/pkg/utils"
+)
+
+const (
+    defaultPageSize = 50
+    maxPageSize     = 200
+)
+
+const (
+    defaultSearchLimit = 20
+    maxSearchLimit     = 50
 )
 
+// duplicateThreshold is the ScoreDuplicate above which a new patient is
+// flagged as a possible duplicate
+const duplicateThreshold = 0.6
+
+var (
+    ErrInvalidPatientRecord = errors.New("invalid patient record")
+    ErrInvalidListQuery     = errors.New("invalid list query")
+    ErrInvalidMerge         = errors.New("invalid merge")
+    // ErrPatientInUse is returned when deleting a patient whose clinical or
+    // financial records must be kept.
+    ErrPatientInUse         = errors.New("patient cannot be deleted once they have records")
+)
+
+// DuplicatePatientError is returned by CreatePatient when the new patient
+// matches existing records.
+type DuplicatePatientError struct {
+    Candidates []models.PatientMatch
+}
+
+func (e *DuplicatePatientError) Error() string {
+    return "possible duplicate of an existing patient"
+}
+
+// PatientService manages patient records. Every call is recorded in the
+// audit trail against the acting user.
 type PatientService struct {
-    repo repository.PatientRepository
+    repo        repository.PatientRepository
+    identifiers repository.IdentifierRepository
+    mrn         *MRNGenerator
+    audit       *AuditService
+    validator   *utils.Validator
+}
+
+func NewPatientService(repo repository.PatientRepository, identifiers repository.IdentifierRepository, mrn *MRNGenerator, audit *AuditService) *PatientService {
+    return &PatientService{repo: repo, identifiers: identifiers, mrn: mrn, audit: audit, validator: utils.NewValidator()}
 }
 
-func NewPatientService(repo repository.PatientRepository) *PatientService {
-    return &PatientService{repo: repo}
+// CreatePatient registers a new patient. Unless allowDuplicate is set, it
+// refuses with a *DuplicatePatientError when the patient looks like someone
+// already on record.
+func (s *PatientService) CreatePatient(actor models.Actor, patient *models.Patient, allowDuplicate bool) error {
+    if err := validatePatient(patient); err != nil {
+        return err
+    }
+
+    if !allowDuplicate {
+        candidates, err := s.FindDuplicateCandidates(patient)
+        if err != nil {
+            return err
+        }
+        if len(candidates) > 0 {
+            return &DuplicatePatientError{Candidates: candidates}
+        }
+    }
+
+    mrn, err := s.mrn.Next()
+    if err != nil {
+        return err
+    }
+    patient.MRN = mrn
+
+    if err := s.repo.Create(patient); err != nil {
+        return err
+    }
+    s.recordWrite(actor, models.AuditCreate, patient.ID, nil, patient)
+    return nil
 }
 
-func (s *PatientService) CreatePatient(patient *models.Patient) error {
+// validatePatient checks the fields every patient record must have
+func validatePatient(patient *models.Patient) error {
     if patient.FirstName == "" || patient.LastName == "" {
-        return errors.New("first name and last name are required")
+        return fmt.Errorf("%w: first name and last name are required", ErrInvalidPatientRecord)
     }
-    
+
     if patient.Email == "" {
-        return errors.New("email is required")
+        return fmt.Errorf("%w: email is required", ErrInvalidPatientRecord)
+    }
+    return nil
+}
+
+// FindDuplicateCandidates returns existing patients likely to be the same
+// person as patient, best match first.
+func (s *PatientService) FindDuplicateCandidates(patient *models.Patient) ([]models.PatientMatch, error) {
+    matches, err := s.repo.FindDuplicateCandidates(patient)
+    if err != nil {
+        return nil, err
+    }
+
+    var candidates []models.PatientMatch
+    for _, m := range matches {
+        m.Score = ScoreDuplicate(m)
+        if m.Score < duplicateThreshold {
+            continue
+        }
+        m.MatchedOn = []string{}
+        if m.Similarity >= 0.5 || m.Metaphone || m.Soundex {
+            m.MatchedOn = append(m.MatchedOn, "name")
+        }
+        if m.DOBMatch {
+            m.MatchedOn = append(m.MatchedOn, "dob")
+        }
+        if m.PhoneMatch {
+            m.MatchedOn = append(m.MatchedOn, "phone")
+        }
+        if m.EmailMatch {
+            m.MatchedOn = append(m.MatchedOn, "email")
+        }
+        candidates = append(candidates, m)
+    }
+
+    sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+    return candidates, nil
+}
+
+// ScoreDuplicate weighs how likely a candidate is to be the same person:
+// name agreement counts most, then date of birth, then contact details.
+func ScoreDuplicate(m models.PatientMatch) float64 {
+    name := m.Similarity
+    if m.Metaphone && name < 0.9 {
+        name = 0.9
+    }
+    if m.Soundex && name < 0.8 {
+        name = 0.8
+    }
+
+    score := 0.4 * name
+    if m.DOBMatch {
+        score += 0.3
+    }
+    if m.PhoneMatch {
+        score += 0.15
+    }
+    if m.EmailMatch {
+        score += 0.15
+    }
+    return math.Round(score*1000) / 1000
+}
+
+// MergePatients folds the duplicate record into the survivor: blank
+// survivor fields are filled from the duplicate, every record pointing at
+// the duplicate is moved to the survivor, and the duplicate is deleted.
+func (s *PatientService) MergePatients(actor models.Actor, survivorID, duplicateID uint) (*models.Patient, error) {
+    if survivorID == duplicateID {
+        return nil, fmt.Errorf("%w: a patient cannot be merged into itself", ErrInvalidMerge)
+    }
+
+    survivor, err := s.repo.FindByID(survivorID)
+    if err != nil {
+        return nil, err
+    }
+    duplicate, err := s.repo.FindByID(duplicateID)
+    if err != nil {
+        return nil, err
+    }
+
+    merged := *survivor
+    fillBlank := func(into *string, from string) {
+        if *into == "" {
+            *into = from
+        }
     }
-    
-    return s.repo.Create(patient)
+    fillBlank(&merged.Gender, duplicate.Gender)
+    fillBlank(&merged.Phone, duplicate.Phone)
+    fillBlank(&merged.Email, duplicate.Email)
+    fillBlank(&merged.Address, duplicate.Address)
+
+    snapshot, err := json.Marshal(duplicate)
+    if err != nil {
+        return nil, err
+    }
+    merge := &models.PatientMerge{
+        SurvivorID:        survivor.ID,
+        DuplicateID:       duplicate.ID,
+        DuplicateSnapshot: snapshot,
+        MergedBy:          actor.UserID,
+    }
+    if err := s.repo.Merge(&merged, duplicateID, merge); err != nil {
+        if errors.Is(err, repository.ErrPatientAdmitted) {
+            return nil, fmt.Errorf("%w: both patients are admitted; discharge one admission first", ErrInvalidMerge)
+        }
+        return nil, err
+    }
+
+    s.recordWrite(actor, models.AuditMerge, duplicate.ID, duplicate, nil)
+    s.recordWrite(actor, models.AuditMerge, merged.ID, survivor, &merged)
+    return &merged, nil
+}
+
+// GetMergeHistory lists the records that were merged into a patient.
+func (s *PatientService) GetMergeHistory(actor models.Actor, survivorID uint) ([]models.PatientMerge, error) {
+    if _, err := s.repo.FindByID(survivorID); err != nil {
+        return nil, err
+    }
+    merges, err := s.repo.FindMerges(survivorID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.record(actor, models.AuditRead, int(survivorID), nil, nil); err != nil {
+        return nil, err
+    }
+    return merges, nil
 }
 
-func (s *PatientService) GetPatientByID(id uint) (*models.Patient, error) {
+func (s *PatientService) GetPatientByID(actor models.Actor, id uint) (*models.Patient, error) {
     patient, err := s.repo.FindByID(id)
     if err != nil {
         return nil, err
     }
+    if err := s.record(actor, models.AuditRead, patient.ID, nil, nil); err != nil {
+        return nil, err
+    }
     return patient, nil
 }
 
-func (s *PatientService) UpdatePatient(patient *models.Patient) error {
+// UpdatePatient saves the patient's demographics and returns the patient
+// as stored.
+func (s *PatientService) UpdatePatient(actor models.Actor, patient *models.Patient) (*models.Patient, error) {
+    if err := validatePatient(patient); err != nil {
+        return nil, err
+    }
     existingPatient, err := s.repo.FindByID(uint(patient.ID))
     if err != nil {
-        return err
+        return nil, err
     }
     if existingPatient == nil {
-        return errors.New("patient not found")
+        return nil, errors.New("patient not found")
+    }
+    // The MRN is assigned once and never edited
+    patient.MRN = existingPatient.MRN
+    if err := s.repo.Update(patient); err != nil {
+        return nil, err
+    }
+    saved, err := s.repo.FindByID(uint(patient.ID))
+    if err != nil {
+        return nil, err
     }
-    return s.repo.Update(patient)
+    s.recordWrite(actor, models.AuditUpdate, patient.ID, existingPatient, saved)
+    return saved, nil
 }
 
-func (s *PatientService) DeletePatient(id uint) error {
+func (s *PatientService) DeletePatient(actor models.Actor, id uint) error {
     existingPatient, err := s.repo.FindByID(id)
     if err != nil {
         return err
@@ -53,13 +274,207 @@ func (s *PatientService) DeletePatient(id uint) error {
     if existingPatient == nil {
         return errors.New("patient not found")
     }
-    return s.repo.Delete(id)
+    if err := s.repo.Delete(id); err != nil {
+        if errors.Is(err, repository.ErrPatientHasRecords) {
+            return fmt.Errorf("%w: %v", ErrPatientInUse, err)
+        }
+        return err
+    }
+    s.recordWrite(actor, models.AuditDelete, existingPatient.ID, existingPatient, nil)
+    return nil
 }
 
-func (s *PatientService) GetAllPatients() ([]models.Patient, error) {
-    patients, err := s.repo.FindAll()
+// ListPatients returns one page of patients. cursor is the NextCursor of the
+// previous page, or empty for the first page.
+func (s *PatientService) ListPatients(actor models.Actor, query models.PatientListQuery, cursor string) (*models.PatientPage, error) {
+    if err := normalizePatientSort(&query); err != nil {
+        return nil, err
+    }
+
+    if query.Limit <= 0 {
+        query.Limit = defaultPageSize
+    }
+    if query.Limit > maxPageSize {
+        query.Limit = maxPageSize
+    }
+
+    if cursor != "" {
+        after, err := decodePatientCursor(cursor)
+        if err != nil || after.SortBy != query.SortBy {
+            return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidListQuery)
+        }
+        query.After = after
+    }
+
+    // Fetch one extra row to learn whether there is a next page
+    limit := query.Limit
+    query.Limit++
+    patients, err := s.repo.List(query)
     if err != nil {
         return nil, err
     }
-    return patients, nil
+
+    page := &models.PatientPage{Data: patients, Limit: limit}
+    if page.Data == nil {
+        page.Data = []models.Patient{}
+    }
+    if len(patients) > limit {
+        page.Data = patients[:limit]
+        page.NextCursor = encodePatientCursor(query.SortBy, page.Data[limit-1])
+    }
+
+    if err := s.record(actor, models.AuditList, 0, nil, nil); err != nil {
+        return nil, err
+    }
+    return page, nil
+}
+
+// normalizePatientSort defaults a listing to newest first and checks its
+// sort field
+func normalizePatientSort(query *models.PatientListQuery) error {
+    if query.SortBy == "" {
+        query.SortBy = models.PatientSortCreatedAt
+        query.SortDesc = true
+    }
+    switch query.SortBy {
+    case models.PatientSortCreatedAt, models.PatientSortLastName, models.PatientSortDOB:
+        return nil
+    default:
+        return fmt.Errorf("%w: unknown sort field %q", ErrInvalidListQuery, query.SortBy)
+    }
+}
+
+// SearchPatients finds patients by approximate or phonetic name, or exact
+// phone number or email, ranked best match first.
+func (s *PatientService) SearchPatients(actor models.Actor, q string, limit int) ([]models.PatientMatch, error) {
+    q = strings.TrimSpace(q)
+    if len(q) < 2 {
+        return nil, fmt.Errorf("%w: search text must be at least 2 characters", ErrInvalidListQuery)
+    }
+    if limit <= 0 {
+        limit = defaultSearchLimit
+    }
+    if limit > maxSearchLimit {
+        limit = maxSearchLimit
+    }
+
+    // Over-fetch so that re-ranking can promote phonetic and exact matches
+    matches, err := s.repo.Search(q, limit*3)
+    if err != nil {
+        return nil, err
+    }
+
+    matches = RankPatientMatches(matches)
+    if matches == nil {
+        matches = []models.PatientMatch{}
+    }
+    if len(matches) > limit {
+        matches = matches[:limit]
+    }
+
+    if err := s.record(actor, models.AuditSearch, 0, nil, nil); err != nil {
+        return nil, err
+    }
+    return matches, nil
+}
+
+// RankPatientMatches scores each match from its signals and sorts them best
+// first. Exact phone or email matches score 1; name matches are weighted
+// towards trigram similarity, with phonetic agreement as a bonus.
+func RankPatientMatches(matches []models.PatientMatch) []models.PatientMatch {
+    for i := range matches {
+        m := &matches[i]
+        m.MatchedOn = []string{}
+        if m.PhoneMatch {
+            m.MatchedOn = append(m.MatchedOn, "phone")
+        }
+        if m.EmailMatch {
+            m.MatchedOn = append(m.MatchedOn, "email")
+        }
+        if m.Similarity >= 0.3 {
+            m.MatchedOn = append(m.MatchedOn, "name")
+        }
+        if m.Metaphone {
+            m.MatchedOn = append(m.MatchedOn, "metaphone")
+        }
+        if m.Soundex {
+            m.MatchedOn = append(m.MatchedOn, "soundex")
+        }
+
+        if m.PhoneMatch || m.EmailMatch {
+            m.Score = 1
+            continue
+        }
+        score := 0.6 * m.Similarity
+        if m.Metaphone {
+            score += 0.25
+        }
+        if m.Soundex {
+            score += 0.15
+        }
+        m.Score = math.Round(score*1000) / 1000
+    }
+
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].Score != matches[j].Score {
+            return matches[i].Score > matches[j].Score
+        }
+        return matches[i].Patient.ID < matches[j].Patient.ID
+    })
+    return matches
+}
+
+// checkExists returns sql.ErrNoRows if there is no patient with the ID,
+// without recording an access
+func (s *PatientService) checkExists(id uint) error {
+    _, err := s.repo.FindByID(id)
+    return err
+}
+
+func (s *PatientService) record(actor models.Actor, action string, patientID int, before, after *models.Patient) error {
+    if err := s.audit.RecordPatientAccess(actor, action, patientID, before, after); err != nil {
+        return auditError(err)
+    }
+    return nil
+}
+
+// recordWrite records a change that has already been committed. It is too
+// late to refuse the change, so a failure to record it is logged instead of
+// reporting the write as failed.
+func (s *PatientService) recordWrite(actor models.Actor, action string, patientID int, before, after *models.Patient) {
+    if err := s.record(actor, action, patientID, before, after); err != nil {
+        log.Printf("Failed to audit %s of patient %d by user %d: %v", action, patientID, actor.UserID, err)
+    }
+}
+
+// encodePatientCursor builds an opaque cursor pointing just past patient
+func encodePatientCursor(sortBy string, patient models.Patient) string {
+    data, _ := json.Marshal(patientCursor(sortBy, patient))
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// patientCursor points just past patient in a listing sorted by sortBy
+func patientCursor(sortBy string, patient models.Patient) *models.PatientCursor {
+    cursor := &models.PatientCursor{SortBy: sortBy, ID: patient.ID}
+    switch sortBy {
+    case models.PatientSortLastName:
+        cursor.SortValue = patient.LastName
+    case models.PatientSortDOB:
+        cursor.SortValue = patient.DOB.Format("2006-01-02")
+    default:
+        cursor.SortValue = patient.CreatedAt.Format(time.RFC3339Nano)
+    }
+    return cursor
+}
+
+func decodePatientCursor(cursor string) (*models.PatientCursor, error) {
+    data, err := base64.RawURLEncoding.DecodeString(cursor)
+    if err != nil {
+        return nil, err
+    }
+    var decoded models.PatientCursor
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        return nil, err
+    }
+    return &decoded, nil
 }
\ No newline at end of file
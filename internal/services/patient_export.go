@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"hospital-management-system/internal/domain/models"
+)
+
+// exportPageSize is how many patients are read from the database at a time
+// when exporting
+const exportPageSize = 500
+
+// ExportPatients passes every patient matching query's filter to fn, in the
+// query's sort order, a page at a time, so that a listing of any size can be
+// streamed. The query's cursor and limit are ignored. The export is recorded
+// in the audit trail before anything is read.
+func (s *PatientService) ExportPatients(ctx context.Context, actor models.Actor, query models.PatientListQuery, fn func([]models.Patient) error) error {
+	if err := normalizePatientSort(&query); err != nil {
+		return err
+	}
+	if err := s.record(actor, models.AuditExport, 0, nil, nil); err != nil {
+		return err
+	}
+	query.After = nil
+	return s.eachPatientPage(ctx, query, fn)
+}
+
+// eachPatientPage calls fn with every patient matching query, a page at a
+// time, stopping early if ctx is cancelled
+func (s *PatientService) eachPatientPage(ctx context.Context, query models.PatientListQuery, fn func([]models.Patient) error) error {
+	query.Limit = exportPageSize
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		patients, err := s.repo.List(query)
+		if err != nil {
+			return err
+		}
+		if len(patients) > 0 {
+			if err := fn(patients); err != nil {
+				return err
+			}
+		}
+		if len(patients) < exportPageSize {
+			return nil
+		}
+		query.After = patientCursor(query.SortBy, patients[len(patients)-1])
+	}
+}
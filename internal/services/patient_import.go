@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/pkg/xlsx"
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// Import file formats
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+)
+
+// maxImportRows bounds the data rows in one import file
+const maxImportRows = 10000
+
+// importFieldWidths are the widths of the patients columns the fields are
+// stored in, checked up front so that one long value cannot fail the whole
+// import transaction
+var importFieldWidths = map[string]int{
+	models.ImportFieldFirstName: 100,
+	models.ImportFieldLastName:  100,
+	models.ImportFieldGender:    10,
+	models.ImportFieldPhone:     15,
+	models.ImportFieldEmail:     100,
+}
+
+// excelEpoch is day 0 of the date serial numbers spreadsheets store dates as
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// PatientImportOptions controls an import. Mapping maps patient fields
+// (models.ImportFields) to the header of the column holding them; without
+// it, columns are matched to fields by header name. AllowDuplicates accepts
+// rows that look like patients already on record, as CreatePatient's
+// allowDuplicate does.
+type PatientImportOptions struct {
+	Mapping         map[string]string
+	DryRun          bool
+	AllowDuplicates bool
+}
+
+// ImportPatients reads patients from a CSV or XLSX file whose first row is
+// a header, and checks every row with the rules CreatePatient applies plus a
+// well-formed email. Unless opts.DryRun is set, the valid rows are inserted
+// in a single transaction and the invalid ones are skipped. The report says
+// what happened to each row.
+func (s *PatientService) ImportPatients(actor models.Actor, format string, data []byte, opts PatientImportOptions) (*models.PatientImportReport, error) {
+	rows, err := readImportRows(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	columns, err := importColumns(rows[0].values, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.PatientImportReport{DryRun: opts.DryRun, Rows: []models.PatientImportRow{}}
+	var valid []*models.Patient
+	var validRows []int // index into report.Rows of each valid patient
+	seen := map[string]int{}
+	for _, r := range rows[1:] {
+		if blankRow(r.values) {
+			continue
+		}
+		if report.Total == maxImportRows {
+			return nil, fmt.Errorf("%w: files are limited to %d patients", ErrInvalidImport, maxImportRows)
+		}
+		report.Total++
+
+		row := models.PatientImportRow{Row: r.line}
+		patient, errs := parseImportRow(format, r.values, columns)
+		errs = append(errs, s.checkImportPatient(patient)...)
+		if len(errs) == 0 && !opts.AllowDuplicates {
+			dup, err := s.importDuplicate(patient, seen, row.Row)
+			if err != nil {
+				return nil, err
+			}
+			if dup != "" {
+				errs = append(errs, dup)
+			}
+		}
+
+		if len(errs) > 0 {
+			row.Status, row.Errors = models.ImportRowInvalid, errs
+			report.Invalid++
+		} else {
+			row.Status = models.ImportRowValid
+			report.Valid++
+			valid = append(valid, patient)
+			validRows = append(validRows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if opts.DryRun || len(valid) == 0 {
+		return report, nil
+	}
+
+	for _, patient := range valid {
+		if patient.MRN, err = s.mrn.Next(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.CreateMany(valid); err != nil {
+		return nil, err
+	}
+	for i, patient := range valid {
+		row := &report.Rows[validRows[i]]
+		row.Status, row.PatientID, row.MRN = models.ImportRowImported, patient.ID, patient.MRN
+		report.Imported++
+		s.recordWrite(actor, models.AuditCreate, patient.ID, nil, patient)
+	}
+	return report, nil
+}
+
+// checkImportPatient applies the CreatePatient rules to an imported
+// patient, along with the checks the database would otherwise make
+func (s *PatientService) checkImportPatient(patient *models.Patient) []string {
+	var errs []string
+	if err := validatePatient(patient); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if patient.Email != "" && !s.validator.IsValidEmail(patient.Email) {
+		errs = append(errs, "email is not a valid email address")
+	}
+
+	values := map[string]string{
+		models.ImportFieldFirstName: patient.FirstName,
+		models.ImportFieldLastName:  patient.LastName,
+		models.ImportFieldGender:    patient.Gender,
+		models.ImportFieldPhone:     patient.Phone,
+		models.ImportFieldEmail:     patient.Email,
+	}
+	for _, field := range models.ImportFields {
+		if width, ok := importFieldWidths[field]; ok && len([]rune(values[field])) > width {
+			errs = append(errs, fmt.Sprintf("%s is longer than %d characters", field, width))
+		}
+	}
+	return errs
+}
+
+// importDuplicate describes why patient looks like a patient already on
+// record or one on an earlier row of the file, or returns "" if it does not
+func (s *PatientService) importDuplicate(patient *models.Patient, seen map[string]int, row int) (string, error) {
+	key := strings.ToLower(patient.FirstName + "|" + patient.LastName + "|" + patient.DOB.Format("2006-01-02"))
+	if earlier, ok := seen[key]; ok {
+		return fmt.Sprintf("same patient as row %d", earlier), nil
+	}
+	seen[key] = row
+
+	candidates, err := s.FindDuplicateCandidates(patient)
+	if err != nil || len(candidates) == 0 {
+		return "", err
+	}
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = strconv.Itoa(candidate.Patient.ID)
+	}
+	return "possible duplicate of patient " + strings.Join(ids, ", "), nil
+}
+
+// importColumns resolves each mapped field to its column in header. Without
+// a mapping, every header naming a field is used.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, dup := index[name]; name != "" && !dup {
+			index[name] = i
+		}
+	}
+
+	columns := map[string]int{}
+	if len(mapping) == 0 {
+		for _, field := range models.ImportFields {
+			if i, ok := index[field]; ok {
+				columns[field] = i
+			}
+		}
+	}
+	for field, name := range mapping {
+		known := false
+		for _, f := range models.ImportFields {
+			known = known || field == f
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: cannot map a column to unknown field %q", ErrInvalidImport, field)
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("%w: no column named %q for %s", ErrInvalidImport, name, field)
+		}
+		columns[field] = i
+	}
+
+	for _, field := range []string{models.ImportFieldFirstName, models.ImportFieldLastName, models.ImportFieldEmail} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("%w: no column for %s", ErrInvalidImport, field)
+		}
+	}
+	return columns, nil
+}
+
+// parseImportRow builds a patient from a row's values, reporting values it
+// cannot read
+func parseImportRow(format string, values []string, columns map[string]int) (*models.Patient, []string) {
+	get := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+
+	patient := &models.Patient{
+		FirstName: get(models.ImportFieldFirstName),
+		LastName:  get(models.ImportFieldLastName),
+		Gender:    get(models.ImportFieldGender),
+		Phone:     get(models.ImportFieldPhone),
+		Email:     get(models.ImportFieldEmail),
+		Address:   get(models.ImportFieldAddress),
+	}
+
+	var errs []string
+	if v := get(models.ImportFieldDOB); v != "" {
+		dob, err := parseImportDate(format, v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("dob %q is not a date such as 1980-04-23", v))
+		}
+		patient.DOB = dob
+	}
+	return patient, errs
+}
+
+// parseImportDate reads a YYYY-MM-DD date or, from a workbook, a date
+// serial number
+func parseImportDate(format, v string) (time.Time, error) {
+	if format == ImportFormatXLSX {
+		if serial, err := strconv.ParseFloat(v, 64); err == nil {
+			if serial < 1 || serial > 2958465 {
+				return time.Time{}, fmt.Errorf("date serial %v is out of range", serial)
+			}
+			return excelEpoch.AddDate(0, 0, int(math.Floor(serial))), nil
+		}
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// importRow is one row of an import file and the line it starts on
+type importRow struct {
+	line   int
+	values []string
+}
+
+func readImportRows(format string, data []byte) ([]importRow, error) {
+	var rows []importRow
+	switch format {
+	case ImportFormatCSV:
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+			}
+			line, _ := r.FieldPos(0)
+			rows = append(rows, importRow{line: line, values: record})
+		}
+	case ImportFormatXLSX:
+		sheet, err := xlsx.ReadFirstSheet(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		for i, values := range sheet {
+			rows = append(rows, importRow{line: i + 1, values: values})
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported file format %q", ErrInvalidImport, format)
+	}
+}
+
+func blankRow(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
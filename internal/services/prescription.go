@@ -0,0 +1,281 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidPrescription = errors.New("invalid prescription")
+	ErrNotPrescriber       = errors.New("only doctors can issue prescriptions")
+)
+
+// PrescriptionWarningsError is returned by CreatePrescription when the new
+// prescription interacts with the patient's current medication or matches
+// one of their allergies.
+type PrescriptionWarningsError struct {
+	Warnings []models.PrescriptionWarning
+}
+
+func (e *PrescriptionWarningsError) Error() string {
+	return "prescription has safety warnings"
+}
+
+// PrescriptionService issues prescriptions after checking them against the
+// local drug-interaction table and the patient's recorded allergies.
+type PrescriptionService struct {
+	repo        repository.PrescriptionRepository
+	drugs       repository.DrugRepository
+	allergies   repository.AllergyRepository
+	medications repository.MedicationRepository
+	patientRepo repository.PatientRepository
+	audit       *AuditService
+}
+
+func NewPrescriptionService(repo repository.PrescriptionRepository, drugs repository.DrugRepository, allergies repository.AllergyRepository, medications repository.MedicationRepository, patientRepo repository.PatientRepository, audit *AuditService) *PrescriptionService {
+	return &PrescriptionService{
+		repo:        repo,
+		drugs:       drugs,
+		allergies:   allergies,
+		medications: medications,
+		patientRepo: patientRepo,
+		audit:       audit,
+	}
+}
+
+// CreatePrescription issues a prescription written by actor, who must be a
+// doctor. Unless override is set, it refuses with a
+// *PrescriptionWarningsError when the safety check finds any warnings;
+// with override the prescription is saved and the warnings are returned.
+func (s *PrescriptionService) CreatePrescription(actor models.Actor, prescription *models.Prescription, override bool) ([]models.PrescriptionWarning, error) {
+	if actor.Role != models.RoleDoctor {
+		return nil, ErrNotPrescriber
+	}
+	if _, err := s.patientRepo.FindByID(uint(prescription.PatientID)); err != nil {
+		return nil, err
+	}
+	prescription.PrescriberID = actor.UserID
+	prescription.Status = models.PrescriptionActive
+	if err := validatePrescription(prescription); err != nil {
+		return nil, err
+	}
+
+	warnings, err := s.checkPrescription(prescription)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 && !override {
+		return nil, &PrescriptionWarningsError{Warnings: warnings}
+	}
+
+	if err := s.repo.Create(prescription); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditCreate, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// CheckPrescription returns the warnings for prescribing a drug to the
+// patient: interactions with their active prescriptions and the active
+// medications in their history, and allergies to the drug or its class.
+// The most serious warnings come first. The warnings reveal the patient's
+// medication and allergies, so the check is recorded as a read of their
+// record.
+func (s *PrescriptionService) CheckPrescription(actor models.Actor, prescription *models.Prescription) ([]models.PrescriptionWarning, error) {
+	if _, err := s.patientRepo.FindByID(uint(prescription.PatientID)); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	return s.checkPrescription(prescription)
+}
+
+func (s *PrescriptionService) checkPrescription(prescription *models.Prescription) ([]models.PrescriptionWarning, error) {
+	drug := normalizeDrug(prescription.Drug)
+	warnings := []models.PrescriptionWarning{}
+
+	active, err := s.repo.FindActiveByPatient(uint(prescription.PatientID))
+	if err != nil {
+		return nil, err
+	}
+	var current []string
+	for _, p := range active {
+		if p.ID != prescription.ID {
+			current = append(current, normalizeDrug(p.Drug))
+		}
+	}
+	history, err := s.medications.FindActiveByPatient(uint(prescription.PatientID))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range history {
+		current = append(current, normalizeDrug(m.Drug))
+	}
+	if len(current) > 0 {
+		interactions, err := s.drugs.FindInteractions(drug, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, interaction := range interactions {
+			with := interaction.DrugA
+			if with == drug {
+				with = interaction.DrugB
+			}
+			warnings = append(warnings, models.PrescriptionWarning{
+				Type:     models.WarningInteraction,
+				Severity: interaction.Severity,
+				Drug:     drug,
+				With:     with,
+				Message:  fmt.Sprintf("%s interacts with %s: %s", drug, with, interaction.Description),
+			})
+		}
+	}
+
+	allergies, err := s.allergies.FindActiveByPatient(uint(prescription.PatientID))
+	if err != nil {
+		return nil, err
+	}
+	if len(allergies) > 0 {
+		classes, err := s.drugs.FindClasses(drug)
+		if err != nil {
+			return nil, err
+		}
+		names := append([]string{drug}, classes...)
+		for _, allergy := range allergies {
+			if matched, ok := matchAllergy(allergy.Substance, names); ok {
+				warnings = append(warnings, models.PrescriptionWarning{
+					Type:     models.WarningAllergy,
+					Severity: allergy.Severity,
+					Drug:     drug,
+					With:     allergy.Substance,
+					Message:  fmt.Sprintf("patient is allergic to %s (%s)", allergy.Substance, matched),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warningRank(warnings[i]) > warningRank(warnings[j])
+	})
+	return warnings, nil
+}
+
+// GetPrescriptions lists a patient's prescriptions, newest first.
+func (s *PrescriptionService) GetPrescriptions(actor models.Actor, patientID uint) ([]models.Prescription, error) {
+	if _, err := s.patientRepo.FindByID(patientID); err != nil {
+		return nil, err
+	}
+	prescriptions, err := s.repo.FindByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return prescriptions, nil
+}
+
+func (s *PrescriptionService) GetPrescription(actor models.Actor, patientID, id uint) (*models.Prescription, error) {
+	prescription, err := s.find(patientID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditRead, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+// DiscontinuePrescription stops an active prescription.
+func (s *PrescriptionService) DiscontinuePrescription(actor models.Actor, patientID, id uint) (*models.Prescription, error) {
+	if actor.Role != models.RoleDoctor {
+		return nil, ErrNotPrescriber
+	}
+	prescription, err := s.find(patientID, id)
+	if err != nil {
+		return nil, err
+	}
+	if prescription.Status != models.PrescriptionActive {
+		return nil, fmt.Errorf("%w: prescription is already %s", ErrInvalidPrescription, prescription.Status)
+	}
+
+	prescription.Status = models.PrescriptionDiscontinued
+	if err := s.repo.UpdateStatus(prescription); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(actor, models.AuditUpdate, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+// find loads a prescription, treating one that belongs to another patient
+// as not found
+func (s *PrescriptionService) find(patientID, id uint) (*models.Prescription, error) {
+	prescription, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if prescription.PatientID != int(patientID) {
+		return nil, sql.ErrNoRows
+	}
+	return prescription, nil
+}
+
+func validatePrescription(p *models.Prescription) error {
+	p.Drug = strings.TrimSpace(p.Drug)
+	if p.Drug == "" || strings.TrimSpace(p.Dose) == "" || strings.TrimSpace(p.Frequency) == "" {
+		return fmt.Errorf("%w: drug, dose and frequency are required", ErrInvalidPrescription)
+	}
+	if p.DurationDays <= 0 {
+		return fmt.Errorf("%w: duration_days must be positive", ErrInvalidPrescription)
+	}
+	switch p.Route {
+	case models.RouteOral, models.RouteIV, models.RouteIM, models.RouteSubcut, models.RouteTopical,
+		models.RouteInhaled, models.RouteSublingual, models.RouteRectal:
+	default:
+		return fmt.Errorf("%w: unknown route %q", ErrInvalidPrescription, p.Route)
+	}
+	return nil
+}
+
+// normalizeDrug puts a drug name in the form used by the drug tables
+func normalizeDrug(drug string) string {
+	return strings.ToLower(strings.Join(strings.Fields(drug), " "))
+}
+
+// matchAllergy reports which of names (a drug and its classes) an allergy
+// substance refers to. Singular and plural forms match, so an allergy to
+// "penicillin" catches the "penicillins" class.
+func matchAllergy(substance string, names []string) (string, bool) {
+	singular := func(s string) string { return strings.TrimSuffix(normalizeDrug(s), "s") }
+	want := singular(substance)
+	for _, name := range names {
+		if singular(name) == want {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// warningRank orders warnings by seriousness across both severity scales
+func warningRank(w models.PrescriptionWarning) int {
+	switch w.Severity {
+	case models.InteractionContraindicated, models.AllergySevere:
+		return 3
+	case models.InteractionMajor:
+		return 2
+	case models.InteractionModerate:
+		return 1
+	}
+	return 0
+}
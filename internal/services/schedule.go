@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+// maxAvailabilityWindow bounds how far a single availability search may reach
+const maxAvailabilityWindow = 31 * 24 * time.Hour
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type ScheduleService struct {
+	repo            repository.ScheduleRepository
+	appointmentRepo repository.AppointmentRepository
+	userRepo        repository.UserRepository
+	// location the weekly templates are expressed in
+	location *time.Location
+}
+
+func NewScheduleService(repo repository.ScheduleRepository, appointmentRepo repository.AppointmentRepository, userRepo repository.UserRepository) *ScheduleService {
+	return &ScheduleService{
+		repo:            repo,
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+		location:        time.Local,
+	}
+}
+
+func (s *ScheduleService) GetWorkingHours(doctorID int64) ([]models.WorkingHours, error) {
+	if err := s.checkDoctor(doctorID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindWorkingHours(doctorID)
+}
+
+// SetWorkingHours replaces the doctor's weekly template with hours.
+func (s *ScheduleService) SetWorkingHours(doctorID int64, hours []models.WorkingHours) error {
+	if err := s.checkDoctor(doctorID); err != nil {
+		return err
+	}
+
+	type session struct{ start, end time.Duration }
+	byDay := map[int][]session{}
+	for _, h := range hours {
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidSchedule)
+		}
+		start, err := parseClock(h.StartTime)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(h.EndTime)
+		if err != nil {
+			return err
+		}
+		if end <= start {
+			return fmt.Errorf("%w: end_time must be after start_time", ErrInvalidSchedule)
+		}
+		if h.SlotMinutes < 5 || h.SlotMinutes > 240 {
+			return fmt.Errorf("%w: slot_minutes must be between 5 and 240", ErrInvalidSchedule)
+		}
+		for _, other := range byDay[h.Weekday] {
+			if start < other.end && end > other.start {
+				return fmt.Errorf("%w: overlapping working hours on weekday %d", ErrInvalidSchedule, h.Weekday)
+			}
+		}
+		byDay[h.Weekday] = append(byDay[h.Weekday], session{start, end})
+	}
+
+	return s.repo.ReplaceWorkingHours(doctorID, hours)
+}
+
+func (s *ScheduleService) AddException(exception *models.ScheduleException) error {
+	if err := s.checkDoctor(exception.DoctorID); err != nil {
+		return err
+	}
+	if exception.Type == "" {
+		exception.Type = models.ExceptionLeave
+	}
+	switch exception.Type {
+	case models.ExceptionHoliday, models.ExceptionLeave, models.ExceptionOther:
+	default:
+		return fmt.Errorf("%w: unknown exception type %q", ErrInvalidSchedule, exception.Type)
+	}
+	if exception.StartTime.IsZero() || !exception.EndTime.After(exception.StartTime) {
+		return fmt.Errorf("%w: end_time must be after start_time", ErrInvalidSchedule)
+	}
+	return s.repo.CreateException(exception)
+}
+
+func (s *ScheduleService) GetExceptions(doctorID int64, from, to time.Time) ([]models.ScheduleException, error) {
+	if err := s.checkDoctor(doctorID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindExceptions(doctorID, from, to)
+}
+
+// RemoveException deletes one of the doctor's exceptions.
+func (s *ScheduleService) RemoveException(doctorID int64, id uint) error {
+	exception, err := s.repo.FindExceptionByID(id)
+	if err != nil {
+		return err
+	}
+	if exception.DoctorID != doctorID {
+		return errors.New("exception does not belong to this doctor")
+	}
+	return s.repo.DeleteException(id)
+}
+
+// GetAvailability returns the doctor's free slots between from and to.
+func (s *ScheduleService) GetAvailability(doctorID int64, from, to time.Time) ([]models.Slot, error) {
+	if err := s.checkDoctor(doctorID); err != nil {
+		return nil, err
+	}
+	if to.Sub(from) > maxAvailabilityWindow {
+		return nil, fmt.Errorf("%w: availability can be searched at most 31 days at a time", ErrInvalidSchedule)
+	}
+
+	hours, err := s.repo.FindWorkingHours(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := s.repo.FindExceptions(doctorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	appointments, err := s.appointmentRepo.FindByDoctor(doctorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return ComputeFreeSlots(hours, exceptions, appointments, from, to, s.location), nil
+}
+
+// ComputeFreeSlots expands the weekly template into concrete slots in loc
+// and drops any that fall outside [from, to), overlap an exception, or
+// overlap a live appointment.
+func ComputeFreeSlots(hours []models.WorkingHours, exceptions []models.ScheduleException,
+	appointments []models.Appointment, from, to time.Time, loc *time.Location) []models.Slot {
+
+	var busy []models.Slot
+	for _, e := range exceptions {
+		busy = append(busy, models.Slot{StartTime: e.StartTime, EndTime: e.EndTime})
+	}
+	for _, a := range appointments {
+		if a.Status != models.AppointmentCancelled {
+			busy = append(busy, models.Slot{StartTime: a.StartTime, EndTime: a.EndTime})
+		}
+	}
+
+	slots := []models.Slot{}
+	first := from.In(loc)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, h := range hours {
+			if time.Weekday(h.Weekday) != day.Weekday() {
+				continue
+			}
+			start, err := parseClock(h.StartTime)
+			if err != nil {
+				continue
+			}
+			end, err := parseClock(h.EndTime)
+			if err != nil {
+				continue
+			}
+			sessionEnd := atClock(day, end)
+			step := time.Duration(h.SlotMinutes) * time.Minute
+			if step <= 0 {
+				continue
+			}
+			for slotStart := atClock(day, start); !slotStart.Add(step).After(sessionEnd); slotStart = slotStart.Add(step) {
+				slot := models.Slot{StartTime: slotStart, EndTime: slotStart.Add(step)}
+				if slot.StartTime.Before(from) || slot.EndTime.After(to) || overlapsAny(slot, busy) {
+					continue
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].StartTime.Before(slots[j].StartTime) })
+	return slots
+}
+
+func (s *ScheduleService) checkDoctor(doctorID int64) error {
+	doctor, err := s.userRepo.FindByID(int(doctorID))
+	if err != nil || doctor.Role != models.RoleDoctor {
+		return ErrInvalidDoctor
+	}
+	return nil
+}
+
+// parseClock parses a wall-clock "HH:MM" into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q must be in HH:MM format", ErrInvalidSchedule, value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// atClock returns the wall-clock time offset on the given midnight, so that
+// 09:00 stays 09:00 across daylight saving changes
+func atClock(midnight time.Time, offset time.Duration) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, midnight.Location())
+}
+
+func overlapsAny(slot models.Slot, busy []models.Slot) bool {
+	for _, b := range busy {
+		if slot.StartTime.Before(b.EndTime) && slot.EndTime.After(b.StartTime) {
+			return true
+		}
+	}
+	return false
+}
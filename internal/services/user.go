@@ -17,6 +17,7 @@ func (s *UserService) GetUserByID(id int) (*models.User, error) {
     return s.userRepo.FindByID(id)
 }
 
+// UpdateUser saves a user's username and role; their password is left as it is
 func (s *UserService) UpdateUser(user *models.User) error {
     return s.userRepo.Update(user)
 }
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+var (
+	ErrInvalidVitals     = errors.New("invalid vital signs")
+	ErrInvalidVitalRange = errors.New("invalid vital sign range")
+)
+
+// vitalLimits are the only values accepted for each measurement. They catch
+// typos and unit mix-ups, such as a temperature entered in Fahrenheit, and
+// are not clinical ranges. The BMI limits also keep weights and heights
+// that are each plausible from combining into an impossible BMI.
+var vitalLimits = map[string][2]float64{
+	models.VitalSystolicBP:      {40, 300},
+	models.VitalDiastolicBP:     {20, 200},
+	models.VitalHeartRate:       {20, 300},
+	models.VitalTemperature:     {25, 45},
+	models.VitalSpO2:            {0, 100},
+	models.VitalRespiratoryRate: {2, 100},
+	"weight_kg":                 {0.2, 500},
+	"height_cm":                 {20, 280},
+	models.VitalBMI:             {2, 250},
+}
+
+// VitalSignsService records vital signs and flags readings outside the
+// normal range for the patient's age.
+type VitalSignsService struct {
+	repo        repository.VitalSignsRepository
+	patientRepo repository.PatientRepository
+	audit       *AuditService
+}
+
+func NewVitalSignsService(repo repository.VitalSignsRepository, patientRepo repository.PatientRepository, audit *AuditService) *VitalSignsService {
+	return &VitalSignsService{
+		repo:        repo,
+		patientRepo: patientRepo,
+		audit:       audit,
+	}
+}
+
+// RecordVitals saves a set of measurements, computing BMI when weight and
+// height are both given, and flags the abnormal ones. recorded_at defaults
+// to now.
+func (s *VitalSignsService) RecordVitals(actor models.Actor, vitals *models.VitalSigns) error {
+	patient, err := s.patientRepo.FindByID(uint(vitals.PatientID))
+	if err != nil {
+		return err
+	}
+	if vitals.RecordedAt.IsZero() {
+		vitals.RecordedAt = time.Now()
+	}
+	vitals.RecordedBy = actor.UserID
+	vitals.BMI = nil
+	if vitals.WeightKg != nil && vitals.HeightCm != nil {
+		bmi := ComputeBMI(*vitals.WeightKg, *vitals.HeightCm)
+		vitals.BMI = &bmi
+	}
+	if err := validateVitals(vitals); err != nil {
+		return err
+	}
+
+	ranges, err := s.repo.FindRanges()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Create(vitals); err != nil {
+		return err
+	}
+	vitals.Flags = FlagVitals(vitals, patient.AgeOn(vitals.RecordedAt), ranges)
+	return s.audit.RecordAccess(actor, models.AuditCreate, vitals.PatientID)
+}
+
+// GetVitals returns the patient's readings between from and to, oldest
+// first, each with its abnormal values flagged. Nil bounds are open.
+func (s *VitalSignsService) GetVitals(actor models.Actor, patientID uint, from, to *time.Time) ([]models.VitalSigns, error) {
+	patient, err := s.patientRepo.FindByID(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && !to.After(*from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidVitals)
+	}
+
+	readings, err := s.repo.FindByPatient(patientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := s.repo.FindRanges()
+	if err != nil {
+		return nil, err
+	}
+	for i := range readings {
+		readings[i].Flags = FlagVitals(&readings[i], patient.AgeOn(readings[i].RecordedAt), ranges)
+	}
+
+	if err := s.audit.RecordAccess(actor, models.AuditRead, int(patientID)); err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// GetRanges returns the configured normal ranges.
+func (s *VitalSignsService) GetRanges() ([]models.VitalRange, error) {
+	return s.repo.FindRanges()
+}
+
+// UpdateRange changes the ages and limits of a normal range. Its vital and
+// age group cannot change.
+func (s *VitalSignsService) UpdateRange(r *models.VitalRange) error {
+	existing, err := s.repo.FindRangeByID(uint(r.ID))
+	if err != nil {
+		return err
+	}
+	r.Vital = existing.Vital
+	r.AgeGroup = existing.AgeGroup
+
+	if r.MinAge < 0 {
+		return fmt.Errorf("%w: min_age must not be negative", ErrInvalidVitalRange)
+	}
+	if r.MaxAge != nil && *r.MaxAge <= r.MinAge {
+		return fmt.Errorf("%w: max_age must be greater than min_age", ErrInvalidVitalRange)
+	}
+	if r.Low >= r.High {
+		return fmt.Errorf("%w: low must be less than high", ErrInvalidVitalRange)
+	}
+
+	return s.repo.UpdateRange(r)
+}
+
+// ComputeBMI returns the body mass index for a weight in kilograms and a
+// height in centimetres, rounded to one decimal place.
+func ComputeBMI(weightKg, heightCm float64) float64 {
+	meters := heightCm / 100
+	return math.Round(weightKg/(meters*meters)*10) / 10
+}
+
+// FlagVitals returns the measurements in vitals that fall outside the
+// normal range for a patient of the given age. Measurements with no range
+// for that age are not flagged.
+func FlagVitals(vitals *models.VitalSigns, age int, ranges []models.VitalRange) []models.VitalFlag {
+	flags := []models.VitalFlag{}
+	for _, m := range vitalMeasurements(vitals) {
+		for _, r := range ranges {
+			if r.Vital != m.name || !r.Covers(age) {
+				continue
+			}
+			flag := models.VitalFlag{Vital: m.name, Value: m.value, Low: r.Low, High: r.High}
+			switch {
+			case m.value < r.Low:
+				flag.Flag = models.VitalLow
+			case m.value > r.High:
+				flag.Flag = models.VitalHigh
+			default:
+				continue
+			}
+			flags = append(flags, flag)
+			break
+		}
+	}
+	return flags
+}
+
+type vitalMeasurement struct {
+	name  string
+	value float64
+}
+
+// vitalMeasurements lists the measurements present in vitals
+func vitalMeasurements(vitals *models.VitalSigns) []vitalMeasurement {
+	var measurements []vitalMeasurement
+	addInt := func(name string, v *int) {
+		if v != nil {
+			measurements = append(measurements, vitalMeasurement{name, float64(*v)})
+		}
+	}
+	addFloat := func(name string, v *float64) {
+		if v != nil {
+			measurements = append(measurements, vitalMeasurement{name, *v})
+		}
+	}
+
+	addInt(models.VitalSystolicBP, vitals.SystolicBP)
+	addInt(models.VitalDiastolicBP, vitals.DiastolicBP)
+	addInt(models.VitalHeartRate, vitals.HeartRate)
+	addFloat(models.VitalTemperature, vitals.Temperature)
+	addInt(models.VitalSpO2, vitals.SpO2)
+	addInt(models.VitalRespiratoryRate, vitals.RespiratoryRate)
+	addFloat("weight_kg", vitals.WeightKg)
+	addFloat("height_cm", vitals.HeightCm)
+	addFloat(models.VitalBMI, vitals.BMI)
+	return measurements
+}
+
+func validateVitals(v *models.VitalSigns) error {
+	measurements := vitalMeasurements(v)
+	if len(measurements) == 0 {
+		return fmt.Errorf("%w: at least one measurement is required", ErrInvalidVitals)
+	}
+	for _, m := range measurements {
+		limits, ok := vitalLimits[m.name]
+		if ok && (m.value < limits[0] || m.value > limits[1]) {
+			return fmt.Errorf("%w: %s must be between %g and %g", ErrInvalidVitals, m.name, limits[0], limits[1])
+		}
+	}
+	if (v.SystolicBP == nil) != (v.DiastolicBP == nil) {
+		return fmt.Errorf("%w: systolic_bp and diastolic_bp must be given together", ErrInvalidVitals)
+	}
+	if v.SystolicBP != nil && *v.DiastolicBP >= *v.SystolicBP {
+		return fmt.Errorf("%w: diastolic_bp must be lower than systolic_bp", ErrInvalidVitals)
+	}
+	if v.RecordedAt.After(time.Now()) {
+		return fmt.Errorf("%w: recorded_at must not be in the future", ErrInvalidVitals)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Package fhir holds the subset of FHIR R4 resources and datatypes the API
+// exposes, in their JSON form.
+package fhir
+
+// ContentType is the FHIR JSON media type.
+const ContentType = "application/fhir+json"
+
+// NDJSONContentType is the media type of bulk export files: one JSON
+// resource per line.
+const NDJSONContentType = "application/fhir+ndjson"
+
+// Version is the FHIR version served.
+const Version = "4.0.1"
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string           `json:"use,omitempty"`
+	Type   *CodeableConcept `json:"type,omitempty"`
+	System string           `json:"system,omitempty"`
+	Value  string           `json:"value,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+// ContactPoint systems
+const (
+	ContactPhone = "phone"
+	ContactEmail = "email"
+)
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use        string   `json:"use,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Line       []string `json:"line,omitempty"`
+	City       string   `json:"city,omitempty"`
+	State      string   `json:"state,omitempty"`
+	PostalCode string   `json:"postalCode,omitempty"`
+	Country    string   `json:"country,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Patient is the FHIR Patient resource.
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id,omitempty"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender,omitempty"`
+	BirthDate    string         `json:"birthDate,omitempty"`
+	Address      []Address      `json:"address,omitempty"`
+}
+
+// EncounterParticipant is a person taking part in an Encounter.
+type EncounterParticipant struct {
+	Individual *Reference `json:"individual,omitempty"`
+}
+
+// Encounter is the FHIR Encounter resource.
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	ID           string                 `json:"id,omitempty"`
+	Meta         *Meta                  `json:"meta,omitempty"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      *Reference             `json:"subject,omitempty"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+	ReasonCode   []CodeableConcept      `json:"reasonCode,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+type BundleEntry struct {
+	FullURL  string        `json:"fullUrl,omitempty"`
+	Resource interface{}   `json:"resource"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+// Bundle is a searchset Bundle of search results.
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+// NewSearchBundle wraps resources in a searchset Bundle. fullURLs holds the
+// absolute URL of each resource.
+func NewSearchBundle(selfURL string, resources []interface{}, fullURLs []string) *Bundle {
+	bundle := &Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Link:         []BundleLink{{Relation: "self", URL: selfURL}},
+		Entry:        make([]BundleEntry, len(resources)),
+	}
+	for i, resource := range resources {
+		bundle.Entry[i] = BundleEntry{FullURL: fullURLs[i], Resource: resource, Search: &BundleSearch{Mode: "match"}}
+	}
+	return bundle
+}
+
+// OperationOutcome issue severities and codes
+const (
+	SeverityError = "error"
+
+	IssueInvalid      = "invalid"
+	IssueNotFound     = "not-found"
+	IssueNotSupported = "not-supported"
+	IssueConflict     = "conflict"
+	IssueDuplicate    = "duplicate"
+	IssueForbidden    = "forbidden"
+	IssueException    = "exception"
+)
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// OperationOutcome reports why a request failed.
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// NewOperationOutcome returns an OperationOutcome with a single error issue.
+func NewOperationOutcome(code, diagnostics string) *OperationOutcome {
+	return &OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OperationOutcomeIssue{{Severity: SeverityError, Code: code, Diagnostics: diagnostics}},
+	}
+}
+
+type CapabilitySoftware struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type CapabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+type CapabilitySearchParam struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type CapabilityResource struct {
+	Type        string                  `json:"type"`
+	Interaction []CapabilityInteraction `json:"interaction"`
+	SearchParam []CapabilitySearchParam `json:"searchParam,omitempty"`
+}
+
+type CapabilitySecurity struct {
+	Description string `json:"description,omitempty"`
+}
+
+type CapabilityRest struct {
+	Mode     string               `json:"mode"`
+	Security *CapabilitySecurity  `json:"security,omitempty"`
+	Resource []CapabilityResource `json:"resource"`
+}
+
+// CapabilityStatement describes what the server supports.
+type CapabilityStatement struct {
+	ResourceType string             `json:"resourceType"`
+	Status       string             `json:"status"`
+	Date         string             `json:"date"`
+	Kind         string             `json:"kind"`
+	Software     CapabilitySoftware `json:"software"`
+	FHIRVersion  string             `json:"fhirVersion"`
+	Format       []string           `json:"format"`
+	Rest         []CapabilityRest   `json:"rest"`
+}
+
+type BulkExportFile struct {
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+	Count int    `json:"count,omitempty"`
+}
+
+// BulkExportManifest is the body of a completed bulk export status
+// request, listing the files to download.
+type BulkExportManifest struct {
+	TransactionTime     string           `json:"transactionTime"`
+	Request             string           `json:"request"`
+	RequiresAccessToken bool             `json:"requiresAccessToken"`
+	Output              []BulkExportFile `json:"output"`
+	Error               []BulkExportFile `json:"error"`
+}
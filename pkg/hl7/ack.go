@@ -0,0 +1,82 @@
+package hl7
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Acknowledgment codes (MSA-1)
+const (
+	// AckAccept means the message was processed.
+	AckAccept = "AA"
+	// AckError means the message was understood but could not be applied,
+	// e.g. an unknown patient; the sender should not resend it unchanged.
+	AckError = "AE"
+	// AckReject means the message was not understood or is not supported.
+	AckReject = "AR"
+)
+
+var ackSequence uint64
+
+// NewACK builds the acknowledgment of msg with the given code. text
+// explains an error and is left out when empty. msg may be nil when the
+// incoming message could not be parsed at all.
+func NewACK(msg *Message, code, text string) []byte {
+	enc := DefaultEncoding
+	var msh *Segment
+	if msg != nil {
+		enc = msg.Encoding
+		msh = msg.Segment("MSH")
+	}
+
+	version := "2.5"
+	processingID := "P"
+	trigger := ""
+	if msh != nil {
+		if v := msh.Component(12, 1); v != "" {
+			version = v
+		}
+		if p := msh.Component(11, 1); p != "" {
+			processingID = p
+		}
+		trigger = msh.Component(9, 2)
+	}
+
+	messageType := "ACK"
+	if trigger != "" {
+		messageType += string(enc.Component) + trigger + string(enc.Component) + "ACK"
+	}
+
+	f := string(enc.Field)
+	// Sender and receiver swap places in the reply
+	header := []string{
+		"MSH", enc.String(),
+		raw(msh, 5), raw(msh, 6), raw(msh, 3), raw(msh, 4),
+		FormatTime(time.Now()), "", messageType,
+		"ACK" + strconv.FormatInt(time.Now().Unix(), 10) + strconv.FormatUint(atomic.AddUint64(&ackSequence, 1), 10),
+		processingID, version,
+	}
+	msa := []string{"MSA", code, raw(msh, 10)}
+	if text != "" {
+		msa = append(msa, enc.EscapeText(truncate(text, 80)))
+	}
+
+	return []byte(strings.Join(header, f) + "\r" + strings.Join(msa, f) + "\r")
+}
+
+// raw returns field n of seg exactly as it was sent
+func raw(seg *Segment, n int) string {
+	if seg == nil || n < 1 || n > len(seg.fields) {
+		return ""
+	}
+	return seg.fields[n-1]
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,277 @@
+// Package hl7 parses and builds HL7 v2 messages and frames them for MLLP.
+package hl7
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMessage is returned when data is not an HL7 v2 message.
+var ErrInvalidMessage = errors.New("invalid HL7 message")
+
+// Encoding holds the delimiters declared in MSH-1 and MSH-2.
+type Encoding struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	SubComponent byte
+}
+
+// DefaultEncoding is the encoding almost every sender uses: |^~\&
+var DefaultEncoding = Encoding{Field: '|', Component: '^', Repetition: '~', Escape: '\\', SubComponent: '&'}
+
+func (e Encoding) String() string {
+	return string([]byte{e.Component, e.Repetition, e.Escape, e.SubComponent})
+}
+
+// Message is a parsed HL7 v2 message.
+type Message struct {
+	Encoding Encoding
+	Segments []*Segment
+}
+
+// Segment is one line of a message. Fields are kept raw, with escape
+// sequences intact, and numbered from 1 as in the HL7 specification.
+type Segment struct {
+	Name   string
+	fields []string
+	enc    Encoding
+}
+
+// Parse parses an HL7 v2 message. Segments may be separated by CR, LF or
+// CRLF.
+func Parse(data []byte) (*Message, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) < 8 || string(data[:3]) != "MSH" {
+		return nil, fmt.Errorf("%w: message must start with an MSH segment", ErrInvalidMessage)
+	}
+
+	enc := Encoding{Field: data[3], Component: data[4], Repetition: data[5], Escape: data[6], SubComponent: data[7]}
+	if data[7] == enc.Field {
+		// Only three encoding characters were sent; there are no subcomponents
+		enc.SubComponent = 0
+	}
+
+	msg := &Message{Encoding: enc}
+	lines := strings.FieldsFunc(string(data), func(r rune) bool { return r == '\r' || r == '\n' })
+	for _, line := range lines {
+		if len(line) < 3 {
+			continue
+		}
+		fields := strings.Split(line, string(enc.Field))
+		seg := &Segment{Name: fields[0], enc: enc}
+		if seg.Name == "MSH" {
+			// MSH-1 is the field separator itself, so MSH-2 is the first
+			// value after it
+			seg.fields = append([]string{string(enc.Field)}, fields[1:]...)
+		} else {
+			seg.fields = fields[1:]
+		}
+		msg.Segments = append(msg.Segments, seg)
+	}
+	return msg, nil
+}
+
+// Segment returns the first segment called name, or nil.
+func (m *Message) Segment(name string) *Segment {
+	for _, seg := range m.Segments {
+		if seg.Name == name {
+			return seg
+		}
+	}
+	return nil
+}
+
+// AllSegments returns every segment called name, in order.
+func (m *Message) AllSegments(name string) []*Segment {
+	var segments []*Segment
+	for _, seg := range m.Segments {
+		if seg.Name == name {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// Type returns the message type and trigger event from MSH-9, e.g. "ADT"
+// and "A01".
+func (m *Message) Type() (string, string) {
+	msh := m.Segment("MSH")
+	if msh == nil {
+		return "", ""
+	}
+	return msh.Component(9, 1), msh.Component(9, 2)
+}
+
+// ControlID returns MSH-10, the ID the sender expects back in its ACK.
+func (m *Message) ControlID() string {
+	if msh := m.Segment("MSH"); msh != nil {
+		return msh.Field(10)
+	}
+	return ""
+}
+
+// Field returns field n unescaped, including every repetition and component
+// separator.
+func (s *Segment) Field(n int) string {
+	if s == nil || n < 1 || n > len(s.fields) {
+		return ""
+	}
+	if s.Name == "MSH" && n <= 2 {
+		return s.fields[n-1]
+	}
+	return s.unescape(s.fields[n-1])
+}
+
+// Repetitions returns the raw repetitions of field n.
+func (s *Segment) Repetitions(n int) []string {
+	if s == nil || n < 1 || n > len(s.fields) || s.fields[n-1] == "" {
+		return nil
+	}
+	return strings.Split(s.fields[n-1], string(s.enc.Repetition))
+}
+
+// Component returns component c of the first repetition of field n,
+// unescaped.
+func (s *Segment) Component(n, c int) string {
+	reps := s.Repetitions(n)
+	if len(reps) == 0 {
+		return ""
+	}
+	return s.RepetitionComponent(reps[0], c)
+}
+
+// RepetitionComponent returns component c of a raw repetition returned by
+// Repetitions, unescaped.
+func (s *Segment) RepetitionComponent(rep string, c int) string {
+	components := strings.Split(rep, string(s.enc.Component))
+	if c < 1 || c > len(components) {
+		return ""
+	}
+	value := components[c-1]
+	if s.enc.SubComponent != 0 {
+		// Subcomponents are not used here; keep the first
+		value = strings.SplitN(value, string(s.enc.SubComponent), 2)[0]
+	}
+	return s.unescape(value)
+}
+
+// unescape replaces the escape sequences in value with the characters
+// they stand for
+func (s *Segment) unescape(value string) string {
+	esc := string(s.enc.Escape)
+	if !strings.Contains(value, esc) {
+		return value
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(value, esc)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start+1:], esc)
+		if end < 0 {
+			break
+		}
+		b.WriteString(value[:start])
+		seq := value[start+1 : start+1+end]
+		switch {
+		case seq == "F":
+			b.WriteByte(s.enc.Field)
+		case seq == "S":
+			b.WriteByte(s.enc.Component)
+		case seq == "T":
+			b.WriteByte(s.enc.SubComponent)
+		case seq == "R":
+			b.WriteByte(s.enc.Repetition)
+		case seq == "E":
+			b.WriteByte(s.enc.Escape)
+		case seq == ".br":
+			b.WriteByte('\n')
+		case strings.HasPrefix(seq, "X") && len(seq)%2 == 1:
+			for i := 1; i+2 <= len(seq); i += 2 {
+				if c, err := strconv.ParseUint(seq[i:i+2], 16, 8); err == nil {
+					b.WriteByte(byte(c))
+				}
+			}
+		default:
+			// Formatting and character-set sequences are dropped
+		}
+		value = value[start+1+end+1:]
+	}
+	b.WriteString(value)
+	return b.String()
+}
+
+// EscapeText escapes the delimiters in value so it can be sent as one
+// component.
+func (e Encoding) EscapeText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case e.Escape:
+			b.WriteString(string([]byte{e.Escape, 'E', e.Escape}))
+		case e.Field:
+			b.WriteString(string([]byte{e.Escape, 'F', e.Escape}))
+		case e.Component:
+			b.WriteString(string([]byte{e.Escape, 'S', e.Escape}))
+		case e.Repetition:
+			b.WriteString(string([]byte{e.Escape, 'R', e.Escape}))
+		case e.SubComponent:
+			b.WriteString(string([]byte{e.Escape, 'T', e.Escape}))
+		case '\r', '\n':
+			b.WriteString(string([]byte{e.Escape}) + ".br" + string([]byte{e.Escape}))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ParseTime parses an HL7 timestamp (YYYY[MM[DD[HH[MM[SS[.S]]]]]][+/-ZZZZ]).
+// Timestamps without an offset are taken to be in loc.
+func ParseTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	offset := ""
+	if i := strings.IndexAny(value, "+-"); i >= 0 {
+		value, offset = value[:i], value[i:]
+	}
+	fraction := ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value, fraction = value[:i], value[i:]
+	}
+
+	layouts := map[int]string{4: "2006", 6: "200601", 8: "20060102", 10: "2006010215", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: bad timestamp %q", ErrInvalidMessage, value+fraction+offset)
+	}
+	if fraction != "" {
+		layout += "." + strings.Repeat("0", len(fraction)-1)
+		value += fraction
+	}
+	if offset != "" {
+		t, err := time.Parse(layout+"-0700", value+offset)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: bad timestamp %q", ErrInvalidMessage, value+offset)
+		}
+		return t, nil
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: bad timestamp %q", ErrInvalidMessage, value)
+	}
+	return t, nil
+}
+
+// FormatTime formats t as an HL7 timestamp with seconds and offset.
+func FormatTime(t time.Time) string {
+	return t.Format("20060102150405-0700")
+}
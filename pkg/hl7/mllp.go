@@ -0,0 +1,100 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// MLLP block characters. Every message is sent as
+// <VT> message <FS><CR>.
+const (
+	mllpStart = 0x0b
+	mllpEnd   = 0x1c
+	mllpCR    = 0x0d
+)
+
+// MaxFrameSize is the largest MLLP frame ReadFrame accepts.
+const MaxFrameSize = 1 << 20
+
+// ErrFrameTooLarge is returned by ReadFrame for frames over MaxFrameSize.
+var ErrFrameTooLarge = errors.New("MLLP frame too large")
+
+// ReadFrame reads the next MLLP-framed message from r, skipping anything
+// before its start block. It returns io.EOF when r ends between frames.
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == mllpStart {
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return nil, fmt.Errorf("MLLP frame not terminated: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == mllpEnd {
+			// The CR after the end block is skipped with anything else
+			// before the next start block, so senders that omit it work too
+			return buf.Bytes(), nil
+		}
+		if buf.Len() >= MaxFrameSize {
+			return nil, ErrFrameTooLarge
+		}
+		buf.WriteByte(b)
+	}
+}
+
+// WriteFrame writes msg to w wrapped in an MLLP frame.
+func WriteFrame(w io.Writer, msg []byte) error {
+	frame := make([]byte, 0, len(msg)+3)
+	frame = append(frame, mllpStart)
+	frame = append(frame, msg...)
+	frame = append(frame, mllpEnd, mllpCR)
+	_, err := w.Write(frame)
+	return err
+}
+
+// Client sends messages to an MLLP listener and reads their
+// acknowledgments.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Dial connects to the MLLP listener at addr.
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Send sends msg and waits up to timeout for the reply.
+func (c *Client) Send(msg []byte, timeout time.Duration) ([]byte, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if err := WriteFrame(c.conn, msg); err != nil {
+		return nil, err
+	}
+	return ReadFrame(c.r)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,167 @@
+// Package pdf writes plain-text reports as PDF documents. Text is set in a
+// fixed-width font, one line at a time, and each page is written out as
+// soon as it is full, so a report of any length is produced in constant
+// memory.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page geometry, in points: landscape A4 with half-inch margins
+const (
+	pageWidth  = 842
+	pageHeight = 595
+	margin     = 36
+	fontSize   = 8
+	leading    = 10
+)
+
+// LineWidth is how many characters fit on a line; longer lines are cut.
+const LineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+
+// linesPerPage is how many lines fit on a page below the title
+const linesPerPage = (pageHeight-2*margin)/leading - 2
+
+// Reserved object numbers; pages take the numbers after them, two each
+const (
+	catalogObject = 1
+	pagesObject   = 2
+	fontObject    = 3
+	firstPage     = 4
+)
+
+// Writer writes a document to an underlying writer. Call Close to finish
+// the document.
+type Writer struct {
+	w       *bufio.Writer
+	n       int64   // bytes written so far
+	offsets []int64 // byte offset of each object, by object number - 1
+	title   string
+	page    []string
+	pages   int
+	err     error
+}
+
+// NewWriter starts a document whose every page is headed by title.
+func NewWriter(w io.Writer, title string) *Writer {
+	pw := &Writer{w: bufio.NewWriter(w), title: title, offsets: make([]int64, firstPage-1)}
+	// The comment of high-bit bytes marks the file as binary
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	return pw
+}
+
+// Line adds a line of text, starting a new page when the current one is
+// full.
+func (pw *Writer) Line(text string) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(pw.page) == linesPerPage {
+		pw.flushPage()
+	}
+	pw.page = append(pw.page, text)
+	return pw.err
+}
+
+// Close writes the last page and the document trailer. It does not close
+// the underlying writer.
+func (pw *Writer) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(pw.page) > 0 || pw.pages == 0 {
+		pw.flushPage()
+	}
+
+	kids := make([]string, pw.pages)
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i+1)
+	}
+	pw.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	pw.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pw.pages))
+	pw.object(fontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, catalogObject, xref)
+
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	return pw.err
+}
+
+// flushPage writes the current page's content stream and page object
+func (pw *Writer) flushPage() {
+	pw.pages++
+	top := pageHeight - margin - fontSize
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, top)
+	fmt.Fprintf(&content, "(%s) Tj T*\n", escape(fmt.Sprintf("%s - page %d", pw.title, pw.pages)))
+	for _, line := range pw.page {
+		fmt.Fprintf(&content, "T* (%s) Tj\n", escape(line))
+	}
+	content.WriteString("ET\n")
+
+	num := firstPage + 2*(pw.pages-1)
+	pw.object(num, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	pw.object(num+1, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>",
+		pagesObject, pageWidth, pageHeight, num, fontObject))
+
+	pw.page = pw.page[:0]
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+}
+
+func (pw *Writer) object(num int, body string) {
+	for len(pw.offsets) < num {
+		pw.offsets = append(pw.offsets, 0)
+	}
+	pw.offsets[num-1] = pw.n
+	pw.printf("%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+func (pw *Writer) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+// escape makes text safe inside a PDF string: it cuts the text to
+// LineWidth, escapes delimiters, and maps it to the font's WinAnsi
+// encoding, replacing characters outside Latin-1 with '?'
+func escape(text string) string {
+	var b strings.Builder
+	count := 0
+	for _, r := range text {
+		if count == LineWidth {
+			break
+		}
+		count++
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		case r >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
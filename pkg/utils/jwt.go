@@ -1,12 +1,18 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// AccessTokenTTL is how long an access token stays valid. Sessions outlive
+// it by exchanging a refresh token for a new access token.
+const AccessTokenTTL = 15 * time.Minute
+
 type Claims struct {
+	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	jwt.StandardClaims
@@ -20,16 +26,30 @@ func SetJWTSecret(secret string) {
 }
 
 func GenerateToken(username, role string) (string, error) {
+	return GenerateTokenWithUserID(0, username, role)
+}
+
+// GenerateTokenWithUserID issues a short-lived access token. Each token
+// carries a unique ID (jti) so it can be revoked before it expires.
+func GenerateTokenWithUserID(userID int, username, role string) (string, error) {
 	if len(jwtSecret) == 0 {
 		jwtSecret = []byte("asdj8123kdsavcilkdsamm129majksdIAnjdsaSM124") // fallback
 	}
 
-	expirationTime := time.Now().Add(24 * time.Hour)
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
+		UserID:   userID,
 		Username: username,
 		Role:     role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        tokenID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
 		},
 	}
 
@@ -44,12 +64,36 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
 		return jwtSecret, nil
 	})
 
-	if err != nil || !token.Valid {
+	if err != nil {
 		return nil, err
 	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
 
 	return claims, nil
 }
+
+// ValidateJWT validates a token and returns its username and role
+func ValidateJWT(tokenString string) (string, string, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.Username, claims.Role, nil
+}
+
+// ValidateJWTWithUserID validates a token and returns its user ID, username and role
+func ValidateJWTWithUserID(tokenString string) (int, string, string, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return claims.UserID, claims.Username, claims.Role, nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+// LuhnCheckDigit returns the Luhn (mod 10) check digit for the digits in s.
+// Non-digit characters are ignored.
+func LuhnCheckDigit(s string) int {
+	sum := 0
+	double := true
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// IsValidLuhn reports whether the last character of s is the correct Luhn
+// check digit for the digits before it.
+func IsValidLuhn(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	last := s[len(s)-1]
+	if last < '0' || last > '9' {
+		return false
+	}
+	return LuhnCheckDigit(s[:len(s)-1]) == int(last-'0')
+}
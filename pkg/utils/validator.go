@@ -39,4 +39,10 @@ func (v *Validator) IsPasswordStrong(password string) bool {
 func (v *Validator) IsValidEmail(email string) bool {
     emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
     return emailRegex.MatchString(email)
+}
+
+// IsValidICD10 checks if code looks like an ICD-10 code, e.g. "E11.9" or "J45"
+func (v *Validator) IsValidICD10(code string) bool {
+    icd10Regex := regexp.MustCompile(`^[A-TV-Z][0-9][0-9AB](\.[0-9A-TV-Z]{1,4})?$`)
+    return icd10Regex.MatchString(code)
 }
\ No newline at end of file
@@ -0,0 +1,183 @@
+package x12
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version837P is the implementation guide of the 837 professional claim.
+const Version837P = "005010X222A1"
+
+// Party is a submitter, receiver, provider or payer in a claim.
+type Party struct {
+	Name string
+	// ID is the submitter or receiver ID, the provider's NPI or the
+	// payer ID
+	ID string
+}
+
+// BillingProvider is the provider that bills the claims.
+type BillingProvider struct {
+	Party
+	TaxID   string
+	Address string
+	City    string
+	State   string
+	Zip     string
+}
+
+// Person is a subscriber or patient.
+type Person struct {
+	FirstName string
+	LastName  string
+	DOB       time.Time
+	// Gender is "male", "female" or anything else for unknown
+	Gender string
+}
+
+// ServiceLine is one billed procedure.
+type ServiceLine struct {
+	ProcedureCode string
+	ChargeCents   int64
+	Units         int
+	Date          time.Time
+}
+
+// ProfessionalClaim is one claim in an 837P.
+type ProfessionalClaim struct {
+	// ControlNumber is the claim's patient control number (CLM-01), which
+	// the payer echoes back in the 835 as CLP-01
+	ControlNumber string
+	Payer         Party
+	// PayerSequence is "P", "S" or "T" for primary, secondary or tertiary
+	PayerSequence string
+	MemberID      string
+	GroupNumber   string
+	Subscriber    Person
+	// Patient is nil when the patient is the subscriber
+	Patient *Person
+	// Relationship is the patient's relationship to the subscriber as an
+	// X12 code, e.g. "01" spouse or "19" child; unused when Patient is nil
+	Relationship string
+	// Diagnoses are ICD-10-CM codes, principal first; at most 12 are sent
+	Diagnoses []string
+	Lines     []ServiceLine
+}
+
+// TotalCents is the sum of the claim's line charges.
+func (c *ProfessionalClaim) TotalCents() int64 {
+	var total int64
+	for _, l := range c.Lines {
+		total += l.ChargeCents
+	}
+	return total
+}
+
+// Build837P builds an 837P transaction set, from ST to SE, with every
+// claim under the one billing provider. Claims are placed in an office
+// (place of service 11) and are original claims.
+func Build837P(controlNumber int, submitter, receiver Party, provider BillingProvider, claims []ProfessionalClaim, now time.Time) []Segment {
+	control := fmt.Sprintf("%04d", controlNumber)
+	segments := []Segment{
+		NewSegment("ST", "837", control, Version837P),
+		NewSegment("BHT", "0019", "00", control, now.Format("20060102"), now.Format("1504"), "CH"),
+		NewSegment("NM1", "41", "2", Text(submitter.Name), "", "", "", "", "46", Text(submitter.ID)),
+		NewSegment("PER", "IC", Text(submitter.Name)),
+		NewSegment("NM1", "40", "2", Text(receiver.Name), "", "", "", "", "46", Text(receiver.ID)),
+		NewSegment("HL", "1", "", "20", "1"),
+		NewSegment("NM1", "85", "2", Text(provider.Name), "", "", "", "", "XX", Text(provider.ID)),
+		NewSegment("N3", Text(provider.Address)),
+		NewSegment("N4", Text(provider.City), Text(provider.State), Text(provider.Zip)),
+		NewSegment("REF", "EI", Text(provider.TaxID)),
+	}
+
+	hl := 1
+	for _, c := range claims {
+		hl++
+		subscriberHL := hl
+		hasPatient := "0"
+		relationship := "18"
+		if c.Patient != nil {
+			hasPatient, relationship = "1", ""
+		}
+		segments = append(segments,
+			NewSegment("HL", strconv.Itoa(subscriberHL), "1", "22", hasPatient),
+			NewSegment("SBR", c.PayerSequence, relationship, Text(c.GroupNumber), "", "", "", "", "", "CI"),
+			NewSegment("NM1", "IL", "1", Text(c.Subscriber.LastName), Text(c.Subscriber.FirstName), "", "", "", "MI", Text(c.MemberID)),
+		)
+		if c.Patient == nil {
+			segments = append(segments, demographics(c.Subscriber))
+		}
+		segments = append(segments, NewSegment("NM1", "PR", "2", Text(c.Payer.Name), "", "", "", "", "PI", Text(c.Payer.ID)))
+		if c.Patient != nil {
+			hl++
+			segments = append(segments,
+				NewSegment("HL", strconv.Itoa(hl), strconv.Itoa(subscriberHL), "23", "0"),
+				NewSegment("PAT", c.Relationship),
+				NewSegment("NM1", "QC", "1", Text(c.Patient.LastName), Text(c.Patient.FirstName)),
+				demographics(*c.Patient),
+			)
+		}
+
+		segments = append(segments,
+			NewSegment("CLM", Text(c.ControlNumber), FormatAmount(c.TotalCents()), "", "", "11:B:1", "Y", "A", "Y", "Y"))
+		if hi := diagnoses(c.Diagnoses); len(hi.Elements) > 0 {
+			segments = append(segments, hi)
+		}
+		for i, l := range c.Lines {
+			segments = append(segments,
+				NewSegment("LX", strconv.Itoa(i+1)),
+				NewSegment("SV1", "HC:"+Text(l.ProcedureCode), FormatAmount(l.ChargeCents), "UN", strconv.Itoa(l.Units), "", "", "1"),
+				NewSegment("DTP", "472", "D8", l.Date.Format("20060102")),
+			)
+		}
+	}
+
+	// SE-01 counts every segment from ST to SE
+	return append(segments, NewSegment("SE", strconv.Itoa(len(segments)+1), control))
+}
+
+func demographics(p Person) Segment {
+	gender := "U"
+	switch strings.ToLower(p.Gender) {
+	case "male":
+		gender = "M"
+	case "female":
+		gender = "F"
+	}
+	return NewSegment("DMG", "D8", p.DOB.Format("20060102"), gender)
+}
+
+// diagnoses builds the HI segment: the principal diagnosis with qualifier
+// ABK, the rest with ABF. ICD-10 codes are sent without their dot.
+func diagnoses(codes []string) Segment {
+	hi := NewSegment("HI")
+	for i, code := range codes {
+		if i == 12 {
+			break
+		}
+		qualifier := "ABF"
+		if i == 0 {
+			qualifier = "ABK"
+		}
+		hi.Elements = append(hi.Elements, qualifier+":"+Text(strings.ReplaceAll(code, ".", "")))
+	}
+	return hi
+}
+
+// Text makes a free-text value safe to send: X12 has no escape sequences,
+// so delimiters and line breaks are replaced with spaces, and the value is
+// upper-cased as most payers expect.
+func Text(value string) string {
+	d := DefaultDelimiters
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case rune(d.Element), rune(d.Component), rune(d.Repetition), rune(d.Segment), '\r', '\n':
+			return ' '
+		}
+		return r
+	}, value)
+	return strings.ToUpper(strings.TrimSpace(value))
+}
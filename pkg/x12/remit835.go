@@ -0,0 +1,115 @@
+package x12
+
+import (
+	"fmt"
+	"time"
+)
+
+// Claim status codes sent in CLP-02
+const (
+	ClaimProcessedPrimary   = "1"
+	ClaimProcessedSecondary = "2"
+	ClaimProcessedTertiary  = "3"
+	ClaimDenied             = "4"
+	ClaimReversed           = "22"
+)
+
+// Remittance is an 835 remittance advice: one payment from a payer and the
+// claims it pays.
+type Remittance struct {
+	PayerName string
+	// TraceNumber is the check or EFT trace number (TRN-02)
+	TraceNumber string
+	PaidCents   int64
+	PaymentDate time.Time
+	Claims      []ClaimPayment
+}
+
+// ClaimPayment is a payer's decision on one claim (a CLP segment).
+type ClaimPayment struct {
+	// ControlNumber is the patient control number sent in CLM-01
+	ControlNumber string
+	Status        string
+	ChargeCents   int64
+	PaidCents     int64
+	// PatientResponsibilityCents is what the payer says the patient owes
+	PatientResponsibilityCents int64
+	// PayerClaimNumber is the payer's own number for the claim
+	PayerClaimNumber string
+}
+
+// ParseRemittance reads the 835 transaction in an interchange parsed by
+// Parse. Only the first transaction set is read.
+func ParseRemittance(segments []Segment) (*Remittance, error) {
+	var remit *Remittance
+	for _, s := range segments {
+		switch s.ID {
+		case "ST":
+			if remit != nil {
+				return remit, nil
+			}
+			if s.Element(1) != "835" {
+				return nil, fmt.Errorf("%w: transaction set is %s, not 835", ErrInvalidInterchange, s.Element(1))
+			}
+			remit = &Remittance{}
+		case "BPR":
+			if remit == nil {
+				continue
+			}
+			paid, err := ParseAmount(s.Element(2))
+			if err != nil {
+				return nil, fmt.Errorf("BPR-02: %w", err)
+			}
+			remit.PaidCents = paid
+			if date := s.Element(16); date != "" {
+				if remit.PaymentDate, err = time.Parse("20060102", date); err != nil {
+					return nil, fmt.Errorf("%w: BPR-16 %q is not a date", ErrInvalidInterchange, date)
+				}
+			}
+		case "TRN":
+			if remit != nil {
+				remit.TraceNumber = s.Element(2)
+			}
+		case "N1":
+			if remit != nil && s.Element(1) == "PR" {
+				remit.PayerName = s.Element(2)
+			}
+		case "CLP":
+			if remit == nil {
+				continue
+			}
+			claim, err := parseClaimPayment(s)
+			if err != nil {
+				return nil, err
+			}
+			remit.Claims = append(remit.Claims, claim)
+		}
+	}
+	if remit == nil {
+		return nil, fmt.Errorf("%w: no 835 transaction set", ErrInvalidInterchange)
+	}
+	return remit, nil
+}
+
+func parseClaimPayment(s Segment) (ClaimPayment, error) {
+	claim := ClaimPayment{ControlNumber: s.Element(1), Status: s.Element(2), PayerClaimNumber: s.Element(7)}
+	if claim.ControlNumber == "" {
+		return claim, fmt.Errorf("%w: CLP without a claim control number", ErrInvalidInterchange)
+	}
+
+	amounts := []struct {
+		n      int
+		target *int64
+	}{{3, &claim.ChargeCents}, {4, &claim.PaidCents}, {5, &claim.PatientResponsibilityCents}}
+	for _, a := range amounts {
+		if s.Element(a.n) == "" {
+			continue
+		}
+		cents, err := ParseAmount(s.Element(a.n))
+		if err != nil {
+			return claim, fmt.Errorf("CLP-%02d of claim %s: %w", a.n, claim.ControlNumber, err)
+		}
+		*a.target = cents
+	}
+	return claim, nil
+}
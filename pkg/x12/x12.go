@@ -0,0 +1,199 @@
+// Package x12 reads and writes ASC X12 interchanges, and builds the 837P
+// professional claim and reads the 835 remittance advice.
+package x12
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidInterchange is returned when data is not an X12 interchange.
+var ErrInvalidInterchange = errors.New("invalid X12 interchange")
+
+// isaLength is the length of the fixed-width ISA segment, including its
+// segment terminator
+const isaLength = 106
+
+// Delimiters are the separators an interchange declares in its ISA segment.
+type Delimiters struct {
+	Element    byte
+	Component  byte
+	Repetition byte
+	Segment    byte
+}
+
+// DefaultDelimiters are the separators almost every trading partner uses.
+var DefaultDelimiters = Delimiters{Element: '*', Component: ':', Repetition: '^', Segment: '~'}
+
+// Segment is one segment of an interchange. Elements are numbered from 1
+// as in the X12 guides, so NM1-03 is Element(3).
+type Segment struct {
+	ID       string
+	Elements []string
+	comp     byte
+}
+
+// NewSegment builds a segment from its ID and elements. Trailing empty
+// elements are dropped when it is written.
+func NewSegment(id string, elements ...string) Segment {
+	return Segment{ID: id, Elements: elements, comp: DefaultDelimiters.Component}
+}
+
+// Element returns element n, or "" if the segment has fewer elements.
+func (s Segment) Element(n int) string {
+	if n < 1 || n > len(s.Elements) {
+		return ""
+	}
+	return s.Elements[n-1]
+}
+
+// Component returns component c of element n.
+func (s Segment) Component(n, c int) string {
+	comp := s.comp
+	if comp == 0 {
+		comp = DefaultDelimiters.Component
+	}
+	components := strings.Split(s.Element(n), string(comp))
+	if c < 1 || c > len(components) {
+		return ""
+	}
+	return components[c-1]
+}
+
+// Parse splits an interchange into its segments, using the delimiters
+// declared in its ISA segment. Line breaks after segment terminators are
+// ignored.
+func Parse(data []byte) ([]Segment, error) {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) < isaLength || string(data[:3]) != "ISA" {
+		return nil, fmt.Errorf("%w: interchange must start with an ISA segment", ErrInvalidInterchange)
+	}
+
+	d := Delimiters{Element: data[3], Component: data[104], Segment: data[105]}
+	if rep := data[82]; rep != 'U' {
+		d.Repetition = rep
+	}
+
+	var segments []Segment
+	for _, raw := range bytes.Split(data, []byte{d.Segment}) {
+		raw = bytes.Trim(raw, "\r\n")
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		elements := strings.Split(string(raw), string(d.Element))
+		segments = append(segments, Segment{ID: elements[0], Elements: elements[1:], comp: d.Component})
+	}
+	if segments[len(segments)-1].ID != "IEA" {
+		return nil, fmt.Errorf("%w: interchange must end with an IEA segment", ErrInvalidInterchange)
+	}
+	return segments, nil
+}
+
+// Encode writes segments with the default delimiters, one per line.
+func Encode(segments []Segment) []byte {
+	d := DefaultDelimiters
+	var b bytes.Buffer
+	for _, s := range segments {
+		elements := s.Elements
+		if s.ID != "ISA" {
+			for len(elements) > 0 && elements[len(elements)-1] == "" {
+				elements = elements[:len(elements)-1]
+			}
+		}
+		b.WriteString(s.ID)
+		for _, e := range elements {
+			b.WriteByte(d.Element)
+			b.WriteString(e)
+		}
+		b.WriteByte(d.Segment)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// Interchange identifies the parties to an interchange and its control
+// number.
+type Interchange struct {
+	SenderID      string
+	ReceiverID    string
+	ControlNumber int
+	Time          time.Time
+	// Production marks the interchange as production data rather than a
+	// test
+	Production bool
+}
+
+// Wrap encloses transaction sets in the ISA/IEA and GS/GE envelopes.
+// Each transaction set must start with its ST segment and end with its SE
+// segment; functionalID is the GS-01 code, e.g. "HC" for claims, and
+// version the GS-08 implementation guide, e.g. "005010X222A1".
+func (ic Interchange) Wrap(functionalID, version string, sets ...[]Segment) []Segment {
+	control := fmt.Sprintf("%09d", ic.ControlNumber)
+	usage := "T"
+	if ic.Production {
+		usage = "P"
+	}
+	d := DefaultDelimiters
+
+	segments := []Segment{
+		NewSegment("ISA", "00", pad("", 10), "00", pad("", 10), "ZZ", pad(ic.SenderID, 15), "ZZ", pad(ic.ReceiverID, 15),
+			ic.Time.Format("060102"), ic.Time.Format("1504"), string(d.Repetition), "00501", control, "0", usage, string(d.Component)),
+		NewSegment("GS", functionalID, ic.SenderID, ic.ReceiverID, ic.Time.Format("20060102"), ic.Time.Format("1504"),
+			strconv.Itoa(ic.ControlNumber), "X", version),
+	}
+	for _, set := range sets {
+		segments = append(segments, set...)
+	}
+	return append(segments,
+		NewSegment("GE", strconv.Itoa(len(sets)), strconv.Itoa(ic.ControlNumber)),
+		NewSegment("IEA", "1", control),
+	)
+}
+
+// pad left-justifies value in a field of width characters, as the
+// fixed-width ISA elements require
+func pad(value string, width int) string {
+	if len(value) > width {
+		return value[:width]
+	}
+	return value + strings.Repeat(" ", width-len(value))
+}
+
+// FormatAmount formats cents as an X12 decimal amount, with trailing zeros
+// after the decimal point suppressed: 12000 is "120" and 12340 "123.4".
+func FormatAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	s := fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// ParseAmount parses an X12 decimal amount into cents. Amounts with more
+// than two decimal places are rejected.
+func ParseAmount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" || len(fraction) > 2 {
+		return 0, fmt.Errorf("%w: bad amount %q", ErrInvalidInterchange, value)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	if whole == "" {
+		whole = "0"
+	}
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-") {
+		return 0, fmt.Errorf("%w: bad amount %q", ErrInvalidInterchange, value)
+	}
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
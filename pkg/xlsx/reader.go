@@ -0,0 +1,260 @@
+// Package xlsx reads cell values out of Office Open XML workbooks (.xlsx).
+// Only what a data import needs is supported: the text of each cell on the
+// first worksheet. Formatting, formulas and further sheets are ignored.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidWorkbook is returned for data that is not a readable workbook.
+var ErrInvalidWorkbook = errors.New("invalid xlsx workbook")
+
+// maxColumns bounds how wide a row may be, so that a cell reference such as
+// XFD1048576 cannot make the reader allocate huge rows.
+const maxColumns = 16384
+
+// ReadFirstSheet returns the rows of the workbook's first worksheet, each
+// as the text of its cells. Rows and cells missing from the file come back
+// empty, so row i of the result is row i+1 of the sheet. Numbers, including
+// dates, are returned as stored, e.g. "45292" for 2024-01-01.
+func ReadFirstSheet(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+	f, ok := files[sheet]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing worksheet %s", ErrInvalidWorkbook, sheet)
+	}
+	return readSheet(f, shared)
+}
+
+type workbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// firstSheetPath finds the part holding the first sheet listed in the
+// workbook, falling back to the conventional name
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var wb workbook
+	if err := decodePart(files["xl/workbook.xml"], &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrInvalidWorkbook)
+	}
+	var rels relationships
+	if err := decodePart(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return fallback, nil
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+// richText is a string item or inline string: plain text, or runs of
+// formatted text to be joined
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r richText) String() string {
+	if len(r.Runs) == 0 {
+		return r.T
+	}
+	var b strings.Builder
+	for _, run := range r.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodePart(f, &sst); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		shared[i] = item.String()
+	}
+	return shared, nil
+}
+
+type cell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline richText `xml:"is"`
+}
+
+type row struct {
+	Num   int    `xml:"r,attr"`
+	Cells []cell `xml:"c"`
+}
+
+// readSheet streams the sheet's rows so that only one row's XML is held in
+// memory at a time
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	defer rc.Close()
+
+	var rows [][]string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var r row
+		if err := dec.DecodeElement(&r, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+		}
+		// Rows without a number follow on from the previous one
+		if r.Num == 0 {
+			r.Num = len(rows) + 1
+		}
+		if r.Num < len(rows)+1 {
+			return nil, fmt.Errorf("%w: row %d is out of order", ErrInvalidWorkbook, r.Num)
+		}
+		for len(rows) < r.Num-1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for _, c := range r.Cells {
+			col := len(values)
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if col < len(values) {
+				return nil, fmt.Errorf("%w: cell %s is out of order", ErrInvalidWorkbook, c.Ref)
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+			value, err := cellValue(c, shared)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+}
+
+func cellValue(c cell, shared []string) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("%w: cell %s refers to a missing shared string", ErrInvalidWorkbook, c.Ref)
+		}
+		return shared[i], nil
+	case "inlineStr":
+		return c.Inline.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// columnIndex returns the zero-based column of a cell reference such as
+// "C7"
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A') + 1
+			continue
+		}
+		if i == 0 || r < '0' || r > '9' {
+			break
+		}
+		if col > maxColumns {
+			break
+		}
+		return col - 1, nil
+	}
+	return 0, fmt.Errorf("%w: bad cell reference %q", ErrInvalidWorkbook, ref)
+}
+
+func decodePart(f *zip.File, v interface{}) error {
+	if f == nil {
+		return fmt.Errorf("%w: missing part", ErrInvalidWorkbook)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidWorkbook, f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package hl7_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/pkg/hl7"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleADT = "MSH|^~\\&|REGADT|REGION|HMS|HOSP|20240301120000||ADT^A01|MSG0001|P|2.5\r" +
+	"PID|1||R-778^^^REGION^MR~1234567890^^^GOV^NI||O\\S\\Brien^Sean||19850214|M|||Flat 2\\F\\3 High St^^Springfield\r" +
+	"NTE|1||line one\\.br\\line two\r"
+
+func TestParse(t *testing.T) {
+	msg, err := hl7.Parse([]byte(sampleADT))
+	require.NoError(t, err)
+
+	msgType, trigger := msg.Type()
+	assert.Equal(t, "ADT", msgType)
+	assert.Equal(t, "A01", trigger)
+	assert.Equal(t, "MSG0001", msg.ControlID())
+	assert.Len(t, msg.Segments, 3)
+
+	msh := msg.Segment("MSH")
+	assert.Equal(t, "|", msh.Field(1))
+	assert.Equal(t, `^~\&`, msh.Field(2))
+	assert.Equal(t, "REGADT", msh.Field(3))
+
+	pid := msg.Segment("PID")
+	reps := pid.Repetitions(3)
+	require.Len(t, reps, 2)
+	assert.Equal(t, "R-778", pid.RepetitionComponent(reps[0], 1))
+	assert.Equal(t, "NI", pid.RepetitionComponent(reps[1], 5))
+	assert.Equal(t, "O^Brien", pid.Component(5, 1), "escaped component separator")
+	assert.Equal(t, "Flat 2|3 High St", pid.Component(11, 1), "escaped field separator")
+	assert.Equal(t, "Springfield", pid.Component(11, 3))
+	assert.Equal(t, "", pid.Field(40))
+
+	assert.Equal(t, "line one\nline two", msg.Segment("NTE").Field(3))
+	assert.Nil(t, msg.Segment("OBX"))
+}
+
+func TestParse_AcceptsLineFeeds(t *testing.T) {
+	msg, err := hl7.Parse([]byte(strings.ReplaceAll(sampleADT, "\r", "\r\n")))
+	require.NoError(t, err)
+	assert.Len(t, msg.Segments, 3)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{"", "PID|1||123", "MSH"} {
+		_, err := hl7.Parse([]byte(data))
+		assert.ErrorIs(t, err, hl7.ErrInvalidMessage, data)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\F\b\S\c\R\d\T\e\E\f`, hl7.DefaultEncoding.EscapeText(`a|b^c~d&e\f`))
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("EST", -5*3600)
+
+	ts, err := hl7.ParseTime("20240301093000", loc)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 9, 30, 0, 0, loc).Unix(), ts.Unix())
+
+	ts, err = hl7.ParseTime("202403010930+0100", loc)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC).Unix(), ts.Unix())
+
+	ts, err = hl7.ParseTime("19850214", loc)
+	require.NoError(t, err)
+	assert.Equal(t, 14, ts.Day())
+
+	_, err = hl7.ParseTime("2024030", loc)
+	assert.ErrorIs(t, err, hl7.ErrInvalidMessage)
+}
+
+func TestNewACK(t *testing.T) {
+	msg, err := hl7.Parse([]byte(sampleADT))
+	require.NoError(t, err)
+
+	ack, err := hl7.Parse(hl7.NewACK(msg, hl7.AckError, "bad|field"))
+	require.NoError(t, err)
+
+	msh := ack.Segment("MSH")
+	assert.Equal(t, "HMS", msh.Field(3))
+	assert.Equal(t, "HOSP", msh.Field(4))
+	assert.Equal(t, "REGADT", msh.Field(5))
+	assert.Equal(t, "REGION", msh.Field(6))
+	assert.Equal(t, "ACK", msh.Component(9, 1))
+	assert.Equal(t, "A01", msh.Component(9, 2))
+
+	msa := ack.Segment("MSA")
+	require.NotNil(t, msa)
+	assert.Equal(t, hl7.AckError, msa.Field(1))
+	assert.Equal(t, "MSG0001", msa.Field(2))
+	assert.Equal(t, "bad|field", msa.Field(3))
+}
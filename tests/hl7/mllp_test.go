@@ -0,0 +1,89 @@
+package hl7_test
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/infrastructure/mllp"
+	"hospital-management-system/pkg/hl7"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFrame(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("noise")
+	require.NoError(t, hl7.WriteFrame(&buf, []byte("MSH|first")))
+	require.NoError(t, hl7.WriteFrame(&buf, []byte("MSH|second")))
+	r := bufio.NewReader(&buf)
+
+	frame, err := hl7.ReadFrame(r)
+	require.NoError(t, err)
+	assert.Equal(t, "MSH|first", string(frame), "bytes before the start block are skipped")
+
+	frame, err = hl7.ReadFrame(r)
+	require.NoError(t, err)
+	assert.Equal(t, "MSH|second", string(frame))
+
+	_, err = hl7.ReadFrame(r)
+	assert.Error(t, err)
+}
+
+func TestServer_RoundTrip(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	handler := func(data []byte) []byte {
+		msg, err := hl7.Parse(data)
+		if err != nil {
+			return nil
+		}
+		return hl7.NewACK(msg, hl7.AckAccept, "")
+	}
+	server := mllp.NewServer("", handler, time.Second)
+	go server.Serve(l)
+	defer server.Close()
+
+	client, err := hl7.Dial(l.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	for _, id := range []string{"MSG1", "MSG2"} {
+		reply, err := client.Send([]byte(`MSH|^~\&|A|B|C|D|20240301||ADT^A08|`+id+`|P|2.5`+"\rPID|1"), time.Second)
+		require.NoError(t, err)
+
+		ack, err := hl7.Parse(reply)
+		require.NoError(t, err)
+		assert.Equal(t, hl7.AckAccept, ack.Segment("MSA").Field(1))
+		assert.Equal(t, id, ack.Segment("MSA").Field(2))
+	}
+}
+
+func TestServer_AllowSources(t *testing.T) {
+	handler := func(data []byte) []byte {
+		msg, err := hl7.Parse(data)
+		if err != nil {
+			return nil
+		}
+		return hl7.NewACK(msg, hl7.AckAccept, "")
+	}
+	server := mllp.NewServer("", handler, time.Second)
+	assert.Error(t, server.AllowSources([]string{"10.0.0.0/33"}))
+	require.NoError(t, server.AllowSources([]string{"10.0.0.0/8", "192.0.2.7"}))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(l)
+	defer server.Close()
+
+	client, err := hl7.Dial(l.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Send([]byte(`MSH|^~\&|A|B|C|D|20240301||ADT^A08|MSG1|P|2.5`+"\rPID|1"), time.Second)
+	assert.Error(t, err, "loopback is not an allowed source")
+}
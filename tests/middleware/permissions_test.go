@@ -0,0 +1,116 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hospital-management-system/internal/api/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		resource middleware.Resource
+		action   middleware.Action
+		want     bool
+	}{
+		{"receptionist reads patients", "receptionist", middleware.ResourcePatients, middleware.ActionRead, true},
+		{"receptionist creates patients", "receptionist", middleware.ResourcePatients, middleware.ActionCreate, true},
+		{"receptionist cannot delete patients", "receptionist", middleware.ResourcePatients, middleware.ActionDelete, false},
+		{"receptionist books appointments", "receptionist", middleware.ResourceAppointments, middleware.ActionCreate, true},
+		{"receptionist cannot update users", "receptionist", middleware.ResourceUsers, middleware.ActionUpdate, false},
+		{"receptionist cannot edit schedules", "receptionist", middleware.ResourceSchedules, middleware.ActionUpdate, false},
+		{"doctor updates patients", "doctor", middleware.ResourcePatients, middleware.ActionUpdate, true},
+		{"doctor cannot create patients", "doctor", middleware.ResourcePatients, middleware.ActionCreate, false},
+		{"doctor cannot delete patients", "doctor", middleware.ResourcePatients, middleware.ActionDelete, false},
+		{"doctor edits schedules", "doctor", middleware.ResourceSchedules, middleware.ActionUpdate, true},
+		{"doctor cannot revoke sessions", "doctor", middleware.ResourceSessions, middleware.ActionDelete, false},
+		{"doctor writes encounter notes", "doctor", middleware.ResourceEncounters, middleware.ActionCreate, true},
+		{"receptionist cannot read encounters", "receptionist", middleware.ResourceEncounters, middleware.ActionRead, false},
+		{"doctor issues prescriptions", "doctor", middleware.ResourcePrescriptions, middleware.ActionCreate, true},
+		{"admin cannot issue prescriptions", "admin", middleware.ResourcePrescriptions, middleware.ActionCreate, false},
+		{"receptionist reads medical history", "receptionist", middleware.ResourceMedicalHistory, middleware.ActionRead, true},
+		{"receptionist cannot record allergies", "receptionist", middleware.ResourceMedicalHistory, middleware.ActionCreate, false},
+		{"doctor deletes history entries", "doctor", middleware.ResourceMedicalHistory, middleware.ActionDelete, true},
+		{"doctor records vitals", "doctor", middleware.ResourceVitals, middleware.ActionCreate, true},
+		{"receptionist cannot read vitals", "receptionist", middleware.ResourceVitals, middleware.ActionRead, false},
+		{"admin configures vital ranges", "admin", middleware.ResourceVitalRanges, middleware.ActionUpdate, true},
+		{"doctor cannot configure vital ranges", "doctor", middleware.ResourceVitalRanges, middleware.ActionUpdate, false},
+		{"doctor orders labs", "doctor", middleware.ResourceLabs, middleware.ActionCreate, true},
+		{"admin cannot order labs", "admin", middleware.ResourceLabs, middleware.ActionCreate, false},
+		{"admin starts bulk exports", "admin", middleware.ResourceBulkExport, middleware.ActionCreate, true},
+		{"doctor cannot bulk export", "doctor", middleware.ResourceBulkExport, middleware.ActionCreate, false},
+		{"admin exports patients", "admin", middleware.ResourcePatientExport, middleware.ActionRead, true},
+		{"receptionist cannot export patients", "receptionist", middleware.ResourcePatientExport, middleware.ActionRead, false},
+		{"receptionist admits patients", "receptionist", middleware.ResourceAdmissions, middleware.ActionCreate, true},
+		{"doctor discharges patients", "doctor", middleware.ResourceAdmissions, middleware.ActionUpdate, true},
+		{"admin cannot admit patients", "admin", middleware.ResourceAdmissions, middleware.ActionCreate, false},
+		{"admin sets up wards", "admin", middleware.ResourceWards, middleware.ActionCreate, true},
+		{"receptionist reads the bed board", "receptionist", middleware.ResourceWards, middleware.ActionRead, true},
+		{"doctor cannot set up wards", "doctor", middleware.ResourceWards, middleware.ActionCreate, false},
+		{"compliance cannot read the bed board", "compliance", middleware.ResourceWards, middleware.ActionRead, false},
+		{"billing issues invoices", "billing", middleware.ResourceBilling, middleware.ActionCreate, true},
+		{"billing cannot update patients", "billing", middleware.ResourcePatients, middleware.ActionUpdate, false},
+		{"admin cannot bill", "admin", middleware.ResourceBilling, middleware.ActionRead, false},
+		{"receptionist cannot bill", "receptionist", middleware.ResourceBilling, middleware.ActionCreate, false},
+		{"admin deletes patients", "admin", middleware.ResourcePatients, middleware.ActionDelete, true},
+		{"admin updates users", "admin", middleware.ResourceUsers, middleware.ActionUpdate, true},
+		{"admin revokes sessions", "admin", middleware.ResourceSessions, middleware.ActionDelete, true},
+		{"admin resets MFA", "admin", middleware.ResourceMFA, middleware.ActionDelete, true},
+		{"doctor cannot reset MFA", "doctor", middleware.ResourceMFA, middleware.ActionDelete, false},
+		{"admin unlocks users", "admin", middleware.ResourceLockouts, middleware.ActionDelete, true},
+		{"receptionist cannot unlock users", "receptionist", middleware.ResourceLockouts, middleware.ActionDelete, false},
+		{"compliance reads audit trail", "compliance", middleware.ResourceAudit, middleware.ActionRead, true},
+		{"compliance cannot read patients", "compliance", middleware.ResourcePatients, middleware.ActionRead, false},
+		{"receptionist cannot read audit trail", "receptionist", middleware.ResourceAudit, middleware.ActionRead, false},
+		{"unknown role is denied", "janitor", middleware.ResourcePatients, middleware.ActionRead, false},
+		{"empty role is denied", "", middleware.ResourcePatients, middleware.ActionRead, false},
+		{"unknown resource is denied", "admin", middleware.Resource("reactor"), middleware.ActionRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, middleware.HasPermission(tt.role, tt.resource, tt.action))
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{"allowed role reaches handler", "admin", http.StatusOK},
+		{"forbidden role gets 403", "receptionist", http.StatusForbidden},
+		{"missing role gets 403", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.DELETE("/patients/:id",
+				func(c *gin.Context) {
+					if tt.role != "" {
+						c.Set("role", tt.role)
+					}
+				},
+				middleware.RequirePermission(middleware.ResourcePatients, middleware.ActionDelete),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/patients/1", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
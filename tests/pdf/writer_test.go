@@ -0,0 +1,73 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hospital-management-system/pkg/pdf"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkStructure verifies the cross-reference table points at every object
+// and returns the number of pages
+func checkStructure(t *testing.T, doc []byte) int {
+	s := string(doc)
+	require.True(t, strings.HasPrefix(s, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(s, "%%EOF\n"))
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(s)
+	require.NotNil(t, m)
+	xref, _ := strconv.Atoi(m[1])
+	lines := strings.Split(s[xref:], "\n")
+	require.Equal(t, "xref", lines[0])
+	var size int
+	_, err := fmt.Sscanf(lines[1], "0 %d", &size)
+	require.NoError(t, err)
+	for i := 1; i < size; i++ {
+		offset, err := strconv.Atoi(lines[2+i][:10])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(s[offset:], fmt.Sprintf("%d 0 obj\n", i)), "object %d", i)
+	}
+
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindStringSubmatch(s)
+	require.NotNil(t, count)
+	pages, _ := strconv.Atoi(count[1])
+	return pages
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := pdf.NewWriter(&buf, "Patients")
+	for i := 0; i < 120; i++ {
+		require.NoError(t, w.Line(fmt.Sprintf("row %d", i)))
+	}
+	require.NoError(t, w.Line("O'Brien (Seán) \\ 日本"))
+	require.NoError(t, w.Close())
+
+	doc := buf.Bytes()
+	assert.Equal(t, 3, checkStructure(t, doc))
+	assert.Contains(t, string(doc), "(Patients - page 3) Tj")
+	assert.Contains(t, string(doc), `(O'Brien \(Se\341n\) \\ ??) Tj`, "escaped and mapped to WinAnsi")
+}
+
+func TestWriter_EmptyDocumentHasAPage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, pdf.NewWriter(&buf, "Nothing").Close())
+
+	assert.Equal(t, 1, checkStructure(t, buf.Bytes()))
+}
+
+func TestWriter_CutsLongLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := pdf.NewWriter(&buf, "Wide")
+	require.NoError(t, w.Line(strings.Repeat("x", pdf.LineWidth+20)))
+	require.NoError(t, w.Close())
+
+	assert.Contains(t, buf.String(), "("+strings.Repeat("x", pdf.LineWidth)+") Tj")
+}
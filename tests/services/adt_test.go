@@ -0,0 +1,305 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adtFixture is a ward with two rooms: room 101 with beds A and B, and
+// room 102 with bed A.
+type adtFixture struct {
+	svc    *services.ADTService
+	repo   *fakeADTRepo
+	audit  *fakeAuditRepo
+	ward   models.Ward
+	bedA   models.Bed
+	bedB   models.Bed
+	bedC   models.Bed
+	doctor int64
+}
+
+func newADTFixture(t *testing.T) *adtFixture {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"},
+		&models.Patient{ID: 11, FirstName: "John", LastName: "Roe"},
+	)
+	users := newFakeUserRepo(
+		&models.User{ID: 1, Username: "dr.house", Role: "doctor"},
+		&models.User{ID: 2, Username: "frontdesk", Role: "receptionist"},
+	)
+	repo := newFakeADTRepo()
+	auditRepo := &fakeAuditRepo{}
+	f := &adtFixture{
+		svc:    services.NewADTService(repo, patients, users, services.NewAuditService(auditRepo)),
+		repo:   repo,
+		audit:  auditRepo,
+		ward:   models.Ward{Name: "  Cardiology "},
+		doctor: 1,
+	}
+
+	require.NoError(t, f.svc.CreateWard(&f.ward))
+	room101 := models.Room{Name: "101"}
+	require.NoError(t, f.svc.CreateRoom(uint(f.ward.ID), &room101))
+	room102 := models.Room{Name: "102"}
+	require.NoError(t, f.svc.CreateRoom(uint(f.ward.ID), &room102))
+	f.bedA = models.Bed{RoomID: room101.ID, Label: "A"}
+	require.NoError(t, f.svc.CreateBed(uint(f.ward.ID), &f.bedA))
+	f.bedB = models.Bed{RoomID: room101.ID, Label: "B"}
+	require.NoError(t, f.svc.CreateBed(uint(f.ward.ID), &f.bedB))
+	f.bedC = models.Bed{RoomID: room102.ID, Label: "A"}
+	require.NoError(t, f.svc.CreateBed(uint(f.ward.ID), &f.bedC))
+	return f
+}
+
+func (f *adtFixture) admit(t *testing.T, patientID, bedID int) *models.Admission {
+	admission := &models.Admission{PatientID: patientID, BedID: bedID, AttendingDoctorID: &f.doctor, Reason: "chest pain"}
+	require.NoError(t, f.svc.Admit(frontDesk, admission))
+	return admission
+}
+
+func TestADTService_SetUp(t *testing.T) {
+	f := newADTFixture(t)
+	assert.Equal(t, "Cardiology", f.ward.Name)
+	assert.Equal(t, "101", f.bedA.RoomName)
+
+	err := f.svc.CreateWard(&models.Ward{Name: " "})
+	assert.ErrorIs(t, err, services.ErrInvalidWard)
+
+	err = f.svc.CreateRoom(99, &models.Room{Name: "201"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	other := models.Ward{Name: "Oncology"}
+	require.NoError(t, f.svc.CreateWard(&other))
+	err = f.svc.CreateBed(uint(other.ID), &models.Bed{RoomID: f.bedA.RoomID, Label: "C"})
+	assert.ErrorIs(t, err, services.ErrInvalidWard, "room belongs to another ward")
+}
+
+func TestADTService_Admit(t *testing.T) {
+	f := newADTFixture(t)
+	admission := f.admit(t, 10, f.bedA.ID)
+
+	assert.Equal(t, models.AdmissionAdmitted, admission.Status)
+	assert.Equal(t, frontDesk.UserID, admission.AdmittedBy)
+	require.Len(t, admission.Occupancies, 1)
+	assert.Equal(t, f.bedA.ID, admission.Occupancies[0].BedID)
+	assert.Nil(t, admission.Occupancies[0].EndedAt)
+
+	require.Len(t, f.audit.entries, 1)
+	assert.Equal(t, models.AuditCreate, f.audit.entries[0].Action)
+	assert.Equal(t, 10, *f.audit.entries[0].PatientID)
+}
+
+func TestADTService_AdmitRejections(t *testing.T) {
+	f := newADTFixture(t)
+	f.admit(t, 10, f.bedA.ID)
+
+	err := f.svc.Admit(frontDesk, &models.Admission{PatientID: 11, BedID: f.bedA.ID})
+	assert.ErrorIs(t, err, services.ErrBedUnavailable, "one patient per bed")
+
+	err = f.svc.Admit(frontDesk, &models.Admission{PatientID: 10, BedID: f.bedB.ID})
+	assert.ErrorIs(t, err, services.ErrAlreadyAdmitted)
+
+	receptionist := int64(2)
+	err = f.svc.Admit(frontDesk, &models.Admission{PatientID: 11, BedID: f.bedB.ID, AttendingDoctorID: &receptionist})
+	assert.ErrorIs(t, err, services.ErrInvalidDoctor)
+
+	err = f.svc.Admit(frontDesk, &models.Admission{PatientID: 11, BedID: 999})
+	assert.ErrorIs(t, err, services.ErrInvalidAdmission)
+
+	err = f.svc.Admit(frontDesk, &models.Admission{PatientID: 99, BedID: f.bedB.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = f.svc.UpdateBed(uint(f.ward.ID), uint(f.bedB.ID), "", true)
+	require.NoError(t, err)
+	err = f.svc.Admit(frontDesk, &models.Admission{PatientID: 11, BedID: f.bedB.ID})
+	assert.ErrorIs(t, err, services.ErrBedUnavailable, "bed is out of service")
+}
+
+func TestADTService_TransferKeepsHistory(t *testing.T) {
+	f := newADTFixture(t)
+	admission := f.admit(t, 10, f.bedA.ID)
+
+	_, err := f.svc.Transfer(drHouse, 10, uint(admission.ID), f.bedA.ID, "")
+	assert.ErrorIs(t, err, services.ErrInvalidAdmission, "same bed")
+
+	other := f.admit(t, 11, f.bedB.ID)
+	_, err = f.svc.Transfer(drHouse, 10, uint(admission.ID), f.bedB.ID, "")
+	assert.ErrorIs(t, err, services.ErrBedUnavailable)
+
+	moved, err := f.svc.Transfer(drHouse, 10, uint(admission.ID), f.bedC.ID, "needs isolation")
+	require.NoError(t, err)
+	assert.Equal(t, f.bedC.ID, moved.BedID)
+	require.Len(t, moved.Occupancies, 2)
+	assert.NotNil(t, moved.Occupancies[0].EndedAt)
+	assert.Nil(t, moved.Occupancies[1].EndedAt)
+	assert.Equal(t, "needs isolation", moved.Occupancies[1].Reason)
+
+	history, err := f.svc.GetBedHistory(drHouse, uint(f.ward.ID), uint(f.bedA.ID))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, admission.ID, history[0].AdmissionID)
+
+	// Bed A is free again
+	_, err = f.svc.Transfer(drHouse, 11, uint(other.ID), f.bedA.ID, "")
+	require.NoError(t, err)
+
+	_, err = f.svc.Transfer(drHouse, 11, uint(admission.ID), f.bedB.ID, "")
+	assert.ErrorIs(t, err, sql.ErrNoRows, "another patient's admission")
+}
+
+func TestADTService_Discharge(t *testing.T) {
+	f := newADTFixture(t)
+	admission := f.admit(t, 10, f.bedA.ID)
+
+	_, err := f.svc.Discharge(drHouse, 10, uint(admission.ID), "spa")
+	assert.ErrorIs(t, err, services.ErrInvalidAdmission)
+
+	discharged, err := f.svc.Discharge(drHouse, 10, uint(admission.ID), "")
+	require.NoError(t, err)
+	assert.Equal(t, models.AdmissionDischarged, discharged.Status)
+	assert.Equal(t, models.DispositionHome, discharged.Disposition)
+	assert.Equal(t, drHouse.UserID, *discharged.DischargedBy)
+	assert.NotNil(t, discharged.Occupancies[0].EndedAt)
+
+	_, err = f.svc.Discharge(drHouse, 10, uint(admission.ID), "")
+	assert.ErrorIs(t, err, services.ErrAdmissionStatus)
+	_, err = f.svc.Transfer(drHouse, 10, uint(admission.ID), f.bedB.ID, "")
+	assert.ErrorIs(t, err, services.ErrAdmissionStatus)
+
+	// The bed and the patient are free for a new admission
+	again := f.admit(t, 10, f.bedA.ID)
+	admissions, err := f.svc.GetAdmissions(drHouse, 10)
+	require.NoError(t, err)
+	require.Len(t, admissions, 2)
+	assert.Equal(t, again.ID, admissions[0].ID)
+}
+
+// staleADTRepo answers FindAdmissionByID and FindBedByID with the
+// admissions and beds as they were when it was made, like a read that
+// raced another change
+type staleADTRepo struct {
+	*fakeADTRepo
+	snapshot map[int]models.Admission
+	beds     map[int]models.Bed
+}
+
+func newStaleADTRepo(repo *fakeADTRepo) *staleADTRepo {
+	snapshot := map[int]models.Admission{}
+	for id, admission := range repo.admissions {
+		snapshot[id] = admission
+	}
+	beds := map[int]models.Bed{}
+	for id := range repo.beds {
+		bed, _ := repo.FindBedByID(uint(id))
+		beds[id] = *bed
+	}
+	return &staleADTRepo{fakeADTRepo: repo, snapshot: snapshot, beds: beds}
+}
+
+func (r *staleADTRepo) FindBedByID(id uint) (*models.Bed, error) {
+	bed, ok := r.beds[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &bed, nil
+}
+
+func (r *staleADTRepo) FindAdmissionByID(id uint) (*models.Admission, error) {
+	a, ok := r.snapshot[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &a, nil
+}
+
+func TestADTService_DischargeRace(t *testing.T) {
+	f := newADTFixture(t)
+	admission := f.admit(t, 10, f.bedA.ID)
+	stale := services.NewADTService(newStaleADTRepo(f.repo), newFakePatientRepo(), newFakeUserRepo(),
+		services.NewAuditService(&fakeAuditRepo{}))
+
+	// The stale service still sees the admission open after it is discharged
+	discharged, err := f.svc.Discharge(drHouse, 10, uint(admission.ID), models.DispositionHome)
+	require.NoError(t, err)
+
+	_, err = stale.Discharge(drHouse, 10, uint(admission.ID), models.DispositionDeceased)
+	assert.ErrorIs(t, err, services.ErrAdmissionStatus)
+	_, err = stale.Transfer(drHouse, 10, uint(admission.ID), f.bedB.ID, "")
+	assert.ErrorIs(t, err, services.ErrAdmissionStatus)
+
+	stored, err := f.repo.FindAdmissionByID(uint(admission.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.DispositionHome, stored.Disposition, "the first discharge stands")
+	assert.Equal(t, discharged.DischargedAt, stored.DischargedAt)
+	assert.Len(t, stored.Occupancies, 1)
+}
+
+func TestADTService_OutOfServiceRace(t *testing.T) {
+	f := newADTFixture(t)
+	stale := services.NewADTService(newStaleADTRepo(f.repo),
+		newFakePatientRepo(&models.Patient{ID: 11, FirstName: "John", LastName: "Roe"}),
+		newFakeUserRepo(&models.User{ID: 1, Username: "dr.house", Role: "doctor"}),
+		services.NewAuditService(&fakeAuditRepo{}))
+
+	// The stale service sees bed A empty and bed C in service
+	f.admit(t, 10, f.bedA.ID)
+	_, err := f.svc.UpdateBed(uint(f.ward.ID), uint(f.bedC.ID), "", true)
+	require.NoError(t, err)
+
+	_, err = stale.UpdateBed(uint(f.ward.ID), uint(f.bedA.ID), "", true)
+	assert.ErrorIs(t, err, services.ErrBedUnavailable, "an occupied bed stays in service")
+	err = stale.Admit(frontDesk, &models.Admission{PatientID: 11, BedID: f.bedC.ID, AttendingDoctorID: &f.doctor})
+	assert.ErrorIs(t, err, services.ErrBedUnavailable, "no one is admitted to an out-of-service bed")
+
+	bed, err := f.repo.FindBedByID(uint(f.bedA.ID))
+	require.NoError(t, err)
+	assert.False(t, bed.OutOfService)
+	_, err = f.repo.FindActiveAdmission(11)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestADTService_BedBoardAndCensus(t *testing.T) {
+	f := newADTFixture(t)
+	admission := f.admit(t, 10, f.bedB.ID)
+	_, err := f.svc.UpdateBed(uint(f.ward.ID), uint(f.bedC.ID), "", true)
+	require.NoError(t, err)
+
+	_, err = f.svc.UpdateBed(uint(f.ward.ID), uint(f.bedB.ID), "", true)
+	assert.ErrorIs(t, err, services.ErrBedUnavailable, "occupied bed cannot go out of service")
+
+	board, err := f.svc.GetBedBoard(frontDesk, uint(f.ward.ID))
+	require.NoError(t, err)
+	require.Len(t, board, 3)
+	assert.Equal(t, models.BedAvailable, board[0].Status)
+	assert.Equal(t, models.BedOccupied, board[1].Status)
+	require.NotNil(t, board[1].Occupant)
+	assert.Equal(t, admission.ID, board[1].Occupant.AdmissionID)
+	assert.Equal(t, models.BedOutOfService, board[2].Status)
+
+	_, err = f.svc.GetBedBoard(frontDesk, 99)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	empty := models.Ward{Name: "Maternity"}
+	require.NoError(t, f.svc.CreateWard(&empty))
+
+	census, err := f.svc.GetCensus()
+	require.NoError(t, err)
+	require.Len(t, census.Wards, 2)
+	cardiology := census.Wards[0]
+	assert.Equal(t, 3, cardiology.Beds)
+	assert.Equal(t, 1, cardiology.Occupied)
+	assert.Equal(t, 1, cardiology.Available)
+	assert.Equal(t, 1, cardiology.OutOfService)
+	assert.InDelta(t, 0.5, cardiology.OccupancyRate, 1e-9)
+	assert.Zero(t, census.Wards[1].OccupancyRate, "no beds")
+	assert.Equal(t, 3, census.Total.Beds)
+	assert.InDelta(t, 0.5, census.Total.OccupancyRate, 1e-9)
+	assert.WithinDuration(t, time.Now(), census.GeneratedAt, time.Minute)
+}
@@ -0,0 +1,119 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAppointmentService() *services.AppointmentService {
+	users := newFakeUserRepo(
+		&models.User{ID: 1, Username: "dr.house", Role: "doctor"},
+		&models.User{ID: 2, Username: "frontdesk", Role: "receptionist"},
+	)
+	patients := newFakePatientRepo(&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"})
+	return services.NewAppointmentService(newFakeAppointmentRepo(), users, patients)
+}
+
+func appointmentAt(start time.Time, minutes int) *models.Appointment {
+	return &models.Appointment{
+		PatientID: 10,
+		DoctorID:  1,
+		StartTime: start,
+		EndTime:   start.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func TestCreateAppointment_DefaultsToScheduled(t *testing.T) {
+	svc := newAppointmentService()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	appointment := appointmentAt(start, 30)
+	err := svc.CreateAppointment(appointment)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AppointmentScheduled, appointment.Status)
+	assert.NotZero(t, appointment.ID)
+}
+
+func TestCreateAppointment_RejectsDoubleBooking(t *testing.T) {
+	svc := newAppointmentService()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, svc.CreateAppointment(appointmentAt(start, 30)))
+
+	// Overlapping slot
+	err := svc.CreateAppointment(appointmentAt(start.Add(15*time.Minute), 30))
+	assert.ErrorIs(t, err, services.ErrAppointmentConflict)
+
+	// The same instant sent with another UTC offset
+	err = svc.CreateAppointment(appointmentAt(start.In(time.FixedZone("EST", -5*3600)), 30))
+	assert.ErrorIs(t, err, services.ErrAppointmentConflict)
+
+	// Back-to-back slot is fine
+	assert.NoError(t, svc.CreateAppointment(appointmentAt(start.Add(30*time.Minute), 30)))
+}
+
+// racingAppointmentRepo sees no overlapping appointments, like a check
+// that ran before another booking for the slot was saved
+type racingAppointmentRepo struct {
+	*fakeAppointmentRepo
+}
+
+func (r racingAppointmentRepo) FindOverlapping(int64, time.Time, time.Time, int) ([]models.Appointment, error) {
+	return nil, nil
+}
+
+func TestCreateAppointment_DatabaseCatchesRacingDoubleBooking(t *testing.T) {
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "dr.house", Role: "doctor"})
+	patients := newFakePatientRepo(&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"})
+	svc := services.NewAppointmentService(racingAppointmentRepo{newFakeAppointmentRepo()}, users, patients)
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, svc.CreateAppointment(appointmentAt(start, 30)))
+
+	err := svc.CreateAppointment(appointmentAt(start.Add(15*time.Minute), 30))
+	assert.ErrorIs(t, err, services.ErrAppointmentConflict)
+}
+
+func TestCreateAppointment_CancelledSlotCanBeRebooked(t *testing.T) {
+	svc := newAppointmentService()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	first := appointmentAt(start, 30)
+	assert.NoError(t, svc.CreateAppointment(first))
+
+	first.Status = models.AppointmentCancelled
+	assert.NoError(t, svc.UpdateAppointment(first))
+
+	assert.NoError(t, svc.CreateAppointment(appointmentAt(start, 30)))
+}
+
+func TestUpdateAppointment_DoesNotConflictWithItself(t *testing.T) {
+	svc := newAppointmentService()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	appointment := appointmentAt(start, 30)
+	assert.NoError(t, svc.CreateAppointment(appointment))
+
+	appointment.EndTime = start.Add(45 * time.Minute)
+	assert.NoError(t, svc.UpdateAppointment(appointment))
+}
+
+func TestCreateAppointment_Validation(t *testing.T) {
+	svc := newAppointmentService()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	notADoctor := appointmentAt(start, 30)
+	notADoctor.DoctorID = 2
+	assert.ErrorIs(t, svc.CreateAppointment(notADoctor), services.ErrInvalidDoctor)
+
+	unknownPatient := appointmentAt(start, 30)
+	unknownPatient.PatientID = 99
+	assert.ErrorIs(t, svc.CreateAppointment(unknownPatient), services.ErrInvalidPatient)
+
+	backwards := appointmentAt(start, -30)
+	assert.ErrorIs(t, svc.CreateAppointment(backwards), services.ErrInvalidAppointment)
+}
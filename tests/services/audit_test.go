@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var frontDesk = models.Actor{UserID: 2, Username: "frontdesk", Role: "receptionist"}
+
+func newAuditedPatientService() (*services.PatientService, *fakeAuditRepo) {
+	audit := &fakeAuditRepo{}
+	identifiers := newFakeIdentifierRepo()
+	mrn := services.NewMRNGenerator(identifiers, "MRN", "01")
+	return services.NewPatientService(newFakePatientRepo(), identifiers, mrn, services.NewAuditService(audit)), audit
+}
+
+func TestPatientService_AuditsEveryCall(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+	patient := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+
+	require.NoError(t, svc.CreatePatient(frontDesk, patient, false))
+	_, err := svc.GetPatientByID(frontDesk, uint(patient.ID))
+	require.NoError(t, err)
+	updated := *patient
+	updated.Phone = "5551234567"
+	_, err = svc.UpdatePatient(frontDesk, &updated)
+	require.NoError(t, err)
+	_, err = svc.ListPatients(frontDesk, models.PatientListQuery{}, "")
+	require.NoError(t, err)
+	require.NoError(t, svc.DeletePatient(frontDesk, uint(patient.ID)))
+
+	require.Len(t, audit.entries, 5)
+	actions := []string{}
+	for _, e := range audit.entries {
+		actions = append(actions, e.Action)
+		assert.Equal(t, int64(2), e.ActorID)
+		assert.Equal(t, "frontdesk", e.ActorUsername)
+	}
+	assert.Equal(t, []string{"create", "read", "update", "list", "delete"}, actions)
+	assert.Nil(t, audit.entries[3].PatientID)
+
+	var changes map[string]models.FieldChange
+	require.NoError(t, json.Unmarshal(audit.entries[2].Changes, &changes))
+	assert.Equal(t, map[string]models.FieldChange{"phone": {Before: "", After: "5551234567"}}, changes)
+}
+
+// failingAuditRepo cannot write the audit trail
+type failingAuditRepo struct {
+	fakeAuditRepo
+}
+
+func (r *failingAuditRepo) Append(entry *models.AuditEntry) error {
+	return errors.New("audit log unavailable")
+}
+
+func TestPatientService_AuditFailureAfterWrite(t *testing.T) {
+	identifiers := newFakeIdentifierRepo()
+	patients := newFakePatientRepo()
+	svc := services.NewPatientService(patients, identifiers, services.NewMRNGenerator(identifiers, "MRN", "01"),
+		services.NewAuditService(&failingAuditRepo{}))
+
+	// The patient is saved, so the caller must not be told otherwise
+	patient := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, patient, false))
+	assert.Contains(t, patients.patients, patient.ID)
+	report, err := svc.ImportPatients(frontDesk, services.ImportFormatCSV, []byte(importCSV),
+		services.PatientImportOptions{Mapping: importMapping})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+
+	// Reads are still refused when they cannot be recorded
+	_, err = svc.GetPatientByID(frontDesk, uint(patient.ID))
+	assert.Error(t, err)
+}
+
+func TestDiffPatients(t *testing.T) {
+	dob := time.Date(1980, 1, 2, 0, 0, 0, 0, time.UTC)
+	before := &models.Patient{ID: 1, FirstName: "Jane", LastName: "Doe", DOB: dob, CreatedAt: time.Now()}
+	after := &models.Patient{ID: 1, FirstName: "Janet", LastName: "Doe", DOB: dob.In(time.FixedZone("X", 3600))}
+
+	changes := services.DiffPatients(before, after)
+
+	// Same instant in another zone and bookkeeping timestamps are not changes
+	assert.Equal(t, map[string]models.FieldChange{"first_name": {Before: "Jane", After: "Janet"}}, changes)
+
+	created := services.DiffPatients(nil, after)
+	assert.Contains(t, created, "first_name")
+	assert.Nil(t, created["first_name"].Before)
+}
+
+func TestVerifyChain(t *testing.T) {
+	audit := &fakeAuditRepo{}
+	svc := services.NewAuditService(audit)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, svc.RecordPatientAccess(frontDesk, models.AuditRead, i, nil, nil))
+	}
+
+	brokenAt, checked, err := svc.VerifyChain()
+	require.NoError(t, err)
+	assert.Zero(t, brokenAt)
+	assert.Equal(t, 3, checked)
+
+	// Rewriting history breaks the chain at the tampered entry
+	audit.entries[1].ActorUsername = "someone-else"
+	brokenAt, _, err = svc.VerifyChain()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), brokenAt)
+}
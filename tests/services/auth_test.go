@@ -0,0 +1,160 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassword = "Str0ng!Pass"
+
+// testThrottle never gets in the way of tests that fail a login or two
+var testThrottle = services.LoginThrottleSettings{
+	MaxUsernameFailures: 10,
+	MaxIPFailures:       20,
+	BaseDelay:           time.Nanosecond,
+	LockoutDuration:     time.Hour,
+}
+
+func newAuthService(t *testing.T) (*services.AuthService, *fakeTokenRepo) {
+	svc, tokens, _ := newThrottledAuthService(t, testThrottle)
+	return svc, tokens
+}
+
+func newThrottledAuthService(t *testing.T, settings services.LoginThrottleSettings) (*services.AuthService, *fakeTokenRepo, *fakeAuditRepo) {
+	utils.SetJWTSecret("test-secret-key")
+	hash, err := utils.HashPassword(testPassword)
+	require.NoError(t, err)
+
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "frontdesk", Password: hash, Role: "receptionist"})
+	tokens := newFakeTokenRepo()
+	auditRepo := &fakeAuditRepo{}
+	audit := services.NewAuditService(auditRepo)
+	throttle := services.NewLoginThrottle(newFakeLoginThrottleRepo(), audit, settings)
+	return services.NewAuthService(users, tokens, newFakeMFARepo(), throttle, audit, "test-secret-key", "Test Hospital"), tokens, auditRepo
+}
+
+// newAuthServiceWithRepos sets the front desk user up with the given
+// token and MFA repositories
+func newAuthServiceWithRepos(t *testing.T, tokens repository.TokenRepository, mfa repository.MFARepository) *services.AuthService {
+	utils.SetJWTSecret("test-secret-key")
+	hash, err := utils.HashPassword(testPassword)
+	require.NoError(t, err)
+
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "frontdesk", Password: hash, Role: "receptionist"})
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	throttle := services.NewLoginThrottle(newFakeLoginThrottleRepo(), audit, testThrottle)
+	return services.NewAuthService(users, tokens, mfa, throttle, audit, "test-secret-key", "Test Hospital")
+}
+
+func TestLogin_IssuesTokenPair(t *testing.T) {
+	svc, _ := newAuthService(t)
+
+	user, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	claims, err := utils.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	svc, _ := newAuthService(t)
+
+	_, _, err := svc.LoginWithUser("frontdesk", "wrong", "10.0.0.1")
+
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+}
+
+func TestRefresh_RotatesAndDetectsReuse(t *testing.T) {
+	svc, _ := newAuthService(t)
+	_, first, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+
+	_, second, err := svc.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// Replaying the rotated token kills the whole session...
+	_, _, err = svc.Refresh(first.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// ...including the legitimate successor and its access token
+	_, _, err = svc.Refresh(second.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	_, err = svc.ValidateToken(second.AccessToken)
+	assert.Error(t, err)
+}
+
+// staleTokenRepo finds refresh tokens as they were before any rotation,
+// like a read that raced another refresh of the same token
+type staleTokenRepo struct {
+	*fakeTokenRepo
+}
+
+func (r staleTokenRepo) FindRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	token, err := r.fakeTokenRepo.FindRefreshTokenByHash(tokenHash)
+	if err == nil {
+		token.RevokedAt = nil
+	}
+	return token, err
+}
+
+func TestRefresh_ConcurrentRotationCountsAsReuse(t *testing.T) {
+	svc := newAuthServiceWithRepos(t, staleTokenRepo{newFakeTokenRepo()}, newFakeMFARepo())
+
+	_, first, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	_, second, err := svc.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+
+	// The losing refresh must not fork the session
+	_, _, err = svc.Refresh(first.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	_, err = svc.ValidateToken(second.AccessToken)
+	assert.Error(t, err, "the whole session ends")
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	svc, _ := newAuthService(t)
+	_, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	claims, err := utils.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Logout(claims.Id, time.Unix(claims.ExpiresAt, 0)))
+
+	revoked, err := svc.IsTokenRevoked(claims.Id)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	_, _, err = svc.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	svc, _ := newAuthService(t)
+	_, laptop, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	_, phone, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RevokeUserSessions(1))
+
+	for _, tokens := range []*models.TokenPair{laptop, phone} {
+		_, err := svc.ValidateToken(tokens.AccessToken)
+		assert.Error(t, err)
+		_, _, err = svc.Refresh(tokens.RefreshToken)
+		assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	}
+}
@@ -0,0 +1,261 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var billingClerk = models.Actor{UserID: 5, Username: "billing", Role: "billing"}
+
+func newBillingService(t *testing.T) (*services.BillingService, *fakeBillingRepo, *fakeEncounterRepo, *fakeAuditRepo) {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"},
+		&models.Patient{ID: 11, FirstName: "John", LastName: "Roe"},
+	)
+	encounters := newFakeEncounterRepo()
+	require.NoError(t, encounters.Create(&models.Encounter{PatientID: 10, DoctorID: 1, Type: models.EncounterOutpatient}))
+	repo := newFakeBillingRepo()
+	auditRepo := &fakeAuditRepo{}
+	return services.NewBillingService(repo, patients, encounters, services.NewAuditService(auditRepo)), repo, encounters, auditRepo
+}
+
+func issueInvoice(t *testing.T, svc *services.BillingService) *models.Invoice {
+	invoice := &models.Invoice{PatientID: 10, Lines: []models.InvoiceLine{
+		{ChargeCode: "99213", Quantity: 1},
+		{ChargeCode: "E0114", Quantity: 2},
+	}}
+	require.NoError(t, svc.CreateInvoice(billingClerk, invoice))
+	return invoice
+}
+
+func TestTaxCents(t *testing.T) {
+	assert.Equal(t, int64(0), services.TaxCents(11000, 0))
+	assert.Equal(t, int64(1089), services.TaxCents(13198, 825), "1088.835 rounds up")
+	assert.Equal(t, int64(1), services.TaxCents(10, 500), "half a cent rounds up")
+	assert.Equal(t, int64(0), services.TaxCents(9, 500))
+}
+
+func TestBillingService_CreateInvoice(t *testing.T) {
+	svc, _, _, audit := newBillingService(t)
+	invoice := issueInvoice(t, svc)
+
+	require.Len(t, invoice.Lines, 2)
+	visit, crutches := invoice.Lines[0], invoice.Lines[1]
+	assert.Equal(t, "Office visit", visit.Description)
+	assert.Equal(t, int64(11000), visit.TotalCents)
+	assert.Equal(t, int64(0), visit.TaxCents)
+	assert.Equal(t, 825, crutches.TaxRateBP)
+	assert.Equal(t, int64(13198), crutches.SubtotalCents)
+	assert.Equal(t, int64(1089), crutches.TaxCents)
+
+	assert.Equal(t, int64(24198), invoice.SubtotalCents)
+	assert.Equal(t, int64(1089), invoice.TaxCents)
+	assert.Equal(t, int64(25287), invoice.TotalCents)
+	assert.Equal(t, invoice.TotalCents, invoice.BalanceCents)
+	assert.Equal(t, models.InvoiceIssued, invoice.Status)
+	assert.Equal(t, billingClerk.UserID, invoice.IssuedBy)
+
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, models.AuditCreate, audit.entries[0].Action)
+	assert.Equal(t, 10, *audit.entries[0].PatientID)
+}
+
+func TestBillingService_CreateInvoiceValidation(t *testing.T) {
+	svc, _, encounters, _ := newBillingService(t)
+	require.NoError(t, encounters.Create(&models.Encounter{PatientID: 11, DoctorID: 1, Type: models.EncounterOutpatient}))
+	line := []models.InvoiceLine{{ChargeCode: "99213", Quantity: 1}}
+
+	cases := []struct {
+		name    string
+		invoice models.Invoice
+		err     error
+	}{
+		{"unknown patient", models.Invoice{PatientID: 99, Lines: line}, sql.ErrNoRows},
+		{"no lines", models.Invoice{PatientID: 10}, services.ErrInvalidInvoice},
+		{"unknown code", models.Invoice{PatientID: 10, Lines: []models.InvoiceLine{{ChargeCode: "00000", Quantity: 1}}}, services.ErrInvalidInvoice},
+		{"inactive code", models.Invoice{PatientID: 10, Lines: []models.InvoiceLine{{ChargeCode: "OLD01", Quantity: 1}}}, services.ErrInvalidInvoice},
+		{"zero quantity", models.Invoice{PatientID: 10, Lines: []models.InvoiceLine{{ChargeCode: "99213"}}}, services.ErrInvalidInvoice},
+		{"another patient's encounter", models.Invoice{PatientID: 10, EncounterID: intPtr(2), Lines: line}, services.ErrInvalidInvoice},
+		{"missing encounter", models.Invoice{PatientID: 10, EncounterID: intPtr(42), Lines: line}, services.ErrInvalidInvoice},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			invoice := tc.invoice
+			assert.ErrorIs(t, svc.CreateInvoice(billingClerk, &invoice), tc.err)
+		})
+	}
+
+	invoice := models.Invoice{PatientID: 10, EncounterID: intPtr(1), Lines: line}
+	assert.NoError(t, svc.CreateInvoice(billingClerk, &invoice))
+}
+
+func TestBillingService_PartialPaymentsAndRefunds(t *testing.T) {
+	svc, _, _, _ := newBillingService(t)
+	invoice := issueInvoice(t, svc)
+	id := uint(invoice.ID)
+
+	_, err := svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 100, Method: "barter"})
+	assert.ErrorIs(t, err, services.ErrInvalidPayment)
+	_, err = svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: invoice.TotalCents + 1, Method: models.PaymentCard})
+	assert.ErrorIs(t, err, services.ErrPaymentExceedsBalance)
+
+	paid, err := svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 20000, Method: models.PaymentCard})
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoicePartiallyPaid, paid.Status)
+	assert.Equal(t, int64(5287), paid.BalanceCents)
+	card := paid.Payments[0]
+
+	paid, err = svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 5287, Method: models.PaymentCash})
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoicePaid, paid.Status)
+	assert.Zero(t, paid.BalanceCents)
+
+	_, err = svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 1, Method: models.PaymentCash})
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus)
+
+	refunded, err := svc.RefundPayment(billingClerk, id, &models.InvoicePayment{RefundOf: &card.ID, AmountCents: 5000})
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoicePartiallyPaid, refunded.Status)
+	assert.Equal(t, int64(5000), refunded.BalanceCents)
+	refund := refunded.Payments[len(refunded.Payments)-1]
+	assert.Equal(t, models.PaymentKindRefund, refund.Kind)
+	assert.Equal(t, models.PaymentCard, refund.Method, "refunded the way it was paid")
+
+	_, err = svc.RefundPayment(billingClerk, id, &models.InvoicePayment{RefundOf: &card.ID, AmountCents: 15001})
+	assert.ErrorIs(t, err, services.ErrPaymentExceedsBalance, "only 15000 of the card payment is left")
+	_, err = svc.RefundPayment(billingClerk, id, &models.InvoicePayment{RefundOf: &refund.ID, AmountCents: 1})
+	assert.ErrorIs(t, err, services.ErrInvalidPayment, "a refund cannot be refunded")
+	_, err = svc.RefundPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 1})
+	assert.ErrorIs(t, err, services.ErrInvalidPayment)
+}
+
+func TestBillingService_VoidAndBalance(t *testing.T) {
+	svc, _, _, _ := newBillingService(t)
+	first := issueInvoice(t, svc)
+	second := issueInvoice(t, svc)
+
+	paid, err := svc.RecordPayment(billingClerk, uint(first.ID), &models.InvoicePayment{AmountCents: 1000, Method: models.PaymentCash})
+	require.NoError(t, err)
+	_, err = svc.VoidInvoice(billingClerk, uint(first.ID))
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus, "has a payment")
+
+	voided, err := svc.VoidInvoice(billingClerk, uint(second.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoiceVoid, voided.Status)
+	_, err = svc.RecordPayment(billingClerk, uint(second.ID), &models.InvoicePayment{AmountCents: 1, Method: models.PaymentCash})
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus)
+
+	balance, err := svc.GetBalance(billingClerk, 10)
+	require.NoError(t, err)
+	assert.Equal(t, first.TotalCents, balance.InvoicedCents, "void invoices are left out")
+	assert.Equal(t, int64(1000), balance.PaidCents)
+	assert.Equal(t, paid.BalanceCents, balance.BalanceCents)
+	assert.Equal(t, 1, balance.OpenInvoices)
+
+	invoices, err := svc.GetInvoices(billingClerk, 10)
+	require.NoError(t, err)
+	assert.Len(t, invoices, 2)
+}
+
+// staleBillingRepo answers FindInvoiceByID with the invoices as they were
+// when it was made, like a read that raced another change
+type staleBillingRepo struct {
+	*fakeBillingRepo
+	snapshot map[int]models.Invoice
+}
+
+func newStaleBillingRepo(repo *fakeBillingRepo) *staleBillingRepo {
+	snapshot := map[int]models.Invoice{}
+	for id, invoice := range repo.invoices {
+		snapshot[id] = invoice
+	}
+	return &staleBillingRepo{fakeBillingRepo: repo, snapshot: snapshot}
+}
+
+func (r *staleBillingRepo) FindInvoiceByID(id uint) (*models.Invoice, error) {
+	i := r.snapshot[int(id)]
+	i.BalanceCents = i.TotalCents - i.PaidCents + i.RefundedCents
+	return &i, nil
+}
+
+func TestBillingService_PaymentAndVoidRace(t *testing.T) {
+	svc, repo, _, _ := newBillingService(t)
+	voided := issueInvoice(t, svc)
+	paid := issueInvoice(t, svc)
+	stale := services.NewBillingService(newStaleBillingRepo(repo), newFakePatientRepo(), newFakeEncounterRepo(),
+		services.NewAuditService(&fakeAuditRepo{}))
+
+	// Both invoices look open to the stale service while they change
+	_, err := svc.VoidInvoice(billingClerk, uint(voided.ID))
+	require.NoError(t, err)
+	_, err = svc.RecordPayment(billingClerk, uint(paid.ID), &models.InvoicePayment{AmountCents: 1000, Method: models.PaymentCash})
+	require.NoError(t, err)
+
+	_, err = stale.RecordPayment(billingClerk, uint(voided.ID), &models.InvoicePayment{AmountCents: 1000, Method: models.PaymentCash})
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus, "a payment cannot revive a void invoice")
+	_, err = stale.VoidInvoice(billingClerk, uint(paid.ID))
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus, "nor can an invoice be voided with money on it")
+
+	invoice, err := svc.GetInvoice(billingClerk, uint(voided.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoiceVoid, invoice.Status)
+	assert.Empty(t, invoice.Payments)
+	invoice, err = svc.GetInvoice(billingClerk, uint(paid.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoicePartiallyPaid, invoice.Status)
+}
+
+func TestBillingService_RefundRace(t *testing.T) {
+	svc, repo, _, _ := newBillingService(t)
+	invoice := issueInvoice(t, svc)
+	id := uint(invoice.ID)
+	first, err := svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 10000, Method: models.PaymentCard})
+	require.NoError(t, err)
+	_, err = svc.RecordPayment(billingClerk, id, &models.InvoicePayment{AmountCents: 10000, Method: models.PaymentCard})
+	require.NoError(t, err)
+	payment := first.Payments[0]
+	stale := services.NewBillingService(newStaleBillingRepo(repo), newFakePatientRepo(), newFakeEncounterRepo(),
+		services.NewAuditService(&fakeAuditRepo{}))
+
+	// Both refunds see the first payment unrefunded
+	_, err = svc.RefundPayment(billingClerk, id, &models.InvoicePayment{RefundOf: &payment.ID, AmountCents: 10000})
+	require.NoError(t, err)
+	_, err = stale.RefundPayment(billingClerk, id, &models.InvoicePayment{RefundOf: &payment.ID, AmountCents: 10000})
+	assert.ErrorIs(t, err, services.ErrPaymentExceedsBalance, "the invoice has 10000 paid but the payment is refunded")
+
+	stored, err := svc.GetInvoice(billingClerk, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), stored.RefundedCents)
+	assert.Len(t, stored.Payments, 3)
+}
+
+func TestBillingService_Chargemaster(t *testing.T) {
+	svc, repo, _, _ := newBillingService(t)
+
+	err := svc.CreateChargeItem(&models.ChargeItem{Code: "99213", Description: "Dup", TaxCode: "exempt"})
+	assert.ErrorIs(t, err, services.ErrInvalidCharge)
+	err = svc.CreateChargeItem(&models.ChargeItem{Code: "A0001", Description: "Ambulance", TaxCode: "luxury"})
+	assert.ErrorIs(t, err, services.ErrInvalidCharge)
+	err = svc.SaveTaxRule(&models.TaxRule{Code: "luxury", Name: "Luxury", RateBP: 10001})
+	assert.ErrorIs(t, err, services.ErrInvalidCharge)
+
+	require.NoError(t, svc.SaveTaxRule(&models.TaxRule{Code: "luxury", Name: "Luxury", RateBP: 2000}))
+	require.NoError(t, svc.CreateChargeItem(&models.ChargeItem{Code: "A0001", Description: "Ambulance", UnitPriceCents: 50000, TaxCode: "luxury", Active: true}))
+
+	invoice := issueInvoice(t, svc)
+	require.NoError(t, svc.UpdateChargeItem(&models.ChargeItem{Code: "99213", Description: "Office visit", UnitPriceCents: 12000, TaxCode: "exempt", Active: true}))
+	assert.Equal(t, int64(12000), repo.items["99213"].UnitPriceCents)
+
+	stored, err := svc.GetInvoice(billingClerk, uint(invoice.ID))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11000), stored.Lines[0].UnitPriceCents, "issued invoices keep their prices")
+
+	err = svc.UpdateChargeItem(&models.ChargeItem{Code: "nope", Description: "x", TaxCode: "exempt"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,224 @@
+package services_test
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportAdmin = models.Actor{UserID: 5, Username: "admin", Role: "admin"}
+
+func newBulkExportService(t *testing.T) (*services.BulkExportService, *fakeExportJobRepo, *fakeAuditRepo) {
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	fhirService, _, identifiers, encounters := newFHIRService(
+		&models.Patient{ID: 1, MRN: "MRN0100000011", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com",
+			CreatedAt: updated.Add(-48 * time.Hour), UpdatedAt: updated.Add(-24 * time.Hour)},
+		&models.Patient{ID: 2, MRN: "MRN0100000029", FirstName: "Omar", LastName: "Haddad",
+			CreatedAt: updated.Add(-47 * time.Hour), UpdatedAt: updated},
+	)
+	require.NoError(t, identifiers.Create(&models.PatientIdentifier{PatientID: 2, System: models.IdentifierNationalID, Value: "555"}))
+	require.NoError(t, encounters.Create(&models.Encounter{PatientID: 1, DoctorID: 1, Type: models.EncounterOutpatient,
+		Status: models.EncounterFinished, StartTime: updated, UpdatedAt: updated}))
+
+	jobs := newFakeExportJobRepo()
+	auditRepo := &fakeAuditRepo{}
+	audit := services.NewAuditService(auditRepo)
+	return services.NewBulkExportService(jobs, fhirService, audit, t.TempDir(), "test-key", time.Hour, 24*time.Hour), jobs, auditRepo
+}
+
+// readNDJSON downloads an export file through a freshly signed link and
+// decodes each line
+func readNDJSON(t *testing.T, svc *services.BulkExportService, jobID, file string) []map[string]interface{} {
+	expires, signature := svc.SignFile(jobID, file)
+	f, err := svc.OpenFile(jobID, file, expires, signature, "192.0.2.1")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var resources []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var resource map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resource))
+		resources = append(resources, resource)
+	}
+	require.NoError(t, scanner.Err())
+	return resources
+}
+
+func TestBulkExport_WritesNDJSONPerType(t *testing.T) {
+	svc, _, _ := newBulkExportService(t)
+
+	job, err := svc.StartExport(exportAdmin, "http://fhir.test/fhir/R4/$export", nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, job.ID, 32)
+	assert.Equal(t, models.ExportInProgress, job.Status)
+	svc.Wait()
+
+	done, err := svc.GetExport(exportAdmin, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ExportCompleted, done.Status, done.Error)
+	assert.NotNil(t, done.CompletedAt)
+	assert.Equal(t, 3, done.Processed)
+	assert.Equal(t, []models.ExportOutput{
+		{Type: "Patient", File: "Patient.ndjson", Count: 2},
+		{Type: "Encounter", File: "Encounter.ndjson", Count: 1},
+	}, done.Output)
+
+	patients := readNDJSON(t, svc, job.ID, "Patient.ndjson")
+	require.Len(t, patients, 2)
+	assert.Equal(t, "Patient", patients[0]["resourceType"])
+	assert.Equal(t, "1", patients[0]["id"], "oldest patient first")
+	assert.Len(t, patients[1]["identifier"], 2, "MRN and national ID")
+
+	encounters := readNDJSON(t, svc, job.ID, "Encounter.ndjson")
+	require.Len(t, encounters, 1)
+	assert.Equal(t, "Patient/1", encounters[0]["subject"].(map[string]interface{})["reference"])
+}
+
+func TestBulkExport_TypeAndSince(t *testing.T) {
+	svc, _, _ := newBulkExportService(t)
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	job, err := svc.StartExport(exportAdmin, "http://fhir.test/fhir/R4/$export?_type=Patient", []string{"Patient"}, &since)
+	require.NoError(t, err)
+	svc.Wait()
+
+	done, err := svc.GetExport(exportAdmin, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExportOutput{{Type: "Patient", File: "Patient.ndjson", Count: 1}}, done.Output)
+	patients := readNDJSON(t, svc, job.ID, "Patient.ndjson")
+	require.Len(t, patients, 1)
+	assert.Equal(t, "2", patients[0]["id"], "only patients changed since _since")
+
+	_, err = svc.StartExport(exportAdmin, "", []string{"Observation"}, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidExport)
+}
+
+func TestBulkExport_SignedLinks(t *testing.T) {
+	svc, _, audit := newBulkExportService(t)
+	job, err := svc.StartExport(exportAdmin, "", []string{"Patient"}, nil)
+	require.NoError(t, err)
+	svc.Wait()
+
+	expires, signature := svc.SignFile(job.ID, "Patient.ndjson")
+	f, err := svc.OpenFile(job.ID, "Patient.ndjson", expires, signature, "192.0.2.1")
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+
+	last := audit.entries[len(audit.entries)-1]
+	assert.Equal(t, models.AuditExportDownload, last.Action)
+	assert.Equal(t, exportAdmin.UserID, last.ActorID, "recorded against the user the link was issued to")
+	var details map[string]string
+	require.NoError(t, json.Unmarshal(last.Changes, &details))
+	assert.Equal(t, map[string]string{"job_id": job.ID, "file": "Patient.ndjson", "client": "192.0.2.1"}, details)
+	downloads := len(audit.entries)
+
+	cases := map[string]func() error{
+		"tampered signature": func() error {
+			tampered := []byte(signature)
+			tampered[0] ^= 1
+			_, err := svc.OpenFile(job.ID, "Patient.ndjson", expires, string(tampered), "192.0.2.1")
+			return err
+		},
+		"extended expiry": func() error {
+			_, err := svc.OpenFile(job.ID, "Patient.ndjson", expires+3600, signature, "192.0.2.1")
+			return err
+		},
+		"other file": func() error {
+			_, err := svc.OpenFile(job.ID, "Encounter.ndjson", expires, signature, "192.0.2.1")
+			return err
+		},
+		"file not in export": func() error {
+			exp, sig := svc.SignFile(job.ID, "../secrets")
+			_, err := svc.OpenFile(job.ID, "../secrets", exp, sig, "192.0.2.1")
+			return err
+		},
+		"expired": func() error {
+			past := time.Now().Add(-time.Minute).Unix()
+			expired := services.NewBulkExportService(newFakeExportJobRepo(), nil, nil, t.TempDir(), "test-key", -2*time.Minute, 24*time.Hour)
+			exp, sig := expired.SignFile(job.ID, "Patient.ndjson")
+			require.Less(t, exp, past)
+			_, err := svc.OpenFile(job.ID, "Patient.ndjson", exp, sig, "192.0.2.1")
+			return err
+		},
+	}
+	for name, open := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, open(), services.ErrExportLink)
+		})
+	}
+	assert.Len(t, audit.entries, downloads, "refused links are not downloads")
+}
+
+func TestBulkExport_OwnerOnlyAndDelete(t *testing.T) {
+	svc, jobs, _ := newBulkExportService(t)
+	job, err := svc.StartExport(exportAdmin, "", nil, nil)
+	require.NoError(t, err)
+	svc.Wait()
+
+	otherAdmin := models.Actor{UserID: 6, Username: "admin2", Role: "admin"}
+	_, err = svc.GetExport(otherAdmin, job.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, svc.DeleteExport(otherAdmin, job.ID), sql.ErrNoRows)
+
+	expires, signature := svc.SignFile(job.ID, "Patient.ndjson")
+	require.NoError(t, svc.DeleteExport(exportAdmin, job.ID))
+	_, err = jobs.FindByID(job.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.OpenFile(job.ID, "Patient.ndjson", expires, signature, "192.0.2.1")
+	assert.ErrorIs(t, err, services.ErrExportLink, "links die with the export")
+}
+
+func TestBulkExport_FailInterruptedExports(t *testing.T) {
+	svc, jobs, _ := newBulkExportService(t)
+	require.NoError(t, jobs.Create(&models.ExportJob{ID: "stale", RequestedBy: exportAdmin.UserID, Status: models.ExportInProgress}))
+
+	n, err := svc.FailInterruptedExports()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	job, err := svc.GetExport(exportAdmin, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportFailed, job.Status)
+	assert.NotEmpty(t, job.Error)
+}
+
+func TestBulkExport_DeleteExpiredExports(t *testing.T) {
+	svc, jobs, _ := newBulkExportService(t)
+	job, err := svc.StartExport(exportAdmin, "", []string{"Patient"}, nil)
+	require.NoError(t, err)
+	svc.Wait()
+	require.NoError(t, jobs.Create(&models.ExportJob{ID: "running", RequestedBy: exportAdmin.UserID, Status: models.ExportInProgress}))
+
+	n, err := svc.DeleteExpiredExports()
+	require.NoError(t, err)
+	assert.Zero(t, n, "the export is still within its retention period")
+
+	expires, signature := svc.SignFile(job.ID, "Patient.ndjson")
+	old, err := jobs.FindByID(job.ID)
+	require.NoError(t, err)
+	finished := time.Now().Add(-25 * time.Hour)
+	old.CompletedAt = &finished
+	require.NoError(t, jobs.Update(old))
+	_, err = svc.OpenFile(job.ID, "Patient.ndjson", expires, signature, "192.0.2.1")
+	assert.ErrorIs(t, err, services.ErrExportLink, "links stop working once retention has passed")
+
+	n, err = svc.DeleteExpiredExports()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = jobs.FindByID(job.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = jobs.FindByID("running")
+	assert.NoError(t, err, "running exports are kept")
+}
@@ -0,0 +1,138 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	drHouse  = models.Actor{UserID: 1, Username: "dr.house", Role: "doctor"}
+	drWilson = models.Actor{UserID: 3, Username: "dr.wilson", Role: "doctor"}
+)
+
+func newEncounterService() *services.EncounterService {
+	users := newFakeUserRepo(
+		&models.User{ID: 1, Username: "dr.house", Role: "doctor"},
+		&models.User{ID: 2, Username: "frontdesk", Role: "receptionist"},
+		&models.User{ID: 3, Username: "dr.wilson", Role: "doctor"},
+	)
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"},
+		&models.Patient{ID: 11, FirstName: "Omar", LastName: "Haddad"},
+	)
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	return services.NewEncounterService(newFakeEncounterRepo(), &fakeClinicalNoteRepo{}, users, patients, audit)
+}
+
+func openEncounter(t *testing.T, svc *services.EncounterService) *models.Encounter {
+	encounter := &models.Encounter{PatientID: 10, Type: models.EncounterOutpatient}
+	require.NoError(t, svc.CreateEncounter(drHouse, encounter))
+	return encounter
+}
+
+func TestCreateEncounter_DefaultsToActingDoctor(t *testing.T) {
+	svc := newEncounterService()
+
+	encounter := openEncounter(t, svc)
+
+	assert.Equal(t, int64(1), encounter.DoctorID)
+	assert.Equal(t, models.EncounterInProgress, encounter.Status)
+	assert.False(t, encounter.StartTime.IsZero())
+}
+
+func TestCreateEncounter_Validation(t *testing.T) {
+	svc := newEncounterService()
+
+	err := svc.CreateEncounter(drHouse, &models.Encounter{PatientID: 10, Type: "spa"})
+	assert.ErrorIs(t, err, services.ErrInvalidEncounter)
+
+	err = svc.CreateEncounter(frontDesk, &models.Encounter{PatientID: 10, Type: models.EncounterOutpatient, DoctorID: 2})
+	assert.ErrorIs(t, err, services.ErrInvalidDoctor)
+
+	err = svc.CreateEncounter(drHouse, &models.Encounter{PatientID: 99, Type: models.EncounterOutpatient})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateEncounter_FinishStampsEndAndLocks(t *testing.T) {
+	svc := newEncounterService()
+	encounter := openEncounter(t, svc)
+
+	update := &models.Encounter{ID: encounter.ID, PatientID: 10, Status: models.EncounterFinished}
+	require.NoError(t, svc.UpdateEncounter(drHouse, update))
+	require.NotNil(t, update.EndTime)
+	assert.Equal(t, models.EncounterOutpatient, update.Type)
+
+	err := svc.UpdateEncounter(drHouse, &models.Encounter{ID: encounter.ID, PatientID: 10, Reason: "late edit"})
+	assert.ErrorIs(t, err, services.ErrInvalidEncounter)
+}
+
+func TestGetEncounter_OtherPatientIsNotFound(t *testing.T) {
+	svc := newEncounterService()
+	encounter := openEncounter(t, svc)
+
+	_, err := svc.GetEncounter(drHouse, 11, uint(encounter.ID))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestClinicalNote_OnlyAuthorEditsDraft(t *testing.T) {
+	svc := newEncounterService()
+	encounter := openEncounter(t, svc)
+
+	note, err := svc.AddNote(drHouse, 10, uint(encounter.ID), "Presenting with cough")
+	require.NoError(t, err)
+	assert.Equal(t, 1, note.Version)
+	assert.Equal(t, models.NoteDraft, note.Status)
+
+	_, err = svc.EditNote(drWilson, 10, uint(encounter.ID), note.ID, "Not my note")
+	assert.ErrorIs(t, err, services.ErrNotNoteAuthor)
+	_, err = svc.SignNote(drWilson, 10, uint(encounter.ID), note.ID)
+	assert.ErrorIs(t, err, services.ErrNotNoteAuthor)
+
+	edited, err := svc.EditNote(drHouse, 10, uint(encounter.ID), note.ID, "Presenting with dry cough")
+	require.NoError(t, err)
+	assert.Equal(t, 1, edited.Version)
+	assert.Equal(t, "Presenting with dry cough", edited.Content)
+}
+
+func TestClinicalNote_AmendmentAfterSigningAddsVersion(t *testing.T) {
+	svc := newEncounterService()
+	encounter := openEncounter(t, svc)
+	note, err := svc.AddNote(drHouse, 10, uint(encounter.ID), "Presenting with cough")
+	require.NoError(t, err)
+
+	// A draft must be edited, not amended
+	_, err = svc.AmendNote(drHouse, 10, uint(encounter.ID), note.ID, "Addendum")
+	assert.ErrorIs(t, err, services.ErrNoteLocked)
+
+	signed, err := svc.SignNote(drHouse, 10, uint(encounter.ID), note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NoteSigned, signed.Status)
+	assert.NotNil(t, signed.SignedAt)
+
+	_, err = svc.EditNote(drHouse, 10, uint(encounter.ID), note.ID, "Rewrite history")
+	assert.ErrorIs(t, err, services.ErrNoteLocked)
+
+	amendment, err := svc.AmendNote(drWilson, 10, uint(encounter.ID), note.ID, "Addendum: chest X-ray clear")
+	require.NoError(t, err)
+	assert.Equal(t, note.ID, amendment.ID)
+	assert.Equal(t, 2, amendment.Version)
+	assert.Equal(t, int64(3), amendment.AuthorID)
+	assert.Equal(t, models.NoteDraft, amendment.Status)
+
+	versions, err := svc.GetNoteVersions(drHouse, 10, uint(encounter.ID), note.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "Presenting with cough", versions[0].Content)
+	assert.Equal(t, models.NoteSigned, versions[0].Status)
+
+	notes, err := svc.GetNotes(drHouse, 10, uint(encounter.ID))
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, 2, notes[0].Version)
+}
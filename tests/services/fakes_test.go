@@ -0,0 +1,1933 @@
+package services_test
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/domain/repository"
+)
+
+// In-memory repositories used by the service tests
+
+type fakeUserRepo struct {
+	users map[int]*models.User
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: map[int]*models.User{}}
+	for _, u := range users {
+		r.users[int(u.ID)] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepo) Create(user *models.User) error {
+	user.ID = int64(len(r.users) + 1)
+	r.users[int(user.ID)] = user
+	return nil
+}
+
+func (r *fakeUserRepo) FindByID(id int) (*models.User, error) {
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeUserRepo) FindByUsername(username string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeUserRepo) Update(user *models.User) error {
+	if u, ok := r.users[int(user.ID)]; ok {
+		u.Username, u.Role = user.Username, user.Role
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(id int64, passwordHash string) error {
+	if u, ok := r.users[int(id)]; ok {
+		u.Password = passwordHash
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) Delete(id int) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepo) FindAll() ([]models.User, error) {
+	var users []models.User
+	for _, u := range r.users {
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+type fakePatientRepo struct {
+	patients map[int]*models.Patient
+	merges   []models.PatientMerge
+	// identifiers, if set, gets the former MRN of merged duplicates
+	identifiers *fakeIdentifierRepo
+	// withRecords lists patients with clinical or financial records, whom
+	// Delete refuses like the restricting foreign keys it stands in for
+	withRecords map[int]bool
+	// admitted lists patients with an open admission; Merge refuses to
+	// give the survivor a second one, like the unique index
+	admitted map[int]bool
+	nextID   int
+}
+
+func newFakePatientRepo(patients ...*models.Patient) *fakePatientRepo {
+	r := &fakePatientRepo{patients: map[int]*models.Patient{}}
+	for _, p := range patients {
+		r.patients[p.ID] = p
+		if p.ID > r.nextID {
+			r.nextID = p.ID
+		}
+	}
+	return r
+}
+
+func (r *fakePatientRepo) Create(patient *models.Patient) error {
+	r.nextID++
+	patient.ID = r.nextID
+	stored := *patient
+	r.patients[patient.ID] = &stored
+	return nil
+}
+
+func (r *fakePatientRepo) CreateMany(patients []*models.Patient) error {
+	for _, patient := range patients {
+		if err := r.Create(patient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakePatientRepo) FindByID(id uint) (*models.Patient, error) {
+	if p, ok := r.patients[int(id)]; ok {
+		return p, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePatientRepo) Update(patient *models.Patient) error {
+	stored := *patient
+	r.patients[patient.ID] = &stored
+	return nil
+}
+
+func (r *fakePatientRepo) Delete(id uint) error {
+	if r.withRecords[int(id)] {
+		return repository.ErrPatientHasRecords
+	}
+	delete(r.patients, int(id))
+	return nil
+}
+
+func (r *fakePatientRepo) FindAll() ([]models.Patient, error) {
+	var patients []models.Patient
+	for _, p := range r.patients {
+		patients = append(patients, *p)
+	}
+	return patients, nil
+}
+
+func (r *fakePatientRepo) FindByMRN(mrn string) (*models.Patient, error) {
+	for _, p := range r.patients {
+		if p.MRN == mrn {
+			return p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePatientRepo) FindWithoutMRN() ([]models.Patient, error) {
+	var patients []models.Patient
+	for _, p := range r.patients {
+		if p.MRN == "" {
+			patients = append(patients, *p)
+		}
+	}
+	sort.Slice(patients, func(i, j int) bool { return patients[i].ID < patients[j].ID })
+	return patients, nil
+}
+
+func (r *fakePatientRepo) SetMRN(id uint, mrn string) error {
+	if p, ok := r.patients[int(id)]; ok && p.MRN == "" {
+		p.MRN = mrn
+	}
+	return nil
+}
+
+// List supports sorting and cursors; of the filters only NamePrefix and the
+// DOB range are applied
+func (r *fakePatientRepo) List(q models.PatientListQuery) ([]models.Patient, error) {
+	key := func(p models.Patient) string {
+		switch q.SortBy {
+		case models.PatientSortLastName:
+			return p.LastName
+		case models.PatientSortDOB:
+			return p.DOB.Format("2006-01-02")
+		default:
+			return p.CreatedAt.Format(time.RFC3339Nano)
+		}
+	}
+	// before reports whether (k1, id1) sorts ahead of (k2, id2)
+	before := func(k1 string, id1 int, k2 string, id2 int) bool {
+		if k1 != k2 {
+			return (k1 < k2) != q.SortDesc
+		}
+		return id1 != id2 && (id1 < id2) != q.SortDesc
+	}
+
+	prefix := strings.ToLower(q.Filter.NamePrefix)
+	var all []models.Patient
+	for _, p := range r.patients {
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(p.LastName), prefix) &&
+			!strings.HasPrefix(strings.ToLower(p.FirstName), prefix) {
+			continue
+		}
+		if (q.Filter.DOBFrom != nil && p.DOB.Before(*q.Filter.DOBFrom)) ||
+			(q.Filter.DOBTo != nil && !p.DOB.Before(*q.Filter.DOBTo)) {
+			continue
+		}
+		if q.After != nil && !before(q.After.SortValue, q.After.ID, key(*p), p.ID) {
+			continue
+		}
+		all = append(all, *p)
+	}
+	sort.Slice(all, func(i, j int) bool { return before(key(all[i]), all[i].ID, key(all[j]), all[j].ID) })
+
+	if len(all) > q.Limit {
+		all = all[:q.Limit]
+	}
+	return all, nil
+}
+
+// Search matches on a case-insensitive name prefix only
+func (r *fakePatientRepo) Search(q string, limit int) ([]models.PatientMatch, error) {
+	var matches []models.PatientMatch
+	for _, p := range r.patients {
+		if strings.HasPrefix(strings.ToLower(p.LastName), strings.ToLower(q)) {
+			matches = append(matches, models.PatientMatch{Patient: *p, Similarity: 0.5})
+		}
+	}
+	return matches, nil
+}
+
+// FindDuplicateCandidates flags exact name, DOB, phone and email agreement
+func (r *fakePatientRepo) FindDuplicateCandidates(patient *models.Patient) ([]models.PatientMatch, error) {
+	var matches []models.PatientMatch
+	for _, p := range r.patients {
+		if p.ID == patient.ID {
+			continue
+		}
+		m := models.PatientMatch{
+			Patient:    *p,
+			DOBMatch:   p.DOB.Equal(patient.DOB),
+			PhoneMatch: patient.Phone != "" && p.Phone == patient.Phone,
+			EmailMatch: patient.Email != "" && strings.EqualFold(p.Email, patient.Email),
+		}
+		if strings.EqualFold(p.FirstName+" "+p.LastName, patient.FirstName+" "+patient.LastName) {
+			m.Similarity = 1
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+func (r *fakePatientRepo) Merge(survivor *models.Patient, duplicateID uint, merge *models.PatientMerge) error {
+	if r.admitted[survivor.ID] && r.admitted[int(duplicateID)] {
+		return repository.ErrPatientAdmitted
+	}
+	if duplicate := r.patients[int(duplicateID)]; r.identifiers != nil && duplicate.MRN != "" {
+		former := &models.PatientIdentifier{PatientID: survivor.ID, System: models.IdentifierMRN, Value: duplicate.MRN}
+		if err := r.identifiers.Create(former); err != nil {
+			return err
+		}
+	}
+	r.patients[survivor.ID] = survivor
+	delete(r.patients, int(duplicateID))
+	merge.ID = int64(len(r.merges) + 1)
+	merge.MergedAt = time.Now()
+	r.merges = append(r.merges, *merge)
+	return nil
+}
+
+func (r *fakePatientRepo) FindMerges(survivorID uint) ([]models.PatientMerge, error) {
+	var merges []models.PatientMerge
+	for _, m := range r.merges {
+		if m.SurvivorID == int(survivorID) {
+			merges = append(merges, m)
+		}
+	}
+	return merges, nil
+}
+
+type fakeIdentifierRepo struct {
+	sequences   map[string]int64
+	identifiers map[int]*models.PatientIdentifier
+	nextID      int
+}
+
+func newFakeIdentifierRepo() *fakeIdentifierRepo {
+	return &fakeIdentifierRepo{sequences: map[string]int64{}, identifiers: map[int]*models.PatientIdentifier{}}
+}
+
+func (r *fakeIdentifierRepo) NextMRNSequence(facilityCode string) (int64, error) {
+	r.sequences[facilityCode]++
+	return r.sequences[facilityCode], nil
+}
+
+func (r *fakeIdentifierRepo) Create(identifier *models.PatientIdentifier) error {
+	r.nextID++
+	identifier.ID = r.nextID
+	identifier.CreatedAt = time.Now()
+	stored := *identifier
+	r.identifiers[identifier.ID] = &stored
+	return nil
+}
+
+func (r *fakeIdentifierRepo) FindByID(id uint) (*models.PatientIdentifier, error) {
+	if i, ok := r.identifiers[int(id)]; ok {
+		return i, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeIdentifierRepo) FindByPatient(patientID uint) ([]models.PatientIdentifier, error) {
+	identifiers := []models.PatientIdentifier{}
+	for _, i := range r.identifiers {
+		if i.PatientID == int(patientID) {
+			identifiers = append(identifiers, *i)
+		}
+	}
+	sort.Slice(identifiers, func(i, j int) bool {
+		if identifiers[i].System != identifiers[j].System {
+			return identifiers[i].System < identifiers[j].System
+		}
+		return identifiers[i].Value < identifiers[j].Value
+	})
+	return identifiers, nil
+}
+
+func (r *fakeIdentifierRepo) FindBySystemValue(system, value string) (*models.PatientIdentifier, error) {
+	for _, i := range r.identifiers {
+		if i.System == system && i.Value == value {
+			return i, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeIdentifierRepo) Delete(id uint) error {
+	delete(r.identifiers, int(id))
+	return nil
+}
+
+type fakeEncounterRepo struct {
+	encounters map[int]*models.Encounter
+	nextID     int
+}
+
+func newFakeEncounterRepo() *fakeEncounterRepo {
+	return &fakeEncounterRepo{encounters: map[int]*models.Encounter{}}
+}
+
+func (r *fakeEncounterRepo) Create(encounter *models.Encounter) error {
+	r.nextID++
+	encounter.ID = r.nextID
+	stored := *encounter
+	r.encounters[encounter.ID] = &stored
+	return nil
+}
+
+func (r *fakeEncounterRepo) FindByID(id uint) (*models.Encounter, error) {
+	if e, ok := r.encounters[int(id)]; ok {
+		found := *e
+		return &found, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeEncounterRepo) FindByPatient(patientID uint) ([]models.Encounter, error) {
+	encounters := []models.Encounter{}
+	for _, e := range r.encounters {
+		if e.PatientID == int(patientID) {
+			encounters = append(encounters, *e)
+		}
+	}
+	sort.Slice(encounters, func(i, j int) bool { return encounters[i].StartTime.After(encounters[j].StartTime) })
+	return encounters, nil
+}
+
+func (r *fakeEncounterRepo) Update(encounter *models.Encounter) error {
+	stored := *encounter
+	r.encounters[encounter.ID] = &stored
+	return nil
+}
+
+// fakeClinicalNoteRepo keeps every version of every note in insertion order
+type fakeClinicalNoteRepo struct {
+	versions []models.ClinicalNote
+	nextID   int64
+}
+
+func (r *fakeClinicalNoteRepo) Create(note *models.ClinicalNote) error {
+	r.nextID++
+	note.ID = r.nextID
+	return r.CreateVersion(note)
+}
+
+func (r *fakeClinicalNoteRepo) CreateVersion(note *models.ClinicalNote) error {
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+	r.versions = append(r.versions, *note)
+	return nil
+}
+
+func (r *fakeClinicalNoteRepo) FindLatest(noteID int64) (*models.ClinicalNote, error) {
+	var latest *models.ClinicalNote
+	for i := range r.versions {
+		if r.versions[i].ID == noteID {
+			found := r.versions[i]
+			latest = &found
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (r *fakeClinicalNoteRepo) FindLatestByEncounter(encounterID uint) ([]models.ClinicalNote, error) {
+	notes := []models.ClinicalNote{}
+	seen := map[int64]bool{}
+	for _, v := range r.versions {
+		if v.EncounterID == int(encounterID) && !seen[v.ID] {
+			seen[v.ID] = true
+			latest, _ := r.FindLatest(v.ID)
+			notes = append(notes, *latest)
+		}
+	}
+	return notes, nil
+}
+
+func (r *fakeClinicalNoteRepo) FindVersions(noteID int64) ([]models.ClinicalNote, error) {
+	versions := []models.ClinicalNote{}
+	for _, v := range r.versions {
+		if v.ID == noteID {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+func (r *fakeClinicalNoteRepo) find(note *models.ClinicalNote) *models.ClinicalNote {
+	for i := range r.versions {
+		if r.versions[i].ID == note.ID && r.versions[i].Version == note.Version && r.versions[i].Status == models.NoteDraft {
+			return &r.versions[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeClinicalNoteRepo) UpdateDraft(note *models.ClinicalNote) error {
+	stored := r.find(note)
+	if stored == nil {
+		return sql.ErrNoRows
+	}
+	stored.Content = note.Content
+	return nil
+}
+
+func (r *fakeClinicalNoteRepo) Sign(note *models.ClinicalNote) error {
+	stored := r.find(note)
+	if stored == nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	stored.Status = models.NoteSigned
+	stored.SignedAt = &now
+	note.Status, note.SignedAt = stored.Status, stored.SignedAt
+	return nil
+}
+
+type fakeAllergyRepo struct {
+	allergies []models.Allergy
+}
+
+func (r *fakeAllergyRepo) Create(allergy *models.Allergy) error {
+	allergy.ID = len(r.allergies) + 1
+	r.allergies = append(r.allergies, *allergy)
+	return nil
+}
+
+func (r *fakeAllergyRepo) FindByID(id uint) (*models.Allergy, error) {
+	for _, a := range r.allergies {
+		if a.ID == int(id) {
+			return &a, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeAllergyRepo) FindByPatient(patientID uint) ([]models.Allergy, error) {
+	allergies := []models.Allergy{}
+	for _, a := range r.allergies {
+		if a.PatientID == int(patientID) {
+			allergies = append(allergies, a)
+		}
+	}
+	return allergies, nil
+}
+
+func (r *fakeAllergyRepo) FindActiveByPatient(patientID uint) ([]models.Allergy, error) {
+	allergies := []models.Allergy{}
+	for _, a := range r.allergies {
+		if a.PatientID == int(patientID) && a.Status == models.AllergyActive {
+			allergies = append(allergies, a)
+		}
+	}
+	return allergies, nil
+}
+
+func (r *fakeAllergyRepo) Update(allergy *models.Allergy) error {
+	for i := range r.allergies {
+		if r.allergies[i].ID == allergy.ID {
+			r.allergies[i] = *allergy
+		}
+	}
+	return nil
+}
+
+func (r *fakeAllergyRepo) Delete(id uint) error {
+	for i := range r.allergies {
+		if r.allergies[i].ID == int(id) {
+			r.allergies = append(r.allergies[:i], r.allergies[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type fakeProblemRepo struct {
+	problems map[int]*models.Problem
+	nextID   int
+}
+
+func newFakeProblemRepo() *fakeProblemRepo {
+	return &fakeProblemRepo{problems: map[int]*models.Problem{}}
+}
+
+func (r *fakeProblemRepo) Create(problem *models.Problem) error {
+	r.nextID++
+	problem.ID = r.nextID
+	stored := *problem
+	r.problems[problem.ID] = &stored
+	return nil
+}
+
+func (r *fakeProblemRepo) FindByID(id uint) (*models.Problem, error) {
+	if p, ok := r.problems[int(id)]; ok {
+		found := *p
+		return &found, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeProblemRepo) FindByPatient(patientID uint) ([]models.Problem, error) {
+	problems := []models.Problem{}
+	for _, p := range r.problems {
+		if p.PatientID == int(patientID) {
+			problems = append(problems, *p)
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].ID < problems[j].ID })
+	return problems, nil
+}
+
+func (r *fakeProblemRepo) Update(problem *models.Problem) error {
+	stored := *problem
+	r.problems[problem.ID] = &stored
+	return nil
+}
+
+func (r *fakeProblemRepo) Delete(id uint) error {
+	delete(r.problems, int(id))
+	return nil
+}
+
+type fakeMedicationRepo struct {
+	medications []models.Medication
+}
+
+func (r *fakeMedicationRepo) Create(medication *models.Medication) error {
+	medication.ID = len(r.medications) + 1
+	r.medications = append(r.medications, *medication)
+	return nil
+}
+
+func (r *fakeMedicationRepo) FindByID(id uint) (*models.Medication, error) {
+	for _, m := range r.medications {
+		if m.ID == int(id) {
+			return &m, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeMedicationRepo) FindByPatient(patientID uint) ([]models.Medication, error) {
+	medications := []models.Medication{}
+	for _, m := range r.medications {
+		if m.PatientID == int(patientID) {
+			medications = append(medications, m)
+		}
+	}
+	return medications, nil
+}
+
+func (r *fakeMedicationRepo) FindActiveByPatient(patientID uint) ([]models.Medication, error) {
+	medications := []models.Medication{}
+	for _, m := range r.medications {
+		if m.PatientID == int(patientID) && m.Status == models.MedicationActive {
+			medications = append(medications, m)
+		}
+	}
+	return medications, nil
+}
+
+func (r *fakeMedicationRepo) Update(medication *models.Medication) error {
+	for i := range r.medications {
+		if r.medications[i].ID == medication.ID {
+			r.medications[i] = *medication
+		}
+	}
+	return nil
+}
+
+func (r *fakeMedicationRepo) Delete(id uint) error {
+	for i := range r.medications {
+		if r.medications[i].ID == int(id) {
+			r.medications = append(r.medications[:i], r.medications[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type fakePrescriptionRepo struct {
+	prescriptions map[int]*models.Prescription
+	nextID        int
+}
+
+func newFakePrescriptionRepo() *fakePrescriptionRepo {
+	return &fakePrescriptionRepo{prescriptions: map[int]*models.Prescription{}}
+}
+
+func (r *fakePrescriptionRepo) Create(prescription *models.Prescription) error {
+	r.nextID++
+	prescription.ID = r.nextID
+	stored := *prescription
+	r.prescriptions[prescription.ID] = &stored
+	return nil
+}
+
+func (r *fakePrescriptionRepo) FindByID(id uint) (*models.Prescription, error) {
+	if p, ok := r.prescriptions[int(id)]; ok {
+		found := *p
+		return &found, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePrescriptionRepo) FindByPatient(patientID uint) ([]models.Prescription, error) {
+	prescriptions := []models.Prescription{}
+	for _, p := range r.prescriptions {
+		if p.PatientID == int(patientID) {
+			prescriptions = append(prescriptions, *p)
+		}
+	}
+	sort.Slice(prescriptions, func(i, j int) bool { return prescriptions[i].ID > prescriptions[j].ID })
+	return prescriptions, nil
+}
+
+func (r *fakePrescriptionRepo) FindActiveByPatient(patientID uint) ([]models.Prescription, error) {
+	all, _ := r.FindByPatient(patientID)
+	active := []models.Prescription{}
+	for _, p := range all {
+		if p.Status == models.PrescriptionActive {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakePrescriptionRepo) UpdateStatus(prescription *models.Prescription) error {
+	r.prescriptions[prescription.ID].Status = prescription.Status
+	return nil
+}
+
+// fakeDrugRepo holds a small drug class and interaction table
+type fakeDrugRepo struct {
+	classes      map[string][]string
+	interactions []models.DrugInteraction
+}
+
+func newFakeDrugRepo() *fakeDrugRepo {
+	return &fakeDrugRepo{
+		classes: map[string][]string{
+			"amoxicillin": {"penicillins"},
+			"ibuprofen":   {"nsaids"},
+			"aspirin":     {"nsaids"},
+		},
+		interactions: []models.DrugInteraction{
+			{DrugA: "aspirin", DrugB: "warfarin", Severity: models.InteractionMajor, Description: "Increased risk of bleeding"},
+			{DrugA: "levothyroxine", DrugB: "omeprazole", Severity: models.InteractionMinor, Description: "Reduced absorption"},
+		},
+	}
+}
+
+func (r *fakeDrugRepo) FindClasses(drug string) ([]string, error) {
+	return r.classes[drug], nil
+}
+
+func (r *fakeDrugRepo) FindInteractions(drug string, others []string) ([]models.DrugInteraction, error) {
+	isOther := map[string]bool{}
+	for _, o := range others {
+		isOther[o] = true
+	}
+	var interactions []models.DrugInteraction
+	for _, i := range r.interactions {
+		if (i.DrugA == drug && isOther[i.DrugB]) || (i.DrugB == drug && isOther[i.DrugA]) {
+			interactions = append(interactions, i)
+		}
+	}
+	return interactions, nil
+}
+
+type fakeAppointmentRepo struct {
+	appointments map[int]*models.Appointment
+	nextID       int
+}
+
+func newFakeAppointmentRepo() *fakeAppointmentRepo {
+	return &fakeAppointmentRepo{appointments: map[int]*models.Appointment{}}
+}
+
+// Create and Update enforce the exclusion constraint like the database
+func (r *fakeAppointmentRepo) Create(appointment *models.Appointment) error {
+	if r.overlaps(appointment) {
+		return repository.ErrAppointmentOverlap
+	}
+	r.nextID++
+	appointment.ID = r.nextID
+	stored := *appointment
+	r.appointments[appointment.ID] = &stored
+	return nil
+}
+
+func (r *fakeAppointmentRepo) FindByID(id uint) (*models.Appointment, error) {
+	if a, ok := r.appointments[int(id)]; ok {
+		return a, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeAppointmentRepo) Update(appointment *models.Appointment) error {
+	if r.overlaps(appointment) {
+		return repository.ErrAppointmentOverlap
+	}
+	stored := *appointment
+	r.appointments[appointment.ID] = &stored
+	return nil
+}
+
+func (r *fakeAppointmentRepo) Delete(id uint) error {
+	delete(r.appointments, int(id))
+	return nil
+}
+
+func (r *fakeAppointmentRepo) FindAll() ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	for _, a := range r.appointments {
+		appointments = append(appointments, *a)
+	}
+	return appointments, nil
+}
+
+func (r *fakeAppointmentRepo) FindByDoctor(doctorID int64, from, to time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	for _, a := range r.appointments {
+		if a.DoctorID == doctorID && a.StartTime.Before(to) && a.EndTime.After(from) {
+			appointments = append(appointments, *a)
+		}
+	}
+	return appointments, nil
+}
+
+func (r *fakeAppointmentRepo) FindByPatient(patientID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	for _, a := range r.appointments {
+		if a.PatientID == int(patientID) {
+			appointments = append(appointments, *a)
+		}
+	}
+	return appointments, nil
+}
+
+func (r *fakeAppointmentRepo) FindOverlapping(doctorID int64, start, end time.Time, excludeID int) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	for _, a := range r.appointments {
+		if a.DoctorID == doctorID && a.ID != excludeID && a.Status != models.AppointmentCancelled &&
+			a.StartTime.Before(end) && a.EndTime.After(start) {
+			appointments = append(appointments, *a)
+		}
+	}
+	return appointments, nil
+}
+
+func (r *fakeAppointmentRepo) overlaps(appointment *models.Appointment) bool {
+	if appointment.Status == models.AppointmentCancelled {
+		return false
+	}
+	overlapping, _ := r.FindOverlapping(appointment.DoctorID, appointment.StartTime, appointment.EndTime, appointment.ID)
+	return len(overlapping) > 0
+}
+
+type fakeTokenRepo struct {
+	refreshTokens map[int64]*models.RefreshToken
+	revoked       map[string]time.Time
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{refreshTokens: map[int64]*models.RefreshToken{}, revoked: map[string]time.Time{}}
+}
+
+func (r *fakeTokenRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	token.ID = int64(len(r.refreshTokens) + 1)
+	token.CreatedAt = time.Now()
+	stored := *token
+	r.refreshTokens[token.ID] = &stored
+	return nil
+}
+
+func (r *fakeTokenRepo) find(match func(*models.RefreshToken) bool) (*models.RefreshToken, error) {
+	for _, t := range r.refreshTokens {
+		if match(t) {
+			found := *t
+			return &found, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeTokenRepo) FindRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	return r.find(func(t *models.RefreshToken) bool { return t.TokenHash == tokenHash })
+}
+
+func (r *fakeTokenRepo) FindRefreshTokenByAccessTokenID(accessTokenID string) (*models.RefreshToken, error) {
+	return r.find(func(t *models.RefreshToken) bool { return t.AccessTokenID == accessTokenID })
+}
+
+func (r *fakeTokenRepo) active(match func(*models.RefreshToken) bool) []models.RefreshToken {
+	var tokens []models.RefreshToken
+	for _, t := range r.refreshTokens {
+		if t.RevokedAt == nil && match(t) {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens
+}
+
+func (r *fakeTokenRepo) FindActiveRefreshTokensByFamily(familyID string) ([]models.RefreshToken, error) {
+	return r.active(func(t *models.RefreshToken) bool { return t.FamilyID == familyID }), nil
+}
+
+func (r *fakeTokenRepo) FindActiveRefreshTokensByUser(userID int64) ([]models.RefreshToken, error) {
+	return r.active(func(t *models.RefreshToken) bool { return t.UserID == userID }), nil
+}
+
+func (r *fakeTokenRepo) RevokeRefreshToken(id int64) (bool, error) {
+	t, ok := r.refreshTokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RevokedAt = &now
+	return true, nil
+}
+
+func (r *fakeTokenRepo) RevokeAccessToken(tokenID string, expiresAt time.Time) error {
+	r.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (r *fakeTokenRepo) IsAccessTokenRevoked(tokenID string) (bool, error) {
+	_, ok := r.revoked[tokenID]
+	return ok, nil
+}
+
+func (r *fakeTokenRepo) DeleteExpired() error {
+	return nil
+}
+
+type fakeMFARepo struct {
+	settings      map[int64]*models.UserMFA
+	recoveryCodes map[int64]map[string]bool // code hash -> used
+	challenges    map[int64]*models.MFAChallenge
+	nextID        int64
+}
+
+func newFakeMFARepo() *fakeMFARepo {
+	return &fakeMFARepo{
+		settings:      map[int64]*models.UserMFA{},
+		recoveryCodes: map[int64]map[string]bool{},
+		challenges:    map[int64]*models.MFAChallenge{},
+	}
+}
+
+func (r *fakeMFARepo) FindByUser(userID int64) (*models.UserMFA, error) {
+	mfa, ok := r.settings[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *mfa
+	return &found, nil
+}
+
+func (r *fakeMFARepo) Save(mfa *models.UserMFA) error {
+	mfa.CreatedAt = time.Now()
+	stored := *mfa
+	r.settings[mfa.UserID] = &stored
+	return nil
+}
+
+func (r *fakeMFARepo) UseStep(userID int64, step int64) (bool, error) {
+	mfa, ok := r.settings[userID]
+	if !ok || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeMFARepo) Delete(userID int64) error {
+	delete(r.settings, userID)
+	delete(r.recoveryCodes, userID)
+	for id, c := range r.challenges {
+		if c.UserID == userID {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeMFARepo) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	r.recoveryCodes[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+
+func (r *fakeMFARepo) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	used, ok := r.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *fakeMFARepo) CountUnusedRecoveryCodes(userID int64) (int, error) {
+	count := 0
+	for _, used := range r.recoveryCodes[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeMFARepo) CreateChallenge(challenge *models.MFAChallenge) error {
+	r.nextID++
+	challenge.ID = r.nextID
+	challenge.CreatedAt = time.Now()
+	stored := *challenge
+	r.challenges[challenge.ID] = &stored
+	return nil
+}
+
+func (r *fakeMFARepo) FindChallengeByHash(tokenHash string) (*models.MFAChallenge, error) {
+	for _, c := range r.challenges {
+		if c.TokenHash == tokenHash {
+			found := *c
+			return &found, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeMFARepo) IncrementChallengeAttempts(id int64, max int) (int, error) {
+	c, ok := r.challenges[id]
+	if !ok || c.Attempts >= max {
+		return 0, sql.ErrNoRows
+	}
+	c.Attempts++
+	return c.Attempts, nil
+}
+
+func (r *fakeMFARepo) DeleteChallenge(id int64) error {
+	delete(r.challenges, id)
+	return nil
+}
+
+type fakeLoginThrottleRepo struct {
+	throttles map[string]*models.LoginThrottle
+}
+
+func newFakeLoginThrottleRepo() *fakeLoginThrottleRepo {
+	return &fakeLoginThrottleRepo{throttles: map[string]*models.LoginThrottle{}}
+}
+
+func (r *fakeLoginThrottleRepo) Find(scope, key string) (*models.LoginThrottle, error) {
+	throttle, ok := r.throttles[scope+"/"+key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *throttle
+	return &found, nil
+}
+
+func (r *fakeLoginThrottleRepo) RecordFailure(scope, key string, at, resetBefore time.Time) (int, error) {
+	throttle, ok := r.throttles[scope+"/"+key]
+	if !ok {
+		throttle = &models.LoginThrottle{Scope: scope, Key: key}
+		r.throttles[scope+"/"+key] = throttle
+	}
+	if throttle.LastFailureAt.Before(resetBefore) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = at
+	return throttle.Failures, nil
+}
+
+func (r *fakeLoginThrottleRepo) Lock(scope, key string, until time.Time) error {
+	if throttle, ok := r.throttles[scope+"/"+key]; ok && (throttle.LockedUntil == nil || throttle.LockedUntil.Before(until)) {
+		throttle.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeLoginThrottleRepo) Clear(scope, key string) error {
+	delete(r.throttles, scope+"/"+key)
+	return nil
+}
+
+func (r *fakeLoginThrottleRepo) DeleteStale(before time.Time) error {
+	for id, throttle := range r.throttles {
+		if throttle.LastFailureAt.Before(before) && (throttle.LockedUntil == nil || throttle.LockedUntil.Before(before)) {
+			delete(r.throttles, id)
+		}
+	}
+	return nil
+}
+
+type fakePasswordResetRepo struct {
+	tokens map[int64]*models.PasswordResetToken
+}
+
+func newFakePasswordResetRepo() *fakePasswordResetRepo {
+	return &fakePasswordResetRepo{tokens: map[int64]*models.PasswordResetToken{}}
+}
+
+func (r *fakePasswordResetRepo) Create(token *models.PasswordResetToken) error {
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == token.UserID && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	token.ID = int64(len(r.tokens) + 1)
+	token.CreatedAt = now
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *fakePasswordResetRepo) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			found := *t
+			return &found, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePasswordResetRepo) MarkUsed(id int64) (bool, error) {
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+// fakeNotifier keeps the reset tokens it was asked to deliver
+type fakeNotifier struct {
+	resets map[string]string // username -> token
+}
+
+func (n *fakeNotifier) NotifyPasswordReset(user *models.User, token string, expiresAt time.Time) error {
+	n.resets[user.Username] = token
+	return nil
+}
+
+type fakeAuditRepo struct {
+	entries []models.AuditEntry
+}
+
+func (r *fakeAuditRepo) Append(entry *models.AuditEntry) error {
+	if len(r.entries) > 0 {
+		entry.PrevHash = r.entries[len(r.entries)-1].Hash
+	}
+	entry.ID = int64(len(r.entries) + 1)
+	entry.CreatedAt = time.Now().UTC()
+	entry.Hash = entry.ComputeHash()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeAuditRepo) FindByPatient(patientID uint, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if e := r.entries[i]; e.PatientID != nil && *e.PatientID == int(patientID) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *fakeAuditRepo) FindRecent(limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, r.entries[i])
+	}
+	return entries, nil
+}
+
+func (r *fakeAuditRepo) FindAfter(afterID int64, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for _, e := range r.entries {
+		if e.ID > afterID && len(entries) < limit {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+type fakeVitalSignsRepo struct {
+	readings []models.VitalSigns
+	ranges   []models.VitalRange
+}
+
+func newFakeVitalSignsRepo() *fakeVitalSignsRepo {
+	maxAge := func(n int) *int { return &n }
+	return &fakeVitalSignsRepo{ranges: []models.VitalRange{
+		{ID: 1, Vital: models.VitalHeartRate, AgeGroup: "infant", MinAge: 0, MaxAge: maxAge(1), Low: 100, High: 160},
+		{ID: 2, Vital: models.VitalHeartRate, AgeGroup: "adult", MinAge: 18, Low: 60, High: 100},
+		{ID: 3, Vital: models.VitalSystolicBP, AgeGroup: "adult", MinAge: 18, Low: 90, High: 139},
+		{ID: 4, Vital: models.VitalDiastolicBP, AgeGroup: "adult", MinAge: 18, Low: 60, High: 89},
+		{ID: 5, Vital: models.VitalSpO2, AgeGroup: "all", MinAge: 0, Low: 95, High: 100},
+		{ID: 6, Vital: models.VitalBMI, AgeGroup: "adult", MinAge: 18, Low: 18.5, High: 24.9},
+	}}
+}
+
+func (r *fakeVitalSignsRepo) Create(vitals *models.VitalSigns) error {
+	vitals.ID = len(r.readings) + 1
+	r.readings = append(r.readings, *vitals)
+	return nil
+}
+
+func (r *fakeVitalSignsRepo) FindByPatient(patientID uint, from, to *time.Time) ([]models.VitalSigns, error) {
+	readings := []models.VitalSigns{}
+	for _, v := range r.readings {
+		if v.PatientID != int(patientID) ||
+			(from != nil && v.RecordedAt.Before(*from)) ||
+			(to != nil && !v.RecordedAt.Before(*to)) {
+			continue
+		}
+		readings = append(readings, v)
+	}
+	sort.Slice(readings, func(i, j int) bool { return readings[i].RecordedAt.Before(readings[j].RecordedAt) })
+	return readings, nil
+}
+
+func (r *fakeVitalSignsRepo) FindRanges() ([]models.VitalRange, error) {
+	return append([]models.VitalRange{}, r.ranges...), nil
+}
+
+func (r *fakeVitalSignsRepo) FindRangeByID(id uint) (*models.VitalRange, error) {
+	for _, vr := range r.ranges {
+		if vr.ID == int(id) {
+			return &vr, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeVitalSignsRepo) UpdateRange(vr *models.VitalRange) error {
+	for i := range r.ranges {
+		if r.ranges[i].ID == vr.ID {
+			r.ranges[i] = *vr
+		}
+	}
+	return nil
+}
+
+type fakeLabRepo struct {
+	tests   map[string]models.LabTest
+	orders  map[int]*models.LabOrder
+	results map[int]*models.LabResult
+	nextID  int
+}
+
+func newFakeLabRepo() *fakeLabRepo {
+	ptr := func(f float64) *float64 { return &f }
+	adult := 18
+	return &fakeLabRepo{
+		tests: map[string]models.LabTest{
+			"718-7": {Code: "718-7", Name: "Hemoglobin", Unit: "g/dL", Specimen: "blood", Ranges: []models.LabReferenceRange{
+				{ID: 1, TestCode: "718-7", MinAge: 0, MaxAge: &adult, Low: 11.0, High: 14.5, CriticalLow: ptr(7), CriticalHigh: ptr(20)},
+				{ID: 2, TestCode: "718-7", Sex: "male", MinAge: 18, Low: 13.5, High: 17.5, CriticalLow: ptr(7), CriticalHigh: ptr(20)},
+				{ID: 3, TestCode: "718-7", Sex: "female", MinAge: 18, Low: 12.0, High: 15.5, CriticalLow: ptr(7), CriticalHigh: ptr(20)},
+			}},
+			"2823-3": {Code: "2823-3", Name: "Potassium", Unit: "mmol/L", Specimen: "serum", Ranges: []models.LabReferenceRange{
+				{ID: 4, TestCode: "2823-3", MinAge: 0, Low: 3.5, High: 5.1, CriticalLow: ptr(2.5), CriticalHigh: ptr(6.5)},
+			}},
+		},
+		orders:  map[int]*models.LabOrder{},
+		results: map[int]*models.LabResult{},
+	}
+}
+
+func (r *fakeLabRepo) FindTests() ([]models.LabTest, error) {
+	tests := []models.LabTest{}
+	for _, t := range r.tests {
+		tests = append(tests, t)
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	return tests, nil
+}
+
+func (r *fakeLabRepo) FindTest(code string) (*models.LabTest, error) {
+	if t, ok := r.tests[code]; ok {
+		return &t, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeLabRepo) CreateOrder(order *models.LabOrder) error {
+	r.nextID++
+	order.ID = r.nextID
+	stored := *order
+	r.orders[order.ID] = &stored
+	return nil
+}
+
+func (r *fakeLabRepo) FindOrderByID(id uint) (*models.LabOrder, error) {
+	o, ok := r.orders[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *o
+	found.TestName = r.tests[o.TestCode].Name
+	if result, ok := r.results[o.ID]; ok {
+		stored := *result
+		found.Result = &stored
+	}
+	return &found, nil
+}
+
+func (r *fakeLabRepo) FindOrdersByPatient(patientID uint) ([]models.LabOrder, error) {
+	orders := []models.LabOrder{}
+	for id, o := range r.orders {
+		if o.PatientID == int(patientID) {
+			found, _ := r.FindOrderByID(uint(id))
+			orders = append(orders, *found)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	return orders, nil
+}
+
+func (r *fakeLabRepo) UpdateOrderStatus(order *models.LabOrder) error {
+	stored := *order
+	stored.Result = nil
+	r.orders[order.ID] = &stored
+	return nil
+}
+
+func (r *fakeLabRepo) SaveResult(order *models.LabOrder, result *models.LabResult) error {
+	result.ID = len(r.results) + 1
+	stored := *result
+	r.results[order.ID] = &stored
+	return r.UpdateOrderStatus(order)
+}
+
+// fakeExportJobRepo is safe for concurrent use, since exports run in the
+// background
+type fakeExportJobRepo struct {
+	mu   sync.Mutex
+	jobs map[string]models.ExportJob
+}
+
+func newFakeExportJobRepo() *fakeExportJobRepo {
+	return &fakeExportJobRepo{jobs: map[string]models.ExportJob{}}
+}
+
+func (r *fakeExportJobRepo) Create(job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.CreatedAt = time.Now()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *fakeExportJobRepo) FindByID(id string) (*models.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	job.Output = append([]models.ExportOutput(nil), job.Output...)
+	return &job, nil
+}
+
+func (r *fakeExportJobRepo) Update(job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[job.ID]; ok {
+		stored := *job
+		stored.Output = append([]models.ExportOutput(nil), job.Output...)
+		r.jobs[job.ID] = stored
+	}
+	return nil
+}
+
+func (r *fakeExportJobRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, id)
+	return nil
+}
+
+func (r *fakeExportJobRepo) FailInProgress(reason string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	now := time.Now()
+	for id, job := range r.jobs {
+		if job.Status == models.ExportInProgress {
+			job.Status, job.Error, job.CompletedAt = models.ExportFailed, reason, &now
+			r.jobs[id] = job
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeExportJobRepo) DeleteFinishedBefore(t time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id, job := range r.jobs {
+		if job.Status != models.ExportInProgress && job.CompletedAt != nil && job.CompletedAt.Before(t) {
+			delete(r.jobs, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// fakeADTRepo enforces one open occupancy per bed and one active admission
+// per patient, like the unique indexes it stands in for
+type fakeADTRepo struct {
+	wards       map[int]models.Ward
+	rooms       map[int]models.Room
+	beds        map[int]models.Bed
+	admissions  map[int]models.Admission
+	occupancies []models.BedOccupancy
+	nextID      int
+}
+
+func newFakeADTRepo() *fakeADTRepo {
+	return &fakeADTRepo{
+		wards:      map[int]models.Ward{},
+		rooms:      map[int]models.Room{},
+		beds:       map[int]models.Bed{},
+		admissions: map[int]models.Admission{},
+	}
+}
+
+func (r *fakeADTRepo) id() int {
+	r.nextID++
+	return r.nextID
+}
+
+func (r *fakeADTRepo) CreateWard(ward *models.Ward) error {
+	ward.ID, ward.CreatedAt = r.id(), time.Now()
+	r.wards[ward.ID] = *ward
+	return nil
+}
+
+func (r *fakeADTRepo) FindWards() ([]models.Ward, error) {
+	wards := []models.Ward{}
+	for _, w := range r.wards {
+		wards = append(wards, w)
+	}
+	sort.Slice(wards, func(i, j int) bool { return wards[i].Name < wards[j].Name })
+	return wards, nil
+}
+
+func (r *fakeADTRepo) FindWardByID(id uint) (*models.Ward, error) {
+	w, ok := r.wards[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (r *fakeADTRepo) CreateRoom(room *models.Room) error {
+	room.ID, room.CreatedAt = r.id(), time.Now()
+	r.rooms[room.ID] = *room
+	return nil
+}
+
+func (r *fakeADTRepo) FindRoomByID(id uint) (*models.Room, error) {
+	room, ok := r.rooms[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &room, nil
+}
+
+func (r *fakeADTRepo) CreateBed(bed *models.Bed) error {
+	bed.ID, bed.CreatedAt = r.id(), time.Now()
+	r.beds[bed.ID] = *bed
+	return nil
+}
+
+func (r *fakeADTRepo) FindBedByID(id uint) (*models.Bed, error) {
+	bed, ok := r.beds[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	room := r.rooms[bed.RoomID]
+	bed.RoomName, bed.WardID, bed.AdmissionID = room.Name, room.WardID, nil
+	if o := r.openOccupancy(bed.ID); o != nil {
+		admissionID := o.AdmissionID
+		bed.AdmissionID = &admissionID
+	}
+	return &bed, nil
+}
+
+func (r *fakeADTRepo) UpdateBed(bed *models.Bed) error {
+	if bed.OutOfService && r.openOccupancy(bed.ID) != nil {
+		return repository.ErrBedOccupied
+	}
+	stored := *bed
+	r.beds[bed.ID] = stored
+	return nil
+}
+
+func (r *fakeADTRepo) FindBedBoard(wardID uint) ([]models.BedBoardEntry, error) {
+	board := []models.BedBoardEntry{}
+	for id := range r.beds {
+		bed, _ := r.FindBedByID(uint(id))
+		if bed.WardID != int(wardID) {
+			continue
+		}
+		entry := models.BedBoardEntry{Bed: *bed, Status: models.BedAvailable}
+		if o := r.openOccupancy(bed.ID); o != nil {
+			a := r.admissions[o.AdmissionID]
+			entry.Status = models.BedOccupied
+			entry.Occupant = &models.BedOccupant{AdmissionID: a.ID, PatientID: a.PatientID,
+				AttendingDoctorID: a.AttendingDoctorID, Since: o.StartedAt}
+		} else if bed.OutOfService {
+			entry.Status = models.BedOutOfService
+		}
+		board = append(board, entry)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Bed.RoomName != board[j].Bed.RoomName {
+			return board[i].Bed.RoomName < board[j].Bed.RoomName
+		}
+		return board[i].Bed.Label < board[j].Bed.Label
+	})
+	return board, nil
+}
+
+func (r *fakeADTRepo) FindBedOccupancies(bedID uint) ([]models.BedOccupancy, error) {
+	occupancies := []models.BedOccupancy{}
+	for i := len(r.occupancies) - 1; i >= 0; i-- {
+		if r.occupancies[i].BedID == int(bedID) {
+			occupancies = append(occupancies, r.occupancies[i])
+		}
+	}
+	return occupancies, nil
+}
+
+func (r *fakeADTRepo) FindCensus() ([]models.WardCensus, error) {
+	byWard := map[int]*models.WardCensus{}
+	census := []models.WardCensus{}
+	wards, _ := r.FindWards()
+	for _, w := range wards {
+		census = append(census, models.WardCensus{WardID: w.ID, WardName: w.Name})
+	}
+	for i := range census {
+		byWard[census[i].WardID] = &census[i]
+	}
+	for id := range r.beds {
+		bed, _ := r.FindBedByID(uint(id))
+		w := byWard[bed.WardID]
+		w.Beds++
+		switch {
+		case bed.AdmissionID != nil:
+			w.Occupied++
+		case bed.OutOfService:
+			w.OutOfService++
+		default:
+			w.Available++
+		}
+	}
+	return census, nil
+}
+
+func (r *fakeADTRepo) Admit(admission *models.Admission) error {
+	if _, err := r.FindActiveAdmission(uint(admission.PatientID)); err == nil {
+		return repository.ErrPatientAdmitted
+	}
+	if r.beds[admission.BedID].OutOfService {
+		return repository.ErrBedOutOfService
+	}
+	if r.openOccupancy(admission.BedID) != nil {
+		return repository.ErrBedOccupied
+	}
+	admission.ID, admission.UpdatedAt = r.id(), time.Now()
+	occupancy := models.BedOccupancy{ID: r.id(), BedID: admission.BedID, AdmissionID: admission.ID,
+		PatientID: admission.PatientID, Reason: "admission", StartedAt: admission.AdmittedAt}
+	r.occupancies = append(r.occupancies, occupancy)
+	admission.Occupancies = []models.BedOccupancy{occupancy}
+	r.admissions[admission.ID] = *admission
+	return nil
+}
+
+func (r *fakeADTRepo) FindAdmissionByID(id uint) (*models.Admission, error) {
+	a, ok := r.admissions[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	a.Occupancies = []models.BedOccupancy{}
+	for _, o := range r.occupancies {
+		if o.AdmissionID == a.ID {
+			a.Occupancies = append(a.Occupancies, o)
+		}
+	}
+	return &a, nil
+}
+
+func (r *fakeADTRepo) FindAdmissionsByPatient(patientID uint) ([]models.Admission, error) {
+	admissions := []models.Admission{}
+	for id, a := range r.admissions {
+		if a.PatientID == int(patientID) {
+			found, _ := r.FindAdmissionByID(uint(id))
+			admissions = append(admissions, *found)
+		}
+	}
+	sort.Slice(admissions, func(i, j int) bool { return admissions[i].ID > admissions[j].ID })
+	return admissions, nil
+}
+
+func (r *fakeADTRepo) FindActiveAdmission(patientID uint) (*models.Admission, error) {
+	for id, a := range r.admissions {
+		if a.PatientID == int(patientID) && a.Status == models.AdmissionAdmitted {
+			return r.FindAdmissionByID(uint(id))
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeADTRepo) Transfer(admission *models.Admission, bedID int, reason string) error {
+	if r.admissions[admission.ID].Status != models.AdmissionAdmitted {
+		return repository.ErrAdmissionClosed
+	}
+	if r.beds[bedID].OutOfService {
+		return repository.ErrBedOutOfService
+	}
+	if r.openOccupancy(bedID) != nil {
+		return repository.ErrBedOccupied
+	}
+	now := time.Now()
+	r.closeOccupancy(admission.ID, now)
+	r.occupancies = append(r.occupancies, models.BedOccupancy{ID: r.id(), BedID: bedID, AdmissionID: admission.ID,
+		PatientID: admission.PatientID, Reason: reason, StartedAt: now})
+	admission.BedID = bedID
+	r.admissions[admission.ID] = *admission
+	found, _ := r.FindAdmissionByID(uint(admission.ID))
+	admission.Occupancies = found.Occupancies
+	return nil
+}
+
+func (r *fakeADTRepo) Discharge(admission *models.Admission) error {
+	if r.admissions[admission.ID].Status != models.AdmissionAdmitted {
+		return repository.ErrAdmissionClosed
+	}
+	r.closeOccupancy(admission.ID, *admission.DischargedAt)
+	r.admissions[admission.ID] = *admission
+	found, _ := r.FindAdmissionByID(uint(admission.ID))
+	admission.Occupancies = found.Occupancies
+	return nil
+}
+
+func (r *fakeADTRepo) openOccupancy(bedID int) *models.BedOccupancy {
+	for i := range r.occupancies {
+		if r.occupancies[i].BedID == bedID && r.occupancies[i].EndedAt == nil {
+			return &r.occupancies[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeADTRepo) closeOccupancy(admissionID int, at time.Time) {
+	for i := range r.occupancies {
+		if r.occupancies[i].AdmissionID == admissionID && r.occupancies[i].EndedAt == nil {
+			r.occupancies[i].EndedAt = &at
+		}
+	}
+}
+
+// fakeBillingRepo rejects overpayments and overrefunds like the invoice
+// check constraints and refund check it stands in for
+type fakeBillingRepo struct {
+	taxRules map[string]models.TaxRule
+	items    map[string]models.ChargeItem
+	invoices map[int]models.Invoice
+	nextID   int
+}
+
+func newFakeBillingRepo() *fakeBillingRepo {
+	return &fakeBillingRepo{
+		taxRules: map[string]models.TaxRule{
+			"exempt":   {Code: "exempt", Name: "Exempt", RateBP: 0},
+			"standard": {Code: "standard", Name: "Standard", RateBP: 825},
+		},
+		items: map[string]models.ChargeItem{
+			"99213": {Code: "99213", Description: "Office visit", UnitPriceCents: 11000, TaxCode: "exempt", Active: true},
+			"85025": {Code: "85025", Description: "Complete blood count", UnitPriceCents: 3500, TaxCode: "exempt", Active: true},
+			"E0114": {Code: "E0114", Description: "Crutches", UnitPriceCents: 6599, TaxCode: "standard", Active: true},
+			"OLD01": {Code: "OLD01", Description: "Retired service", UnitPriceCents: 100, TaxCode: "exempt", Active: false},
+		},
+		invoices: map[int]models.Invoice{},
+	}
+}
+
+func (r *fakeBillingRepo) FindTaxRules() ([]models.TaxRule, error) {
+	rules := []models.TaxRule{}
+	for _, t := range r.taxRules {
+		rules = append(rules, t)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Code < rules[j].Code })
+	return rules, nil
+}
+
+func (r *fakeBillingRepo) FindTaxRule(code string) (*models.TaxRule, error) {
+	if t, ok := r.taxRules[code]; ok {
+		return &t, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeBillingRepo) SaveTaxRule(rule *models.TaxRule) error {
+	rule.UpdatedAt = time.Now()
+	r.taxRules[rule.Code] = *rule
+	return nil
+}
+
+func (r *fakeBillingRepo) FindChargeItems() ([]models.ChargeItem, error) {
+	items := []models.ChargeItem{}
+	for _, i := range r.items {
+		items = append(items, i)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+	return items, nil
+}
+
+func (r *fakeBillingRepo) FindChargeItem(code string) (*models.ChargeItem, error) {
+	if i, ok := r.items[code]; ok {
+		return &i, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeBillingRepo) CreateChargeItem(item *models.ChargeItem) error {
+	item.UpdatedAt = time.Now()
+	r.items[item.Code] = *item
+	return nil
+}
+
+func (r *fakeBillingRepo) UpdateChargeItem(item *models.ChargeItem) error {
+	return r.CreateChargeItem(item)
+}
+
+func (r *fakeBillingRepo) CreateInvoice(invoice *models.Invoice) error {
+	r.nextID++
+	invoice.ID = r.nextID
+	for i := range invoice.Lines {
+		r.nextID++
+		invoice.Lines[i].ID, invoice.Lines[i].InvoiceID = r.nextID, invoice.ID
+	}
+	r.store(invoice)
+	return nil
+}
+
+func (r *fakeBillingRepo) store(invoice *models.Invoice) {
+	stored := *invoice
+	stored.Lines = append([]models.InvoiceLine(nil), invoice.Lines...)
+	stored.Payments = append([]models.InvoicePayment(nil), invoice.Payments...)
+	r.invoices[invoice.ID] = stored
+}
+
+func (r *fakeBillingRepo) FindInvoiceByID(id uint) (*models.Invoice, error) {
+	i, ok := r.invoices[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	i.BalanceCents = i.TotalCents - i.PaidCents + i.RefundedCents
+	i.Lines = append([]models.InvoiceLine{}, i.Lines...)
+	i.Payments = append([]models.InvoicePayment{}, i.Payments...)
+	return &i, nil
+}
+
+func (r *fakeBillingRepo) FindInvoicesByPatient(patientID uint) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	for id, i := range r.invoices {
+		if i.PatientID == int(patientID) {
+			found, _ := r.FindInvoiceByID(uint(id))
+			invoices = append(invoices, *found)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool { return invoices[i].ID > invoices[j].ID })
+	return invoices, nil
+}
+
+func (r *fakeBillingRepo) AddPayment(invoice *models.Invoice, payment *models.InvoicePayment) error {
+	stored := r.invoices[invoice.ID]
+	if stored.Status == models.InvoiceVoid {
+		return repository.ErrInvoiceStatus
+	}
+	paid, refunded := stored.PaidCents, stored.RefundedCents
+	if payment.Kind == models.PaymentKindRefund {
+		refunded += payment.AmountCents
+	} else {
+		paid += payment.AmountCents
+	}
+	if paid-refunded > stored.TotalCents || refunded > paid {
+		return repository.ErrInvoiceOverpaid
+	}
+	if payment.Kind == models.PaymentKindRefund {
+		var original, refundedBefore int64
+		for _, p := range stored.Payments {
+			if p.ID == *payment.RefundOf {
+				original = p.AmountCents
+			}
+			if p.RefundOf != nil && *p.RefundOf == *payment.RefundOf {
+				refundedBefore += p.AmountCents
+			}
+		}
+		if refundedBefore+payment.AmountCents > original {
+			return repository.ErrInvoiceOverpaid
+		}
+	}
+
+	r.nextID++
+	payment.ID, payment.InvoiceID = r.nextID, invoice.ID
+	invoice.PaidCents, invoice.RefundedCents = paid, refunded
+	invoice.BalanceCents = invoice.TotalCents - paid + refunded
+	switch {
+	case paid-refunded >= invoice.TotalCents:
+		invoice.Status = models.InvoicePaid
+	case paid-refunded > 0:
+		invoice.Status = models.InvoicePartiallyPaid
+	default:
+		invoice.Status = models.InvoiceIssued
+	}
+	invoice.Payments = append(invoice.Payments, *payment)
+	r.store(invoice)
+	return nil
+}
+
+func (r *fakeBillingRepo) VoidInvoice(invoice *models.Invoice) error {
+	stored := r.invoices[invoice.ID]
+	if stored.Status == models.InvoiceVoid || stored.PaidCents != stored.RefundedCents {
+		return repository.ErrInvoiceStatus
+	}
+	stored.Status = models.InvoiceVoid
+	r.invoices[invoice.ID] = stored
+	invoice.Status = models.InvoiceVoid
+	return nil
+}
+
+func (r *fakeBillingRepo) FindPatientBalance(patientID uint) (*models.PatientBalance, error) {
+	balance := &models.PatientBalance{PatientID: int(patientID)}
+	for _, i := range r.invoices {
+		if i.PatientID != int(patientID) || i.Status == models.InvoiceVoid {
+			continue
+		}
+		balance.InvoicedCents += i.TotalCents
+		balance.PaidCents += i.PaidCents
+		balance.RefundedCents += i.RefundedCents
+		if i.Status == models.InvoiceIssued || i.Status == models.InvoicePartiallyPaid {
+			balance.OpenInvoices++
+		}
+	}
+	balance.BalanceCents = balance.InvoicedCents - balance.PaidCents + balance.RefundedCents
+	return balance, nil
+}
+
+// fakeInsuranceRepo records remittance payments in billing, the way the
+// repository shares the invoice tables
+type fakeInsuranceRepo struct {
+	policies    map[int]models.InsurancePolicy
+	claims      map[int]models.Claim
+	billing     *fakeBillingRepo
+	nextID      int
+	claimNumber int64
+}
+
+func newFakeInsuranceRepo(billing *fakeBillingRepo) *fakeInsuranceRepo {
+	return &fakeInsuranceRepo{policies: map[int]models.InsurancePolicy{}, claims: map[int]models.Claim{}, billing: billing}
+}
+
+func (r *fakeInsuranceRepo) CreatePolicy(policy *models.InsurancePolicy) error {
+	r.nextID++
+	policy.ID = r.nextID
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+func (r *fakeInsuranceRepo) FindPolicyByID(id uint) (*models.InsurancePolicy, error) {
+	p, ok := r.policies[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (r *fakeInsuranceRepo) FindPoliciesByPatient(patientID uint) ([]models.InsurancePolicy, error) {
+	policies := []models.InsurancePolicy{}
+	for _, p := range r.policies {
+		if p.PatientID == int(patientID) {
+			policies = append(policies, p)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority < policies[j].Priority
+		}
+		return policies[i].EffectiveFrom.After(policies[j].EffectiveFrom)
+	})
+	return policies, nil
+}
+
+func (r *fakeInsuranceRepo) UpdatePolicy(policy *models.InsurancePolicy) error {
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+// DeletePolicy enforces the claims foreign key like the database does
+func (r *fakeInsuranceRepo) DeletePolicy(id uint) error {
+	for _, c := range r.claims {
+		if c.PolicyID == int(id) {
+			return repository.ErrPolicyHasClaims
+		}
+	}
+	delete(r.policies, int(id))
+	return nil
+}
+
+func (r *fakeInsuranceRepo) NextClaimNumber() (int64, error) {
+	r.claimNumber++
+	return r.claimNumber, nil
+}
+
+func (r *fakeInsuranceRepo) CreateClaim(claim *models.Claim) error {
+	for _, c := range r.claims {
+		if c.InvoiceID == claim.InvoiceID && c.PolicyID == claim.PolicyID && c.Status != models.ClaimDenied {
+			return repository.ErrClaimExists
+		}
+	}
+	r.nextID++
+	claim.ID = r.nextID
+	r.claims[claim.ID] = *claim
+	return nil
+}
+
+func (r *fakeInsuranceRepo) FindClaimByID(id uint) (*models.Claim, error) {
+	c, ok := r.claims[int(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (r *fakeInsuranceRepo) FindClaimByControlNumber(controlNumber string) (*models.Claim, error) {
+	for _, c := range r.claims {
+		if c.ControlNumber == controlNumber {
+			return &c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeInsuranceRepo) FindClaimsByInvoice(invoiceID uint) ([]models.Claim, error) {
+	claims := []models.Claim{}
+	for _, c := range r.claims {
+		if c.InvoiceID == int(invoiceID) {
+			claims = append(claims, c)
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].ID < claims[j].ID })
+	return claims, nil
+}
+
+func (r *fakeInsuranceRepo) PostClaimRemittance(claim *models.Claim, invoice *models.Invoice, payment *models.InvoicePayment) error {
+	if r.claims[claim.ID].Status != models.ClaimSubmitted {
+		return repository.ErrClaimPosted
+	}
+	if payment != nil {
+		if err := r.billing.AddPayment(invoice, payment); err != nil {
+			return err
+		}
+	}
+	r.claims[claim.ID] = *claim
+	return nil
+}
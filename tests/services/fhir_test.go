@@ -0,0 +1,237 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/fhir"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFHIRService(patients ...*models.Patient) (*services.FHIRService, *fakePatientRepo, *fakeIdentifierRepo, *fakeEncounterRepo) {
+	patientRepo := newFakePatientRepo(patients...)
+	identifiers := newFakeIdentifierRepo()
+	encounters := newFakeEncounterRepo()
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "dr.house", Role: "doctor"})
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	mrn := services.NewMRNGenerator(identifiers, "MRN", "01")
+	patientService := services.NewPatientService(patientRepo, identifiers, mrn, audit)
+	encounterService := services.NewEncounterService(encounters, &fakeClinicalNoteRepo{}, users, patientRepo, audit)
+	return services.NewFHIRService(patientService, encounterService), patientRepo, identifiers, encounters
+}
+
+func TestFHIR_CreateAndReadPatient(t *testing.T) {
+	svc, patients, identifiers, _ := newFHIRService()
+
+	created, err := svc.CreatePatient(frontDesk, &fhir.Patient{
+		ResourceType: "Patient",
+		Identifier: []fhir.Identifier{
+			{System: services.FHIRIdentifierSystemPrefix + "mrn", Value: "ignored"},
+			{System: services.FHIRIdentifierSystemPrefix + models.IdentifierNationalID, Value: "1234567890"},
+			{System: "urn:oid:2.16.840.1.113883.4.1", Value: "123-45-6789"},
+		},
+		Name:      []fhir.HumanName{{Use: "nickname", Given: []string{"Sam"}}, {Use: "official", Family: "Okafor", Given: []string{"Chidi", "Emeka"}}},
+		Telecom:   []fhir.ContactPoint{{System: "phone", Value: "2175550123"}, {System: "email", Value: "chidi@example.com"}},
+		Gender:    "male",
+		BirthDate: "1985-02-14",
+		Address:   []fhir.Address{{Line: []string{"12 High St"}, City: "Springfield", State: "IL", PostalCode: "62701"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1", created.ID)
+
+	stored := patients.patients[1]
+	assert.Equal(t, "Chidi Emeka", stored.FirstName)
+	assert.Equal(t, "Okafor", stored.LastName)
+	assert.Equal(t, time.Date(1985, 2, 14, 0, 0, 0, 0, time.UTC), stored.DOB)
+	assert.Equal(t, "12 High St, Springfield, IL, 62701", stored.Address)
+	assert.NotEqual(t, "ignored", stored.MRN, "the MRN is assigned, never taken from the resource")
+
+	national, err := identifiers.FindBySystemValue(models.IdentifierNationalID, "1234567890")
+	require.NoError(t, err)
+	assert.Equal(t, 1, national.PatientID)
+	_, err = identifiers.FindBySystemValue("urn:oid:2.16.840.1.113883.4.1", "123-45-6789")
+	assert.NoError(t, err, "URI systems are kept as they are")
+
+	read, err := svc.ReadPatient(frontDesk, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1985-02-14", read.BirthDate)
+	assert.Equal(t, []fhir.HumanName{{Use: "official", Family: "Okafor", Given: []string{"Chidi", "Emeka"}}}, read.Name)
+	require.Len(t, read.Identifier, 3)
+	assert.Equal(t, stored.MRN, read.Identifier[0].Value)
+	assert.Equal(t, "MR", read.Identifier[0].Type.Coding[0].Code)
+	assert.Equal(t, services.FHIRIdentifierSystemPrefix+models.IdentifierNationalID, read.Identifier[1].System)
+	assert.Equal(t, "urn:oid:2.16.840.1.113883.4.1", read.Identifier[2].System)
+}
+
+func TestFHIR_CreatePatientValidation(t *testing.T) {
+	existing := &models.Patient{ID: 7, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	svc, patients, identifiers, _ := newFHIRService(existing)
+	require.NoError(t, identifiers.Create(&models.PatientIdentifier{PatientID: 7, System: models.IdentifierNationalID, Value: "555"}))
+
+	valid := func() *fhir.Patient {
+		return &fhir.Patient{
+			ResourceType: "Patient",
+			Name:         []fhir.HumanName{{Family: "Roe", Given: []string{"Richard"}}},
+			Telecom:      []fhir.ContactPoint{{System: "email", Value: "richard@example.com"}},
+		}
+	}
+
+	cases := map[string]struct {
+		edit func(*fhir.Patient)
+		want error
+	}{
+		"wrong resource type": {func(p *fhir.Patient) { p.ResourceType = "Practitioner" }, services.ErrInvalidFHIRResource},
+		"no name":             {func(p *fhir.Patient) { p.Name = nil }, services.ErrInvalidFHIRResource},
+		"partial birth date":  {func(p *fhir.Patient) { p.BirthDate = "1985-02" }, services.ErrInvalidFHIRResource},
+		"unknown gender":      {func(p *fhir.Patient) { p.Gender = "M" }, services.ErrInvalidFHIRResource},
+		"no email":            {func(p *fhir.Patient) { p.Telecom = nil }, services.ErrInvalidPatientRecord},
+		"identifier taken": {func(p *fhir.Patient) {
+			p.Identifier = []fhir.Identifier{{System: services.FHIRIdentifierSystemPrefix + models.IdentifierNationalID, Value: "555"}}
+		}, services.ErrIdentifierInUse},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resource := valid()
+			tc.edit(resource)
+			_, err := svc.CreatePatient(frontDesk, resource)
+			assert.ErrorIs(t, err, tc.want)
+		})
+	}
+	assert.Len(t, patients.patients, 1, "nothing is created when a check fails")
+
+	duplicate := valid()
+	duplicate.Name = []fhir.HumanName{{Family: "Doe", Given: []string{"Jane"}}}
+	duplicate.Telecom = []fhir.ContactPoint{{System: "email", Value: "jane@example.com"}}
+	_, err := svc.CreatePatient(frontDesk, duplicate)
+	var dup *services.DuplicatePatientError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, 7, dup.Candidates[0].Patient.ID)
+}
+
+func TestFHIR_UpdatePatient(t *testing.T) {
+	existing := &models.Patient{ID: 7, MRN: "MRN0100000011", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Phone: "555"}
+	svc, patients, identifiers, _ := newFHIRService(existing)
+
+	resource := &fhir.Patient{
+		ResourceType: "Patient",
+		ID:           "7",
+		Identifier:   []fhir.Identifier{{System: services.FHIRIdentifierSystemPrefix + models.IdentifierInsuranceMemberID, Value: "INS-9"}},
+		Name:         []fhir.HumanName{{Family: "Doe-Smith", Given: []string{"Jane"}}},
+		Telecom:      []fhir.ContactPoint{{System: "email", Value: "jane@example.com"}},
+		Gender:       "female",
+		BirthDate:    "1990-05-01",
+	}
+	updated, err := svc.UpdatePatient(frontDesk, 7, resource)
+	require.NoError(t, err)
+	assert.Equal(t, "Doe-Smith", updated.Name[0].Family)
+
+	stored := patients.patients[7]
+	assert.Equal(t, "Doe-Smith", stored.LastName)
+	assert.Equal(t, "MRN0100000011", stored.MRN)
+	assert.Empty(t, stored.Phone, "an update replaces the whole record")
+	_, err = identifiers.FindBySystemValue(models.IdentifierInsuranceMemberID, "INS-9")
+	assert.NoError(t, err)
+
+	// Sending the same identifier again is not a conflict
+	_, err = svc.UpdatePatient(frontDesk, 7, resource)
+	assert.NoError(t, err)
+
+	resource.Telecom = nil
+	_, err = svc.UpdatePatient(frontDesk, 7, resource)
+	assert.ErrorIs(t, err, services.ErrInvalidPatientRecord, "an update must still have an email")
+	assert.Equal(t, "jane@example.com", patients.patients[7].Email)
+	resource.Telecom = []fhir.ContactPoint{{System: "email", Value: "jane@example.com"}}
+
+	_, err = svc.UpdatePatient(frontDesk, 8, resource)
+	assert.ErrorIs(t, err, services.ErrInvalidFHIRResource, "the body id must match the URL")
+	resource.ID = "99"
+	_, err = svc.UpdatePatient(frontDesk, 99, resource)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestFHIR_SearchPatients(t *testing.T) {
+	svc, _, _, _ := newFHIRService(
+		&models.Patient{ID: 1, MRN: "MRN0100000011", FirstName: "Jane", LastName: "Doe", DOB: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)},
+		&models.Patient{ID: 2, MRN: "MRN0100000029", FirstName: "John", LastName: "Doe", DOB: time.Date(1975, 2, 1, 0, 0, 0, 0, time.UTC)},
+		&models.Patient{ID: 3, MRN: "MRN0100000037", FirstName: "Omar", LastName: "Haddad", DOB: time.Date(1990, 11, 30, 0, 0, 0, 0, time.UTC)},
+	)
+	ids := func(patients []*fhir.Patient) []string {
+		out := []string{}
+		for _, p := range patients {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	cases := map[string]struct {
+		search services.FHIRPatientSearch
+		want   []string
+	}{
+		"name prefix":           {services.FHIRPatientSearch{Name: "do"}, []string{"1", "2"}},
+		"birth year":            {services.FHIRPatientSearch{BirthDate: []string{"1990"}}, []string{"1", "3"}},
+		"birth month":           {services.FHIRPatientSearch{BirthDate: []string{"eq1990-05"}}, []string{"1"}},
+		"birth date range":      {services.FHIRPatientSearch{BirthDate: []string{"ge1975-02-01", "lt1990-05-01"}}, []string{"2"}},
+		"after year":            {services.FHIRPatientSearch{BirthDate: []string{"gt1989"}}, []string{"1", "3"}},
+		"up to day":             {services.FHIRPatientSearch{BirthDate: []string{"le1990-05-01"}}, []string{"1", "2"}},
+		"MRN":                   {services.FHIRPatientSearch{Identifier: "MRN0100000037"}, []string{"3"}},
+		"MRN with system":       {services.FHIRPatientSearch{Identifier: services.FHIRIdentifierSystemPrefix + "mrn|MRN0100000029"}, []string{"2"}},
+		"identifier and name":   {services.FHIRPatientSearch{Identifier: "MRN0100000037", Name: "doe"}, []string{}},
+		"unknown identifier":    {services.FHIRPatientSearch{Identifier: "MRN0199999999"}, []string{}},
+		"name and birth period": {services.FHIRPatientSearch{Name: "doe", BirthDate: []string{"1975"}}, []string{"2"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patients, _, err := svc.SearchPatients(frontDesk, tc.search)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.want, ids(patients))
+		})
+	}
+
+	page, next, err := svc.SearchPatients(frontDesk, services.FHIRPatientSearch{Count: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, ids(page), "ordered by family name")
+	require.NotEmpty(t, next)
+	page, next, err = svc.SearchPatients(frontDesk, services.FHIRPatientSearch{Count: 2, Cursor: next})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, ids(page))
+	assert.Empty(t, next)
+
+	for _, bad := range []string{"1990-5", "sa1990", "19900501", "nope"} {
+		_, _, err := svc.SearchPatients(frontDesk, services.FHIRPatientSearch{BirthDate: []string{bad}})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFHIR_Encounters(t *testing.T) {
+	svc, _, _, encounters := newFHIRService(&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"})
+	end := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	require.NoError(t, encounters.Create(&models.Encounter{
+		PatientID: 10, DoctorID: 1, Type: models.EncounterOutpatient, Status: models.EncounterFinished,
+		StartTime: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), EndTime: &end, Reason: "Cough",
+	}))
+
+	encounter, err := svc.ReadEncounter(drHouse, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "finished", encounter.Status)
+	assert.Equal(t, "AMB", encounter.Class.Code)
+	assert.Equal(t, "Patient/10", encounter.Subject.Reference)
+	assert.Equal(t, "Practitioner/1", encounter.Participant[0].Individual.Reference)
+	assert.Equal(t, "2024-03-01T10:30:00Z", encounter.Period.End)
+	assert.Equal(t, "Cough", encounter.ReasonCode[0].Text)
+
+	found, err := svc.SearchEncounters(drHouse, "Patient/10")
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	_, err = svc.ReadEncounter(drHouse, 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.SearchEncounters(drHouse, "Patient/99")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.SearchEncounters(drHouse, "Practitioner/1")
+	assert.ErrorIs(t, err, services.ErrInvalidFHIRResource)
+}
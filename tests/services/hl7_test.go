@@ -0,0 +1,201 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/hl7"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHL7Service(patients ...*models.Patient) (*services.HL7Service, *fakePatientRepo, *fakeIdentifierRepo, *fakeLabRepo) {
+	patientRepo := newFakePatientRepo(patients...)
+	identifiers := newFakeIdentifierRepo()
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	mrn := services.NewMRNGenerator(identifiers, "MRN", "01")
+	patientService := services.NewPatientService(patientRepo, identifiers, mrn, audit)
+	labRepo := newFakeLabRepo()
+	labService := services.NewLabService(labRepo, patientRepo, audit)
+	return services.NewHL7Service(patientService, labService), patientRepo, identifiers, labRepo
+}
+
+// hl7Message joins segments with the CR separator
+func hl7Message(segments ...string) []byte {
+	return []byte(strings.Join(segments, "\r"))
+}
+
+// ackCode returns MSA-1 and MSA-3 of an acknowledgment
+func ackCode(t *testing.T, ack []byte) (string, string) {
+	msg, err := hl7.Parse(ack)
+	require.NoError(t, err)
+	msa := msg.Segment("MSA")
+	require.NotNil(t, msa)
+	return msa.Field(1), msa.Field(3)
+}
+
+func TestHL7_ADTA01RegistersPatient(t *testing.T) {
+	svc, patients, identifiers, _ := newHL7Service()
+
+	ack := svc.HandleMessage(hl7Message(
+		`MSH|^~\&|REGADT|REGION|HMS|HOSP|20240301120000||ADT^A01^ADT_A01|MSG0001|P|2.5`,
+		`EVN|A01|20240301120000`,
+		`PID|1||R-778^^^REGION^MR~1234567890^^^GOV^NI||Okafor^Chidi^E||19850214|M|||12 High St^^Springfield^IL^62701||^PRN^PH^^^217^5550123~^NET^Internet^chidi@example.com`,
+		`PV1|1|I|WARD1^101^A`,
+	))
+
+	code, text := ackCode(t, ack)
+	require.Equal(t, hl7.AckAccept, code, text)
+	require.Len(t, patients.patients, 1)
+	p := patients.patients[1]
+	assert.Equal(t, "Chidi", p.FirstName)
+	assert.Equal(t, "Okafor", p.LastName)
+	assert.Equal(t, "male", p.Gender)
+	assert.Equal(t, time.Date(1985, 2, 14, 0, 0, 0, 0, time.UTC), p.DOB)
+	assert.Equal(t, "2175550123", p.Phone)
+	assert.Equal(t, "chidi@example.com", p.Email)
+	assert.Equal(t, "12 High St, Springfield, IL, 62701", p.Address)
+	assert.NotEmpty(t, p.MRN)
+
+	national, err := identifiers.FindBySystemValue(models.IdentifierNationalID, "1234567890")
+	require.NoError(t, err)
+	assert.Equal(t, 1, national.PatientID)
+	_, err = identifiers.FindBySystemValue(models.IdentifierMRN, "R-778")
+	assert.Error(t, err, "the sender's MRN is not one of ours")
+}
+
+func TestHL7_ADTA08UpdatesMatchedPatient(t *testing.T) {
+	existing := &models.Patient{ID: 7, MRN: "MRN0100000011", FirstName: "Chidi", LastName: "Okafor", Email: "chidi@example.com", Phone: "2175550123"}
+	svc, patients, _, _ := newHL7Service(existing)
+
+	ack := svc.HandleMessage(hl7Message(
+		`MSH|^~\&|REGADT|REGION|HMS|HOSP|20240302090000||ADT^A08|MSG0002|P|2.5`,
+		`PID|1||MRN0100000011^^^HOSP^MR||Okafor-Smith^Chidi||19850214|M|||||`,
+	))
+
+	code, text := ackCode(t, ack)
+	require.Equal(t, hl7.AckAccept, code, text)
+	p := patients.patients[7]
+	assert.Equal(t, "Okafor-Smith", p.LastName)
+	assert.Equal(t, "2175550123", p.Phone, "empty fields are left as they were")
+	assert.Equal(t, "chidi@example.com", p.Email)
+}
+
+func TestHL7_ADTA08UnknownPatient(t *testing.T) {
+	svc, patients, _, _ := newHL7Service()
+
+	ack := svc.HandleMessage(hl7Message(
+		`MSH|^~\&|REGADT|REGION|HMS|HOSP|20240302090000||ADT^A08|MSG0003|P|2.5`,
+		`PID|1||999^^^GOV^NI||Doe^Jane||19900101|F`,
+	))
+
+	code, text := ackCode(t, ack)
+	assert.Equal(t, hl7.AckError, code)
+	assert.Contains(t, text, "no patient matches")
+	assert.Empty(t, patients.patients)
+}
+
+func TestHL7_ORUR01RecordsLabResult(t *testing.T) {
+	patient := &models.Patient{ID: 11, MRN: "MRN0100000029", FirstName: "John", LastName: "Roe", Gender: "male", DOB: time.Date(1975, 2, 1, 0, 0, 0, 0, time.UTC)}
+	svc, _, _, labs := newHL7Service(patient)
+	require.NoError(t, labs.CreateOrder(&models.LabOrder{PatientID: 11, TestCode: "2823-3", OrderedBy: 1, Status: models.LabOrdered}))
+
+	ack := svc.HandleMessage(hl7Message(
+		`MSH|^~\&|ANALYZER|LAB|HMS|HOSP|20240301130000||ORU^R01|LAB0001|P|2.5`,
+		`PID|1||MRN0100000029^^^HOSP^MR||Roe^John`,
+		`OBR|1|1|F-1|2823-3^Potassium^LN|||20240301090000`,
+		`OBX|1|NM|2823-3^Potassium^LN||6.8|mmol/L|3.5-5.1|HH|||F|||20240301120000`,
+		`NTE|1||Sample haemolysed`,
+	))
+
+	code, text := ackCode(t, ack)
+	require.Equal(t, hl7.AckAccept, code, text)
+	order, err := labs.FindOrderByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.LabResulted, order.Status)
+	require.NotNil(t, order.CollectedAt)
+	require.NotNil(t, order.Result)
+	assert.Equal(t, 6.8, order.Result.Value)
+	assert.Equal(t, models.LabFlagHigh, order.Result.Flag)
+	assert.True(t, order.Result.Critical)
+	assert.Equal(t, "Sample haemolysed", order.Result.Comment)
+}
+
+func TestHL7_ORUR01Rejections(t *testing.T) {
+	patient := &models.Patient{ID: 11, MRN: "MRN0100000029", FirstName: "John", LastName: "Roe", Gender: "male"}
+	svc, _, _, labs := newHL7Service(patient)
+	require.NoError(t, labs.CreateOrder(&models.LabOrder{PatientID: 11, TestCode: "2823-3", OrderedBy: 1, Status: models.LabOrdered}))
+
+	msh := `MSH|^~\&|ANALYZER|LAB|HMS|HOSP|20240301130000||ORU^R01|LAB0002|P|2.5`
+	pid := `PID|1||MRN0100000029^^^HOSP^MR||Roe^John`
+	cases := map[string][]byte{
+		"unknown order":   hl7Message(msh, pid, `OBR|1|42`, `OBX|1|NM|2823-3^Potassium^LN||4.2|mmol/L`),
+		"wrong unit":      hl7Message(msh, pid, `OBR|1|1`, `OBX|1|NM|2823-3^Potassium^LN||4.2|mg/dL`),
+		"not numeric":     hl7Message(msh, pid, `OBR|1|1`, `OBX|1|ST|2823-3^Potassium^LN||normal`),
+		"other analyte":   hl7Message(msh, pid, `OBR|1|1`, `OBX|1|NM|2951-2^Sodium^LN||140|mmol/L`),
+		"unknown patient": hl7Message(msh, `PID|1||MRN0199999999^^^HOSP^MR||Roe^John`, `OBR|1|1`, `OBX|1|NM|2823-3||4.2`),
+	}
+	for name, msg := range cases {
+		t.Run(name, func(t *testing.T) {
+			code, _ := ackCode(t, svc.HandleMessage(msg))
+			assert.Equal(t, hl7.AckError, code)
+		})
+	}
+
+	order, err := labs.FindOrderByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.LabOrdered, order.Status, "rejected messages change nothing")
+}
+
+func TestHL7_ORUR01ChecksEveryOrderFirst(t *testing.T) {
+	patient := &models.Patient{ID: 11, MRN: "MRN0100000029", FirstName: "John", LastName: "Roe", Gender: "male"}
+	svc, _, _, labs := newHL7Service(patient)
+	for _, status := range []string{models.LabOrdered, models.LabCancelled, models.LabOrdered} {
+		require.NoError(t, labs.CreateOrder(&models.LabOrder{PatientID: 11, TestCode: "2823-3", OrderedBy: 1, Status: status}))
+	}
+
+	msh := `MSH|^~\&|ANALYZER|LAB|HMS|HOSP|20240301130000||ORU^R01|LAB0003|P|2.5`
+	pid := `PID|1||MRN0100000029^^^HOSP^MR||Roe^John`
+	first := `OBX|1|NM|2823-3^Potassium^LN||4.2|mmol/L||||F|||20240301120000`
+
+	code, text := ackCode(t, svc.HandleMessage(hl7Message(msh, pid, `OBR|1|1`, first, `OBR|2|2`, first)))
+	assert.Equal(t, hl7.AckError, code)
+	assert.Contains(t, text, "cancelled")
+	order, err := labs.FindOrderByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.LabOrdered, order.Status, "the first order is left alone")
+
+	code, _ = ackCode(t, svc.HandleMessage(hl7Message(msh, pid, `OBR|1|1`, first, `OBR|2|1`, first)))
+	assert.Equal(t, hl7.AckError, code, "an order is reported twice")
+
+	// A retransmission after the first result was recorded applies the rest
+	code, text = ackCode(t, svc.HandleMessage(hl7Message(msh, pid, `OBR|1|1`, first)))
+	require.Equal(t, hl7.AckAccept, code, text)
+	code, text = ackCode(t, svc.HandleMessage(hl7Message(msh, pid, `OBR|1|1`, first, `OBR|2|3`, first)))
+	require.Equal(t, hl7.AckAccept, code, text)
+	order, err = labs.FindOrderByID(3)
+	require.NoError(t, err)
+	assert.Equal(t, models.LabResulted, order.Status)
+
+	different := `OBX|1|NM|2823-3^Potassium^LN||5.0|mmol/L||||F|||20240301120000`
+	code, _ = ackCode(t, svc.HandleMessage(hl7Message(msh, pid, `OBR|1|1`, different)))
+	assert.Equal(t, hl7.AckError, code, "a resulted order keeps its result")
+}
+
+func TestHL7_UnsupportedMessageIsRejected(t *testing.T) {
+	svc, _, _, _ := newHL7Service()
+
+	ack := svc.HandleMessage(hl7Message(
+		`MSH|^~\&|REGADT|REGION|HMS|HOSP|20240302090000||ADT^A03|MSG0004|P|2.5`,
+		`PID|1||999^^^GOV^NI||Doe^Jane`,
+	))
+	code, _ := ackCode(t, ack)
+	assert.Equal(t, hl7.AckReject, code)
+
+	code, _ = ackCode(t, svc.HandleMessage([]byte("not hl7")))
+	assert.Equal(t, hl7.AckReject, code)
+}
@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentifierPatientService(patients ...*models.Patient) (*services.PatientService, *fakeIdentifierRepo) {
+	identifiers := newFakeIdentifierRepo()
+	mrn := services.NewMRNGenerator(identifiers, "MRN", "01")
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	repo := newFakePatientRepo(patients...)
+	repo.identifiers = identifiers
+	return services.NewPatientService(repo, identifiers, mrn, audit), identifiers
+}
+
+func TestFormatMRN(t *testing.T) {
+	mrn := services.FormatMRN("MRN", "01", 42)
+	assert.Equal(t, "MRN010000042", mrn[:len(mrn)-1])
+	assert.True(t, services.IsValidMRN("MRN", mrn))
+	assert.False(t, services.IsValidMRN("MRN", mrn[:len(mrn)-1]+"x"))
+	assert.False(t, services.IsValidMRN("HOS", mrn))
+}
+
+func TestCreatePatient_AssignsSequentialMRNs(t *testing.T) {
+	svc, _ := newIdentifierPatientService()
+	first := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	second := &models.Patient{FirstName: "Omar", LastName: "Haddad", Email: "omar@example.com"}
+
+	require.NoError(t, svc.CreatePatient(frontDesk, first, false))
+	require.NoError(t, svc.CreatePatient(frontDesk, second, false))
+
+	assert.Equal(t, services.FormatMRN("MRN", "01", 1), first.MRN)
+	assert.Equal(t, services.FormatMRN("MRN", "01", 2), second.MRN)
+
+	// Updates cannot change the MRN
+	edited := *first
+	edited.MRN = "MRN999"
+	saved, err := svc.UpdatePatient(frontDesk, &edited)
+	require.NoError(t, err)
+	assert.Equal(t, first.MRN, saved.MRN)
+	stored, err := svc.GetPatientByID(frontDesk, uint(first.ID))
+	require.NoError(t, err)
+	assert.Equal(t, first.MRN, stored.MRN)
+}
+
+func TestFindPatientByIdentifier(t *testing.T) {
+	svc, _ := newIdentifierPatientService()
+	patient := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, patient, false))
+
+	found, err := svc.FindPatientByIdentifier(frontDesk, models.IdentifierMRN, patient.MRN)
+	require.NoError(t, err)
+	assert.Equal(t, patient.ID, found.ID)
+
+	nationalID := &models.PatientIdentifier{PatientID: patient.ID, System: models.IdentifierNationalID, Value: "AB123456"}
+	require.NoError(t, svc.AddIdentifier(frontDesk, nationalID))
+	found, err = svc.FindPatientByIdentifier(frontDesk, models.IdentifierNationalID, "AB123456")
+	require.NoError(t, err)
+	assert.Equal(t, patient.ID, found.ID)
+
+	_, err = svc.FindPatientByIdentifier(frontDesk, models.IdentifierNationalID, "ZZ000000")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.FindPatientByIdentifier(frontDesk, "", "AB123456")
+	assert.ErrorIs(t, err, services.ErrInvalidIdentifier)
+}
+
+func TestAddIdentifier_RejectsIdentifierOfAnotherPatient(t *testing.T) {
+	svc, _ := newIdentifierPatientService()
+	jane := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	omar := &models.Patient{FirstName: "Omar", LastName: "Haddad", Email: "omar@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, jane, false))
+	require.NoError(t, svc.CreatePatient(frontDesk, omar, false))
+
+	require.NoError(t, svc.AddIdentifier(frontDesk, &models.PatientIdentifier{
+		PatientID: jane.ID, System: models.IdentifierInsuranceMemberID, Value: "M-1001"}))
+	err := svc.AddIdentifier(frontDesk, &models.PatientIdentifier{
+		PatientID: omar.ID, System: models.IdentifierInsuranceMemberID, Value: "M-1001"})
+	assert.ErrorIs(t, err, services.ErrIdentifierInUse)
+}
+
+func TestMergePatients_KeepsDuplicateMRN(t *testing.T) {
+	svc, _ := newIdentifierPatientService()
+	survivor := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	duplicate := &models.Patient{FirstName: "Jane", LastName: "Doe", Email: "jdoe@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, survivor, false))
+	require.NoError(t, svc.CreatePatient(frontDesk, duplicate, true))
+
+	_, err := svc.MergePatients(frontDesk, uint(survivor.ID), uint(duplicate.ID))
+	require.NoError(t, err)
+
+	found, err := svc.FindPatientByIdentifier(frontDesk, models.IdentifierMRN, duplicate.MRN)
+	require.NoError(t, err)
+	assert.Equal(t, survivor.ID, found.ID)
+}
+
+func TestAssignMissingMRNs(t *testing.T) {
+	svc, _ := newIdentifierPatientService(
+		&models.Patient{ID: 1, FirstName: "Jane", LastName: "Doe"},
+		&models.Patient{ID: 2, FirstName: "Omar", LastName: "Haddad", MRN: "MRN0100000001"},
+	)
+
+	n, err := svc.AssignMissingMRNs()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	jane, err := svc.GetPatientByID(frontDesk, 1)
+	require.NoError(t, err)
+	assert.True(t, services.IsValidMRN("MRN", jane.MRN))
+}
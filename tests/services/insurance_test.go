@@ -0,0 +1,354 @@
+package services_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/x12"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type insuranceFixture struct {
+	svc      *services.InsuranceService
+	billing  *services.BillingService
+	problems *fakeProblemRepo
+	audit    *fakeAuditRepo
+	// stale shares the fixture's data but misses claims made by others
+	stale *services.InsuranceService
+}
+
+func newInsuranceService(t *testing.T) *insuranceFixture {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe", Gender: "female", DOB: time.Date(1980, 5, 1, 0, 0, 0, 0, time.UTC)},
+		&models.Patient{ID: 11, FirstName: "John", LastName: "Roe"},
+	)
+	problems := newFakeProblemRepo()
+	require.NoError(t, problems.Create(&models.Problem{PatientID: 10, ICD10Code: "E11.9", Status: models.ProblemActive}))
+	require.NoError(t, problems.Create(&models.Problem{PatientID: 10, ICD10Code: "I10", Status: models.ProblemResolved}))
+
+	billingRepo := newFakeBillingRepo()
+	repo := newFakeInsuranceRepo(billingRepo)
+	auditRepo := &fakeAuditRepo{}
+	audit := services.NewAuditService(auditRepo)
+	billing := services.NewBillingService(billingRepo, patients, newFakeEncounterRepo(), audit)
+	settings := services.ClaimsSettings{
+		SenderID:   "HMS",
+		ReceiverID: "CLEARINGHOUSE",
+		Submitter:  x12.Party{Name: "General Hospital", ID: "HMS"},
+		Receiver:   x12.Party{Name: "Clearinghouse", ID: "CLEARINGHOUSE"},
+		Provider:   x12.BillingProvider{Party: x12.Party{Name: "General Hospital", ID: "1234567893"}, TaxID: "123456789"},
+	}
+	svc := services.NewInsuranceService(repo, billingRepo, patients, problems, billing,
+		services.NewLocalEligibilityChecker(), settings, audit)
+	stale := services.NewInsuranceService(&staleInsuranceRepo{repo}, billingRepo, patients, problems, billing,
+		services.NewLocalEligibilityChecker(), settings, audit)
+	return &insuranceFixture{svc: svc, billing: billing, problems: problems, audit: auditRepo, stale: stale}
+}
+
+// staleInsuranceRepo finds no claims, like a read that raced another
+// clerk's claim for the same invoice
+type staleInsuranceRepo struct {
+	*fakeInsuranceRepo
+}
+
+func (r *staleInsuranceRepo) FindClaimsByInvoice(invoiceID uint) ([]models.Claim, error) {
+	return []models.Claim{}, nil
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func primaryPolicy() *models.InsurancePolicy {
+	return &models.InsurancePolicy{PatientID: 10, PayerName: "Acme Health", PayerID: "ACME1", MemberID: "M123",
+		GroupNumber: "G9", Priority: 1, EffectiveFrom: date(2020, 1, 1)}
+}
+
+// remittance builds an 835 paying claims
+func remittance(claims ...x12.Segment) []byte {
+	set := []x12.Segment{
+		x12.NewSegment("ST", "835", "0001"),
+		x12.NewSegment("BPR", "I", "0", "C", "ACH"),
+		x12.NewSegment("TRN", "1", "EFT555"),
+		x12.NewSegment("N1", "PR", "ACME HEALTH"),
+	}
+	set = append(set, claims...)
+	set = append(set, x12.NewSegment("SE", "0", "0001"))
+	ic := x12.Interchange{SenderID: "ACME", ReceiverID: "HMS", ControlNumber: 9, Time: time.Now()}
+	return x12.Encode(ic.Wrap("HP", "005010X221A1", set))
+}
+
+func TestInsuranceService_CreatePolicyValidation(t *testing.T) {
+	f := newInsuranceService(t)
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, primaryPolicy()))
+
+	spouse := func(p *models.InsurancePolicy) {
+		p.Relationship = models.RelationshipSpouse
+	}
+	cases := []struct {
+		name   string
+		change func(p *models.InsurancePolicy)
+		err    error
+	}{
+		{"unknown patient", func(p *models.InsurancePolicy) { p.PatientID = 99 }, sql.ErrNoRows},
+		{"no member ID", func(p *models.InsurancePolicy) { p.MemberID = " " }, services.ErrInvalidPolicy},
+		{"bad priority", func(p *models.InsurancePolicy) { p.Priority = 4 }, services.ErrInvalidPolicy},
+		{"bad relationship", func(p *models.InsurancePolicy) { p.Relationship = "cousin" }, services.ErrInvalidPolicy},
+		{"dependent without subscriber", spouse, services.ErrInvalidPolicy},
+		{"ends before it starts", func(p *models.InsurancePolicy) {
+			end := date(2019, 1, 1)
+			p.EffectiveTo = &end
+		}, services.ErrInvalidPolicy},
+		{"overlapping primary", func(p *models.InsurancePolicy) { p.EffectiveFrom = date(2024, 1, 1) }, services.ErrPolicyOverlap},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := primaryPolicy()
+			tc.change(p)
+			assert.ErrorIs(t, f.svc.CreatePolicy(billingClerk, p), tc.err)
+		})
+	}
+
+	secondary := primaryPolicy()
+	secondary.Priority = 2
+	secondary.Relationship = models.RelationshipSpouse
+	secondary.SubscriberFirstName, secondary.SubscriberLastName = "Joe", "Doe"
+	dob := date(1978, 2, 3)
+	secondary.SubscriberDOB = &dob
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, secondary), "a different priority may overlap")
+
+	policies, err := f.svc.GetPolicies(billingClerk, 10)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, 1, policies[0].Priority)
+	assert.Equal(t, models.RelationshipSelf, policies[0].Relationship)
+}
+
+func TestInsuranceService_UpdatePolicyEndsCoverage(t *testing.T) {
+	f := newInsuranceService(t)
+	old := primaryPolicy()
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, old))
+
+	end := date(2023, 12, 31)
+	old.EffectiveTo = &end
+	require.NoError(t, f.svc.UpdatePolicy(billingClerk, old))
+
+	replacement := primaryPolicy()
+	replacement.EffectiveFrom = date(2024, 1, 1)
+	assert.NoError(t, f.svc.CreatePolicy(billingClerk, replacement), "coverage that ends the day before does not overlap")
+
+	other := *old
+	other.PatientID = 11
+	assert.ErrorIs(t, f.svc.UpdatePolicy(billingClerk, &other), sql.ErrNoRows)
+}
+
+func TestInsuranceService_CheckEligibility(t *testing.T) {
+	f := newInsuranceService(t)
+	policy := primaryPolicy()
+	end := date(2024, 6, 30)
+	policy.EffectiveTo = &end
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, policy))
+
+	result, err := f.svc.CheckEligibility(billingClerk, 10, uint(policy.ID), date(2024, 6, 30))
+	require.NoError(t, err)
+	assert.True(t, result.Eligible, "the last day of coverage is covered")
+	assert.Equal(t, "local", result.Source)
+
+	result, err = f.svc.CheckEligibility(billingClerk, 10, uint(policy.ID), date(2024, 7, 1))
+	require.NoError(t, err)
+	assert.False(t, result.Eligible)
+
+	policy.MemberID = "INACTIVE-1"
+	require.NoError(t, f.svc.UpdatePolicy(billingClerk, policy))
+	result, err = f.svc.CheckEligibility(billingClerk, 10, uint(policy.ID), date(2024, 3, 1))
+	require.NoError(t, err)
+	assert.False(t, result.Eligible)
+
+	_, err = f.svc.CheckEligibility(billingClerk, 11, uint(policy.ID), date(2024, 3, 1))
+	assert.ErrorIs(t, err, sql.ErrNoRows, "another patient's policy")
+}
+
+func TestInsuranceService_CreateClaim(t *testing.T) {
+	f := newInsuranceService(t)
+	policy := primaryPolicy()
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, policy))
+	invoice := issueInvoice(t, f.billing)
+
+	claim, err := f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, policy.ID, claim.PolicyID, "the primary policy is billed by default")
+	assert.Equal(t, "CLM000000001", claim.ControlNumber)
+	assert.Equal(t, models.ClaimSubmitted, claim.Status)
+	assert.Equal(t, invoice.TotalCents, claim.ChargeCents)
+
+	segments, err := x12.Parse([]byte(claim.Content))
+	require.NoError(t, err)
+	byID := map[string][]x12.Segment{}
+	for _, s := range segments {
+		byID[s.ID] = append(byID[s.ID], s)
+	}
+	assert.Equal(t, "000000001", byID["ISA"][0].Element(13))
+	assert.Equal(t, "CLM000000001", byID["CLM"][0].Element(1))
+	assert.Equal(t, x12.FormatAmount(invoice.TotalCents), byID["CLM"][0].Element(2))
+	assert.Equal(t, []string{"ABK:E119"}, byID["HI"][0].Elements, "only active problems are coded")
+	assert.Equal(t, "P", byID["SBR"][0].Element(1))
+	assert.Len(t, byID["SV1"], 2)
+	last := f.audit.entries[len(f.audit.entries)-1]
+	assert.Equal(t, models.AuditCreate, last.Action)
+	assert.Equal(t, 10, *last.PatientID)
+
+	_, err = f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	assert.ErrorIs(t, err, services.ErrClaimExists)
+
+	claims, err := f.svc.GetClaims(billingClerk, uint(invoice.ID))
+	require.NoError(t, err)
+	assert.Len(t, claims, 1)
+
+	assert.ErrorIs(t, f.svc.DeletePolicy(billingClerk, 10, uint(policy.ID)), services.ErrPolicyInUse)
+}
+
+func TestInsuranceService_CreateClaimRace(t *testing.T) {
+	f := newInsuranceService(t)
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, primaryPolicy()))
+	invoice := issueInvoice(t, f.billing)
+
+	_, err := f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	require.NoError(t, err)
+	_, err = f.stale.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	assert.ErrorIs(t, err, services.ErrClaimExists)
+
+	claims, err := f.svc.GetClaims(billingClerk, uint(invoice.ID))
+	require.NoError(t, err)
+	assert.Len(t, claims, 1)
+}
+
+func TestInsuranceService_CreateClaimValidation(t *testing.T) {
+	f := newInsuranceService(t)
+	invoice := issueInvoice(t, f.billing)
+
+	_, err := f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	assert.ErrorIs(t, err, services.ErrInvalidClaim, "no policy")
+
+	future := primaryPolicy()
+	future.EffectiveFrom = time.Now().AddDate(1, 0, 0)
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, future))
+	_, err = f.svc.CreateClaim(billingClerk, uint(invoice.ID), &future.ID)
+	assert.ErrorIs(t, err, services.ErrInvalidClaim, "not in effect on the invoice's date")
+
+	current := primaryPolicy()
+	end := time.Now().AddDate(0, 6, 0)
+	current.EffectiveTo = &end
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, current))
+	other := primaryPolicy()
+	other.PatientID = 11
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, other))
+	_, err = f.svc.CreateClaim(billingClerk, uint(invoice.ID), &other.ID)
+	assert.ErrorIs(t, err, services.ErrInvalidClaim, "another patient's policy")
+
+	for id := range f.problems.problems {
+		f.problems.problems[id].Status = models.ProblemResolved
+	}
+	_, err = f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	assert.ErrorIs(t, err, services.ErrInvalidClaim, "no active problems")
+
+	_, err = f.billing.VoidInvoice(billingClerk, uint(invoice.ID))
+	require.NoError(t, err)
+	_, err = f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	assert.ErrorIs(t, err, services.ErrInvoiceStatus)
+}
+
+func TestInsuranceService_PostRemittance(t *testing.T) {
+	f := newInsuranceService(t)
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, primaryPolicy()))
+	paidInvoice := issueInvoice(t, f.billing)
+	deniedInvoice := issueInvoice(t, f.billing)
+	paidClaim, err := f.svc.CreateClaim(billingClerk, uint(paidInvoice.ID), nil)
+	require.NoError(t, err)
+	deniedClaim, err := f.svc.CreateClaim(billingClerk, uint(deniedInvoice.ID), nil)
+	require.NoError(t, err)
+
+	data := remittance(
+		x12.NewSegment("CLP", paidClaim.ControlNumber, "1", x12.FormatAmount(paidClaim.ChargeCents), "200", "52.87", "12", "PCN-1"),
+		x12.NewSegment("CLP", deniedClaim.ControlNumber, "4", x12.FormatAmount(deniedClaim.ChargeCents), "0", "0", "12", "PCN-2"),
+		x12.NewSegment("CLP", "CLM999999999", "1", "10", "10"),
+	)
+	report, err := f.svc.PostRemittance(billingClerk, data)
+	require.NoError(t, err)
+	assert.Equal(t, "ACME HEALTH", report.PayerName)
+	assert.Equal(t, "EFT555", report.TraceNumber)
+	assert.Equal(t, 2, report.Posted)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Claims, 3)
+	assert.Equal(t, models.ClaimPartiallyPaid, report.Claims[0].Status)
+	assert.Equal(t, models.ClaimDenied, report.Claims[1].Status)
+	assert.NotEmpty(t, report.Claims[2].Error, "unknown control number")
+
+	invoice, err := f.billing.GetInvoice(billingClerk, uint(paidInvoice.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoicePartiallyPaid, invoice.Status)
+	require.Len(t, invoice.Payments, 1)
+	assert.Equal(t, int64(20000), invoice.Payments[0].AmountCents)
+	assert.Equal(t, models.PaymentInsurance, invoice.Payments[0].Method)
+	assert.True(t, strings.Contains(invoice.Payments[0].Reference, "PCN-1"))
+
+	claim, err := f.svc.GetClaim(billingClerk, uint(paidClaim.ID))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5287), claim.PatientResponsibilityCents)
+	assert.Equal(t, "EFT555", claim.RemittanceTrace)
+	assert.NotNil(t, claim.AdjudicatedAt)
+
+	denied, err := f.billing.GetInvoice(billingClerk, uint(deniedInvoice.ID))
+	require.NoError(t, err)
+	assert.Empty(t, denied.Payments)
+
+	report, err = f.svc.PostRemittance(billingClerk, data)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Posted, "posting the same remittance again posts nothing")
+	invoice, err = f.billing.GetInvoice(billingClerk, uint(paidInvoice.ID))
+	require.NoError(t, err)
+	assert.Len(t, invoice.Payments, 1)
+
+	_, err = f.svc.CreateClaim(billingClerk, uint(deniedInvoice.ID), nil)
+	assert.NoError(t, err, "a denied claim may be sent again")
+}
+
+func TestInsuranceService_PostRemittanceOverpayment(t *testing.T) {
+	f := newInsuranceService(t)
+	require.NoError(t, f.svc.CreatePolicy(billingClerk, primaryPolicy()))
+	invoice := issueInvoice(t, f.billing)
+	claim, err := f.svc.CreateClaim(billingClerk, uint(invoice.ID), nil)
+	require.NoError(t, err)
+	_, err = f.billing.RecordPayment(billingClerk, uint(invoice.ID), &models.InvoicePayment{AmountCents: 25000, Method: models.PaymentCard})
+	require.NoError(t, err)
+
+	report, err := f.svc.PostRemittance(billingClerk, remittance(
+		x12.NewSegment("CLP", claim.ControlNumber, "1", x12.FormatAmount(claim.ChargeCents), "200")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Contains(t, report.Claims[0].Error, "exceeds")
+
+	stored, err := f.svc.GetClaim(billingClerk, uint(claim.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.ClaimSubmitted, stored.Status, "the claim is left to post again")
+}
+
+func TestInsuranceService_PostRemittanceRejectsOtherFiles(t *testing.T) {
+	f := newInsuranceService(t)
+
+	_, err := f.svc.PostRemittance(billingClerk, []byte("not an interchange"))
+	assert.ErrorIs(t, err, services.ErrInvalidRemittance)
+
+	ic := x12.Interchange{SenderID: "HMS", ReceiverID: "CH", ControlNumber: 1, Time: time.Now()}
+	claimFile := x12.Encode(ic.Wrap("HC", x12.Version837P, []x12.Segment{
+		x12.NewSegment("ST", "837", "0001", x12.Version837P),
+		x12.NewSegment("SE", "2", "0001"),
+	}))
+	_, err = f.svc.PostRemittance(billingClerk, claimFile)
+	assert.ErrorIs(t, err, services.ErrInvalidRemittance)
+}
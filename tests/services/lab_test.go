@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLabService() (*services.LabService, *fakeLabRepo) {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe", Gender: "female", DOB: time.Date(1980, 5, 1, 0, 0, 0, 0, time.UTC)},
+		&models.Patient{ID: 11, FirstName: "John", LastName: "Roe", Gender: "male", DOB: time.Date(1975, 2, 1, 0, 0, 0, 0, time.UTC)},
+	)
+	repo := newFakeLabRepo()
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	return services.NewLabService(repo, patients, audit), repo
+}
+
+func TestFlagLabResult(t *testing.T) {
+	repo := newFakeLabRepo()
+	hemoglobin := repo.tests["718-7"].Ranges
+
+	cases := []struct {
+		name     string
+		value    float64
+		sex      string
+		age      int
+		flag     string
+		critical bool
+		low      float64
+	}{
+		{"normal for a woman", 13.0, "female", 40, "", false, 12.0},
+		{"low for a man", 13.0, "male", 40, models.LabFlagLow, false, 13.5},
+		{"child range ignores sex", 13.0, "male", 8, "", false, 11.0},
+		{"no sex-specific adult range", 13.0, "other", 40, "", false, 0},
+		{"critically low", 6.5, "female", 40, models.LabFlagLow, true, 12.0},
+		{"high but not critical", 18.0, "male", 40, models.LabFlagHigh, false, 13.5},
+		{"critically high", 21.0, "male", 40, models.LabFlagHigh, true, 13.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := &models.LabResult{Value: tc.value}
+			services.FlagLabResult(result, hemoglobin, tc.sex, tc.age)
+			assert.Equal(t, tc.flag, result.Flag)
+			assert.Equal(t, tc.critical, result.Critical)
+			if tc.low == 0 {
+				assert.Nil(t, result.ReferenceLow)
+			} else {
+				require.NotNil(t, result.ReferenceLow)
+				assert.Equal(t, tc.low, *result.ReferenceLow)
+			}
+		})
+	}
+}
+
+func TestOrderTest_UnknownTest(t *testing.T) {
+	svc, _ := newLabService()
+
+	err := svc.OrderTest(drHouse, &models.LabOrder{PatientID: 10, TestCode: "0000-0"})
+	assert.ErrorIs(t, err, services.ErrInvalidLabOrder)
+}
+
+func TestLabOrder_Workflow(t *testing.T) {
+	svc, _ := newLabService()
+
+	order := &models.LabOrder{PatientID: 11, TestCode: "2823-3", Status: models.LabVerified}
+	require.NoError(t, svc.OrderTest(drHouse, order))
+	assert.Equal(t, models.LabOrdered, order.Status)
+	assert.Equal(t, "Potassium", order.TestName)
+	assert.Equal(t, int64(1), order.OrderedBy)
+
+	_, err := svc.RecordResult(drHouse, 11, uint(order.ID), &models.LabResult{Value: 4.0})
+	assert.ErrorIs(t, err, services.ErrLabOrderStatus, "a result needs a collected specimen")
+
+	collected, err := svc.CollectSpecimen(drHouse, 11, uint(order.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.LabCollected, collected.Status)
+	require.NotNil(t, collected.CollectedAt)
+
+	resulted, err := svc.RecordResult(drWilson, 11, uint(order.ID), &models.LabResult{Value: 6.8, Comment: "Haemolysed?"})
+	require.NoError(t, err)
+	assert.Equal(t, models.LabResulted, resulted.Status)
+	require.NotNil(t, resulted.Result)
+	assert.Equal(t, "mmol/L", resulted.Result.Unit)
+	assert.Equal(t, models.LabFlagHigh, resulted.Result.Flag)
+	assert.True(t, resulted.Result.Critical)
+	assert.Equal(t, int64(3), resulted.Result.ResultedBy)
+
+	_, err = svc.CancelOrder(drHouse, 11, uint(order.ID))
+	assert.ErrorIs(t, err, services.ErrLabOrderStatus)
+
+	verified, err := svc.VerifyResult(drHouse, 11, uint(order.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.LabVerified, verified.Status)
+	require.NotNil(t, verified.VerifiedBy)
+	assert.Equal(t, int64(1), *verified.VerifiedBy)
+
+	orders, err := svc.GetOrders(drHouse, 11)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.NotNil(t, orders[0].Result)
+	assert.Equal(t, 6.8, orders[0].Result.Value)
+}
+
+func TestLabOrder_CancelBeforeResult(t *testing.T) {
+	svc, _ := newLabService()
+	order := &models.LabOrder{PatientID: 10, TestCode: "718-7"}
+	require.NoError(t, svc.OrderTest(drHouse, order))
+
+	cancelled, err := svc.CancelOrder(drHouse, 10, uint(order.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.LabCancelled, cancelled.Status)
+
+	_, err = svc.CollectSpecimen(drHouse, 10, uint(order.ID), nil)
+	assert.ErrorIs(t, err, services.ErrLabOrderStatus)
+}
+
+func TestLabOrder_OtherPatientIsNotFound(t *testing.T) {
+	svc, _ := newLabService()
+	order := &models.LabOrder{PatientID: 10, TestCode: "718-7"}
+	require.NoError(t, svc.OrderTest(drHouse, order))
+
+	_, err := svc.GetOrder(drHouse, 11, uint(order.ID))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.CollectSpecimen(drHouse, 11, uint(order.ID), nil)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
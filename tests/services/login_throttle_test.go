@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var unlockAdmin = models.Actor{UserID: 9, Username: "admin", Role: models.RoleAdmin}
+
+// retryAfter asserts that the login was throttled and returns for how long
+func retryAfter(t *testing.T, err error) time.Duration {
+	var throttled *services.LoginThrottledError
+	require.True(t, errors.As(err, &throttled), "expected a throttled login, got %v", err)
+	assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts)
+	return throttled.RetryAfter
+}
+
+func TestLogin_BlocksUsernameAndIPAfterFailure(t *testing.T) {
+	svc, _, _ := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 10, MaxIPFailures: 10, BaseDelay: time.Minute, LockoutDuration: time.Hour,
+	})
+
+	_, _, err := svc.LoginWithUser("nobody", "guess", "10.0.0.9")
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+
+	// The username is blocked from anywhere...
+	wait := retryAfter(t, loginErr(svc.LoginWithUser("nobody", "guess", "10.0.0.10")))
+	assert.True(t, wait > 59*time.Second && wait <= time.Minute, wait)
+	// ...and the IP for any username
+	_, _, err = svc.LoginWithUser("frontdesk", testPassword, "10.0.0.9")
+	retryAfter(t, err)
+
+	_, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.10")
+	require.NoError(t, err, "other users from other places are not affected")
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func TestLogin_DoublesTheDelayWithEachFailure(t *testing.T) {
+	svc, _, _ := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 10, MaxIPFailures: 10, BaseDelay: 20 * time.Millisecond, LockoutDuration: time.Hour,
+	})
+
+	for _, want := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond} {
+		_, _, err := svc.LoginWithUser("nobody", "guess", "")
+		require.ErrorIs(t, err, services.ErrInvalidCredentials)
+
+		wait := retryAfter(t, loginErr(svc.LoginWithUser("nobody", "guess", "")))
+		assert.True(t, wait > want/2 && wait <= want, "want about %v, got %v", want, wait)
+		time.Sleep(wait)
+	}
+}
+
+func TestLogin_LocksOutUsernameAndUnlock(t *testing.T) {
+	svc, _, auditRepo := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 3, MaxIPFailures: 20, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour,
+	})
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		_, _, err := svc.LoginWithUser("frontdesk", "wrong", ip)
+		require.ErrorIs(t, err, services.ErrInvalidCredentials)
+	}
+
+	// Even the right password is refused while locked out
+	wait := retryAfter(t, loginErr(svc.LoginWithUser("frontdesk", testPassword, "10.0.0.4")))
+	assert.True(t, wait > 59*time.Minute, wait)
+
+	require.Len(t, auditRepo.entries, 1)
+	lockout := auditRepo.entries[0]
+	assert.Equal(t, models.AuditLockout, lockout.Action)
+	assert.Equal(t, "login-throttle", lockout.ActorUsername)
+	assert.Nil(t, lockout.PatientID)
+	assert.Contains(t, string(lockout.Changes), `"key":"frontdesk"`)
+	assert.Contains(t, string(lockout.Changes), `"scope":"username"`)
+
+	require.NoError(t, svc.UnlockUser(unlockAdmin, &models.User{ID: 1, Username: "frontdesk"}))
+	_, _, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.4")
+	require.NoError(t, err)
+
+	require.Len(t, auditRepo.entries, 2)
+	assert.Equal(t, models.AuditUnlock, auditRepo.entries[1].Action)
+	assert.Equal(t, "admin", auditRepo.entries[1].ActorUsername)
+}
+
+func TestLogin_LocksOutIPAcrossUsernames(t *testing.T) {
+	svc, _, auditRepo := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 5, MaxIPFailures: 3, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour,
+	})
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		_, _, err := svc.LoginWithUser(username, "guess", "10.0.0.9")
+		require.ErrorIs(t, err, services.ErrInvalidCredentials)
+	}
+
+	retryAfter(t, loginErr(svc.LoginWithUser("frontdesk", testPassword, "10.0.0.9")))
+	_, _, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.10")
+	assert.NoError(t, err)
+
+	require.Len(t, auditRepo.entries, 1)
+	assert.Contains(t, string(auditRepo.entries[0].Changes), `"scope":"ip"`)
+}
+
+func TestLogin_SuccessForgetsUsernameFailures(t *testing.T) {
+	svc, _, auditRepo := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 3, MaxIPFailures: 20, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			_, _, err := svc.LoginWithUser("frontdesk", "wrong", "10.0.0.1")
+			require.ErrorIs(t, err, services.ErrInvalidCredentials)
+		}
+		_, _, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	assert.Empty(t, auditRepo.entries, "four failures in all, but never three in a row")
+}
+
+// loginErr returns the error of a LoginWithUser call
+func loginErr(_ *models.User, _ *models.TokenPair, err error) error {
+	return err
+}
@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMedicalHistoryService() *services.MedicalHistoryService {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"},
+		&models.Patient{ID: 11, FirstName: "Omar", LastName: "Haddad"},
+	)
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	return services.NewMedicalHistoryService(&fakeAllergyRepo{}, newFakeProblemRepo(), &fakeMedicationRepo{}, patients, audit)
+}
+
+func TestAllergySummary_CountsActiveAllergies(t *testing.T) {
+	svc := newMedicalHistoryService()
+	for _, a := range []models.Allergy{
+		{PatientID: 10, Substance: "Penicillin", Reaction: "Hives", Severity: models.AllergyModerate},
+		{PatientID: 10, Substance: "Peanuts", Reaction: "Anaphylaxis", Severity: models.AllergySevere},
+		{PatientID: 10, Substance: "Latex", Severity: models.AllergyMild, Status: models.AllergyInactive},
+	} {
+		allergy := a
+		require.NoError(t, svc.AddAllergy(drHouse, &allergy))
+	}
+
+	summary, err := svc.AllergySummary(10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Count)
+	assert.Equal(t, models.AllergySevere, summary.HighestSeverity)
+
+	empty, err := svc.AllergySummary(11)
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Count)
+	assert.Empty(t, empty.HighestSeverity)
+}
+
+func TestAddAllergy_Validation(t *testing.T) {
+	svc := newMedicalHistoryService()
+
+	err := svc.AddAllergy(drHouse, &models.Allergy{PatientID: 10, Substance: "Penicillin", Severity: "fatal"})
+	assert.ErrorIs(t, err, services.ErrInvalidAllergy)
+
+	err = svc.AddAllergy(drHouse, &models.Allergy{PatientID: 99, Substance: "Penicillin", Severity: models.AllergyMild})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAddProblem_RequiresICD10Code(t *testing.T) {
+	svc := newMedicalHistoryService()
+
+	problem := &models.Problem{PatientID: 10, ICD10Code: "e11.9", Description: "Type 2 diabetes", Chronic: true}
+	require.NoError(t, svc.AddProblem(drHouse, problem))
+	assert.Equal(t, "E11.9", problem.ICD10Code)
+	assert.Equal(t, models.ProblemActive, problem.Status)
+	assert.Equal(t, int64(1), problem.RecordedBy)
+
+	err := svc.AddProblem(drHouse, &models.Problem{PatientID: 10, ICD10Code: "diabetes", Description: "Type 2 diabetes"})
+	assert.ErrorIs(t, err, services.ErrInvalidProblem)
+}
+
+func TestUpdateProblem_OtherPatientIsNotFound(t *testing.T) {
+	svc := newMedicalHistoryService()
+	problem := &models.Problem{PatientID: 10, ICD10Code: "J45", Description: "Asthma"}
+	require.NoError(t, svc.AddProblem(drHouse, problem))
+
+	resolved := &models.Problem{ID: problem.ID, PatientID: 10, ICD10Code: "J45", Description: "Asthma", Status: models.ProblemResolved}
+	require.NoError(t, svc.UpdateProblem(drHouse, resolved))
+	assert.Equal(t, int64(1), resolved.RecordedBy)
+
+	err := svc.UpdateProblem(drHouse, &models.Problem{ID: problem.ID, PatientID: 11, ICD10Code: "J45", Description: "Asthma"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, svc.DeleteProblem(drHouse, 11, uint(problem.ID)), sql.ErrNoRows)
+}
+
+func TestAddMedication_EndDateMeansStopped(t *testing.T) {
+	svc := newMedicalHistoryService()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	medication := &models.Medication{PatientID: 10, Drug: "Metformin", StartDate: &start, EndDate: &end}
+	require.NoError(t, svc.AddMedication(drHouse, medication))
+	assert.Equal(t, models.MedicationStopped, medication.Status)
+
+	err := svc.AddMedication(drHouse, &models.Medication{PatientID: 10, Drug: "Metformin", StartDate: &end, EndDate: &start})
+	assert.ErrorIs(t, err, services.ErrInvalidMedication)
+}
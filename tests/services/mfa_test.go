@@ -0,0 +1,263 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// totpCode returns the authenticator code offset time steps from now
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	require.NoError(t, err)
+	return code
+}
+
+// wrongCode returns a six digit code that is not the current one
+func wrongCode(t *testing.T, secret string) string {
+	code := []byte(totpCode(t, secret, 0))
+	code[0] = '0' + (code[0]-'0'+1)%10
+	return string(code)
+}
+
+// enableMFA turns MFA on for the front desk user. The code for the current
+// time step is spent on confirming, so logins use the next step's code.
+func enableMFA(t *testing.T, svc *services.AuthService) (string, []string) {
+	enrollment, err := svc.EnrollMFA(1)
+	require.NoError(t, err)
+	recoveryCodes, err := svc.ConfirmMFA(1, totpCode(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	return enrollment.Secret, recoveryCodes
+}
+
+// startMFALogin logs in with the password and returns the MFA challenge token
+func startMFALogin(t *testing.T, svc *services.AuthService) string {
+	_, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.ErrorIs(t, err, services.ErrMFARequired)
+	assert.Nil(t, tokens, "no session before the second factor")
+
+	var mfaRequired *services.MFARequiredError
+	require.True(t, errors.As(err, &mfaRequired))
+	assert.Equal(t, int64(300), mfaRequired.ExpiresIn)
+	return mfaRequired.Token
+}
+
+func TestEnrollMFA_ConfirmsBeforeEnforcing(t *testing.T) {
+	svc, _ := newAuthService(t)
+
+	enrollment, err := svc.EnrollMFA(1)
+	require.NoError(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+	assert.True(t, strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/Test%20Hospital:frontdesk?"))
+	assert.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+
+	// Until confirmed, the password alone still logs in
+	_, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	_, err = svc.ConfirmMFA(1, wrongCode(t, enrollment.Secret))
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+
+	recoveryCodes, err := svc.ConfirmMFA(1, totpCode(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+	assert.Regexp(t, `^[A-Z2-7]{5}-[A-Z2-7]{5}$`, recoveryCodes[0])
+
+	status, err := svc.GetMFAStatus(1)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, 10, status.RecoveryCodesRemaining)
+
+	_, err = svc.EnrollMFA(1)
+	assert.ErrorIs(t, err, services.ErrMFAAlreadyEnabled)
+}
+
+func TestConfirmMFA_RequiresEnrollment(t *testing.T) {
+	svc, _ := newAuthService(t)
+
+	_, err := svc.ConfirmMFA(1, "123456")
+	assert.ErrorIs(t, err, services.ErrMFANotEnrolled)
+
+	status, err := svc.GetMFAStatus(1)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+}
+
+func TestLogin_WithMFAIssuesTokensOnlyAfterVerification(t *testing.T) {
+	svc, tokenRepo := newAuthService(t)
+	secret, _ := enableMFA(t, svc)
+
+	mfaToken := startMFALogin(t, svc)
+	assert.Empty(t, tokenRepo.refreshTokens)
+
+	_, _, err := svc.VerifyMFA(mfaToken, wrongCode(t, secret), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+
+	user, tokens, err := svc.VerifyMFA(mfaToken, totpCode(t, secret, 1), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+	claims, err := utils.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+
+	// A challenge completes a single login
+	_, _, err = svc.VerifyMFA(mfaToken, totpCode(t, secret, 1), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAChallenge)
+
+	_, err = svc.Login("frontdesk", testPassword)
+	assert.ErrorIs(t, err, services.ErrMFARequired, "the password-only login cannot bypass MFA")
+}
+
+func TestVerifyMFA_RefusesReplayedCode(t *testing.T) {
+	svc, _ := newAuthService(t)
+	secret, _ := enableMFA(t, svc)
+	code := totpCode(t, secret, 1)
+
+	_, _, err := svc.VerifyMFA(startMFALogin(t, svc), code, "10.0.0.1")
+	require.NoError(t, err)
+
+	_, _, err = svc.VerifyMFA(startMFALogin(t, svc), code, "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+
+	// Nor is a code for the step used when confirming accepted
+	_, _, err = svc.VerifyMFA(startMFALogin(t, svc), totpCode(t, secret, 0), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+}
+
+func TestVerifyMFA_RecoveryCodesAreSingleUse(t *testing.T) {
+	svc, _ := newAuthService(t)
+	_, recoveryCodes := enableMFA(t, svc)
+
+	// Case and the dash do not matter
+	typed := strings.ToLower(strings.Replace(recoveryCodes[3], "-", "", 1))
+	_, tokens, err := svc.VerifyMFA(startMFALogin(t, svc), typed, "10.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	status, err := svc.GetMFAStatus(1)
+	require.NoError(t, err)
+	assert.Equal(t, 9, status.RecoveryCodesRemaining)
+
+	_, _, err = svc.VerifyMFA(startMFALogin(t, svc), recoveryCodes[3], "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+}
+
+func TestVerifyMFA_EndsChallengeAfterTooManyAttempts(t *testing.T) {
+	svc, _ := newAuthService(t)
+	secret, _ := enableMFA(t, svc)
+	mfaToken := startMFALogin(t, svc)
+
+	for i := 0; i < 5; i++ {
+		_, _, err := svc.VerifyMFA(mfaToken, wrongCode(t, secret), "10.0.0.1")
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	}
+
+	_, _, err := svc.VerifyMFA(mfaToken, totpCode(t, secret, 1), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAChallenge, "the user has to start over with their password")
+}
+
+// lingeringChallengeRepo never gets around to deleting challenges, like
+// parallel requests that all found the challenge before one deleted it
+type lingeringChallengeRepo struct {
+	*fakeMFARepo
+}
+
+func (r lingeringChallengeRepo) DeleteChallenge(int64) error {
+	return nil
+}
+
+func TestVerifyMFA_LimitsParallelAttempts(t *testing.T) {
+	svc := newAuthServiceWithRepos(t, newFakeTokenRepo(), lingeringChallengeRepo{newFakeMFARepo()})
+	secret, _ := enableMFA(t, svc)
+	mfaToken := startMFALogin(t, svc)
+
+	for i := 0; i < 5; i++ {
+		_, _, err := svc.VerifyMFA(mfaToken, wrongCode(t, secret), "10.0.0.1")
+		assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	}
+
+	_, _, err := svc.VerifyMFA(mfaToken, totpCode(t, secret, 1), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAChallenge, "the sixth attempt is refused")
+}
+
+func TestVerifyMFA_WrongCodesCountAsFailedLogins(t *testing.T) {
+	svc, _, _ := newThrottledAuthService(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 3, MaxIPFailures: 20, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour,
+	})
+	secret, _ := enableMFA(t, svc)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := svc.LoginWithUser("frontdesk", "guess", "10.0.0.1")
+		require.ErrorIs(t, err, services.ErrInvalidCredentials)
+	}
+	// The right password alone does not forget the failures...
+	mfaToken := startMFALogin(t, svc)
+
+	// ...so one wrong code locks the account out
+	_, _, err := svc.VerifyMFA(mfaToken, wrongCode(t, secret), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	_, _, err = svc.VerifyMFA(mfaToken, totpCode(t, secret, 1), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts)
+	_, _, err = svc.LoginWithUser("frontdesk", testPassword, "10.0.0.2")
+	assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts)
+}
+
+func TestRegenerateRecoveryCodes_ReplacesOldCodes(t *testing.T) {
+	svc, _ := newAuthService(t)
+	secret, oldCodes := enableMFA(t, svc)
+
+	_, err := svc.RegenerateRecoveryCodes(1, oldCodes[0])
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode, "an authenticator code is required")
+
+	newCodes, err := svc.RegenerateRecoveryCodes(1, totpCode(t, secret, 1))
+	require.NoError(t, err)
+	assert.Len(t, newCodes, 10)
+
+	_, _, err = svc.VerifyMFA(startMFALogin(t, svc), oldCodes[0], "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+	_, _, err = svc.VerifyMFA(startMFALogin(t, svc), newCodes[0], "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestRegenerateRecoveryCodes_RequiresMFA(t *testing.T) {
+	svc, _ := newAuthService(t)
+
+	_, err := svc.RegenerateRecoveryCodes(1, "123456")
+	assert.ErrorIs(t, err, services.ErrMFANotEnabled)
+}
+
+func TestResetMFA_FallsBackToPassword(t *testing.T) {
+	svc, _, auditRepo := newThrottledAuthService(t, testThrottle)
+	secret, _ := enableMFA(t, svc)
+	_, session, err := svc.VerifyMFA(startMFALogin(t, svc), totpCode(t, secret, 1), "10.0.0.1")
+	require.NoError(t, err)
+	pending := startMFALogin(t, svc)
+
+	require.NoError(t, svc.ResetMFA(resetAdmin, 1))
+
+	_, _, err = svc.Refresh(session.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken, "sessions from before the reset end")
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, models.AuditMFAReset, auditRepo.entries[0].Action)
+	assert.Equal(t, "admin", auditRepo.entries[0].ActorUsername)
+	assert.Contains(t, string(auditRepo.entries[0].Changes), `"username":"frontdesk"`)
+
+	_, tokens, err := svc.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	_, _, err = svc.VerifyMFA(pending, totpCode(t, secret, 1), "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAChallenge, "pending logins die with the reset")
+
+	_, err = svc.EnrollMFA(1)
+	assert.NoError(t, err, "the user can enroll again")
+}
@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+	"hospital-management-system/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const newPassword = "N3w!Passw0rd"
+
+var frontDeskActor = models.Actor{UserID: 1, Username: "frontdesk", Role: "receptionist"}
+var resetAdmin = models.Actor{UserID: 9, Username: "admin", Role: models.RoleAdmin}
+
+type passwordFixture struct {
+	svc      *services.PasswordService
+	auth     *services.AuthService
+	tokens   *fakeTokenRepo
+	audit    *fakeAuditRepo
+	notifier *fakeNotifier
+}
+
+func newPasswordFixture(t *testing.T, throttle services.LoginThrottleSettings, resetTTL time.Duration) *passwordFixture {
+	utils.SetJWTSecret("test-secret-key")
+	hash, err := utils.HashPassword(testPassword)
+	require.NoError(t, err)
+
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "frontdesk", Password: hash, Role: "receptionist"})
+	tokens := newFakeTokenRepo()
+	auditRepo := &fakeAuditRepo{}
+	audit := services.NewAuditService(auditRepo)
+	loginThrottle := services.NewLoginThrottle(newFakeLoginThrottleRepo(), audit, throttle)
+	auth := services.NewAuthService(users, tokens, newFakeMFARepo(), loginThrottle, audit, "test-secret-key", "Test Hospital")
+	notifier := &fakeNotifier{resets: map[string]string{}}
+
+	return &passwordFixture{
+		svc:      services.NewPasswordService(users, newFakePasswordResetRepo(), auth, loginThrottle, audit, notifier, resetTTL),
+		auth:     auth,
+		tokens:   tokens,
+		audit:    auditRepo,
+		notifier: notifier,
+	}
+}
+
+// login logs the front desk user in and returns the session's tokens and
+// the ID of its access token
+func (f *passwordFixture) login(t *testing.T, password string) (*models.TokenPair, string) {
+	_, tokens, err := f.auth.LoginWithUser("frontdesk", password, "10.0.0.1")
+	require.NoError(t, err)
+	claims, err := utils.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	return tokens, claims.Id
+}
+
+func TestChangePassword_RequiresCurrentPassword(t *testing.T) {
+	f := newPasswordFixture(t, testThrottle, time.Hour)
+	laptop, laptopTokenID := f.login(t, testPassword)
+	phone, _ := f.login(t, testPassword)
+
+	err := f.svc.ChangePassword(frontDeskActor, laptopTokenID, "10.0.0.1", "wrong", newPassword)
+	assert.ErrorIs(t, err, services.ErrWrongPassword)
+	err = f.svc.ChangePassword(frontDeskActor, laptopTokenID, "10.0.0.1", testPassword, "weak")
+	assert.ErrorIs(t, err, services.ErrWeakPassword)
+
+	require.NoError(t, f.svc.ChangePassword(frontDeskActor, laptopTokenID, "10.0.0.1", testPassword, newPassword))
+
+	_, _, err = f.auth.LoginWithUser("frontdesk", testPassword, "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+	f.login(t, newPassword)
+
+	// The session that made the change stays, every other one ends
+	_, _, err = f.auth.Refresh(laptop.RefreshToken)
+	assert.NoError(t, err)
+	_, _, err = f.auth.Refresh(phone.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	require.Len(t, f.audit.entries, 1)
+	assert.Equal(t, models.AuditPasswordChange, f.audit.entries[0].Action)
+	assert.Equal(t, "frontdesk", f.audit.entries[0].ActorUsername)
+	assert.Contains(t, string(f.audit.entries[0].Changes), `"via":"current_password"`)
+}
+
+func TestChangePassword_WrongPasswordsCountAsFailedLogins(t *testing.T) {
+	f := newPasswordFixture(t, services.LoginThrottleSettings{
+		MaxUsernameFailures: 2, MaxIPFailures: 20, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour,
+	}, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		err := f.svc.ChangePassword(frontDeskActor, "", "10.0.0.1", "guess", newPassword)
+		require.ErrorIs(t, err, services.ErrWrongPassword)
+	}
+
+	err := f.svc.ChangePassword(frontDeskActor, "", "10.0.0.1", testPassword, newPassword)
+	assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts)
+	_, _, err = f.auth.LoginWithUser("frontdesk", testPassword, "10.0.0.2")
+	assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts, "the account is locked out for logins too")
+}
+
+func TestIssueResetToken_SendsTokenToUser(t *testing.T) {
+	f := newPasswordFixture(t, testThrottle, time.Hour)
+
+	reset, err := f.svc.IssueResetToken(resetAdmin, 1)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), reset.ExpiresAt, time.Minute)
+	assert.Equal(t, int64(9), reset.IssuedBy)
+
+	token := f.notifier.resets["frontdesk"]
+	require.NotEmpty(t, token)
+	assert.Equal(t, utils.HashToken(token), reset.TokenHash, "only the hash is stored")
+
+	require.Len(t, f.audit.entries, 1)
+	assert.Equal(t, models.AuditPasswordReset, f.audit.entries[0].Action)
+	assert.Equal(t, "admin", f.audit.entries[0].ActorUsername)
+	assert.False(t, strings.Contains(string(f.audit.entries[0].Changes), token), "the token stays out of the audit trail")
+
+	_, err = f.svc.IssueResetToken(resetAdmin, 42)
+	assert.Error(t, err, "unknown user")
+}
+
+func TestIssueResetToken_RefusesWithoutNotifier(t *testing.T) {
+	users := newFakeUserRepo(&models.User{ID: 1, Username: "frontdesk", Role: "receptionist"})
+	resets := newFakePasswordResetRepo()
+	auditRepo := &fakeAuditRepo{}
+	svc := services.NewPasswordService(users, resets, nil, nil, services.NewAuditService(auditRepo), nil, time.Hour)
+
+	_, err := svc.IssueResetToken(resetAdmin, 1)
+	assert.ErrorIs(t, err, services.ErrNoNotifier)
+	assert.Empty(t, resets.tokens, "no token is stored that could not be sent")
+	assert.Empty(t, auditRepo.entries)
+}
+
+func TestResetPassword_TokenWorksOnce(t *testing.T) {
+	f := newPasswordFixture(t, testThrottle, time.Hour)
+	session, _ := f.login(t, testPassword)
+
+	_, err := f.svc.IssueResetToken(resetAdmin, 1)
+	require.NoError(t, err)
+	token := f.notifier.resets["frontdesk"]
+
+	assert.ErrorIs(t, f.svc.ResetPassword("made-up", newPassword), services.ErrInvalidResetToken)
+	assert.ErrorIs(t, f.svc.ResetPassword(token, "weak"), services.ErrWeakPassword, "without spending the token")
+
+	require.NoError(t, f.svc.ResetPassword(token, newPassword))
+	assert.ErrorIs(t, f.svc.ResetPassword(token, newPassword), services.ErrInvalidResetToken)
+
+	f.login(t, newPassword)
+	_, _, err = f.auth.Refresh(session.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken, "sessions from before the reset end")
+
+	last := f.audit.entries[len(f.audit.entries)-1]
+	assert.Equal(t, models.AuditPasswordChange, last.Action)
+	assert.Contains(t, string(last.Changes), `"via":"reset_token"`)
+}
+
+func TestResetPassword_NewTokenReplacesOld(t *testing.T) {
+	f := newPasswordFixture(t, testThrottle, time.Hour)
+
+	_, err := f.svc.IssueResetToken(resetAdmin, 1)
+	require.NoError(t, err)
+	first := f.notifier.resets["frontdesk"]
+	_, err = f.svc.IssueResetToken(resetAdmin, 1)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.svc.ResetPassword(first, newPassword), services.ErrInvalidResetToken)
+	assert.NoError(t, f.svc.ResetPassword(f.notifier.resets["frontdesk"], newPassword))
+}
+
+func TestResetPassword_ExpiredToken(t *testing.T) {
+	f := newPasswordFixture(t, testThrottle, -time.Second)
+
+	_, err := f.svc.IssueResetToken(resetAdmin, 1)
+	require.NoError(t, err)
+
+	err = f.svc.ResetPassword(f.notifier.resets["frontdesk"], newPassword)
+	assert.ErrorIs(t, err, services.ErrInvalidResetToken)
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPatients_StreamsEveryMatchInPages(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+	start := time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 1203; i++ {
+		patient := &models.Patient{FirstName: "Pat", LastName: fmt.Sprintf("Name%04d", i), DOB: start.AddDate(0, 0, i), Email: "pat@example.com"}
+		require.NoError(t, svc.CreatePatient(frontDesk, patient, true))
+	}
+	audit.entries = nil
+
+	from, to := start.AddDate(0, 0, 2), start.AddDate(0, 0, 1202)
+	query := models.PatientListQuery{
+		Filter:   models.PatientFilter{DOBFrom: &from, DOBTo: &to},
+		SortBy:   models.PatientSortLastName,
+		SortDesc: true,
+		Limit:    10,
+	}
+	var pages []int
+	var names []string
+	err := svc.ExportPatients(context.Background(), frontDesk, query, func(patients []models.Patient) error {
+		pages = append(pages, len(patients))
+		for _, p := range patients {
+			names = append(names, p.LastName)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{500, 500, 200}, pages, "the limit is ignored")
+	require.Len(t, names, 1200)
+	assert.Equal(t, "Name1201", names[0])
+	assert.Equal(t, "Name0002", names[len(names)-1])
+
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, models.AuditExport, audit.entries[0].Action)
+}
+
+func TestExportPatients_RejectsBadSortBeforeAuditing(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+
+	called := false
+	err := svc.ExportPatients(context.Background(), frontDesk, models.PatientListQuery{SortBy: "email"}, func([]models.Patient) error {
+		called = true
+		return nil
+	})
+
+	assert.ErrorIs(t, err, services.ErrInvalidListQuery)
+	assert.False(t, called)
+	assert.Empty(t, audit.entries)
+}
+
+func TestExportPatients_StopsWhenCancelled(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+	for i := 0; i < 501; i++ {
+		require.NoError(t, svc.CreatePatient(frontDesk, &models.Patient{FirstName: "Pat", LastName: "Doe", Email: "pat@example.com"}, true))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := 0
+	err := svc.ExportPatients(ctx, frontDesk, models.PatientListQuery{}, func([]models.Patient) error {
+		pages++
+		cancel()
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, pages)
+}
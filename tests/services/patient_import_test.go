@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"testing"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const importCSV = "Given,Family,Born,E-mail,Sex\n" +
+	"Ada,Lovelace,1815-12-10,ada@example.com,F\n" +
+	"\n" +
+	"Alan,,1912-06-23,alan@example.com,M\n" +
+	"Grace,Hopper,1906-12-09,not-an-email,F\n" +
+	"Edsger,Dijkstra,11/05/1930,edsger@example.com,M\n" +
+	"\"Katherine\",\"Johnson\",1918-08-26,katherine@example.com,F\n" +
+	"Ada,Lovelace,1815-12-10,ada.l@example.com,F\n"
+
+var importMapping = map[string]string{
+	models.ImportFieldFirstName: "Given",
+	models.ImportFieldLastName:  "Family",
+	models.ImportFieldDOB:       "Born",
+	models.ImportFieldEmail:     "E-mail",
+	models.ImportFieldGender:    "sex",
+}
+
+func TestImportPatients_DryRunReportsEveryRow(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+
+	report, err := svc.ImportPatients(frontDesk, services.ImportFormatCSV, []byte(importCSV),
+		services.PatientImportOptions{Mapping: importMapping, DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 2, report.Valid)
+	assert.Equal(t, 4, report.Invalid)
+	assert.Equal(t, 0, report.Imported)
+
+	byRow := map[int]models.PatientImportRow{}
+	for _, row := range report.Rows {
+		byRow[row.Row] = row
+	}
+	assert.Equal(t, models.ImportRowValid, byRow[2].Status)
+	assert.Contains(t, byRow[4].Errors[0], "first name and last name are required")
+	assert.Equal(t, []string{"email is not a valid email address"}, byRow[5].Errors)
+	assert.Contains(t, byRow[6].Errors[0], "dob")
+	assert.Equal(t, models.ImportRowValid, byRow[7].Status)
+	assert.Equal(t, []string{"same patient as row 2"}, byRow[8].Errors)
+
+	page, err := svc.ListPatients(frontDesk, models.PatientListQuery{}, "")
+	require.NoError(t, err)
+	assert.Empty(t, page.Data, "a dry run saves nothing")
+	for _, e := range audit.entries {
+		assert.NotEqual(t, models.AuditCreate, e.Action)
+	}
+}
+
+func TestImportPatients_InsertsValidRows(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+
+	report, err := svc.ImportPatients(frontDesk, services.ImportFormatCSV, []byte(importCSV),
+		services.PatientImportOptions{Mapping: importMapping})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Imported)
+	var imported []models.PatientImportRow
+	for _, row := range report.Rows {
+		if row.Status == models.ImportRowImported {
+			imported = append(imported, row)
+		}
+	}
+	require.Len(t, imported, 2)
+	assert.Equal(t, 2, imported[0].Row)
+	assert.Equal(t, 7, imported[1].Row)
+
+	patient, err := svc.GetPatientByID(frontDesk, uint(imported[1].PatientID))
+	require.NoError(t, err)
+	assert.Equal(t, "Johnson", patient.LastName)
+	assert.Equal(t, "1918-08-26", patient.DOB.Format("2006-01-02"))
+	assert.Equal(t, "F", patient.Gender)
+	assert.Equal(t, imported[1].MRN, patient.MRN)
+	assert.True(t, services.IsValidMRN("MRN", patient.MRN))
+
+	creates := 0
+	for _, e := range audit.entries {
+		if e.Action == models.AuditCreate {
+			creates++
+		}
+	}
+	assert.Equal(t, 2, creates)
+}
+
+func TestImportPatients_FlagsExistingPatients(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+	existing := &models.Patient{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, existing, false))
+	csv := "first_name,last_name,email\nAda,Lovelace,ada@example.com\n"
+
+	report, err := svc.ImportPatients(frontDesk, services.ImportFormatCSV, []byte(csv), services.PatientImportOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 1)
+	assert.Contains(t, report.Rows[0].Errors[0], "possible duplicate of patient")
+
+	report, err = svc.ImportPatients(frontDesk, services.ImportFormatCSV, []byte(csv), services.PatientImportOptions{AllowDuplicates: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+}
+
+func TestImportPatients_RejectsBadFiles(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		mapping map[string]string
+	}{
+		{"unknown format", "ods", "first_name,last_name,email\n", nil},
+		{"empty file", services.ImportFormatCSV, "", nil},
+		{"missing required column", services.ImportFormatCSV, "first_name,last_name\nAda,Lovelace\n", nil},
+		{"unknown field", services.ImportFormatCSV, "a,b,c\n", map[string]string{"password": "a"}},
+		{"unknown column", services.ImportFormatCSV, "a,b,c\n", map[string]string{models.ImportFieldEmail: "d"}},
+		{"not a workbook", services.ImportFormatXLSX, "first_name,last_name,email\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ImportPatients(frontDesk, tt.format, []byte(tt.data), services.PatientImportOptions{Mapping: tt.mapping})
+			assert.ErrorIs(t, err, services.ErrInvalidImport)
+		})
+	}
+}
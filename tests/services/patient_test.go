@@ -0,0 +1,209 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPatients_PagesThroughWithCursor(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+	for _, name := range []string{"Evans", "Adams", "Cole", "Brown", "Davis"} {
+		require.NoError(t, svc.CreatePatient(frontDesk, &models.Patient{FirstName: "Pat", LastName: name, Email: "pat@example.com"}, false))
+	}
+
+	query := models.PatientListQuery{SortBy: models.PatientSortLastName, Limit: 2}
+	var (
+		names  []string
+		cursor string
+		pages  int
+	)
+	for {
+		page, err := svc.ListPatients(frontDesk, query, cursor)
+		require.NoError(t, err)
+		pages++
+		for _, p := range page.Data {
+			names = append(names, p.LastName)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"Adams", "Brown", "Cole", "Davis", "Evans"}, names)
+}
+
+func TestListPatients_Defaults(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+
+	page, err := svc.ListPatients(frontDesk, models.PatientListQuery{}, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, 50, page.Limit)
+	assert.NotNil(t, page.Data)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListPatients_RejectsBadInput(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+
+	_, err := svc.ListPatients(frontDesk, models.PatientListQuery{SortBy: "password"}, "")
+	assert.ErrorIs(t, err, services.ErrInvalidListQuery)
+
+	_, err = svc.ListPatients(frontDesk, models.PatientListQuery{}, "not-a-cursor")
+	assert.ErrorIs(t, err, services.ErrInvalidListQuery)
+}
+
+func TestRankPatientMatches(t *testing.T) {
+	matches := []models.PatientMatch{
+		{Patient: models.Patient{ID: 1, LastName: "Smithers"}, Similarity: 0.4},
+		{Patient: models.Patient{ID: 2, LastName: "Smyth"}, Similarity: 0.3, Metaphone: true, Soundex: true},
+		{Patient: models.Patient{ID: 3, LastName: "Jones"}, PhoneMatch: true},
+		{Patient: models.Patient{ID: 4, LastName: "Smith"}, Similarity: 0.9, Metaphone: true, Soundex: true},
+	}
+
+	ranked := services.RankPatientMatches(matches)
+
+	ids := []int{}
+	for _, m := range ranked {
+		ids = append(ids, m.Patient.ID)
+	}
+	assert.Equal(t, []int{3, 4, 2, 1}, ids)
+	assert.Equal(t, 1.0, ranked[0].Score)
+	assert.Equal(t, []string{"phone"}, ranked[0].MatchedOn)
+	assert.Equal(t, []string{"name", "metaphone", "soundex"}, ranked[1].MatchedOn)
+	assert.InDelta(t, 0.94, ranked[1].Score, 0.001)
+}
+
+func TestSearchPatients(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+	require.NoError(t, svc.CreatePatient(frontDesk, &models.Patient{FirstName: "Jane", LastName: "Smith", Email: "jane@example.com"}, false))
+
+	matches, err := svc.SearchPatients(frontDesk, "smi", 0)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "Smith", matches[0].Patient.LastName)
+	assert.Equal(t, models.AuditSearch, audit.entries[len(audit.entries)-1].Action)
+
+	matches, err = svc.SearchPatients(frontDesk, "zz", 0)
+	require.NoError(t, err)
+	assert.NotNil(t, matches)
+	assert.Empty(t, matches)
+
+	_, err = svc.SearchPatients(frontDesk, " x ", 0)
+	assert.ErrorIs(t, err, services.ErrInvalidListQuery)
+}
+
+func TestCreatePatient_FlagsDuplicates(t *testing.T) {
+	svc, _ := newAuditedPatientService()
+	dob := time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC)
+	original := &models.Patient{FirstName: "John", LastName: "Smith", DOB: dob, Phone: "5551234567", Email: "john@example.com"}
+	require.NoError(t, svc.CreatePatient(frontDesk, original, false))
+
+	again := &models.Patient{FirstName: "John", LastName: "Smith", DOB: dob, Email: "jsmith@example.com"}
+	err := svc.CreatePatient(frontDesk, again, false)
+
+	var duplicate *services.DuplicatePatientError
+	require.ErrorAs(t, err, &duplicate)
+	require.Len(t, duplicate.Candidates, 1)
+	assert.Equal(t, original.ID, duplicate.Candidates[0].Patient.ID)
+	assert.Equal(t, []string{"name", "dob"}, duplicate.Candidates[0].MatchedOn)
+
+	// Overriding registers the patient anyway
+	assert.NoError(t, svc.CreatePatient(frontDesk, again, true))
+	assert.NotZero(t, again.ID)
+}
+
+func TestScoreDuplicate(t *testing.T) {
+	tests := []struct {
+		name  string
+		match models.PatientMatch
+		dup   bool
+	}{
+		{"same name and DOB", models.PatientMatch{Similarity: 1, DOBMatch: true}, true},
+		{"sound-alike name and DOB", models.PatientMatch{Similarity: 0.2, Metaphone: true, DOBMatch: true}, true},
+		{"same name, phone and email", models.PatientMatch{Similarity: 1, PhoneMatch: true, EmailMatch: true}, true},
+		{"same name only", models.PatientMatch{Similarity: 1}, false},
+		{"changed name, same DOB and contact details", models.PatientMatch{DOBMatch: true, PhoneMatch: true, EmailMatch: true}, true},
+		{"same DOB and phone only", models.PatientMatch{DOBMatch: true, PhoneMatch: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.dup, services.ScoreDuplicate(tt.match) >= 0.6)
+		})
+	}
+}
+
+func TestMergePatients(t *testing.T) {
+	svc, audit := newAuditedPatientService()
+	survivor := &models.Patient{FirstName: "John", LastName: "Smith", Email: "john@example.com"}
+	duplicate := &models.Patient{FirstName: "Jon", LastName: "Smith", Email: "jon@example.com", Phone: "5551234567"}
+	require.NoError(t, svc.CreatePatient(frontDesk, survivor, true))
+	require.NoError(t, svc.CreatePatient(frontDesk, duplicate, true))
+
+	merged, err := svc.MergePatients(frontDesk, uint(survivor.ID), uint(duplicate.ID))
+	require.NoError(t, err)
+
+	// Blank fields are filled in, populated ones are kept
+	assert.Equal(t, "5551234567", merged.Phone)
+	assert.Equal(t, "john@example.com", merged.Email)
+
+	_, err = svc.GetPatientByID(frontDesk, uint(duplicate.ID))
+	assert.Error(t, err)
+
+	history, err := svc.GetMergeHistory(frontDesk, uint(survivor.ID))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, duplicate.ID, history[0].DuplicateID)
+	assert.Contains(t, string(history[0].DuplicateSnapshot), "jon@example.com")
+
+	var mergeEntries int
+	for _, e := range audit.entries {
+		if e.Action == models.AuditMerge {
+			mergeEntries++
+		}
+	}
+	assert.Equal(t, 2, mergeEntries)
+
+	_, err = svc.MergePatients(frontDesk, uint(survivor.ID), uint(survivor.ID))
+	assert.ErrorIs(t, err, services.ErrInvalidMerge)
+}
+
+func TestMergePatients_BothAdmitted(t *testing.T) {
+	repo := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "John", LastName: "Smith"},
+		&models.Patient{ID: 11, FirstName: "Jon", LastName: "Smith"},
+	)
+	repo.admitted = map[int]bool{10: true, 11: true}
+	identifiers := newFakeIdentifierRepo()
+	svc := services.NewPatientService(repo, identifiers, services.NewMRNGenerator(identifiers, "MRN", "01"), services.NewAuditService(&fakeAuditRepo{}))
+
+	_, err := svc.MergePatients(frontDesk, 10, 11)
+	assert.ErrorIs(t, err, services.ErrInvalidMerge)
+	assert.Contains(t, repo.patients, 11)
+
+	repo.admitted[11] = false
+	_, err = svc.MergePatients(frontDesk, 10, 11)
+	require.NoError(t, err)
+}
+
+func TestDeletePatient_KeepsPatientsWithRecords(t *testing.T) {
+	repo := newFakePatientRepo(&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"})
+	repo.withRecords = map[int]bool{10: true}
+	identifiers := newFakeIdentifierRepo()
+	audit := &fakeAuditRepo{}
+	svc := services.NewPatientService(repo, identifiers, services.NewMRNGenerator(identifiers, "MRN", "01"), services.NewAuditService(audit))
+
+	err := svc.DeletePatient(frontDesk, 10)
+	assert.ErrorIs(t, err, services.ErrPatientInUse)
+	assert.Contains(t, repo.patients, 10)
+	assert.Empty(t, audit.entries)
+}
@@ -0,0 +1,163 @@
+package services_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPrescriptionService(allergies ...models.Allergy) (*services.PrescriptionService, *fakePrescriptionRepo) {
+	svc, prescriptions, _ := newPrescriptionServiceWithHistory(allergies...)
+	return svc, prescriptions
+}
+
+func newPrescriptionServiceWithHistory(allergies ...models.Allergy) (*services.PrescriptionService, *fakePrescriptionRepo, *fakeMedicationRepo) {
+	svc, prescriptions, medications, _ := newPrescriptionServiceWithAudit(allergies...)
+	return svc, prescriptions, medications
+}
+
+func newPrescriptionServiceWithAudit(allergies ...models.Allergy) (*services.PrescriptionService, *fakePrescriptionRepo, *fakeMedicationRepo, *fakeAuditRepo) {
+	prescriptions := newFakePrescriptionRepo()
+	medications := &fakeMedicationRepo{}
+	patients := newFakePatientRepo(&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe"})
+	auditRepo := &fakeAuditRepo{}
+	svc := services.NewPrescriptionService(prescriptions, newFakeDrugRepo(), &fakeAllergyRepo{allergies: allergies}, medications, patients, services.NewAuditService(auditRepo))
+	return svc, prescriptions, medications, auditRepo
+}
+
+func prescriptionFor(drug string) *models.Prescription {
+	return &models.Prescription{
+		PatientID:    10,
+		Drug:         drug,
+		Dose:         "75 mg",
+		Route:        models.RouteOral,
+		Frequency:    "once daily",
+		DurationDays: 30,
+	}
+}
+
+func TestCreatePrescription_OnlyDoctors(t *testing.T) {
+	svc, _ := newPrescriptionService()
+
+	_, err := svc.CreatePrescription(frontDesk, prescriptionFor("aspirin"), false)
+	assert.ErrorIs(t, err, services.ErrNotPrescriber)
+
+	prescription := prescriptionFor("aspirin")
+	warnings, err := svc.CreatePrescription(drHouse, prescription, false)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, int64(1), prescription.PrescriberID)
+	assert.Equal(t, models.PrescriptionActive, prescription.Status)
+}
+
+func TestCreatePrescription_Validation(t *testing.T) {
+	svc, _ := newPrescriptionService()
+
+	bad := prescriptionFor("aspirin")
+	bad.Route = "ear"
+	_, err := svc.CreatePrescription(drHouse, bad, false)
+	assert.ErrorIs(t, err, services.ErrInvalidPrescription)
+
+	bad = prescriptionFor("aspirin")
+	bad.DurationDays = 0
+	_, err = svc.CreatePrescription(drHouse, bad, false)
+	assert.ErrorIs(t, err, services.ErrInvalidPrescription)
+
+	missing := prescriptionFor("aspirin")
+	missing.PatientID = 99
+	_, err = svc.CreatePrescription(drHouse, missing, false)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreatePrescription_InteractionNeedsOverride(t *testing.T) {
+	svc, repo := newPrescriptionService()
+	_, err := svc.CreatePrescription(drHouse, prescriptionFor("Warfarin"), false)
+	require.NoError(t, err)
+
+	_, err = svc.CreatePrescription(drHouse, prescriptionFor("Aspirin"), false)
+	var unsafe *services.PrescriptionWarningsError
+	require.True(t, errors.As(err, &unsafe))
+	require.Len(t, unsafe.Warnings, 1)
+	assert.Equal(t, models.WarningInteraction, unsafe.Warnings[0].Type)
+	assert.Equal(t, models.InteractionMajor, unsafe.Warnings[0].Severity)
+	assert.Equal(t, "warfarin", unsafe.Warnings[0].With)
+	assert.Len(t, repo.prescriptions, 1)
+
+	warnings, err := svc.CreatePrescription(drHouse, prescriptionFor("Aspirin"), true)
+	require.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Len(t, repo.prescriptions, 2)
+}
+
+func TestCheckPrescription_AllergyToDrugClass(t *testing.T) {
+	svc, _ := newPrescriptionService(models.Allergy{
+		PatientID: 10, Substance: "Penicillin", Severity: models.AllergySevere, Status: models.AllergyActive,
+	})
+
+	warnings, err := svc.CheckPrescription(drHouse, prescriptionFor("amoxicillin"))
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, models.WarningAllergy, warnings[0].Type)
+	assert.Equal(t, models.AllergySevere, warnings[0].Severity)
+	assert.Equal(t, "Penicillin", warnings[0].With)
+
+	warnings, err = svc.CheckPrescription(drHouse, prescriptionFor("ibuprofen"))
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestCheckPrescription_AuditsAndNeedsPatient(t *testing.T) {
+	svc, _, _, auditRepo := newPrescriptionServiceWithAudit()
+
+	_, err := svc.CheckPrescription(drHouse, prescriptionFor("aspirin"))
+	require.NoError(t, err)
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, models.AuditRead, auditRepo.entries[0].Action)
+	require.NotNil(t, auditRepo.entries[0].PatientID)
+	assert.Equal(t, 10, *auditRepo.entries[0].PatientID)
+
+	missing := prescriptionFor("aspirin")
+	missing.PatientID = 99
+	_, err = svc.CheckPrescription(drHouse, missing)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Len(t, auditRepo.entries, 1)
+}
+
+func TestCheckPrescription_IgnoresDiscontinuedAndInactive(t *testing.T) {
+	svc, _ := newPrescriptionService(models.Allergy{
+		PatientID: 10, Substance: "aspirin", Severity: models.AllergyMild, Status: models.AllergyInactive,
+	})
+	warfarin := prescriptionFor("warfarin")
+	_, err := svc.CreatePrescription(drHouse, warfarin, false)
+	require.NoError(t, err)
+	_, err = svc.DiscontinuePrescription(drHouse, 10, uint(warfarin.ID))
+	require.NoError(t, err)
+
+	warnings, err := svc.CheckPrescription(drHouse, prescriptionFor("aspirin"))
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	_, err = svc.DiscontinuePrescription(drHouse, 10, uint(warfarin.ID))
+	assert.ErrorIs(t, err, services.ErrInvalidPrescription)
+}
+
+func TestCheckPrescription_InteractionWithMedicationHistory(t *testing.T) {
+	svc, _, medications := newPrescriptionServiceWithHistory()
+	require.NoError(t, medications.Create(&models.Medication{PatientID: 10, Drug: "Warfarin", Status: models.MedicationActive}))
+	require.NoError(t, medications.Create(&models.Medication{PatientID: 10, Drug: "Omeprazole", Status: models.MedicationStopped}))
+
+	warnings, err := svc.CheckPrescription(drHouse, prescriptionFor("aspirin"))
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "warfarin", warnings[0].With)
+
+	warnings, err = svc.CheckPrescription(drHouse, prescriptionFor("levothyroxine"))
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
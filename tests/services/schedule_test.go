@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Monday 4 March 2024
+var monday = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+func mondayMorning() []models.WorkingHours {
+	return []models.WorkingHours{
+		{DoctorID: 1, Weekday: int(time.Monday), StartTime: "09:00", EndTime: "10:00", SlotMinutes: 20},
+	}
+}
+
+func TestComputeFreeSlots_ExpandsTemplate(t *testing.T) {
+	slots := services.ComputeFreeSlots(mondayMorning(), nil, nil, monday, monday.AddDate(0, 0, 7), time.UTC)
+
+	assert.Len(t, slots, 3)
+	assert.Equal(t, monday.Add(9*time.Hour), slots[0].StartTime)
+	assert.Equal(t, monday.Add(9*time.Hour+20*time.Minute), slots[0].EndTime)
+	assert.Equal(t, monday.Add(9*time.Hour+40*time.Minute), slots[2].StartTime)
+}
+
+func TestComputeFreeSlots_DropsPartialTrailingSlot(t *testing.T) {
+	hours := []models.WorkingHours{
+		{Weekday: int(time.Monday), StartTime: "09:00", EndTime: "09:50", SlotMinutes: 20},
+	}
+
+	slots := services.ComputeFreeSlots(hours, nil, nil, monday, monday.AddDate(0, 0, 1), time.UTC)
+
+	assert.Len(t, slots, 2)
+}
+
+func TestComputeFreeSlots_SkipsBookedAndExceptions(t *testing.T) {
+	appointments := []models.Appointment{
+		{StartTime: monday.Add(9 * time.Hour), EndTime: monday.Add(9*time.Hour + 20*time.Minute), Status: models.AppointmentScheduled},
+		// Cancelled bookings free the slot again
+		{StartTime: monday.Add(9*time.Hour + 20*time.Minute), EndTime: monday.Add(9*time.Hour + 40*time.Minute), Status: models.AppointmentCancelled},
+	}
+	nextMonday := monday.AddDate(0, 0, 7)
+	exceptions := []models.ScheduleException{
+		{StartTime: nextMonday, EndTime: nextMonday.AddDate(0, 0, 1), Type: models.ExceptionHoliday},
+	}
+
+	slots := services.ComputeFreeSlots(mondayMorning(), exceptions, appointments, monday, monday.AddDate(0, 0, 14), time.UTC)
+
+	assert.Len(t, slots, 2)
+	for _, slot := range slots {
+		assert.Equal(t, monday.Day(), slot.StartTime.Day())
+		assert.NotEqual(t, monday.Add(9*time.Hour), slot.StartTime)
+	}
+}
+
+func TestComputeFreeSlots_RespectsWindow(t *testing.T) {
+	from := monday.Add(9*time.Hour + 10*time.Minute)
+
+	slots := services.ComputeFreeSlots(mondayMorning(), nil, nil, from, monday.Add(10*time.Hour), time.UTC)
+
+	assert.Len(t, slots, 2)
+	assert.Equal(t, monday.Add(9*time.Hour+20*time.Minute), slots[0].StartTime)
+}
+
+func TestComputeFreeSlots_KeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	// Clocks go forward on Sunday 31 March 2024
+	hours := []models.WorkingHours{
+		{Weekday: int(time.Monday), StartTime: "09:00", EndTime: "09:20", SlotMinutes: 20},
+	}
+	from := time.Date(2024, 3, 25, 0, 0, 0, 0, loc)
+
+	slots := services.ComputeFreeSlots(hours, nil, nil, from, from.AddDate(0, 0, 14), loc)
+
+	assert.Len(t, slots, 2)
+	for _, slot := range slots {
+		assert.Equal(t, 9, slot.StartTime.In(loc).Hour())
+	}
+}
@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hospital-management-system/internal/domain/models"
+	"hospital-management-system/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int           { return &n }
+func floatPtr(f float64) *float64 { return &f }
+
+func newVitalSignsService() (*services.VitalSignsService, *fakeVitalSignsRepo) {
+	patients := newFakePatientRepo(
+		&models.Patient{ID: 10, FirstName: "Jane", LastName: "Doe", DOB: time.Date(1980, 5, 1, 0, 0, 0, 0, time.UTC)},
+		&models.Patient{ID: 11, FirstName: "Baby", LastName: "Doe", DOB: time.Now().AddDate(0, -6, 0)},
+	)
+	repo := newFakeVitalSignsRepo()
+	audit := services.NewAuditService(&fakeAuditRepo{})
+	return services.NewVitalSignsService(repo, patients, audit), repo
+}
+
+func TestComputeBMI(t *testing.T) {
+	assert.Equal(t, 22.9, services.ComputeBMI(70, 175))
+	assert.Equal(t, 31.1, services.ComputeBMI(95.5, 175.2))
+}
+
+func TestRecordVitals_ComputesBMIAndFlags(t *testing.T) {
+	svc, _ := newVitalSignsService()
+
+	vitals := &models.VitalSigns{
+		PatientID:   10,
+		SystolicBP:  intPtr(150),
+		DiastolicBP: intPtr(85),
+		HeartRate:   intPtr(72),
+		SpO2:        intPtr(91),
+		WeightKg:    floatPtr(70),
+		HeightCm:    floatPtr(175),
+		BMI:         floatPtr(40),
+	}
+	require.NoError(t, svc.RecordVitals(drHouse, vitals))
+
+	require.NotNil(t, vitals.BMI)
+	assert.Equal(t, 22.9, *vitals.BMI)
+	assert.False(t, vitals.RecordedAt.IsZero())
+	assert.Equal(t, int64(1), vitals.RecordedBy)
+
+	flagged := map[string]string{}
+	for _, f := range vitals.Flags {
+		flagged[f.Vital] = f.Flag
+	}
+	assert.Equal(t, map[string]string{
+		models.VitalSystolicBP: models.VitalHigh,
+		models.VitalSpO2:       models.VitalLow,
+	}, flagged)
+}
+
+func TestRecordVitals_RangesDependOnAge(t *testing.T) {
+	svc, _ := newVitalSignsService()
+
+	adult := &models.VitalSigns{PatientID: 10, HeartRate: intPtr(130)}
+	require.NoError(t, svc.RecordVitals(drHouse, adult))
+	require.Len(t, adult.Flags, 1)
+	assert.Equal(t, models.VitalHigh, adult.Flags[0].Flag)
+	assert.Equal(t, 100.0, adult.Flags[0].High)
+
+	infant := &models.VitalSigns{PatientID: 11, HeartRate: intPtr(130)}
+	require.NoError(t, svc.RecordVitals(drHouse, infant))
+	assert.Empty(t, infant.Flags)
+}
+
+func TestRecordVitals_Validation(t *testing.T) {
+	svc, _ := newVitalSignsService()
+
+	cases := map[string]*models.VitalSigns{
+		"no measurements":            {PatientID: 10},
+		"diastolic above systolic":   {PatientID: 10, SystolicBP: intPtr(80), DiastolicBP: intPtr(90)},
+		"systolic without diastolic": {PatientID: 10, SystolicBP: intPtr(120)},
+		"temperature in fahrenheit":  {PatientID: 10, Temperature: floatPtr(98.6)},
+		"spo2 over 100":              {PatientID: 10, SpO2: intPtr(101)},
+		"implausible bmi":            {PatientID: 10, WeightKg: floatPtr(500), HeightCm: floatPtr(20)},
+		"recorded in the future":     {PatientID: 10, HeartRate: intPtr(70), RecordedAt: time.Now().Add(time.Hour)},
+	}
+	for name, vitals := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, svc.RecordVitals(drHouse, vitals), services.ErrInvalidVitals)
+		})
+	}
+}
+
+func TestGetVitals_TimeRange(t *testing.T) {
+	svc, _ := newVitalSignsService()
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 8, 0, 0, 0, time.UTC) }
+	for d := 1; d <= 4; d++ {
+		require.NoError(t, svc.RecordVitals(drHouse, &models.VitalSigns{PatientID: 10, RecordedAt: day(d), HeartRate: intPtr(50 + 20*d)}))
+	}
+
+	from, to := day(2), day(4)
+	readings, err := svc.GetVitals(drHouse, 10, &from, &to)
+	require.NoError(t, err)
+	require.Len(t, readings, 2)
+	assert.Equal(t, 90, *readings[0].HeartRate)
+	assert.Empty(t, readings[0].Flags)
+	assert.Equal(t, 110, *readings[1].HeartRate)
+	require.Len(t, readings[1].Flags, 1)
+
+	all, err := svc.GetVitals(drHouse, 10, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	_, err = svc.GetVitals(drHouse, 10, &to, &from)
+	assert.ErrorIs(t, err, services.ErrInvalidVitals)
+}
+
+func TestUpdateRange(t *testing.T) {
+	svc, repo := newVitalSignsService()
+
+	err := svc.UpdateRange(&models.VitalRange{ID: 2, Vital: models.VitalSpO2, MinAge: 18, Low: 50, High: 110})
+	require.NoError(t, err)
+	assert.Equal(t, models.VitalHeartRate, repo.ranges[1].Vital)
+	assert.Equal(t, 110.0, repo.ranges[1].High)
+
+	vitals := &models.VitalSigns{PatientID: 10, HeartRate: intPtr(105)}
+	require.NoError(t, svc.RecordVitals(drHouse, vitals))
+	assert.Empty(t, vitals.Flags)
+
+	err = svc.UpdateRange(&models.VitalRange{ID: 2, MinAge: 18, Low: 100, High: 60})
+	assert.ErrorIs(t, err, services.ErrInvalidVitalRange)
+	err = svc.UpdateRange(&models.VitalRange{ID: 2, MinAge: 18, MaxAge: intPtr(12), Low: 60, High: 100})
+	assert.ErrorIs(t, err, services.ErrInvalidVitalRange)
+}
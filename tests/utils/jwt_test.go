@@ -120,7 +120,37 @@ func TestTokenExpiration(t *testing.T) {
 	claims, err := utils.ValidateToken(token)
 	assert.NoError(t, err)
 
-	// Token should expire in 24 hours (86400 seconds)
-	expectedExpiry := time.Now().Add(24 * time.Hour).Unix()
+	// Access tokens are short-lived
+	expectedExpiry := time.Now().Add(utils.AccessTokenTTL).Unix()
 	assert.InDelta(t, expectedExpiry, claims.ExpiresAt, 60) // Allow 60 second difference
 }
+
+func TestGenerateToken_UniqueTokenIDs(t *testing.T) {
+	utils.SetJWTSecret("test-secret-key")
+
+	token1, err := utils.GenerateToken("testuser", "receptionist")
+	assert.NoError(t, err)
+	token2, err := utils.GenerateToken("testuser", "receptionist")
+	assert.NoError(t, err)
+
+	claims1, err := utils.ValidateToken(token1)
+	assert.NoError(t, err)
+	claims2, err := utils.ValidateToken(token2)
+	assert.NoError(t, err)
+
+	// Every token needs its own ID so it can be revoked on its own
+	assert.NotEmpty(t, claims1.Id)
+	assert.NotEqual(t, claims1.Id, claims2.Id)
+}
+
+func TestValidateToken_WrongSecret(t *testing.T) {
+	utils.SetJWTSecret("test-secret-key")
+	token, err := utils.GenerateToken("testuser", "receptionist")
+	assert.NoError(t, err)
+
+	utils.SetJWTSecret("another-secret")
+	claims, err := utils.ValidateToken(token)
+
+	assert.Error(t, err)
+	assert.Nil(t, claims)
+}
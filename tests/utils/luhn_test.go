@@ -0,0 +1,22 @@
+package utils_test
+
+import (
+	"testing"
+
+	"hospital-management-system/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	assert.Equal(t, 3, utils.LuhnCheckDigit("7992739871"))
+	assert.Equal(t, 3, utils.LuhnCheckDigit("7992-7398-71"))
+	assert.Equal(t, 0, utils.LuhnCheckDigit("0"))
+}
+
+func TestIsValidLuhn(t *testing.T) {
+	assert.True(t, utils.IsValidLuhn("79927398713"))
+	assert.False(t, utils.IsValidLuhn("79927398710"))
+	assert.False(t, utils.IsValidLuhn("7"))
+	assert.False(t, utils.IsValidLuhn("7992739871X"))
+}
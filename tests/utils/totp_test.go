@@ -0,0 +1,73 @@
+package utils_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"hospital-management-system/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; ours are their last six digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		code, err := utils.TOTPCode(rfc6238Secret, utils.TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+
+	_, err := utils.TOTPCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP_AllowsOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := utils.TOTPStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, err := utils.TOTPCode(rfc6238Secret, step+offset)
+		require.NoError(t, err)
+		matched, ok := utils.ValidateTOTP(rfc6238Secret, code, now)
+		assert.True(t, ok, offset)
+		assert.Equal(t, step+offset, matched)
+	}
+
+	stale, err := utils.TOTPCode(rfc6238Secret, step-2)
+	require.NoError(t, err)
+	_, ok := utils.ValidateTOTP(rfc6238Secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = utils.ValidateTOTP(rfc6238Secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32, "160 bits, base32 encoded")
+
+	other, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := utils.TOTPProvisioningURI("General Hospital", "dr.house", "JBSWY3DPEHPK3PXP")
+
+	assert.Equal(t, "otpauth://totp/General%20Hospital:dr.house?algorithm=SHA1&digits=6"+
+		"&issuer=General+Hospital&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
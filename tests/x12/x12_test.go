@@ -0,0 +1,194 @@
+package x12_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hospital-management-system/pkg/x12"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample835 uses non-default element (|) and component (>) delimiters, as
+// some payers do
+const sample835 = "ISA|00|          |00|          |ZZ|PAYERSENDER    |ZZ|HMS            |240315|0930|^|00501|000000077|0|P|>~\n" +
+	"GS|HP|PAYERSENDER|HMS|20240315|0930|77|X|005010X221A1~\n" +
+	"ST|835|0001~\n" +
+	"BPR|I|150.5|C|ACH|CCP|||||||||||20240315~\n" +
+	"TRN|1|EFT12345|1512345678~\n" +
+	"N1|PR|ACME HEALTH~\n" +
+	"CLP|CLM000000001|1|200|150.5|49.5|12|PCN-1~\n" +
+	"CLP|CLM000000002|4|80|0|0|12|PCN-2~\n" +
+	"SE|7|0001~\n" +
+	"GE|1|77~\n" +
+	"IEA|1|000000077~\n"
+
+func TestParse_UsesDelimitersFromISA(t *testing.T) {
+	segments, err := x12.Parse([]byte(sample835))
+	require.NoError(t, err)
+	require.Len(t, segments, 11)
+
+	assert.Equal(t, "ISA", segments[0].ID)
+	assert.Equal(t, "PAYERSENDER    ", segments[0].Element(6))
+	assert.Equal(t, "CLP", segments[6].ID)
+	assert.Equal(t, "CLM000000001", segments[6].Element(1))
+	assert.Equal(t, "", segments[6].Element(20), "missing elements are empty")
+	assert.Equal(t, "IEA", segments[10].ID)
+}
+
+func TestParse_RejectsNonInterchanges(t *testing.T) {
+	_, err := x12.Parse([]byte("MSH|^~\\&|REGADT"))
+	assert.ErrorIs(t, err, x12.ErrInvalidInterchange)
+
+	truncated := strings.Split(sample835, "GE|")[0]
+	_, err = x12.Parse([]byte(truncated))
+	assert.ErrorIs(t, err, x12.ErrInvalidInterchange, "must end with IEA")
+}
+
+func TestWrapAndEncode_RoundTrip(t *testing.T) {
+	ic := x12.Interchange{SenderID: "HMS", ReceiverID: "CLEARINGHOUSE", ControlNumber: 42,
+		Time: time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)}
+	set := []x12.Segment{
+		x12.NewSegment("ST", "837", "0001", x12.Version837P),
+		x12.NewSegment("REF", "EI", "123456789", "", ""),
+		x12.NewSegment("SE", "3", "0001"),
+	}
+
+	data := x12.Encode(ic.Wrap("HC", x12.Version837P, set))
+	isa := strings.SplitN(string(data), "\n", 2)[0]
+	assert.Len(t, isa, 106, "the ISA segment is fixed width")
+	assert.Contains(t, string(data), "REF*EI*123456789~\n", "trailing empty elements are dropped")
+
+	segments, err := x12.Parse(data)
+	require.NoError(t, err)
+	require.Len(t, segments, 7)
+	assert.Equal(t, "000000042", segments[0].Element(13))
+	assert.Equal(t, "T", segments[0].Element(15), "test data unless production")
+	assert.Equal(t, "GS", segments[1].ID)
+	assert.Equal(t, "HC", segments[1].Element(1))
+	assert.Equal(t, []string{"GE", "1", "42"}, append([]string{segments[5].ID}, segments[5].Elements...))
+	assert.Equal(t, "000000042", segments[6].Element(2))
+}
+
+func TestAmounts(t *testing.T) {
+	for cents, want := range map[int64]string{12000: "120", 12340: "123.4", 12345: "123.45", 5: "0.05", 0: "0", -250: "-2.5"} {
+		assert.Equal(t, want, x12.FormatAmount(cents))
+		back, err := x12.ParseAmount(want)
+		require.NoError(t, err)
+		assert.Equal(t, cents, back)
+	}
+
+	cents, err := x12.ParseAmount(".5")
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), cents)
+
+	for _, bad := range []string{"", "1.234", "abc", "1.-5", "--1"} {
+		_, err := x12.ParseAmount(bad)
+		assert.ErrorIs(t, err, x12.ErrInvalidInterchange, bad)
+	}
+}
+
+func TestBuild837P(t *testing.T) {
+	dob := time.Date(1980, 5, 1, 0, 0, 0, 0, time.UTC)
+	service := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	claims := []x12.ProfessionalClaim{
+		{
+			ControlNumber: "CLM000000001",
+			Payer:         x12.Party{Name: "Acme Health", ID: "ACME1"},
+			PayerSequence: "P",
+			MemberID:      "M123",
+			Subscriber:    x12.Person{FirstName: "Jane", LastName: "Doe", DOB: dob, Gender: "female"},
+			Diagnoses:     []string{"E11.9", "I10"},
+			Lines: []x12.ServiceLine{
+				{ProcedureCode: "99213", ChargeCents: 15000, Units: 1, Date: service},
+				{ProcedureCode: "85025", ChargeCents: 5050, Units: 2, Date: service},
+			},
+		},
+		{
+			ControlNumber: "CLM000000002",
+			Payer:         x12.Party{Name: "Acme Health", ID: "ACME1"},
+			PayerSequence: "S",
+			MemberID:      "M999",
+			Subscriber:    x12.Person{FirstName: "John", LastName: "Roe", DOB: dob},
+			Patient:       &x12.Person{FirstName: "Kid", LastName: "Roe", DOB: service, Gender: "male"},
+			Relationship:  "19",
+			Diagnoses:     []string{"J06.9"},
+			Lines:         []x12.ServiceLine{{ProcedureCode: "99213", ChargeCents: 15000, Units: 1, Date: service}},
+		},
+	}
+	provider := x12.BillingProvider{Party: x12.Party{Name: "General*Hospital", ID: "1234567893"}, TaxID: "123456789"}
+
+	set := x12.Build837P(1, x12.Party{Name: "HMS", ID: "HMS"}, x12.Party{Name: "CH", ID: "CH"}, provider, claims, service)
+	require.NotEmpty(t, set)
+	assert.Equal(t, "ST", set[0].ID)
+	se := set[len(set)-1]
+	assert.Equal(t, "SE", se.ID)
+	assert.Equal(t, "0001", se.Element(2))
+	assert.Equal(t, strconv.Itoa(len(set)), se.Element(1), "SE-01 counts ST through SE")
+
+	byID := map[string][]x12.Segment{}
+	for _, s := range set {
+		byID[s.ID] = append(byID[s.ID], s)
+	}
+	assert.Equal(t, "GENERAL HOSPITAL", byID["NM1"][2].Element(3), "delimiters are removed from text")
+
+	clm := byID["CLM"]
+	require.Len(t, clm, 2)
+	assert.Equal(t, "CLM000000001", clm[0].Element(1))
+	assert.Equal(t, "200.5", clm[0].Element(2), "the claim total is the sum of its lines")
+	assert.Equal(t, "11:B:1", clm[0].Element(5))
+	assert.Equal(t, []string{"ABK:E119", "ABF:I10"}, byID["HI"][0].Elements)
+
+	hl := byID["HL"]
+	require.Len(t, hl, 4)
+	assert.Equal(t, []string{"2", "1", "22", "0"}, hl[1].Elements, "the subscriber is the patient")
+	assert.Equal(t, []string{"3", "1", "22", "1"}, hl[2].Elements, "the dependent patient has its own level")
+	assert.Equal(t, []string{"4", "3", "23", "0"}, hl[3].Elements)
+	assert.Equal(t, "19", byID["PAT"][0].Element(1))
+
+	assert.Equal(t, "18", byID["SBR"][0].Element(2), "self")
+	assert.Equal(t, "", byID["SBR"][1].Element(2))
+	assert.Len(t, byID["SV1"], 3)
+	assert.Equal(t, "HC:85025", byID["SV1"][1].Element(1))
+	assert.Equal(t, "50.5", byID["SV1"][1].Element(2))
+	assert.Equal(t, "2", byID["SV1"][1].Element(4))
+}
+
+func TestParseRemittance(t *testing.T) {
+	segments, err := x12.Parse([]byte(sample835))
+	require.NoError(t, err)
+
+	remit, err := x12.ParseRemittance(segments)
+	require.NoError(t, err)
+	assert.Equal(t, "ACME HEALTH", remit.PayerName)
+	assert.Equal(t, "EFT12345", remit.TraceNumber)
+	assert.Equal(t, int64(15050), remit.PaidCents)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), remit.PaymentDate)
+
+	require.Len(t, remit.Claims, 2)
+	assert.Equal(t, x12.ClaimPayment{
+		ControlNumber:              "CLM000000001",
+		Status:                     x12.ClaimProcessedPrimary,
+		ChargeCents:                20000,
+		PaidCents:                  15050,
+		PatientResponsibilityCents: 4950,
+		PayerClaimNumber:           "PCN-1",
+	}, remit.Claims[0])
+	assert.Equal(t, x12.ClaimDenied, remit.Claims[1].Status)
+}
+
+func TestParseRemittance_RejectsOtherTransactions(t *testing.T) {
+	ic := x12.Interchange{SenderID: "HMS", ReceiverID: "CH", ControlNumber: 1, Time: time.Now()}
+	data := x12.Encode(ic.Wrap("HC", x12.Version837P, []x12.Segment{
+		x12.NewSegment("ST", "837", "0001", x12.Version837P),
+		x12.NewSegment("SE", "2", "0001"),
+	}))
+	segments, err := x12.Parse(data)
+	require.NoError(t, err)
+
+	_, err = x12.ParseRemittance(segments)
+	assert.ErrorIs(t, err, x12.ErrInvalidInterchange)
+}
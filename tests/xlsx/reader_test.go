@@ -0,0 +1,81 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"hospital-management-system/pkg/xlsx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// workbook zips the given parts into an xlsx file
+func workbook(t *testing.T, parts map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+const workbookXML = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Patients" sheetId="1" r:id="rId3"/><sheet name="Other" sheetId="2" r:id="rId1"/></sheets>
+</workbook>`
+
+const relsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+</Relationships>`
+
+const sharedStringsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>first_name</t></si><si><t>dob</t></si><si><r><t>Ada </t></r><r><t>Mary</t></r></si>
+</sst>`
+
+func TestReadFirstSheet(t *testing.T) {
+	data := workbook(t, map[string]string{
+		"xl/workbook.xml":            workbookXML,
+		"xl/_rels/workbook.xml.rels": relsXML,
+		"xl/sharedStrings.xml":       sharedStringsXML,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>wrong sheet</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" t="b"><v>1</v></c><c r="C3"><v>30431</v></c><c r="D3" t="inlineStr"><is><t>inline</t></is></c></row>
+</sheetData></worksheet>`,
+	})
+
+	rows, err := xlsx.ReadFirstSheet(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"first_name", "", "dob"},
+		nil,
+		{"Ada Mary", "TRUE", "30431", "inline"},
+	}, rows)
+}
+
+func TestReadFirstSheet_Invalid(t *testing.T) {
+	_, err := xlsx.ReadFirstSheet([]byte("first_name,last_name\n"))
+	assert.ErrorIs(t, err, xlsx.ErrInvalidWorkbook)
+
+	_, err = xlsx.ReadFirstSheet(workbook(t, map[string]string{
+		"xl/workbook.xml":          workbookXML,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>4</v></c></row></sheetData></worksheet>`,
+	}))
+	assert.ErrorIs(t, err, xlsx.ErrInvalidWorkbook, "shared string out of range")
+
+	_, err = xlsx.ReadFirstSheet(workbook(t, map[string]string{
+		"xl/workbook.xml":          workbookXML,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="B1"><v>1</v></c><c r="A1"><v>2</v></c></row></sheetData></worksheet>`,
+	}))
+	assert.ErrorIs(t, err, xlsx.ErrInvalidWorkbook, "cells out of order")
+}
@@ -41,20 +41,30 @@ class AuthManager {
         // Show loading state
         this.setLoadingState(loginBtn, loginText, loginSpinner, true);
         
-        const formData = {
+        // Once the password was accepted, the second step sends the
+        // authentication code for the pending MFA challenge instead
+        let url = '/api/auth/login';
+        let formData = {
             username: document.getElementById('username').value.trim(),
             password: document.getElementById('password').value
         };
+        if (this.mfaToken) {
+            url = '/api/auth/mfa/verify';
+            formData = {
+                mfa_token: this.mfaToken,
+                code: document.getElementById('mfaCode').value.trim()
+            };
+        }
 
         // Basic validation
-        if (!formData.username || !formData.password) {
+        if (Object.values(formData).some((value) => !value)) {
             this.showAlert('Please fill in all fields', 'danger');
             this.setLoadingState(loginBtn, loginText, loginSpinner, false);
             return;
         }
 
         try {
-            const response = await fetch('/api/auth/login', {
+            const response = await fetch(url, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -64,8 +74,14 @@ class AuthManager {
 
             const data = await response.json();
 
-            if (data.success && data.token) {
+            if (data.success && data.mfa_required) {
+                this.mfaToken = data.mfa_token;
+                document.getElementById('mfaGroup').classList.remove('d-none');
+                document.getElementById('mfaCode').focus();
+                this.showAlert(data.message, 'info');
+            } else if (data.success && data.token) {
                 localStorage.setItem('token', data.token);
+                localStorage.setItem('refresh_token', data.refresh_token);
                 localStorage.setItem('user', JSON.stringify(data.user));
                 
                 this.showAlert('Login successful! Redirecting...', 'success');
@@ -74,6 +90,12 @@ class AuthManager {
                     window.location.href = '/api/dashboard';
                 }, 1000);
             } else {
+                if (this.mfaToken && response.status === 401 && data.error !== 'invalid authentication code') {
+                    // The challenge expired or ran out of attempts; start over
+                    this.mfaToken = null;
+                    document.getElementById('mfaCode').value = '';
+                    document.getElementById('mfaGroup').classList.add('d-none');
+                }
                 this.showAlert(data.error || 'Login failed. Please check your credentials.', 'danger');
             }
         } catch (error) {
@@ -1,6 +1,7 @@
 class DashboardManager {
     constructor() {
         this.patients = [];
+        this.nextCursor = null;
         this.currentUser = null;
         this.isEditing = false;
         
@@ -39,11 +40,20 @@ class DashboardManager {
                 }
             });
         }
+        const vitalsModal = document.getElementById('vitalsModal');
+        if (vitalsModal) {
+            vitalsModal.addEventListener('click', (e) => {
+                if (e.target === vitalsModal) {
+                    this.closeVitalsModal();
+                }
+            });
+        }
 
         // Keyboard shortcuts
         document.addEventListener('keydown', (e) => {
             if (e.key === 'Escape') {
                 this.closePatientModal();
+                this.closeVitalsModal();
             }
         });
     }
@@ -64,35 +74,43 @@ class DashboardManager {
         }
     }
 
-    async loadPatients() {
+    // loadPatients fetches the first page of patients, or the next page
+    // when append is true
+    async loadPatients(append = false) {
         const loading = document.getElementById('loading');
         const patientList = document.getElementById('patient-list');
         const noPatients = document.getElementById('no-patients');
+        const loadMore = document.getElementById('load-more');
 
         loading.classList.remove('d-none');
-        patientList.innerHTML = '';
+        if (!append) {
+            patientList.innerHTML = '';
+            this.patients = [];
+            this.nextCursor = null;
+        }
         noPatients.classList.add('d-none');
 
         try {
-            const token = localStorage.getItem('token');
-            const response = await fetch('/api/patients', {
-                headers: {
-                    'Authorization': `Bearer ${token}`
-                }
-            });
+            const params = new URLSearchParams({ limit: '50' });
+            if (append && this.nextCursor) {
+                params.set('cursor', this.nextCursor);
+            }
+            const response = await this.authFetch(`/api/patients?${params}`);
 
             if (!response.ok) {
                 throw new Error('Failed to fetch patients');
             }
 
-            const patients = await response.json();
-            this.patients = patients || [];
+            const page = await response.json();
+            this.patients = this.patients.concat(page.data || []);
+            this.nextCursor = page.next_cursor || null;
 
             if (this.patients.length === 0) {
                 noPatients.classList.remove('d-none');
             } else {
                 this.renderPatients();
             }
+            loadMore.classList.toggle('d-none', !this.nextCursor);
         } catch (error) {
             console.error('Error loading patients:', error);
             this.showAlert('Failed to load patients. Please try again.', 'danger');
@@ -109,6 +127,7 @@ class DashboardManager {
             const row = document.createElement('tr');
             row.innerHTML = `
                 <td>${patient.id}</td>
+                <td>${this.escapeHtml(patient.mrn || 'N/A')}</td>
                 <td>${this.escapeHtml(patient.first_name)} ${this.escapeHtml(patient.last_name)}</td>
                 <td>${this.formatDate(patient.date_of_birth)}</td>
                 <td>${this.calculateAge(patient.date_of_birth)}</td>
@@ -120,6 +139,11 @@ class DashboardManager {
                         <button class="btn btn-primary btn-sm" onclick="dashboard.editPatient(${patient.id})">
                             ✏️ Edit
                         </button>
+                        ${['doctor', 'admin'].includes(this.currentUser?.role) ? `
+                            <button class="btn btn-primary btn-sm" onclick="dashboard.showVitals(${patient.id})">
+                                📈 Vitals
+                            </button>
+                        ` : ''}
                         ${this.currentUser?.role === 'receptionist' ? `
                             <button class="btn btn-danger btn-sm" onclick="dashboard.deletePatient(${patient.id})">
                                 🗑️ Delete
@@ -165,18 +189,77 @@ class DashboardManager {
         document.getElementById('patientModal').classList.add('show');
     }
 
+    // showVitals lists a patient's vital signs, highlighting the values
+    // flagged as outside the normal range for their age
+    async showVitals(id) {
+        const patient = this.patients.find(p => p.id === id);
+        const vitalsList = document.getElementById('vitals-list');
+        const noVitals = document.getElementById('no-vitals');
+
+        try {
+            const response = await this.authFetch(`/api/patients/${id}/vitals`);
+            if (!response.ok) {
+                throw new Error('Failed to fetch vital signs');
+            }
+            const readings = await response.json();
+
+            document.getElementById('vitalsTitle').textContent = patient
+                ? `Vital Signs - ${patient.first_name} ${patient.last_name}`
+                : 'Vital Signs';
+            vitalsList.innerHTML = '';
+            noVitals.classList.toggle('d-none', readings.length > 0);
+
+            // Newest first
+            readings.reverse().forEach(reading => {
+                const flags = {};
+                (reading.flags || []).forEach(f => { flags[f.vital] = f; });
+                const cell = (vital, value) => {
+                    if (value === undefined || value === null) {
+                        return '<td>-</td>';
+                    }
+                    const flag = flags[vital];
+                    if (!flag) {
+                        return `<td>${value}</td>`;
+                    }
+                    const arrow = flag.flag === 'high' ? '↑' : '↓';
+                    return `<td class="vital-abnormal" title="Normal ${flag.low}-${flag.high}">${value} ${arrow}</td>`;
+                };
+                const bpFlag = flags.systolic_bp || flags.diastolic_bp;
+
+                const row = document.createElement('tr');
+                row.innerHTML = `
+                    <td>${new Date(reading.recorded_at).toLocaleString()}</td>
+                    ${reading.systolic_bp ? `<td class="${bpFlag ? 'vital-abnormal' : ''}">${reading.systolic_bp}/${reading.diastolic_bp}</td>` : '<td>-</td>'}
+                    ${cell('heart_rate', reading.heart_rate)}
+                    ${cell('temperature', reading.temperature)}
+                    ${cell('spo2', reading.spo2)}
+                    ${cell('respiratory_rate', reading.respiratory_rate)}
+                    ${cell('weight_kg', reading.weight_kg)}
+                    ${cell('height_cm', reading.height_cm)}
+                    ${cell('bmi', reading.bmi)}
+                `;
+                vitalsList.appendChild(row);
+            });
+
+            document.getElementById('vitalsModal').classList.add('show');
+        } catch (error) {
+            console.error('Error loading vital signs:', error);
+            this.showAlert('Failed to load vital signs. Please try again.', 'danger');
+        }
+    }
+
+    closeVitalsModal() {
+        document.getElementById('vitalsModal').classList.remove('show');
+    }
+
     async deletePatient(id) {
         if (!confirm('Are you sure you want to delete this patient? This action cannot be undone.')) {
             return;
         }
 
         try {
-            const token = localStorage.getItem('token');
-            const response = await fetch(`/api/patients/${id}`, {
-                method: 'DELETE',
-                headers: {
-                    'Authorization': `Bearer ${token}`
-                }
+            const response = await this.authFetch(`/api/patients/${id}`, {
+                method: 'DELETE'
             });
 
             if (response.ok) {
@@ -217,21 +300,32 @@ class DashboardManager {
         }
 
         try {
-            const token = localStorage.getItem('token');
             const patientId = document.getElementById('patientId').value;
             
             const url = this.isEditing ? `/api/patients/${patientId}` : '/api/patients';
             const method = this.isEditing ? 'PUT' : 'POST';
-
-            const response = await fetch(url, {
+            const send = (target) => this.authFetch(target, {
                 method: method,
                 headers: {
-                    'Content-Type': 'application/json',
-                    'Authorization': `Bearer ${token}`
+                    'Content-Type': 'application/json'
                 },
                 body: JSON.stringify(formData)
             });
 
+            let response = await send(url);
+
+            // Possible duplicate: let the user confirm it is a different person
+            if (response.status === 409) {
+                const conflict = await response.json();
+                const names = (conflict.candidates || [])
+                    .map(c => `#${c.patient.id} ${c.patient.first_name} ${c.patient.last_name}`)
+                    .join('\n');
+                if (!confirm(`This patient may already exist:\n${names}\n\nCreate a new record anyway?`)) {
+                    return;
+                }
+                response = await send(`${url}?allow_duplicate=true`);
+            }
+
             if (response.ok) {
                 const message = this.isEditing ? 'Patient updated successfully' : 'Patient added successfully';
                 this.showAlert(message, 'success');
@@ -285,6 +379,54 @@ class DashboardManager {
         this.isEditing = false;
     }
 
+    // authFetch sends an authenticated request, refreshing the access token
+    // once if it has expired
+    async authFetch(url, options = {}) {
+        const send = () => fetch(url, {
+            ...options,
+            headers: {
+                ...(options.headers || {}),
+                'Authorization': `Bearer ${localStorage.getItem('token')}`
+            }
+        });
+
+        let response = await send();
+        if (response.status === 401 && await this.refreshToken()) {
+            response = await send();
+        }
+        if (response.status === 401) {
+            clearSession();
+            window.location.href = '/login';
+        }
+        return response;
+    }
+
+    async refreshToken() {
+        const refreshToken = localStorage.getItem('refresh_token');
+        if (!refreshToken) {
+            return false;
+        }
+
+        try {
+            const response = await fetch('/api/auth/refresh', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken })
+            });
+            if (!response.ok) {
+                return false;
+            }
+
+            const data = await response.json();
+            localStorage.setItem('token', data.token);
+            localStorage.setItem('refresh_token', data.refresh_token);
+            return true;
+        } catch (error) {
+            console.error('Error refreshing token:', error);
+            return false;
+        }
+    }
+
     // Utility functions
     calculateAge(dateOfBirth) {
         const today = new Date();
@@ -362,11 +504,28 @@ class DashboardManager {
 // Global functions for onclick handlers
 window.showAddPatientModal = () => dashboard.showAddPatientModal();
 window.closePatientModal = () => dashboard.closePatientModal();
+window.closeVitalsModal = () => dashboard.closeVitalsModal();
 
-// Logout function
-window.logout = () => {
+function clearSession() {
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('user');
+}
+
+// Logout function
+window.logout = async () => {
+    try {
+        // Revoke the session server-side so the tokens cannot be reused
+        await fetch('/api/logout', {
+            method: 'POST',
+            headers: {
+                'Authorization': `Bearer ${localStorage.getItem('token')}`
+            }
+        });
+    } catch (error) {
+        console.error('Error logging out:', error);
+    }
+    clearSession();
     window.location.href = '/login';
 };
 